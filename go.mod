@@ -3,6 +3,7 @@ module github.com/neuspaces/terraform-provider-system
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/alessio/shellescape v1.4.2
 	github.com/bflad/tfproviderlint v0.30.0
 	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
@@ -12,6 +13,7 @@ require (
 	github.com/hashicorp/terraform-plugin-log v0.9.0
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.34.0
 	github.com/joho/godotenv v1.5.1
+	github.com/klauspost/compress v1.17.9
 	github.com/mitchellh/mapstructure v1.5.0
 	github.com/sethvargo/go-envconfig v1.0.3
 	github.com/sethvargo/go-retry v0.2.4
@@ -24,7 +26,6 @@ require (
 )
 
 require (
-	github.com/BurntSushi/toml v1.2.1 // indirect
 	github.com/Kunde21/markdownfmt/v3 v3.1.0 // indirect
 	github.com/Masterminds/goutils v1.1.1 // indirect
 	github.com/Masterminds/semver/v3 v3.2.0 // indirect
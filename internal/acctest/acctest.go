@@ -116,7 +116,7 @@ func setupTarget(ctx context.Context, target *Target) error {
 	var err error
 
 	// Create base path for tests which involve the file system
-	err = client.NewFolderClient(target.Provider.System).Create(ctx, client.Folder{
+	_, err = client.NewFolderClient(target.Provider.System).Create(ctx, client.Folder{
 		Path: target.BasePath,
 	})
 	if err != nil {
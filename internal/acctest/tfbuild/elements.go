@@ -114,6 +114,12 @@ func AttributeString(name string, val string) BlockElement {
 	}
 }
 
+func AttributeValue(name string, val cty.Value) BlockElement {
+	return func(block *hclwrite.Block) {
+		block.Body().SetAttributeValue(name, val)
+	}
+}
+
 func AttributeTraversal(name string, ref hcl.Traversal) BlockElement {
 	return func(block *hclwrite.Block) {
 		block.Body().SetAttributeTraversal(name, ref)
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UserAccount is an entry of the user database
+type UserAccount struct {
+	Name string
+	Uid  int
+
+	// Group is the name of the primary group. Empty if the primary group does not exist.
+	Group string
+	Gid   int
+
+	Gecos  string
+	Home   string
+	Shell  string
+	System bool
+}
+
+// GroupAccount is an entry of the group database
+type GroupAccount struct {
+	Name    string
+	Gid     int
+	Members []string
+	System  bool
+}
+
+// AccountsQuery describes the users or groups to list. The id is the uid of users and the gid of groups.
+type AccountsQuery struct {
+	// System limits the accounts to system accounts if true or to regular accounts if false. All accounts are listed
+	// if nil.
+	System *bool
+
+	// MinId and MaxId limit the range of ids including the bounds. The range is not limited if nil.
+	MinId *int
+	MaxId *int
+}
+
+// match reports whether an account with the id matches the query. system reports whether the account is a system
+// account.
+func (q AccountsQuery) match(id int, system bool) bool {
+	if q.System != nil && to.Bool(q.System) != system {
+		return false
+	}
+	if q.MinId != nil && id < to.Int(q.MinId) {
+		return false
+	}
+	if q.MaxId != nil && id > to.Int(q.MaxId) {
+		return false
+	}
+	return true
+}
+
+const (
+	// accountDefaultIdMin is the lowest id of regular accounts if /etc/login.defs does not configure the range
+	accountDefaultIdMin = 1000
+
+	// accountNobodyId is the id of the user and the group nobody which are system accounts despite the high id
+	accountNobodyId = 65534
+)
+
+// accountLimits are the highest uid and gid of system accounts
+type accountLimits struct {
+	SysUidMax int
+	SysGidMax int
+}
+
+// parseLoginDefs returns the limits of system accounts configured in /etc/login.defs. Like useradd, SYS_UID_MAX and
+// SYS_GID_MAX default to UID_MIN - 1 and GID_MIN - 1.
+func parseLoginDefs(content string) accountLimits {
+	values := map[string]int{}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if v, err := strconv.Atoi(fields[1]); err == nil {
+			values[fields[0]] = v
+		}
+	}
+
+	sysMax := func(sysMaxKey string, minKey string) int {
+		if v, ok := values[sysMaxKey]; ok {
+			return v
+		}
+		if v, ok := values[minKey]; ok {
+			return v - 1
+		}
+		return accountDefaultIdMin - 1
+	}
+
+	return accountLimits{
+		SysUidMax: sysMax("SYS_UID_MAX", "UID_MIN"),
+		SysGidMax: sysMax("SYS_GID_MAX", "GID_MIN"),
+	}
+}
+
+// accountIsSystem reports whether the uid or gid belongs to a system account given the highest id of system accounts
+func accountIsSystem(id int, sysMax int) bool {
+	return id <= sysMax || id == accountNobodyId
+}
+
+type AccountsClient interface {
+	// GetUser returns the user with the name or uid. Returns ErrAccountsUserNotFound if the user does not exist.
+	GetUser(ctx context.Context, nameOrUid string) (*UserAccount, error)
+
+	// ListUsers returns the users which match the query sorted by uid
+	ListUsers(ctx context.Context, q AccountsQuery) ([]UserAccount, error)
+
+	// GetGroup returns the group with the name or gid. Returns ErrAccountsGroupNotFound if the group does not exist.
+	GetGroup(ctx context.Context, nameOrGid string) (*GroupAccount, error)
+
+	// ListGroups returns the groups which match the query sorted by gid
+	ListGroups(ctx context.Context, q AccountsQuery) ([]GroupAccount, error)
+}
+
+func NewAccountsClient(s system.System) AccountsClient {
+	return &accountsClient{
+		s: s,
+	}
+}
+
+var (
+	ErrAccounts = errors.New("accounts data source")
+
+	ErrAccountsUserNotFound = errors.Join(ErrAccounts, errors.New("user not found"))
+
+	ErrAccountsGroupNotFound = errors.Join(ErrAccounts, errors.New("group not found"))
+
+	ErrAccountsUnexpected = errors.Join(ErrAccounts, errors.New("unexpected error"))
+)
+
+const (
+	// codeAccountsNotFound is the exit code of getent if the key is not found
+	codeAccountsNotFound = 2
+)
+
+type accountsClient struct {
+	s system.System
+
+	// limits caches the limits of system accounts
+	limits *accountLimits
+}
+
+var _ AccountsClient = &accountsClient{}
+
+// getent returns the entries of the database. All entries are returned if no key is provided.
+func (c *accountsClient) getent(ctx context.Context, database string, keys ...string) ([]string, int, error) {
+	args := []string{database}
+	for _, key := range keys {
+		args = append(args, shellescape.Quote(key))
+	}
+
+	res, err := ExecuteCommand(ctx, c.s, NewCommand(fmt.Sprintf(`getent %s`, strings.Join(args, " "))))
+	if err != nil {
+		return nil, -1, errors.Join(ErrAccountsUnexpected, err)
+	}
+
+	if res.ExitCode == codeAccountsNotFound {
+		return nil, res.ExitCode, nil
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, res.ExitCode, errors.Join(ErrAccountsUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	var entries []string
+	for _, line := range strings.Split(res.StdoutString(), "\n") {
+		line = strings.TrimSpace(line)
+
+		// Skip empty lines and compat entries of NIS
+		if line == "" || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		entries = append(entries, line)
+	}
+
+	return entries, res.ExitCode, nil
+}
+
+// getLimits returns the limits of system accounts of the remote system
+func (c *accountsClient) getLimits(ctx context.Context) (accountLimits, error) {
+	if c.limits != nil {
+		return *c.limits, nil
+	}
+
+	res, err := ExecuteCommand(ctx, c.s, NewCommand(`cat /etc/login.defs 2>/dev/null || true`))
+	if err != nil {
+		return accountLimits{}, errors.Join(ErrAccountsUnexpected, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return accountLimits{}, errors.Join(ErrAccountsUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	limits := parseLoginDefs(res.StdoutString())
+	c.limits = &limits
+
+	return limits, nil
+}
+
+func (c *accountsClient) GetUser(ctx context.Context, nameOrUid string) (*UserAccount, error) {
+	entries, code, err := c.getent(ctx, "passwd", nameOrUid)
+	if err != nil {
+		return nil, err
+	}
+	if code == codeAccountsNotFound || len(entries) == 0 {
+		return nil, ErrAccountsUserNotFound
+	}
+
+	parsedUser, err := parsePasswdEntry([]byte(entries[0]))
+	if err != nil {
+		return nil, ErrAccountsUnexpected
+	}
+
+	groupNames := map[int]string{}
+
+	groupEntries, _, err := c.getent(ctx, "group", fmt.Sprintf("%d", parsedUser.Gid))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range groupEntries {
+		parsedGroup, err := parseGroupEntry([]byte(entry))
+		if err != nil {
+			return nil, ErrAccountsUnexpected
+		}
+		groupNames[parsedGroup.Gid] = parsedGroup.Name
+	}
+
+	limits, err := c.getLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user := newUserAccount(parsedUser, groupNames, limits)
+
+	return &user, nil
+}
+
+func (c *accountsClient) ListUsers(ctx context.Context, q AccountsQuery) ([]UserAccount, error) {
+	entries, _, err := c.getent(ctx, "passwd")
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := c.listGroupEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := c.getLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := map[int]string{}
+	for _, g := range groups {
+		if _, exists := groupNames[g.Gid]; !exists {
+			groupNames[g.Gid] = g.Name
+		}
+	}
+
+	users := make([]UserAccount, 0, len(entries))
+	for _, entry := range entries {
+		parsedUser, err := parsePasswdEntry([]byte(entry))
+		if err != nil {
+			return nil, ErrAccountsUnexpected
+		}
+
+		user := newUserAccount(parsedUser, groupNames, limits)
+		if !q.match(user.Uid, user.System) {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].Uid < users[j].Uid
+	})
+
+	return users, nil
+}
+
+func (c *accountsClient) GetGroup(ctx context.Context, nameOrGid string) (*GroupAccount, error) {
+	entries, code, err := c.getent(ctx, "group", nameOrGid)
+	if err != nil {
+		return nil, err
+	}
+	if code == codeAccountsNotFound || len(entries) == 0 {
+		return nil, ErrAccountsGroupNotFound
+	}
+
+	parsedGroup, err := parseGroupEntry([]byte(entries[0]))
+	if err != nil {
+		return nil, ErrAccountsUnexpected
+	}
+
+	limits, err := c.getLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group := newGroupAccount(parsedGroup, limits)
+
+	return &group, nil
+}
+
+func (c *accountsClient) ListGroups(ctx context.Context, q AccountsQuery) ([]GroupAccount, error) {
+	parsedGroups, err := c.listGroupEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := c.getLimits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]GroupAccount, 0, len(parsedGroups))
+	for _, parsedGroup := range parsedGroups {
+		group := newGroupAccount(&parsedGroup, limits)
+		if !q.match(group.Gid, group.System) {
+			continue
+		}
+
+		groups = append(groups, group)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Gid < groups[j].Gid
+	})
+
+	return groups, nil
+}
+
+// listGroupEntries returns all entries of the group database in the order of the database
+func (c *accountsClient) listGroupEntries(ctx context.Context) ([]groupEntry, error) {
+	entries, _, err := c.getent(ctx, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]groupEntry, 0, len(entries))
+	for _, entry := range entries {
+		parsedGroup, err := parseGroupEntry([]byte(entry))
+		if err != nil {
+			return nil, ErrAccountsUnexpected
+		}
+		groups = append(groups, *parsedGroup)
+	}
+
+	return groups, nil
+}
+
+func newUserAccount(e *passwdEntry, groupNames map[int]string, limits accountLimits) UserAccount {
+	return UserAccount{
+		Name:   e.Name,
+		Uid:    e.Uid,
+		Group:  groupNames[e.Gid],
+		Gid:    e.Gid,
+		Gecos:  e.Gecos,
+		Home:   e.Home,
+		Shell:  e.Shell,
+		System: accountIsSystem(e.Uid, limits.SysUidMax),
+	}
+}
+
+func newGroupAccount(e *groupEntry, limits accountLimits) GroupAccount {
+	members := e.Members
+	if members == nil {
+		members = []string{}
+	}
+
+	return GroupAccount{
+		Name:    e.Name,
+		Gid:     e.Gid,
+		Members: members,
+		System:  accountIsSystem(e.Gid, limits.SysGidMax),
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLoginDefs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		expected accountLimits
+	}{
+		{
+			name:     "missing",
+			content:  "",
+			expected: accountLimits{SysUidMax: 999, SysGidMax: 999},
+		},
+		{
+			name:     "system range",
+			content:  "# SYS_UID_MAX 100\nUID_MIN\t\t 1000\nSYS_UID_MAX\t\t 499\nSYS_GID_MAX 299\nUMASK 022\n",
+			expected: accountLimits{SysUidMax: 499, SysGidMax: 299},
+		},
+		{
+			name:     "derived from minimum",
+			content:  "UID_MIN 500\nGID_MIN 600\n#SYS_GID_MAX 100\n",
+			expected: accountLimits{SysUidMax: 499, SysGidMax: 599},
+		},
+		{
+			name:     "invalid value",
+			content:  "UID_MIN abc\nGID_MIN\n",
+			expected: accountLimits{SysUidMax: 999, SysGidMax: 999},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, parseLoginDefs(tt.content))
+		})
+	}
+}
+
+func TestAccountsQuery_match(t *testing.T) {
+	t.Parallel()
+
+	limits := parseLoginDefs("UID_MIN 500\n")
+
+	regular := AccountsQuery{System: to.BoolPtr(false)}
+	system := AccountsQuery{System: to.BoolPtr(true)}
+
+	for _, tt := range []struct {
+		id     int
+		system bool
+	}{
+		{id: 0, system: true},
+		{id: 499, system: true},
+		{id: 500, system: false},
+		{id: 65533, system: false},
+		{id: accountNobodyId, system: true},
+	} {
+		isSystem := accountIsSystem(tt.id, limits.SysUidMax)
+		assert.Equal(t, tt.system, isSystem, tt.id)
+		assert.Equal(t, tt.system, system.match(tt.id, isSystem), tt.id)
+		assert.Equal(t, !tt.system, regular.match(tt.id, isSystem), tt.id)
+	}
+
+	assert.False(t, AccountsQuery{MinId: to.IntPtr(10)}.match(9, true))
+	assert.False(t, AccountsQuery{MaxId: to.IntPtr(10)}.match(11, true))
+	assert.True(t, AccountsQuery{MinId: to.IntPtr(10), MaxId: to.IntPtr(10)}.match(10, true))
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io"
+)
+
+// ArchiveEntry is a file, folder, or link which has been extracted to the destination folder
+type ArchiveEntry struct {
+	// Path is the slash-separated path relative to the destination folder
+	Path string
+
+	IsDir bool
+}
+
+type ArchiveClient interface {
+	// Exists returns ErrArchiveNotFound if the destination folder does not exist
+	Exists(ctx context.Context, destination string) error
+
+	// Extract creates the destination folder and extracts the tar archive which is written by write
+	Extract(ctx context.Context, destination string, write func(w io.Writer) error) error
+
+	// Chown changes the ownership of the entries below the destination folder. Symbolic links are not dereferenced.
+	Chown(ctx context.Context, destination string, entries []ArchiveEntry, user string, group string) error
+
+	// Delete removes entries below the destination folder. Folders are only removed if empty.
+	Delete(ctx context.Context, destination string, entries []ArchiveEntry) error
+
+	// DeleteIfEmpty removes the destination folder if it is empty
+	DeleteIfEmpty(ctx context.Context, destination string) error
+}
+
+type ArchiveClientOpt func(c *archiveClient)
+
+func ArchiveClientCompression(enabled bool) ArchiveClientOpt {
+	return func(c *archiveClient) {
+		c.compress = enabled
+	}
+}
+
+func NewArchiveClient(s system.System, opts ...ArchiveClientOpt) ArchiveClient {
+	c := &archiveClient{
+		s: s,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+var (
+	ErrArchive = errors.New("archive resource")
+
+	ErrArchiveNotFound = errors.Join(ErrArchive, errors.New("destination folder not found"))
+
+	ErrArchiveUnexpected = errors.Join(ErrArchive, errors.New("unexpected error"))
+)
+
+type archiveClient struct {
+	s system.System
+
+	compress bool
+}
+
+var _ ArchiveClient = &archiveClient{}
+
+func (c *archiveClient) Exists(ctx context.Context, destination string) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; }; _do %[1]s;`, shellescape.Quote(destination), codeDestinationNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrArchiveUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeDestinationNotFound:
+		return ErrArchiveNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrArchiveUnexpected, err)
+	}
+
+	return nil
+}
+
+func (c *archiveClient) Extract(ctx context.Context, destination string, write func(w io.Writer) error) error {
+	err := extractTar(ctx, c.s, destination, c.compress, write)
+	if err != nil {
+		return errors.Join(ErrArchiveUnexpected, err)
+	}
+
+	return nil
+}
+
+// runScript executes a shell script in the destination folder
+func (c *archiveClient) runScript(ctx context.Context, destination string, script []string) error {
+	err := runDestinationScript(ctx, c.s, destination, script)
+	if errors.Is(err, errDestinationNotFound) {
+		return ErrArchiveNotFound
+	}
+	if err != nil {
+		return errors.Join(ErrArchiveUnexpected, err)
+	}
+
+	return nil
+}
+
+func (c *archiveClient) Chown(ctx context.Context, destination string, entries []ArchiveEntry, user string, group string) error {
+	if user == "" && group == "" {
+		return nil
+	}
+
+	var script []string
+
+	for _, e := range entries {
+		p := quoteRelative(e.Path)
+
+		var cmd Command
+		if user != "" {
+			cmd = &ChownCommand{Path: p, User: user, Group: group, NoDereference: true}
+		} else {
+			cmd = &ChgrpCommand{Path: p, Group: group, NoDereference: true}
+		}
+
+		script = append(script, cmd.Command())
+	}
+
+	return c.runScript(ctx, destination, script)
+}
+
+func (c *archiveClient) Delete(ctx context.Context, destination string, entries []ArchiveEntry) error {
+	var files, dirs []string
+	for _, e := range entries {
+		if e.IsDir {
+			dirs = append(dirs, e.Path)
+		} else {
+			files = append(files, e.Path)
+		}
+	}
+
+	err := c.runScript(ctx, destination, deleteDestinationScript(files, dirs))
+	if errors.Is(err, ErrArchiveNotFound) {
+		// Not interpreted as error because this is the desired state
+		return nil
+	}
+
+	return err
+}
+
+func (c *archiveClient) DeleteIfEmpty(ctx context.Context, destination string) error {
+	err := deleteDestinationIfEmpty(ctx, c.s, destination)
+	if err != nil {
+		return errors.Join(ErrArchiveUnexpected, err)
+	}
+
+	return nil
+}
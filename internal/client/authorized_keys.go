@@ -0,0 +1,219 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/authorizedkeys"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"path"
+	"strings"
+)
+
+// AuthorizedKeys are the keys in the authorized_keys file of a user
+type AuthorizedKeys struct {
+	User string
+
+	// Path of the authorized_keys file in the home folder of the user
+	Path string
+
+	Keys []authorizedkeys.Key
+}
+
+// AuthorizedKeysChange describes a modification of the authorized_keys file of a user
+type AuthorizedKeysChange struct {
+	User string
+
+	// Set contains keys which are added or replace the key with the same id
+	Set []authorizedkeys.Key
+
+	// Remove contains the ids of keys which are removed
+	Remove []string
+
+	// Exclusive removes all keys which are not contained in Set
+	Exclusive bool
+}
+
+type AuthorizedKeysClient interface {
+	// Get returns the keys in the authorized_keys file of the user. Keys is empty if the file does not exist. Returns
+	// ErrAuthorizedKeysUserNotFound if the user does not exist.
+	Get(ctx context.Context, user string) (*AuthorizedKeys, error)
+
+	// Apply modifies the authorized_keys file of the user. Lines of the file which are not affected by the change are
+	// retained unless the change is exclusive. Apply creates the folder ~/.ssh and the file if they do not exist.
+	Apply(ctx context.Context, k AuthorizedKeysChange) error
+}
+
+func NewAuthorizedKeysClient(s system.System) AuthorizedKeysClient {
+	return &authorizedKeysClient{
+		s: s,
+	}
+}
+
+var (
+	ErrAuthorizedKeys = errors.New("authorized keys resource")
+
+	ErrAuthorizedKeysUserNotFound = errors.Join(ErrAuthorizedKeys, errors.New("user not found"))
+
+	ErrAuthorizedKeysHomeNotFound = errors.Join(ErrAuthorizedKeys, errors.New("home folder not found"))
+
+	ErrAuthorizedKeysSymlink = errors.Join(ErrAuthorizedKeys, errors.New("~/.ssh or authorized_keys is a symbolic link"))
+
+	ErrAuthorizedKeysUnexpected = errors.Join(ErrAuthorizedKeys, errors.New("unexpected error"))
+)
+
+const (
+	codeAuthorizedKeysUserNotFound = 17
+
+	codeAuthorizedKeysHomeNotFound = 18
+
+	codeAuthorizedKeysFileNotFound = 19
+
+	codeAuthorizedKeysSymlink = 20
+)
+
+type authorizedKeysClient struct {
+	s system.System
+}
+
+var _ AuthorizedKeysClient = &authorizedKeysClient{}
+
+// authorizedKeysFile is the authorized_keys file of a user
+type authorizedKeysFile struct {
+	user    *passwdEntry
+	path    string
+	exists  bool
+	content string
+}
+
+// authorizedKeysReadScript prints the passwd entry of the user in the first line followed by the content of the
+// authorized_keys file. Symbolic links are rejected because the folder is owned by the user and the script runs as root.
+const authorizedKeysReadScript = `_do() {
+  entry=$(getent passwd "$1") || return %[2]d;
+  echo "${entry}";
+  dir="$(echo "${entry}" | cut -d: -f6)/.ssh"; file="${dir}/authorized_keys";
+  [ ! -L "${dir}" ] && [ ! -L "${file}" ] || return %[4]d;
+  [ -f "${file}" ] || return %[3]d;
+  cat "${file}";
+}; _do %[1]s;`
+
+// read returns the passwd entry of the user and the content of the authorized_keys file
+func (c *authorizedKeysClient) read(ctx context.Context, user string) (*authorizedKeysFile, error) {
+	cmd := NewCommand(fmt.Sprintf(authorizedKeysReadScript, shellescape.Quote(user), codeAuthorizedKeysUserNotFound, codeAuthorizedKeysFileNotFound, codeAuthorizedKeysSymlink))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrAuthorizedKeysUnexpected, err)
+	}
+
+	exists := true
+
+	switch res.ExitCode {
+	case 0:
+		break
+	case codeAuthorizedKeysFileNotFound:
+		exists = false
+	case codeAuthorizedKeysUserNotFound:
+		return nil, ErrAuthorizedKeysUserNotFound
+	case codeAuthorizedKeysSymlink:
+		return nil, ErrAuthorizedKeysSymlink
+	default:
+		return nil, errors.Join(ErrAuthorizedKeysUnexpected, res.Error(), errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	entry, content, _ := bytes.Cut(res.Stdout, []byte("\n"))
+
+	u, err := parsePasswdEntry(entry)
+	if err != nil {
+		return nil, errors.Join(ErrAuthorizedKeysUnexpected, err)
+	}
+
+	return &authorizedKeysFile{
+		user:    u,
+		path:    path.Join(u.Home, ".ssh", "authorized_keys"),
+		exists:  exists,
+		content: string(content),
+	}, nil
+}
+
+// authorizedKeysWriteScript creates the folder ~/.ssh and replaces the authorized_keys file atomically. The modes and
+// the ownership are enforced because sshd rejects files which are writable by other users. The folder is owned by the
+// user while the script runs as root: symbolic links are rejected, and the script changes into the folder and verifies
+// the physical path so that all subsequent operations are relative to the folder even if the path is replaced.
+const authorizedKeysWriteScript = `_do() {
+  home=$1; owner=$2;
+  [ -d "${home}" ] || return %[2]d;
+  home=$(cd -P "${home}" && pwd -P) || return 1;
+  dir="${home}/.ssh";
+  [ ! -L "${dir}" ] || return %[3]d;
+  [ -d "${dir}" ] || mkdir -m 700 "${dir}" || return 1;
+  cd -P "${dir}" && [ "$(pwd -P)" = "${dir}" ] || return %[3]d;
+  [ ! -L authorized_keys ] || return %[3]d;
+  chmod 700 . && chown -h "${owner}" . || return 1;
+  tmp=$(mktemp .authorized_keys.XXXXXX) || return 1;
+  cat - > "${tmp}" && chmod 600 "${tmp}" && chown -h "${owner}" "${tmp}" && mv -f "${tmp}" authorized_keys || { rm -f "${tmp}"; return 1; };
+}; _do %[1]s;`
+
+// write replaces the content of the authorized_keys file of the user
+func (c *authorizedKeysClient) write(ctx context.Context, u *passwdEntry, content string) error {
+	args := []string{
+		shellescape.Quote(u.Home),
+		fmt.Sprintf("%d:%d", u.Uid, u.Gid),
+	}
+
+	cmd := NewInputCommand(fmt.Sprintf(authorizedKeysWriteScript, strings.Join(args, " "), codeAuthorizedKeysHomeNotFound, codeAuthorizedKeysSymlink), strings.NewReader(content))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrAuthorizedKeysUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeAuthorizedKeysHomeNotFound:
+		return ErrAuthorizedKeysHomeNotFound
+	case codeAuthorizedKeysSymlink:
+		return ErrAuthorizedKeysSymlink
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrAuthorizedKeysUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+func (c *authorizedKeysClient) Get(ctx context.Context, user string) (*AuthorizedKeys, error) {
+	f, err := c.read(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizedKeys{
+		User: f.user.Name,
+		Path: f.path,
+		Keys: authorizedkeys.List(f.content),
+	}, nil
+}
+
+func (c *authorizedKeysClient) Apply(ctx context.Context, k AuthorizedKeysChange) error {
+	f, err := c.read(ctx, k.User)
+	if err != nil {
+		return err
+	}
+
+	var content string
+	if k.Exclusive {
+		content = authorizedkeys.Format(k.Set)
+	} else {
+		content = authorizedkeys.Set(authorizedkeys.Remove(f.content, k.Remove), k.Set)
+	}
+
+	if content == "" && !f.exists {
+		// Nothing to do because no keys
+		return nil
+	}
+
+	// The file is written even if the content is up-to-date to enforce the modes and the ownership
+
+	return c.write(ctx, f.user, content)
+}
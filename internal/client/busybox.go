@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"strings"
+)
+
+var ErrToolNotFound = errors.New("command not found")
+
+// detectBusybox reports whether the BusyBox command busyboxCommand must be used because command is not available on
+// the remote system. Returns ErrToolNotFound if neither command is available.
+func detectBusybox(ctx context.Context, s system.System, command string, busyboxCommand string) (bool, error) {
+	cmd := NewCommand(fmt.Sprintf(`if command -v %[1]s >/dev/null 2>&1; then echo %[1]s; elif command -v %[2]s >/dev/null 2>&1; then echo %[2]s; else exit 1; fi`, shellescape.Quote(command), shellescape.Quote(busyboxCommand)))
+	res, err := ExecuteCommand(ctx, s, cmd)
+	if err != nil {
+		return false, err
+	}
+
+	if res.ExitCode != 0 {
+		return false, errors.Join(ErrToolNotFound, fmt.Errorf("neither %s nor %s found", command, busyboxCommand))
+	}
+
+	return strings.TrimSpace(res.StdoutString()) == busyboxCommand, nil
+}
+
+// busyboxFunctions defines shell functions which emulate the modifications of usermod, groupmod, and chage on systems
+// with BusyBox.
+//
+// _edit replaces a file with the output of awk. The edit is serialized with shadow and BusyBox by exclusively creating
+// the lock file `<file>.lock` of shadow and the temporary file `<file>+` of BusyBox. The temporary file is a copy of the
+// file which retains the mode and the ownership and replaces the file atomically using mv. _edit gives up after
+// ${_tries} attempts to acquire the locks in intervals of one second.
+//
+// _set sets the field of the entry with the key in the first field of a colon-separated file. _rename renames a member
+// in the member lists of group files. Values are passed through the environment because awk interprets escape sequences
+// in variables.
+const busyboxFunctions = `_tries=${_tries:-10};
+_lock() {
+  _i=0;
+  until ( set -C; echo "$$" > "$1.lock" ) 2>/dev/null; do
+    _i=$((_i + 1)); [ "${_i}" -lt "${_tries}" ] || { echo "failed to lock $1" >&2; return 1; }; sleep 1;
+  done;
+  until ( set -C; : > "$1+" ) 2>/dev/null; do
+    _i=$((_i + 1)); [ "${_i}" -lt "${_tries}" ] || { rm -f "$1.lock"; echo "failed to lock $1" >&2; return 1; }; sleep 1;
+  done;
+};
+_edit() {
+  _file=$1; shift;
+  [ -f "${_file}" ] || return 0;
+  _lock "${_file}" || return 1;
+  cp -p "${_file}" "${_file}+" && awk "$@" "${_file}" > "${_file}+" && mv -f "${_file}+" "${_file}" || { rm -f "${_file}+" "${_file}.lock"; return 1; };
+  rm -f "${_file}.lock";
+};
+_set() {
+  _k=$2 _f=$3 _v=$4; export _k _f _v;
+  _edit "$1" -F: -v OFS=: '$1 == ENVIRON["_k"] { $(ENVIRON["_f"]) = ENVIRON["_v"] } { print }';
+};
+_rename() {
+  _o=$1 _n=$2; export _o _n; shift 2;
+  for _file in "$@"; do
+    _edit "${_file}" -F: -v OFS=: 'NF >= 4 { c = split($4, m, ","); s = ""; for (i = 1; i <= c; i++) { if (m[i] == ENVIRON["_o"]) m[i] = ENVIRON["_n"]; s = s (i > 1 ? "," : "") m[i] }; $4 = s } { print }' || return 1;
+  done;
+};`
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBusyboxTools are the commands of the host which are available in the test environment
+var testBusyboxTools = []string{"sh", "awk", "sed", "cut", "tr", "grep", "tail", "cat", "cp", "mv", "rm", "sleep", "echo", "printf", "mkdir", "chmod", "chown"}
+
+// testBusyboxStubs emulate the BusyBox commands and getent on the files in the folder ${STUB_DB}. adduser adds the user
+// as member of the group like BusyBox. Each invocation is logged to ${STUB_DB}/log.
+var testBusyboxStubs = map[string]string{
+	"getent": `#!/bin/sh
+db="${STUB_DB}/$1"; [ -f "${db}" ] || exit 2;
+if [ $# -lt 2 ]; then cat "${db}"; exit 0; fi;
+awk -F: -v k="$2" '$1 == k || $3 == k { print; found = 1; exit } END { exit !found }' "${db}" || exit 2;
+`,
+	"adduser": `#!/bin/sh
+echo "adduser $*" >> "${STUB_DB}/log";
+uid=1000; group=""; home=""; shell=/bin/sh;
+while [ $# -gt 1 ]; do case "$1" in -u) uid=$2; shift 2;; -G) group=$2; shift 2;; -h) home=$2; shift 2;; -s) shell=$2; shift 2;; -k) shift 2;; *) shift;; esac; done;
+name=$1; home=${home:-/home/${name}};
+gid=$(awk -F: -v g="${group}" '$1 == g { print $3 }' "${STUB_DB}/group");
+echo "${name}:x:${uid}:${gid}::${home}:${shell}" >> "${STUB_DB}/passwd";
+awk -F: -v OFS=: -v g="${group}" -v u="${name}" '$1 == g { $4 = ($4 == "" ? u : $4 "," u) } { print }' "${STUB_DB}/group" > "${STUB_DB}/group.new" && mv "${STUB_DB}/group.new" "${STUB_DB}/group";
+`,
+	"deluser": `#!/bin/sh
+echo "deluser $*" >> "${STUB_DB}/log";
+`,
+	"addgroup": `#!/bin/sh
+echo "addgroup $*" >> "${STUB_DB}/log";
+gid=1000;
+while [ $# -gt 1 ]; do case "$1" in -g) gid=$2; shift 2;; *) shift;; esac; done;
+echo "$1:x:${gid}:" >> "${STUB_DB}/group";
+`,
+	"delgroup": `#!/bin/sh
+echo "delgroup $*" >> "${STUB_DB}/log";
+[ $# -eq 2 ] || exit 0;
+awk -F: -v OFS=: -v g="$2" -v u="$1" '$1 == g { n = split($4, m, ","); s = ""; for (i = 1; i <= n; i++) if (m[i] != u) s = (s == "" ? m[i] : s "," m[i]); $4 = s } { print }' "${STUB_DB}/group" > "${STUB_DB}/group.new" && mv "${STUB_DB}/group.new" "${STUB_DB}/group";
+`,
+}
+
+// testBusyboxEnv is a folder with the stubs and the account database
+type testBusyboxEnv struct {
+	db string
+}
+
+// newTestBusyboxEnv restricts PATH to the stubs and the tools of the host so that useradd and groupadd are not found
+func newTestBusyboxEnv(t *testing.T, passwd string, group string) *testBusyboxEnv {
+	bin := t.TempDir()
+	db := t.TempDir()
+
+	for _, tool := range testBusyboxTools {
+		p, err := exec.LookPath(tool)
+		if err != nil {
+			continue
+		}
+		require.NoError(t, os.Symlink(p, filepath.Join(bin, tool)))
+	}
+
+	for name, script := range testBusyboxStubs {
+		require.NoError(t, os.WriteFile(filepath.Join(bin, name), []byte(script), 0755))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(db, "passwd"), []byte(passwd), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(db, "group"), []byte(group), 0644))
+
+	t.Setenv("PATH", bin)
+	t.Setenv("STUB_DB", db)
+
+	return &testBusyboxEnv{db: db}
+}
+
+func (e *testBusyboxEnv) read(t *testing.T, name string) string {
+	data, err := os.ReadFile(filepath.Join(e.db, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	require.NoError(t, err)
+	return string(data)
+}
+
+// runBusyboxFunctions executes the script with busyboxFunctions using sh. The arguments are available as $0, $1, ...
+func runBusyboxFunctions(t *testing.T, script string, args ...string) (string, error) {
+	out, err := exec.Command("sh", append([]string{"-c", "_tries=2; " + busyboxFunctions + "\n" + script}, args...)...).CombinedOutput()
+	return string(out), err
+}
+
+func TestDetectBusybox(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := local.NewSystem()
+
+	busybox, err := detectBusybox(ctx, s, "sh", "busybox-test-missing")
+	require.NoError(t, err)
+	assert.False(t, busybox)
+
+	busybox, err = detectBusybox(ctx, s, "useradd-test-missing", "sh")
+	require.NoError(t, err)
+	assert.True(t, busybox)
+
+	_, err = detectBusybox(ctx, s, "useradd-test-missing", "adduser-test-missing")
+	assert.ErrorIs(t, err, ErrToolNotFound)
+}
+
+func TestBusyboxFunctions_set(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "passwd")
+	require.NoError(t, os.WriteFile(file, []byte("root:x:0:0:root:/root:/bin/sh\nalice:x:1000:100::/home/alice:/bin/sh\n"), 0640))
+
+	out, err := runBusyboxFunctions(t, `_set "$0" alice 7 '/bin/a\b c' && _set "$0" missing 7 x && _set "$0.missing" alice 7 x`+"\n", file)
+	require.NoError(t, err, out)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "root:x:0:0:root:/root:/bin/sh\nalice:x:1000:100::/home/alice:/bin/a\\b c\n", string(data))
+
+	// Mode is retained and lock files are removed
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestBusyboxFunctions_rename(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	group := filepath.Join(dir, "group")
+	gshadow := filepath.Join(dir, "gshadow")
+	require.NoError(t, os.WriteFile(group, []byte("users:x:100:alice,bob\nwheel:x:10:root,malice,alice\nalice:x:1000:\n"), 0644))
+	require.NoError(t, os.WriteFile(gshadow, []byte("users:!::alice\n"), 0600))
+
+	out, err := runBusyboxFunctions(t, `_rename alice alicia "$0" "$0.missing" "$1"`, group, gshadow)
+	require.NoError(t, err, out)
+
+	data, err := os.ReadFile(group)
+	require.NoError(t, err)
+	assert.Equal(t, "users:x:100:alicia,bob\nwheel:x:10:root,malice,alicia\nalice:x:1000:\n", string(data))
+
+	data, err = os.ReadFile(gshadow)
+	require.NoError(t, err)
+	assert.Equal(t, "users:!::alicia\n", string(data))
+
+	info, err := os.Stat(gshadow)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestBusyboxFunctions_locked(t *testing.T) {
+	t.Parallel()
+
+	for _, lock := range []string{".lock", "+"} {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "passwd")
+		require.NoError(t, os.WriteFile(file, []byte("alice:x:1000:100::/home/alice:/bin/sh\n"), 0644))
+		require.NoError(t, os.WriteFile(file+lock, []byte("1\n"), 0644))
+
+		out, err := runBusyboxFunctions(t, `_set "$0" alice 7 /bin/false`, file)
+		assert.Error(t, err)
+		assert.Contains(t, out, "failed to lock")
+
+		// The file and the lock of the other process are retained
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "alice:x:1000:100::/home/alice:/bin/sh\n", string(data))
+		assert.FileExists(t, file+lock)
+	}
+}
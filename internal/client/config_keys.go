@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/configfile"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+)
+
+type ConfigKeys struct {
+	Path   string
+	Format configfile.Format
+
+	// Set contains keys and values which are present in the file
+	Set map[string]string
+
+	// Remove contains keys which are absent in the file
+	Remove []string
+}
+
+type ConfigKeysClient interface {
+	// Get returns the values of the provided keys which are present in the file at path
+	Get(ctx context.Context, path string, format configfile.Format, keys []string) (map[string]string, error)
+
+	// Apply sets and removes keys in the file. Apply creates the file if it does not exist.
+	Apply(ctx context.Context, k ConfigKeys) error
+
+	// Delete removes the provided keys from the file
+	Delete(ctx context.Context, path string, format configfile.Format, keys []string) error
+}
+
+func NewConfigKeysClient(s system.System) ConfigKeysClient {
+	return &configKeysClient{
+		s: s,
+	}
+}
+
+var (
+	ErrConfigKeys = errors.New("config keys resource")
+
+	ErrConfigKeysFileNotFound = errors.Join(ErrConfigKeys, errors.New("file not found"))
+
+	ErrConfigKeysMalformed = errors.Join(ErrConfigKeys, errors.New("malformed file"))
+
+	ErrConfigKeysInvalid = errors.Join(ErrConfigKeys, errors.New("invalid key or value"))
+
+	ErrConfigKeysUnexpected = errors.Join(ErrConfigKeys, errors.New("unexpected error"))
+)
+
+const (
+	codeConfigKeysUnexpected = 1
+
+	codeConfigKeysFileNotFound = 17
+)
+
+type configKeysClient struct {
+	s system.System
+}
+
+var _ ConfigKeysClient = &configKeysClient{}
+
+// read returns the entire content of the file
+func (c *configKeysClient) read(ctx context.Context, path string) ([]byte, error) {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -f "${path}" ] || return %[2]d; cat "${path}" || return %[3]d; }; _do %[1]s;`, shellescape.Quote(path), codeConfigKeysFileNotFound, codeConfigKeysUnexpected))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrConfigKeysUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeConfigKeysFileNotFound:
+		return nil, ErrConfigKeysFileNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrConfigKeysUnexpected, err)
+	}
+
+	return res.Stdout, nil
+}
+
+// configKeysWriteScript replaces the content of the file atomically with a copy in the same folder. The copy retains
+// the mode and the ownership of an existing file. A new file is created with mode 644. A symbolic link is retained and
+// the file it points to is replaced.
+const configKeysWriteScript = `_do() {
+  path=$1;
+  [ ! -L "${path}" ] || path=$(readlink -f "${path}") || return %[2]d;
+  tmp="$(mktemp "$(dirname "${path}")/.$(basename "${path}").XXXXXX")" || return %[2]d;
+  { if [ -f "${path}" ]; then cp -p "${path}" "${tmp}"; else chmod 644 "${tmp}"; fi; } && cat - > "${tmp}" && mv -f "${tmp}" "${path}" || { rm -f "${tmp}"; return %[2]d; };
+}; _do %[1]s;`
+
+// write replaces the content of the file or creates the file
+func (c *configKeysClient) write(ctx context.Context, path string, content []byte) error {
+	cmd := NewInputCommand(fmt.Sprintf(configKeysWriteScript, shellescape.Quote(path), codeConfigKeysUnexpected), bytes.NewReader(content))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrConfigKeysUnexpected, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrConfigKeysUnexpected, err)
+	}
+
+	return nil
+}
+
+func (c *configKeysClient) parse(format configfile.Format, content []byte) (configfile.Document, error) {
+	doc, err := configfile.Parse(format, content)
+	if err != nil {
+		return nil, errors.Join(ErrConfigKeysMalformed, err)
+	}
+	return doc, nil
+}
+
+func (c *configKeysClient) Get(ctx context.Context, path string, format configfile.Format, keys []string) (map[string]string, error) {
+	content, err := c.read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := c.parse(format, content)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, key := range keys {
+		value, found, err := doc.Get(key)
+		if err != nil {
+			return nil, errors.Join(ErrConfigKeysInvalid, err)
+		}
+		if found {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (c *configKeysClient) Apply(ctx context.Context, k ConfigKeys) error {
+	content, err := c.read(ctx, k.Path)
+	if err != nil && !errors.Is(err, ErrConfigKeysFileNotFound) {
+		return err
+	}
+
+	doc, err := c.parse(k.Format, content)
+	if err != nil {
+		return err
+	}
+
+	// The file is only written if at least one key changes which avoids reformatting of an up-to-date file
+	changed := false
+
+	for key, value := range k.Set {
+		desired, err := configfile.NormalizeValue(k.Format, value)
+		if err != nil {
+			return errors.Join(ErrConfigKeysInvalid, err)
+		}
+
+		current, found, err := doc.Get(key)
+		if err != nil {
+			return errors.Join(ErrConfigKeysInvalid, err)
+		}
+		if found && current == desired {
+			continue
+		}
+
+		err = doc.Set(key, value)
+		if err != nil {
+			return errors.Join(ErrConfigKeysInvalid, err)
+		}
+		changed = true
+	}
+
+	for _, key := range k.Remove {
+		_, found, err := doc.Get(key)
+		if err != nil {
+			return errors.Join(ErrConfigKeysInvalid, err)
+		}
+		if !found {
+			continue
+		}
+
+		err = doc.Delete(key)
+		if err != nil {
+			return errors.Join(ErrConfigKeysInvalid, err)
+		}
+		changed = true
+	}
+
+	if !changed {
+		// Nothing to do because up-to-date
+		return nil
+	}
+
+	newContent, err := doc.Bytes()
+	if err != nil {
+		return errors.Join(ErrConfigKeysUnexpected, err)
+	}
+
+	return c.write(ctx, k.Path, newContent)
+}
+
+func (c *configKeysClient) Delete(ctx context.Context, path string, format configfile.Format, keys []string) error {
+	// A missing file is not interpreted as error because this is the desired state
+	return c.Apply(ctx, ConfigKeys{
+		Path:   path,
+		Format: format,
+		Remove: keys,
+	})
+}
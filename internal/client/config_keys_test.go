@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/lib/configfile"
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigKeysClient_apply(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewConfigKeysClient(local.NewSystem())
+
+	dir := t.TempDir()
+
+	// The path is quoted in the script
+	path := filepath.Join(dir, "it's $(touch injected).env")
+
+	require.NoError(t, c.Apply(ctx, ConfigKeys{Path: path, Format: configfile.FormatDotenv, Set: map[string]string{"A": "1"}}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\n", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	// The mode of an existing file is retained
+	require.NoError(t, os.Chmod(path, 0600))
+	require.NoError(t, c.Apply(ctx, ConfigKeys{Path: path, Format: configfile.FormatDotenv, Set: map[string]string{"B": "2"}}))
+
+	values, err := c.Get(ctx, path, configfile.FormatDotenv, []string{"A", "B"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"A": "1", "B": "2"}, values)
+
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Neither injected commands nor temporary files remain
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
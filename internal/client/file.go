@@ -8,6 +8,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
 	"github.com/neuspaces/terraform-provider-system/internal/system"
 	"io"
@@ -24,9 +26,18 @@ type File struct {
 	Group string
 	Gid   int
 
+	// Size is the size of the file contents in bytes
+	Size int64
+
 	// Content optionally contains the file contents when enabled with FileClientIncludeContent
 	Content io.Reader
 	Md5Sum  string
+
+	// Sha256Sum is the sha256 checksum of the file contents in hex encoding
+	Sha256Sum string
+
+	// Checksum optionally verifies Content on the remote system before the file is replaced
+	Checksum *checksum.Checksum
 }
 
 func newFileFromStat(s *stat.Stat) *File {
@@ -37,6 +48,7 @@ func newFileFromStat(s *stat.Stat) *File {
 		Uid:   s.Uid,
 		Group: s.Group,
 		Gid:   s.Gid,
+		Size:  s.Size,
 	}
 }
 
@@ -45,6 +57,16 @@ type FileClient interface {
 	Create(ctx context.Context, f File) error
 	Update(ctx context.Context, f File) error
 	Delete(ctx context.Context, path string) error
+
+	// Download creates the file with the contents of a url which is fetched by the remote system
+	Download(ctx context.Context, f File, dl FileDownload) (*FileDownloadResult, error)
+
+	// Stash copies the file including its mode and ownership to FileStashDir. Returns the path of the stashed file.
+	// An existing stash of the file is retained.
+	Stash(ctx context.Context, path string) (string, error)
+
+	// Restore moves the stashed file back to the path. Returns ErrFileStashNotFound if the stashed file does not exist.
+	Restore(ctx context.Context, path string, stashPath string) error
 }
 
 type FileClientOpt func(c *fileClient)
@@ -61,6 +83,14 @@ func FileClientIncludeContent(include bool) FileClientOpt {
 	}
 }
 
+// FileClientContentLimit limits the size of the content in bytes which is included by Get. Get returns
+// ErrFileContentLimit if the content exceeds the limit. The content is not limited if limit is not positive.
+func FileClientContentLimit(limit int64) FileClientOpt {
+	return func(c *fileClient) {
+		c.contentLimit = limit
+	}
+}
+
 func NewFileClient(s system.System, opts ...FileClientOpt) FileClient {
 	fc := &fileClient{
 		s: s,
@@ -81,6 +111,10 @@ var (
 	ErrFileNotFound = errors.Join(ErrFile, errors.New("file not found"))
 
 	ErrFileUnexpected = errors.Join(ErrFile, errors.New("unexpected error"))
+
+	ErrFileChecksum = errors.Join(ErrFile, errors.New("file content does not match the expected checksum"))
+
+	ErrFileContentLimit = errors.Join(ErrFile, errors.New("file content exceeds limit"))
 )
 
 const (
@@ -89,6 +123,8 @@ const (
 	codeFilePathExists = 16
 
 	codeFileNotFound = 17
+
+	codeFileChecksum = 21
 )
 
 type fileClient struct {
@@ -96,10 +132,11 @@ type fileClient struct {
 
 	compress       bool
 	includeContent bool
+	contentLimit   int64
 }
 
 func (c *fileClient) Get(ctx context.Context, path string) (*File, error) {
-	cmd := NewCommand(fmt.Sprintf(`_do() { path='%[1]s'; [ -f "${path}" ] || return %[2]d; { stat -c '%[3]s' "${path}" && md5sum "${path}"; } || return 1; }; _do;`, path, codeFileNotFound, stat.FormatJsonGnu))
+	cmd := NewCommand(fmt.Sprintf(`_do() { path='%[1]s'; [ -f "${path}" ] || return %[2]d; { stat -c '%[3]s' "${path}" && md5sum "${path}" && sha256sum "${path}"; } || return 1; }; _do;`, path, codeFileNotFound, stat.FormatJsonGnu))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return nil, errors.Join(ErrFileUnexpected, err)
@@ -112,7 +149,7 @@ func (c *fileClient) Get(ctx context.Context, path string) (*File, error) {
 
 	stdoutLines := strings.Split(strings.TrimSpace(string(res.Stdout)), "\n")
 
-	if res.ExitCode != 0 || len(res.Stdout) == 0 || len(stdoutLines) != 2 {
+	if res.ExitCode != 0 || len(res.Stdout) == 0 || len(stdoutLines) != 3 {
 		return nil, ErrFileUnexpected
 	}
 
@@ -137,24 +174,160 @@ func (c *fileClient) Get(ctx context.Context, path string) (*File, error) {
 
 	file.Md5Sum = base64.StdEncoding.EncodeToString(md5Hex)
 
+	sha256Parts := strings.Split(stdoutLines[2], "  ")
+	if len(sha256Parts) != 2 {
+		return nil, ErrFileUnexpected
+	}
+
+	file.Sha256Sum = sha256Parts[0]
+
 	// Get content if requested
 	if c.includeContent {
-		catCmd := NewCommand(fmt.Sprintf(`cat '%s'`, path))
-		catRes, err := ExecuteCommand(ctx, c.s, catCmd)
-		if err != nil {
-			return nil, errors.Join(ErrFileUnexpected, err)
+		if c.contentLimit > 0 && file.Size > c.contentLimit {
+			return nil, ErrFileContentLimit
 		}
 
-		if err := catRes.Error(); err != nil {
-			return nil, errors.Join(ErrFileUnexpected, err)
+		content, err := c.readContent(ctx, path)
+		if err != nil {
+			return nil, err
 		}
 
-		file.Content = bytes.NewReader(catRes.Stdout)
+		file.Content = content
 	}
 
 	return file, nil
 }
 
+// readContent returns the contents of the file at the path. With transport compression, the contents are compressed
+// on the remote system and decompressed when read.
+func (c *fileClient) readContent(ctx context.Context, path string) (io.Reader, error) {
+	readCmd := fmt.Sprintf(`cat %s`, shellescape.Quote(path))
+	if c.compress {
+		readCmd = fmt.Sprintf(`gzip -c %s`, shellescape.Quote(path))
+	}
+
+	opts := []ExecuteCommandOption{WithStdout(), WithStderr()}
+	if c.contentLimit > 0 && !c.compress {
+		// The file may grow after its size has been retrieved
+		opts = append(opts, WithStdoutFunc(func(w io.Writer) io.Writer {
+			return &fileContentLimitWriter{w: w, n: c.contentLimit}
+		}))
+	}
+
+	res, err := ExecuteCommandWithOptions(ctx, c.s, NewCommand(readCmd), opts...)
+	if errors.Is(err, ErrFileContentLimit) {
+		return nil, ErrFileContentLimit
+	}
+	if err != nil {
+		return nil, errors.Join(ErrFileUnexpected, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFileUnexpected, err)
+	}
+
+	if !c.compress {
+		return bytes.NewReader(res.Stdout), nil
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(res.Stdout))
+	if err != nil {
+		return nil, errors.Join(ErrFileUnexpected, err)
+	}
+
+	var content io.Reader = gzipReader
+	if c.contentLimit > 0 {
+		content = &fileContentLimitReader{r: gzipReader, n: c.contentLimit}
+	}
+
+	return content, nil
+}
+
+// fileContentLimitWriter fails with ErrFileContentLimit when more than n bytes are written
+type fileContentLimitWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *fileContentLimitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, ErrFileContentLimit
+	}
+	n, err := l.w.Write(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// fileContentLimitReader fails with ErrFileContentLimit when more than n bytes are read
+type fileContentLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *fileContentLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrFileContentLimit
+	}
+	return n, err
+}
+
+// contentCommands returns the commands which write f.Content to the path and the stdin of the commands. With a
+// checksum, the content is written to a temporary file which is verified and moved to the path. The temporary file is
+// removed if the checksum does not match.
+func (c *fileClient) contentCommands(f File, pathSub string) ([]Command, io.Reader, error) {
+	var cmds []Command
+	var cmdIn io.Reader
+
+	targetSub := pathSub
+	if f.Checksum != nil {
+		targetSub = `"${tmp}"`
+		cmds = append(cmds, NewCommand(`tmp="$(dirname "${path}")/.$(basename "${path}").tmp.$$"`))
+	}
+
+	var writeCmd string
+
+	if !c.compress {
+		// Without transport compression
+		writeCmd = fmt.Sprintf(`cat - > %s`, targetSub)
+		cmdIn = f.Content
+	} else {
+		// With transport compression
+		writeCmd = fmt.Sprintf(`gzip -d > %s`, targetSub)
+
+		// Setup pipe to compress source
+		pipeReader, pipeWriter := io.Pipe()
+		gzipWriter, err := gzip.NewWriterLevel(pipeWriter, gzip.BestCompression)
+		if err != nil {
+			return nil, nil, errors.Join(ErrFile, err)
+		}
+
+		go func() {
+			_, err := io.Copy(gzipWriter, f.Content)
+			_ = gzipWriter.Close()
+			_ = pipeWriter.CloseWithError(err)
+		}()
+
+		// Remote command stdin is the pipe output
+		cmdIn = pipeReader
+	}
+
+	if f.Checksum != nil {
+		// Remove the temporary file if the transfer fails
+		writeCmd = fmt.Sprintf(`{ %[1]s || { rm -f %[2]s; false; }; }`, writeCmd, targetSub)
+	}
+
+	cmds = append(cmds, NewCommand(writeCmd))
+
+	if f.Checksum != nil {
+		cmds = append(cmds, NewCommand(fmt.Sprintf(`{ [ "$(%[1]s %[2]s | cut -d ' ' -f 1)" = '%[3]s' ] || { rm -f %[2]s; return %[4]d; }; }`, f.Checksum.Command(), targetSub, f.Checksum.Sum, codeFileChecksum)))
+		cmds = append(cmds, NewCommand(fmt.Sprintf(`mv -f %s %s`, targetSub, pathSub)))
+	}
+
+	return cmds, cmdIn, nil
+}
+
 func (c *fileClient) Create(ctx context.Context, f File) error {
 	pathSub := `"${path}"`
 
@@ -163,31 +336,13 @@ func (c *fileClient) Create(ctx context.Context, f File) error {
 
 	if f.Content != nil {
 		// File content is provided from io.Reader
-
-		if !c.compress {
-			// Without transport compression
-			createCmds = append(createCmds, NewCommand(fmt.Sprintf(`cat - > %s`, pathSub)))
-			createCmdIn = f.Content
-		} else {
-			// With transport compression
-			createCmds = append(createCmds, NewCommand(fmt.Sprintf(`gzip -d > %s`, pathSub)))
-
-			// Setup pipe to compress source
-			pipeReader, pipeWriter := io.Pipe()
-			gzipWriter, err := gzip.NewWriterLevel(pipeWriter, gzip.BestCompression)
-			if err != nil {
-				return errors.Join(ErrFile, err)
-			}
-
-			go func() {
-				_, _ = io.Copy(gzipWriter, f.Content)
-				_ = gzipWriter.Close()
-				_ = pipeWriter.Close()
-			}()
-
-			// Remote command stdin is the pipe output
-			createCmdIn = pipeReader
+		contentCmds, contentIn, err := c.contentCommands(f, pathSub)
+		if err != nil {
+			return err
 		}
+
+		createCmds = append(createCmds, contentCmds...)
+		createCmdIn = contentIn
 	} else {
 		// Create file without content
 		createCmds = append(createCmds, NewCommand(fmt.Sprintf(`touch %s`, pathSub)))
@@ -218,6 +373,8 @@ func (c *fileClient) Create(ctx context.Context, f File) error {
 	switch res.ExitCode {
 	case codeFilePathExists:
 		return ErrFileExists
+	case codeFileChecksum:
+		return ErrFileChecksum
 	}
 
 	err = res.Error()
@@ -236,31 +393,13 @@ func (c *fileClient) Update(ctx context.Context, f File) error {
 
 	if f.Content != nil {
 		// File content is provided from io.Reader
-
-		if !c.compress {
-			// Without transport compression
-			updateCmds = append(updateCmds, NewCommand(fmt.Sprintf(`cat - > %s`, pathSub)))
-			updateCmdIn = f.Content
-		} else {
-			// With transport compression
-			updateCmds = append(updateCmds, NewCommand(fmt.Sprintf(`gzip -d > %s`, pathSub)))
-
-			// Setup pipe to compress source
-			pipeReader, pipeWriter := io.Pipe()
-			gzipWriter, err := gzip.NewWriterLevel(pipeWriter, gzip.BestCompression)
-			if err != nil {
-				return errors.Join(ErrFile, err)
-			}
-
-			go func() {
-				_, _ = io.Copy(gzipWriter, f.Content)
-				_ = gzipWriter.Close()
-				_ = pipeWriter.Close()
-			}()
-
-			// Remote command stdin is the pipe output
-			updateCmdIn = pipeReader
+		contentCmds, contentIn, err := c.contentCommands(f, pathSub)
+		if err != nil {
+			return err
 		}
+
+		updateCmds = append(updateCmds, contentCmds...)
+		updateCmdIn = contentIn
 	}
 
 	if f.Mode != 0 {
@@ -293,6 +432,8 @@ func (c *fileClient) Update(ctx context.Context, f File) error {
 	switch res.ExitCode {
 	case codeFileNotFound:
 		return ErrFileNotFound
+	case codeFileChecksum:
+		return ErrFileChecksum
 	}
 
 	err = res.Error()
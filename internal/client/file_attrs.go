@@ -0,0 +1,315 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileattr"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"sort"
+	"strings"
+)
+
+// FileAttrs are the access control list, extended attributes, SELinux context, and file attributes of a file, folder,
+// or link
+type FileAttrs struct {
+	// Acl contains the named entries of the access control list. Nil if getfacl is not available or the path does not
+	// support access control lists.
+	Acl []fileattr.AclEntry
+
+	// Xattrs contains the extended attributes in the user and security namespaces except the attributes which are
+	// maintained by the kernel. Nil if getfattr is not available.
+	Xattrs map[string]string
+
+	// SelinuxContext is the SELinux security context. Empty if the path is not labeled.
+	SelinuxContext string
+
+	// Attributes contains the file attributes which are set. Nil if lsattr is not available or the file system does not
+	// support file attributes.
+	Attributes []fileattr.Attribute
+}
+
+// xattrSelinux is the extended attribute which holds the SELinux security context
+const xattrSelinux = "security.selinux"
+
+type FileAttrsClient interface {
+	Get(ctx context.Context, path string) (*FileAttrs, error)
+
+	// SetAcl replaces the named entries of the access control list. The mode of the path is retained.
+	SetAcl(ctx context.Context, path string, entries []fileattr.AclEntry) error
+
+	// SetXattrs sets the extended attributes in set and removes the extended attributes in remove
+	SetXattrs(ctx context.Context, path string, set map[string]string, remove []string) error
+
+	// SetSelinuxContext sets the SELinux security context using chcon
+	SetSelinuxContext(ctx context.Context, path string, context string) error
+
+	// RestoreSelinuxContext sets the SELinux security context to the default of the policy using restorecon
+	RestoreSelinuxContext(ctx context.Context, path string) error
+
+	// SelinuxContextRestored returns false if the SELinux security context deviates from the default of the policy
+	SelinuxContextRestored(ctx context.Context, path string) (bool, error)
+
+	// SetAttributes sets the file attributes in set and clears the file attributes in clear using chattr
+	SetAttributes(ctx context.Context, path string, set []fileattr.Attribute, clear []fileattr.Attribute) error
+}
+
+func NewFileAttrsClient(s system.System) FileAttrsClient {
+	return &fileAttrsClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFileAttrs = errors.New("file attributes")
+
+	ErrFileAttrsNotFound = errors.Join(ErrFileAttrs, errors.New("path not found"))
+
+	ErrFileAttrsUnexpected = errors.Join(ErrFileAttrs, errors.New("unexpected error"))
+)
+
+const (
+	codeFileAttrsNotFound = 17
+)
+
+type fileAttrsClient struct {
+	s system.System
+}
+
+var _ FileAttrsClient = &fileAttrsClient{}
+
+const (
+	fileAttrsSectionAcl        = "@acl"
+	fileAttrsSectionXattrs     = "@xattrs"
+	fileAttrsSectionAttributes = "@attributes"
+)
+
+// fileAttrsGetScript prints the output of getfacl, getfattr, and lsattr in sections. A section is omitted if the
+// command is not available or fails. Access control lists and file attributes do not apply to symlinks.
+const fileAttrsGetScript = `_do() {
+  path=$1;
+  [ -e "${path}" ] || [ -L "${path}" ] || return %[1]d;
+  if [ ! -L "${path}" ] && command -v getfacl >/dev/null 2>&1; then
+    out="$(getfacl --omit-header --absolute-names --no-effective -- "${path}" 2>/dev/null)" && printf '%%s\n%%s\n' '%[3]s' "${out}";
+  fi;
+  if command -v getfattr >/dev/null 2>&1; then
+    out="$(getfattr --no-dereference --dump --absolute-names --encoding=base64 --match='^(user|security)\.' -- "${path}" 2>/dev/null)";
+    printf '%%s\n%%s\n' '%[4]s' "${out}";
+  fi;
+  if [ ! -L "${path}" ] && command -v lsattr >/dev/null 2>&1; then
+    out="$(lsattr -d -- "${path}" 2>/dev/null)" && printf '%%s\n%%s\n' '%[5]s' "${out}";
+  fi;
+  return 0;
+}; _do %[2]s;`
+
+func (c *fileAttrsClient) Get(ctx context.Context, path string) (*FileAttrs, error) {
+	cmd := NewCommand(fmt.Sprintf(fileAttrsGetScript, codeFileAttrsNotFound, shellescape.Quote(path), fileAttrsSectionAcl, fileAttrsSectionXattrs, fileAttrsSectionAttributes))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFileAttrs, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileAttrsNotFound:
+		return nil, ErrFileAttrsNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return nil, errors.Join(ErrFileAttrs, err)
+	}
+
+	sections := splitFileAttrsSections(res.Stdout)
+
+	attrs := &FileAttrs{}
+
+	if out, ok := sections[fileAttrsSectionAcl]; ok {
+		entries, err := fileattr.ParseGetfacl(out)
+		if err != nil {
+			return nil, errors.Join(ErrFileAttrsUnexpected, err)
+		}
+
+		attrs.Acl = []fileattr.AclEntry{}
+		for _, e := range entries {
+			if e.IsNamed() {
+				attrs.Acl = append(attrs.Acl, e)
+			}
+		}
+	}
+
+	if out, ok := sections[fileAttrsSectionXattrs]; ok {
+		xattrs, err := fileattr.ParseGetfattr(out)
+		if err != nil {
+			return nil, errors.Join(ErrFileAttrsUnexpected, err)
+		}
+
+		attrs.Xattrs = map[string]string{}
+		for name, value := range xattrs {
+			if name == xattrSelinux {
+				attrs.SelinuxContext = strings.TrimRight(string(value), "\x00")
+			}
+			if !fileattr.IsManagedXattr(name) {
+				continue
+			}
+			attrs.Xattrs[name] = string(value)
+		}
+	}
+
+	if out, ok := sections[fileAttrsSectionAttributes]; ok {
+		attributes, err := fileattr.ParseLsattr(out)
+		if err != nil {
+			return nil, errors.Join(ErrFileAttrsUnexpected, err)
+		}
+
+		attrs.Attributes = append([]fileattr.Attribute{}, attributes...)
+	}
+
+	return attrs, nil
+}
+
+// splitFileAttrsSections returns the output of each section printed by fileAttrsGetScript
+func splitFileAttrsSections(data []byte) map[string][]byte {
+	sections := map[string][]byte{}
+
+	var current string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch line {
+		case fileAttrsSectionAcl, fileAttrsSectionXattrs, fileAttrsSectionAttributes:
+			current = line
+			sections[current] = []byte{}
+			continue
+		}
+
+		if current != "" {
+			sections[current] = append(append(sections[current], line...), '\n')
+		}
+	}
+
+	return sections
+}
+
+// executeFileAttrsCommand executes the commands on the path. The path is available as `${path}` in the commands.
+func (c *fileAttrsClient) executeFileAttrsCommand(ctx context.Context, path string, cmds CompositeCommand) error {
+	if len(cmds) == 0 {
+		// Nothing to do
+		return nil
+	}
+
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -e "${path}" ] || [ -L "${path}" ] || return %[2]d; { %[3]s; } || return 1; }; _do %[1]s;`, shellescape.Quote(path), codeFileAttrsNotFound, cmds.Command()))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFileAttrs, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileAttrsNotFound:
+		return ErrFileAttrsNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		// The commands report the cause like a missing tool or an unsupported file system on stderr
+		return errors.Join(ErrFileAttrs, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+func (c *fileAttrsClient) SetAcl(ctx context.Context, path string, entries []fileattr.AclEntry) error {
+	// Removing the access control list modifies the group permissions of the mode which are restored afterwards
+	cmds := CompositeCommand{
+		NewCommand(`mode="$(stat -c '%a' "${path}")"`),
+		NewCommand(`setfacl --remove-all --remove-default -- "${path}"`),
+	}
+
+	if len(entries) > 0 {
+		var spec []string
+		for _, e := range entries {
+			spec = append(spec, e.String())
+		}
+		cmds = append(cmds, NewCommand(fmt.Sprintf(`setfacl --modify=%s -- "${path}"`, shellescape.Quote(strings.Join(spec, ",")))))
+	}
+
+	cmds = append(cmds, NewCommand(`chmod "${mode}" "${path}"`))
+
+	return c.executeFileAttrsCommand(ctx, path, cmds)
+}
+
+func (c *fileAttrsClient) SetXattrs(ctx context.Context, path string, set map[string]string, remove []string) error {
+	var cmds CompositeCommand
+
+	for _, name := range remove {
+		cmds = append(cmds, NewCommand(fmt.Sprintf(`setfattr --no-dereference --remove=%s -- "${path}"`, shellescape.Quote(name))))
+	}
+
+	// Sort names to obtain deterministic commands
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cmds = append(cmds, NewCommand(fmt.Sprintf(`setfattr --no-dereference --name=%s --value=%s -- "${path}"`, shellescape.Quote(name), fileattr.EncodeXattrValue([]byte(set[name])))))
+	}
+
+	return c.executeFileAttrsCommand(ctx, path, cmds)
+}
+
+func (c *fileAttrsClient) SetSelinuxContext(ctx context.Context, path string, context string) error {
+	return c.executeFileAttrsCommand(ctx, path, CompositeCommand{
+		NewCommand(fmt.Sprintf(`chcon --no-dereference %s "${path}"`, shellescape.Quote(context))),
+	})
+}
+
+func (c *fileAttrsClient) RestoreSelinuxContext(ctx context.Context, path string) error {
+	return c.executeFileAttrsCommand(ctx, path, CompositeCommand{
+		NewCommand(`restorecon -- "${path}"`),
+	})
+}
+
+func (c *fileAttrsClient) SelinuxContextRestored(ctx context.Context, path string) (bool, error) {
+	// restorecon reports the paths which would be relabeled in dry-run mode
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -e "${path}" ] || [ -L "${path}" ] || return %[2]d; restorecon -n -v -- "${path}"; }; _do %[1]s;`, shellescape.Quote(path), codeFileAttrsNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return false, errors.Join(ErrFileAttrs, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileAttrsNotFound:
+		return false, ErrFileAttrsNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return false, errors.Join(ErrFileAttrs, err)
+	}
+
+	return strings.TrimSpace(res.StdoutString()) == "", nil
+}
+
+func (c *fileAttrsClient) SetAttributes(ctx context.Context, path string, set []fileattr.Attribute, clear []fileattr.Attribute) error {
+	var flags []string
+
+	for _, a := range clear {
+		flags = append(flags, "-"+string(a.Flag()))
+	}
+
+	for _, a := range set {
+		flags = append(flags, "+"+string(a.Flag()))
+	}
+
+	if len(flags) == 0 {
+		return nil
+	}
+
+	return c.executeFileAttrsCommand(ctx, path, CompositeCommand{
+		NewCommand(fmt.Sprintf(`chattr %s "${path}"`, strings.Join(flags, " "))),
+	})
+}
@@ -0,0 +1,239 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/textblock"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"regexp"
+	"strings"
+)
+
+type FileBlock struct {
+	Path        string
+	MarkerBegin string
+	MarkerEnd   string
+
+	// Content is the body of the block without the marker lines
+	Content string
+
+	// Insert defines the position of the block if the block does not yet exist in the file
+	Insert FileBlockInsert
+}
+
+type FileBlockPosition string
+
+const (
+	FileBlockPositionEnd FileBlockPosition = "end"
+
+	FileBlockPositionBeginning FileBlockPosition = "beginning"
+
+	FileBlockPositionAfter FileBlockPosition = "after"
+
+	FileBlockPositionBefore FileBlockPosition = "before"
+)
+
+type FileBlockInsert struct {
+	Position FileBlockPosition
+
+	// Pattern is a regular expression which is required for FileBlockPositionAfter and FileBlockPositionBefore
+	Pattern string
+}
+
+func (i FileBlockInsert) toTextblockInsert() (textblock.Insert, error) {
+	var ins textblock.Insert
+
+	switch i.Position {
+	case FileBlockPositionEnd, "":
+		ins.Position = textblock.PositionEnd
+	case FileBlockPositionBeginning:
+		ins.Position = textblock.PositionBeginning
+	case FileBlockPositionAfter:
+		ins.Position = textblock.PositionAfter
+	case FileBlockPositionBefore:
+		ins.Position = textblock.PositionBefore
+	default:
+		return ins, fmt.Errorf("unsupported position %q", i.Position)
+	}
+
+	if i.Pattern != "" {
+		pattern, err := regexp.Compile(i.Pattern)
+		if err != nil {
+			return ins, err
+		}
+		ins.Pattern = pattern
+	}
+
+	return ins, nil
+}
+
+func (b FileBlock) markers() textblock.Markers {
+	return textblock.Markers{
+		Begin: b.MarkerBegin,
+		End:   b.MarkerEnd,
+	}
+}
+
+type FileBlockClient interface {
+	// Get returns the block in the file identified by Path, MarkerBegin, and MarkerEnd of the provided FileBlock
+	Get(ctx context.Context, b FileBlock) (*FileBlock, error)
+
+	// Apply creates or updates the block in the file
+	Apply(ctx context.Context, b FileBlock) error
+
+	// Delete removes the block including the marker lines from the file
+	Delete(ctx context.Context, b FileBlock) error
+}
+
+func NewFileBlockClient(s system.System) FileBlockClient {
+	return &fileBlockClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFileBlock = errors.New("file block resource")
+
+	ErrFileBlockNotFound = errors.Join(ErrFileBlock, errors.New("block not found"))
+
+	ErrFileBlockFileNotFound = errors.Join(ErrFileBlock, errors.New("file not found"))
+
+	ErrFileBlockMalformed = errors.Join(ErrFileBlock, errors.New("malformed block"))
+
+	ErrFileBlockUnexpected = errors.Join(ErrFileBlock, errors.New("unexpected error"))
+)
+
+const (
+	codeFileBlockUnexpected = 1
+
+	codeFileBlockFileNotFound = 17
+)
+
+type fileBlockClient struct {
+	s system.System
+}
+
+var _ FileBlockClient = &fileBlockClient{}
+
+// read returns the entire content of the file
+func (c *fileBlockClient) read(ctx context.Context, path string) (string, error) {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -f "${path}" ] || return %[2]d; cat "${path}" || return %[3]d; }; _do %[1]s;`, shellescape.Quote(path), codeFileBlockFileNotFound, codeFileBlockUnexpected))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return "", errors.Join(ErrFileBlockUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileBlockFileNotFound:
+		return "", ErrFileBlockFileNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return "", errors.Join(ErrFileBlockUnexpected, err)
+	}
+
+	return res.StdoutString(), nil
+}
+
+// fileBlockWriteScript replaces the content of the file atomically with a copy in the same folder. The copy retains the
+// mode and the ownership of the file. A symbolic link is retained and the file it points to is replaced.
+const fileBlockWriteScript = `_do() {
+  path=$1;
+  [ -f "${path}" ] || return %[2]d;
+  [ ! -L "${path}" ] || path=$(readlink -f "${path}") || return %[3]d;
+  tmp="$(mktemp "$(dirname "${path}")/.$(basename "${path}").XXXXXX")" || return %[3]d;
+  cp -p "${path}" "${tmp}" && cat - > "${tmp}" && mv -f "${tmp}" "${path}" || { rm -f "${tmp}"; return %[3]d; };
+}; _do %[1]s;`
+
+// write replaces the content of the file
+func (c *fileBlockClient) write(ctx context.Context, path string, content string) error {
+	cmd := NewInputCommand(fmt.Sprintf(fileBlockWriteScript, shellescape.Quote(path), codeFileBlockFileNotFound, codeFileBlockUnexpected), strings.NewReader(content))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFileBlockUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileBlockFileNotFound:
+		return ErrFileBlockFileNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrFileBlockUnexpected, err)
+	}
+
+	return nil
+}
+
+func (c *fileBlockClient) Get(ctx context.Context, b FileBlock) (*FileBlock, error) {
+	content, err := c.read(ctx, b.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	body, found, err := textblock.Find(content, b.markers())
+	if err != nil {
+		return nil, errors.Join(ErrFileBlockMalformed, err)
+	}
+
+	if !found {
+		return nil, ErrFileBlockNotFound
+	}
+
+	return &FileBlock{
+		Path:        b.Path,
+		MarkerBegin: b.MarkerBegin,
+		MarkerEnd:   b.MarkerEnd,
+		Content:     body,
+		Insert:      b.Insert,
+	}, nil
+}
+
+func (c *fileBlockClient) Apply(ctx context.Context, b FileBlock) error {
+	ins, err := b.Insert.toTextblockInsert()
+	if err != nil {
+		return errors.Join(ErrFileBlockUnexpected, err)
+	}
+
+	content, err := c.read(ctx, b.Path)
+	if err != nil {
+		return err
+	}
+
+	newContent, err := textblock.Set(content, b.markers(), b.Content, ins)
+	if err != nil {
+		return errors.Join(ErrFileBlockMalformed, err)
+	}
+
+	if newContent == content {
+		// Nothing to do because up-to-date
+		return nil
+	}
+
+	return c.write(ctx, b.Path, newContent)
+}
+
+func (c *fileBlockClient) Delete(ctx context.Context, b FileBlock) error {
+	content, err := c.read(ctx, b.Path)
+	if errors.Is(err, ErrFileBlockFileNotFound) {
+		// Not interpreted as error because this is the desired state
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	newContent, found, err := textblock.Remove(content, b.markers())
+	if err != nil {
+		return errors.Join(ErrFileBlockMalformed, err)
+	}
+
+	if !found {
+		// Not interpreted as error because this is the desired state
+		return nil
+	}
+
+	return c.write(ctx, b.Path, newContent)
+}
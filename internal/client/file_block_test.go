@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBlockClient_applyDelete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	c := NewFileBlockClient(local.NewSystem())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sshd config")
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.WriteFile(path, []byte("Port 22"), 0640))
+	require.NoError(t, os.Symlink(path, link))
+
+	b := FileBlock{
+		Path:        link,
+		MarkerBegin: "# BEGIN terraform test",
+		MarkerEnd:   "# END terraform test",
+		Content:     "PasswordAuthentication no\n",
+	}
+
+	require.NoError(t, c.Apply(ctx, b))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Port 22\n# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test", string(data))
+
+	// The mode of the file and the symbolic link are retained
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	info, err = os.Lstat(link)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode().Type())
+
+	actual, err := c.Get(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, b.Content, actual.Content)
+
+	require.NoError(t, c.Delete(ctx, b))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Port 22", string(data))
+
+	// Temporary files are removed
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
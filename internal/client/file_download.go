@@ -0,0 +1,193 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
+	"strconv"
+	"strings"
+)
+
+// FileDownload describes a url which is fetched by the remote system
+type FileDownload struct {
+	Url string
+
+	// Checksum is the expected checksum. The checksum is not verified if nil.
+	Checksum *checksum.Checksum
+
+	// Size is the expected size in bytes. The size is not verified if negative.
+	Size int64
+
+	// Attempts is the maximum number of attempts to fetch the url. Defaults to a single attempt.
+	Attempts int
+
+	// Replace allows the download to replace an existing file at the path
+	Replace bool
+}
+
+// FileDownloadResult reports the progress of a download on the remote system
+type FileDownloadResult struct {
+	// Tool is the command which has been used to fetch the url
+	Tool string
+
+	// Failures contains a message for each failed attempt
+	Failures []string
+
+	// Size is the size of the downloaded file in bytes
+	Size int64
+
+	// Stderr contains the diagnostic output of the download tool
+	Stderr string
+}
+
+var (
+	ErrFileDownloadToolNotFound = errors.Join(ErrFile, errors.New("neither curl nor wget is available on the remote system"))
+
+	ErrFileDownloadFailed = errors.Join(ErrFile, errors.New("download failed"))
+
+	ErrFileDownloadChecksum = errors.Join(ErrFile, errors.New("downloaded file does not match the expected checksum or size"))
+)
+
+const (
+	codeFileDownloadToolNotFound = 18
+
+	codeFileDownloadFailed = 19
+
+	codeFileDownloadChecksum = 20
+)
+
+// fileDownloadScript fetches a url to a temporary file next to the path with the first available download tool,
+// verifies the temporary file, and moves the file to the path. The script reports progress on stdout.
+const fileDownloadScript = `_do() {
+  path=$1; url=$2; attempts=$3; size=$4; sum_cmd=$5; sum=$6; replace=$7;
+  [ "${replace}" -eq 1 ] || [ ! -e "${path}" ] || return %[1]d;
+  if command -v curl >/dev/null 2>&1; then tool='curl';
+  elif command -v wget >/dev/null 2>&1; then tool='wget';
+  elif command -v busybox >/dev/null 2>&1; then tool='busybox wget';
+  else return %[2]d; fi;
+  echo "tool ${tool}";
+  tmp="$(dirname "${path}")/.$(basename "${path}").download.$$";
+  n=0;
+  while :; do
+    n=$((n+1));
+    if [ "${tool}" = 'curl' ]; then curl -fsSL -o "${tmp}" "${url}"; else ${tool} -q -O "${tmp}" "${url}"; fi;
+    rc=$?;
+    [ "${rc}" -ne 0 ] || break;
+    echo "failed ${n} ${rc}";
+    [ "${n}" -lt "${attempts}" ] || { rm -f "${tmp}"; return %[3]d; };
+    sleep "${n}";
+  done;
+  actual_size=$(wc -c < "${tmp}" | tr -d ' ');
+  echo "size ${actual_size}";
+  [ "${size}" -lt 0 ] || [ "${actual_size}" -eq "${size}" ] || { rm -f "${tmp}"; return %[4]d; };
+  [ -z "${sum}" ] || [ "$("${sum_cmd}" "${tmp}" | cut -d ' ' -f 1)" = "${sum}" ] || { rm -f "${tmp}"; return %[4]d; };
+  { %[5]s; } || { rm -f "${tmp}"; return 1; };
+  mv -f "${tmp}" "${path}" || { rm -f "${tmp}"; return 1; };
+}; _do %[6]s %[7]s %[8]d %[9]d %[10]s %[11]s %[12]d;`
+
+// parseFileDownloadOutput parses the progress which is reported by fileDownloadScript
+func parseFileDownloadOutput(res *CommandResult) *FileDownloadResult {
+	r := &FileDownloadResult{
+		Size:   -1,
+		Stderr: strings.TrimSpace(res.StderrString()),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(res.Stdout))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "tool":
+			r.Tool = value
+		case "failed":
+			attempt, code, _ := strings.Cut(value, " ")
+			r.Failures = append(r.Failures, fmt.Sprintf("attempt %s failed with exit code %s", attempt, code))
+		case "size":
+			if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+				r.Size = size
+			}
+		}
+	}
+
+	return r
+}
+
+func (c *fileClient) Download(ctx context.Context, f File, dl FileDownload) (*FileDownloadResult, error) {
+	// Attributes are applied to the temporary file before the file is moved to the path
+	tmpSub := `"${tmp}"`
+
+	attrCmds := CompositeCommand{NewCommand(`true`)}
+
+	if f.Mode != 0 {
+		attrCmds = append(attrCmds, &ChmodCommand{Path: tmpSub, Mode: f.Mode})
+	}
+
+	if f.Uid != -1 {
+		attrCmds = append(attrCmds, &ChownCommand{Path: tmpSub, User: strconv.Itoa(f.Uid)})
+	} else if f.User != "" {
+		attrCmds = append(attrCmds, &ChownCommand{Path: tmpSub, User: f.User})
+	}
+
+	if f.Gid != -1 {
+		attrCmds = append(attrCmds, &ChgrpCommand{Path: tmpSub, Group: strconv.Itoa(f.Gid)})
+	} else if f.Group != "" {
+		attrCmds = append(attrCmds, &ChgrpCommand{Path: tmpSub, Group: f.Group})
+	}
+
+	var sumCmd, sum string
+	if dl.Checksum != nil {
+		sumCmd, sum = dl.Checksum.Command(), dl.Checksum.Sum
+	}
+
+	attempts := dl.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	replace := 0
+	if dl.Replace {
+		replace = 1
+	}
+
+	cmd := NewCommand(fmt.Sprintf(fileDownloadScript,
+		codeFilePathExists,
+		codeFileDownloadToolNotFound,
+		codeFileDownloadFailed,
+		codeFileDownloadChecksum,
+		attrCmds.Command(),
+		shellescape.Quote(f.Path),
+		shellescape.Quote(dl.Url),
+		attempts,
+		dl.Size,
+		shellescape.Quote(sumCmd),
+		shellescape.Quote(sum),
+		replace,
+	))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFile, err)
+	}
+
+	result := parseFileDownloadOutput(res)
+
+	switch res.ExitCode {
+	case codeFilePathExists:
+		return result, ErrFileExists
+	case codeFileDownloadToolNotFound:
+		return result, ErrFileDownloadToolNotFound
+	case codeFileDownloadFailed:
+		return result, ErrFileDownloadFailed
+	case codeFileDownloadChecksum:
+		return result, ErrFileDownloadChecksum
+	}
+
+	err = res.Error()
+	if err != nil {
+		return result, errors.Join(ErrFile, err)
+	}
+
+	return result, nil
+}
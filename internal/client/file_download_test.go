@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileClient_Download(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("curl"); err != nil {
+		if _, err := exec.LookPath("wget"); err != nil {
+			t.Skip("neither curl nor wget is available")
+		}
+	}
+
+	content := "hello world\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+
+	sum := sha256.Sum256([]byte(content))
+	validChecksum := &checksum.Checksum{Algorithm: checksum.AlgorithmSha256, Sum: hex.EncodeToString(sum[:])}
+	invalidChecksum := &checksum.Checksum{Algorithm: checksum.AlgorithmSha256, Sum: hex.EncodeToString(make([]byte, sha256.Size))}
+
+	type testCase struct {
+		Desc      string
+		Download  FileDownload
+		ExpectErr error
+	}
+
+	tcs := []testCase{
+		{
+			Desc:     "size and checksum",
+			Download: FileDownload{Size: int64(len(content)), Checksum: validChecksum},
+		},
+		{
+			Desc:     "unknown size",
+			Download: FileDownload{Size: -1},
+		},
+		{
+			Desc:      "size mismatch",
+			Download:  FileDownload{Size: 1},
+			ExpectErr: ErrFileDownloadChecksum,
+		},
+		{
+			Desc:      "checksum mismatch",
+			Download:  FileDownload{Size: -1, Checksum: invalidChecksum},
+			ExpectErr: ErrFileDownloadChecksum,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "file")
+
+			dl := tc.Download
+			dl.Url = srv.URL
+
+			c := NewFileClient(local.NewSystem())
+			_, err := c.Download(context.Background(), File{Path: path, Mode: 0640, Uid: -1, Gid: -1}, dl)
+
+			entries, readErr := os.ReadDir(dir)
+			require.NoError(t, readErr)
+
+			if tc.ExpectErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectErr)
+
+				// The temporary file is removed
+				assert.Empty(t, entries)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+
+			actual, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(actual))
+
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+		})
+	}
+}
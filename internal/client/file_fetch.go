@@ -0,0 +1,274 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// FileFetch is a file or folder on the remote system which is transferred to the local system
+type FileFetch struct {
+	Path string
+
+	// Type is either FileTypeFile or FileTypeDirectory
+	Type FileType
+	Mode fs.FileMode
+	Size int64
+
+	// Md5Sum is the md5 checksum in base64 encoding. For a folder, Md5Sum is the checksum of the sorted md5sum listing
+	// of the regular files within the folder and changes if the content or the name of any regular file changes.
+	Md5Sum string
+}
+
+type FileFetchClient interface {
+	// Get returns the file or folder at the path. Symbolic links are followed. Returns ErrFileFetchNotFound if the path
+	// does not exist and ErrFileFetchUnsupported if the path is neither a regular file nor a folder.
+	Get(ctx context.Context, path string) (*FileFetch, error)
+
+	// Fetch writes the contents of a file or a gzip compressed tar archive of a folder to w. The transferred data is
+	// verified against the md5 checksum of f. Returns ErrFileFetchChecksum if the verification fails.
+	Fetch(ctx context.Context, f FileFetch, w io.Writer) error
+}
+
+func NewFileFetchClient(s system.System) FileFetchClient {
+	return &fileFetchClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFileFetch = errors.New("file download resource")
+
+	ErrFileFetchNotFound = errors.Join(ErrFileFetch, errors.New("file not found"))
+
+	ErrFileFetchUnsupported = errors.Join(ErrFileFetch, errors.New("path is neither a file nor a folder"))
+
+	ErrFileFetchChecksum = errors.Join(ErrFileFetch, errors.New("checksum mismatch"))
+
+	ErrFileFetchUnexpected = errors.Join(ErrFileFetch, errors.New("unexpected error"))
+)
+
+const (
+	codeFileFetchNotFound = 17
+
+	codeFileFetchUnsupported = 18
+)
+
+type fileFetchClient struct {
+	s system.System
+}
+
+var _ FileFetchClient = &fileFetchClient{}
+
+// fileFetchScript prints the stat of the path followed by the md5 checksum of a file or the md5 checksum of the sorted
+// md5sum listing of the regular files within a folder
+const fileFetchScript = `_do() {
+  path=$1;
+  [ -e "${path}" ] || return %[2]d;
+  if [ -f "${path}" ]; then
+    stat -L -c %[4]s "${path}" || return 1;
+    echo "md5 $(md5sum < "${path}" | cut -d ' ' -f 1)";
+  elif [ -d "${path}" ]; then
+    stat -L -c %[4]s "${path}" || return 1;
+    echo "md5 $(cd "${path}" && find . -type f -exec md5sum {} + | LC_ALL=C sort | md5sum | cut -d ' ' -f 1)";
+  else
+    return %[3]d;
+  fi;
+}; _do %[1]s;`
+
+func (c *fileFetchClient) Get(ctx context.Context, p string) (*FileFetch, error) {
+	cmd := NewCommand(fmt.Sprintf(fileFetchScript, shellescape.Quote(p), codeFileFetchNotFound, codeFileFetchUnsupported, shellescape.Quote(stat.FormatJsonGnu)))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFileFetch, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileFetchNotFound:
+		return nil, ErrFileFetchNotFound
+	case codeFileFetchUnsupported:
+		return nil, ErrFileFetchUnsupported
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFileFetchUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(res.Stdout))
+	if !scanner.Scan() {
+		return nil, ErrFileFetchUnexpected
+	}
+
+	s, err := stat.ParseJsonFormat(scanner.Bytes())
+	if err != nil {
+		return nil, errors.Join(ErrFileFetchUnexpected, err)
+	}
+
+	f := &FileFetch{
+		Path: p,
+		Type: fileTypeFromStat(s.Mode),
+		Mode: s.Mode.ToFsFileMode().Perm(),
+		Size: s.Size,
+	}
+
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		if key == "md5" {
+			f.Md5Sum, err = fileFetchMd5HexToBase64(value)
+			if err != nil {
+				return nil, errors.Join(ErrFileFetchUnexpected, err)
+			}
+		}
+	}
+
+	if f.Md5Sum == "" {
+		return nil, errors.Join(ErrFileFetchUnexpected, errors.New("missing md5 checksum"))
+	}
+
+	return f, nil
+}
+
+func (c *fileFetchClient) Fetch(ctx context.Context, f FileFetch, w io.Writer) error {
+	var readCmd string
+	var out io.Writer
+	var sum func() (string, error)
+
+	switch f.Type {
+	case FileTypeFile:
+		readCmd = fmt.Sprintf(`cat %s`, shellescape.Quote(f.Path))
+
+		h := md5.New()
+		out = io.MultiWriter(w, h)
+		sum = func() (string, error) {
+			return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+		}
+	case FileTypeDirectory:
+		readCmd = fmt.Sprintf(`tar -C %s -czf - .`, shellescape.Quote(f.Path))
+
+		// The archive is read concurrently to compute the checksum of the contained files
+		pr, pw := io.Pipe()
+		type archiveSum struct {
+			sum string
+			err error
+		}
+		done := make(chan archiveSum, 1)
+		go func() {
+			s, err := fileFetchArchiveMd5Sum(pr)
+			_, _ = io.Copy(io.Discard, pr)
+			done <- archiveSum{sum: s, err: err}
+		}()
+
+		out = io.MultiWriter(w, pw)
+		sum = func() (string, error) {
+			_ = pw.Close()
+			r := <-done
+			return r.sum, r.err
+		}
+	default:
+		return ErrFileFetchUnsupported
+	}
+
+	res, err := ExecuteCommandWithOptions(ctx, c.s, NewCommand(readCmd), WithStdoutFunc(func(io.Writer) io.Writer {
+		return out
+	}), WithStderr())
+
+	// Always collect the checksum to release resources
+	actualSum, sumErr := sum()
+
+	if err != nil {
+		return errors.Join(ErrFileFetchUnexpected, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrFileFetchUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	if sumErr != nil {
+		return errors.Join(ErrFileFetchUnexpected, sumErr)
+	}
+
+	if actualSum != f.Md5Sum {
+		return errors.Join(ErrFileFetchChecksum, fmt.Errorf("expected md5 %s but got %s", f.Md5Sum, actualSum))
+	}
+
+	return nil
+}
+
+// fileFetchArchiveMd5Sum computes the checksum of a gzip compressed tar archive which equals the md5 checksum of
+// fileFetchScript for the folder from which the archive has been created
+func fileFetchArchiveMd5Sum(r io.Reader) (string, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Md5 checksums by entry name to resolve hard links
+	sums := map[string]string{}
+	var lines []string
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		name := "./" + strings.TrimPrefix(path.Clean(hdr.Name), "./")
+
+		var sum string
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			h := md5.New()
+			if _, err := io.Copy(h, tr); err != nil {
+				return "", err
+			}
+			sum = hex.EncodeToString(h.Sum(nil))
+		case tar.TypeLink:
+			linked, ok := sums["./"+strings.TrimPrefix(path.Clean(hdr.Linkname), "./")]
+			if !ok {
+				return "", fmt.Errorf("hard link %q to unknown entry %q", hdr.Name, hdr.Linkname)
+			}
+			sum = linked
+		default:
+			continue
+		}
+
+		sums[name] = sum
+		lines = append(lines, fmt.Sprintf("%s  %s", sum, name))
+	}
+
+	sort.Strings(lines)
+
+	h := md5.New()
+	for _, line := range lines {
+		_, _ = io.WriteString(h, line+"\n")
+	}
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileFetchMd5HexToBase64(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FileFragmentDir is the folder on the remote system in which the fragments of target files are stored
+const FileFragmentDir = "/var/lib/terraform-provider-system/fragments"
+
+// FileFragmentMaxOrder is the maximum order of a fragment
+const FileFragmentMaxOrder = 99999999
+
+// FileFragment is a part of a target file which is assembled from all fragments of the target
+type FileFragment struct {
+	Target string
+
+	// Name identifies the fragment within the target. Must only contain letters, digits, `_`, `-`, and `.`.
+	Name string
+
+	// Order defines the position of the fragment in the target. Fragments with the same order are sorted by name.
+	Order int
+
+	Content string
+
+	// Rendered reports whether the target equals the concatenation of all fragments of the target
+	Rendered bool
+}
+
+type FileFragmentClient interface {
+	// Get returns the fragment of the target with the name. Returns ErrFileFragmentNotFound if the fragment does not
+	// exist.
+	Get(ctx context.Context, target string, name string) (*FileFragment, error)
+
+	// Apply stores the fragment and renders the target. The folder of the target must exist.
+	Apply(ctx context.Context, f FileFragment) error
+
+	// Delete removes the fragment and renders the target. The target is removed if no fragment remains.
+	Delete(ctx context.Context, target string, name string) error
+}
+
+func NewFileFragmentClient(s system.System) FileFragmentClient {
+	return &fileFragmentClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFileFragment = errors.New("file fragment resource")
+
+	ErrFileFragmentNotFound = errors.Join(ErrFileFragment, errors.New("fragment not found"))
+
+	ErrFileFragmentTargetFolderNotFound = errors.Join(ErrFileFragment, errors.New("folder of target not found"))
+
+	ErrFileFragmentUnexpected = errors.Join(ErrFileFragment, errors.New("unexpected error"))
+)
+
+const (
+	codeFileFragmentNotFound = 17
+
+	codeFileFragmentTargetFolderNotFound = 18
+)
+
+type fileFragmentClient struct {
+	s system.System
+}
+
+var _ FileFragmentClient = &fileFragmentClient{}
+
+// fileFragmentDir returns the folder in FileFragmentDir in which the fragments of the target are stored
+func fileFragmentDir(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return path.Join(FileFragmentDir, hex.EncodeToString(sum[:]))
+}
+
+// fileFragmentFileName returns the name of the file in which the fragment is stored. The order is zero-padded to sort
+// the files lexically.
+func fileFragmentFileName(order int, name string) string {
+	return fmt.Sprintf("%08d-%s", order, name)
+}
+
+// fileFragmentFunctions defines shell functions which are shared by the fragment scripts. _fragments lists the
+// fragment files of a folder in order. _render assembles the target from the fragments in a temporary file which
+// retains the mode and the ownership of an existing target and replaces the target atomically.
+//
+// _locked runs a command while holding the lock `<folder>.lock` of the fragments folder so that concurrent resources
+// of the same target do not lose fragments. The lock is acquired using flock or, if flock is not available, by creating
+// the folder `<folder>.lock.d`. _locked gives up after 60 seconds.
+const fileFragmentFunctions = `_locked() {
+  lock="$1.lock"; shift;
+  mkdir -p "$(dirname "${lock}")" || return 1;
+  if command -v flock >/dev/null 2>&1; then
+    ( flock -w 60 9 || { echo "failed to lock ${lock}" >&2; exit 1; }; "$@" ) 9> "${lock}";
+    return;
+  fi;
+  tries=0;
+  until mkdir "${lock}.d" 2>/dev/null; do
+    tries=$((tries + 1)); [ "${tries}" -lt 60 ] || { echo "failed to lock ${lock}" >&2; return 1; }; sleep 1;
+  done;
+  "$@"; rc=$?; rmdir "${lock}.d"; return ${rc};
+};
+_fragments() {
+  find "$1" -mindepth 1 -maxdepth 1 -type f -name '????????-*' 2>/dev/null | LC_ALL=C sort;
+};
+_render() {
+  target=$1; dir=$2;
+  fragments="$(_fragments "${dir}")";
+  if [ -z "${fragments}" ]; then
+    rm -f "${target}" && { rmdir "${dir}" 2>/dev/null || true; };
+    return;
+  fi;
+  tmp="$(mktemp "$(dirname "${target}")/.$(basename "${target}").XXXXXX")" || return 1;
+  { if [ -f "${target}" ]; then cp -p "${target}" "${tmp}"; else chmod 644 "${tmp}"; fi; } && cat ${fragments} > "${tmp}" && mv -f "${tmp}" "${target}" || { rm -f "${tmp}"; return 1; };
+};`
+
+// fileFragmentGetScript prints the file name of the fragment, whether the target is rendered, and the content of the
+// fragment
+const fileFragmentGetScript = fileFragmentFunctions + `
+_do() {
+  target=$1; dir=$2; name=$3;
+  fragment="$(find "${dir}" -mindepth 1 -maxdepth 1 -type f -name "????????-${name}" 2>/dev/null | head -n 1)";
+  [ -n "${fragment}" ] || return %[4]d;
+  basename "${fragment}" || return 1;
+  if [ -f "${target}" ] && [ "$(cat $(_fragments "${dir}") | sha256sum)" = "$(sha256sum < "${target}")" ]; then echo rendered; else echo stale; fi;
+  cat "${fragment}" || return 1;
+}; _do %[1]s %[2]s %[3]s;`
+
+func (c *fileFragmentClient) Get(ctx context.Context, target string, name string) (*FileFragment, error) {
+	cmd := NewCommand(fmt.Sprintf(fileFragmentGetScript, shellescape.Quote(target), shellescape.Quote(fileFragmentDir(target)), shellescape.Quote(name), codeFileFragmentNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFileFragment, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileFragmentNotFound:
+		return nil, ErrFileFragmentNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFileFragmentUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	// The first two lines contain the file name and the render state followed by the verbatim content
+	parts := bytes.SplitN(res.Stdout, []byte("\n"), 3)
+	if len(parts) != 3 {
+		return nil, errors.Join(ErrFileFragmentUnexpected, errors.New("unexpected output"))
+	}
+
+	orderStr, _, _ := strings.Cut(string(parts[0]), "-")
+	order, err := strconv.Atoi(orderStr)
+	if err != nil {
+		return nil, errors.Join(ErrFileFragmentUnexpected, err)
+	}
+
+	return &FileFragment{
+		Target:   target,
+		Name:     name,
+		Order:    order,
+		Content:  string(parts[2]),
+		Rendered: string(parts[1]) == "rendered",
+	}, nil
+}
+
+// fileFragmentApplyScript replaces the fragment with the same name and any order and renders the target
+const fileFragmentApplyScript = fileFragmentFunctions + `
+_apply() {
+  target=$1; dir=$2; name=$3; file=$4;
+  [ -d "$(dirname "${target}")" ] || return %[5]d;
+  mkdir -p "${dir}" && chmod 700 "${dir}" || return 1;
+  find "${dir}" -mindepth 1 -maxdepth 1 -type f -name "????????-${name}" ! -name "${file}" -exec rm -f {} + || return 1;
+  cat > "${dir}/${file}" || return 1;
+  _render "${target}" "${dir}";
+};
+_do() { _locked "$2" _apply "$@"; }; _do %[1]s %[2]s %[3]s %[4]s;`
+
+func (c *fileFragmentClient) Apply(ctx context.Context, f FileFragment) error {
+	if f.Order < 0 || f.Order > FileFragmentMaxOrder {
+		return errors.Join(ErrFileFragment, fmt.Errorf("order must be between 0 and %d", FileFragmentMaxOrder))
+	}
+
+	cmd := NewInputCommand(fmt.Sprintf(fileFragmentApplyScript, shellescape.Quote(f.Target), shellescape.Quote(fileFragmentDir(f.Target)), shellescape.Quote(f.Name), shellescape.Quote(fileFragmentFileName(f.Order, f.Name)), codeFileFragmentTargetFolderNotFound), strings.NewReader(f.Content))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFileFragment, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileFragmentTargetFolderNotFound:
+		return ErrFileFragmentTargetFolderNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrFileFragmentUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+// fileFragmentDeleteScript removes the fragment and renders the target
+const fileFragmentDeleteScript = fileFragmentFunctions + `
+_delete() {
+  target=$1; dir=$2; name=$3;
+  fragment="$(find "${dir}" -mindepth 1 -maxdepth 1 -type f -name "????????-${name}" 2>/dev/null)";
+  [ -n "${fragment}" ] || return %[4]d;
+  rm -f ${fragment} || return 1;
+  _render "${target}" "${dir}";
+};
+_do() { _locked "$2" _delete "$@"; }; _do %[1]s %[2]s %[3]s;`
+
+func (c *fileFragmentClient) Delete(ctx context.Context, target string, name string) error {
+	cmd := NewCommand(fmt.Sprintf(fileFragmentDeleteScript, shellescape.Quote(target), shellescape.Quote(fileFragmentDir(target)), shellescape.Quote(name), codeFileFragmentNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFileFragment, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileFragmentNotFound:
+		return ErrFileFragmentNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrFileFragmentUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alessio/shellescape"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFileFragmentApplyConcurrently applies fragments concurrently and verifies that the target contains all fragments
+func testFileFragmentApplyConcurrently(t *testing.T, env []string) {
+	root := t.TempDir()
+	target := filepath.Join(root, "target.conf")
+	dir := filepath.Join(root, "fragments", "target")
+
+	const n = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("f%02d", i)
+			script := fmt.Sprintf(fileFragmentApplyScript, shellescape.Quote(target), shellescape.Quote(dir), name, fileFragmentFileName(i, name), codeFileFragmentTargetFolderNotFound)
+
+			cmd := exec.Command("sh", "-c", script)
+			cmd.Env = env
+			cmd.Stdin = strings.NewReader(name + "\n")
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				errs[i] = fmt.Errorf("%w: %s", err, out)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var expected strings.Builder
+	for i := 0; i < n; i++ {
+		require.NoError(t, errs[i])
+		expected.WriteString(fmt.Sprintf("f%02d\n", i))
+	}
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, expected.String(), string(data))
+
+	// The lock folder of the fallback is removed
+	assert.NoDirExists(t, dir+".lock.d")
+}
+
+func TestFileFragmentApplyScript_locked(t *testing.T) {
+	t.Parallel()
+
+	t.Run("flock", func(t *testing.T) {
+		if _, err := exec.LookPath("flock"); err != nil {
+			t.Skip("flock not available")
+		}
+		testFileFragmentApplyConcurrently(t, os.Environ())
+	})
+
+	t.Run("mkdir", func(t *testing.T) {
+		// PATH without flock
+		bin := t.TempDir()
+		for _, tool := range []string{"sh", "find", "sort", "mkdir", "rmdir", "chmod", "rm", "cat", "cp", "mv", "mktemp", "dirname", "basename", "sleep"} {
+			p, err := exec.LookPath(tool)
+			require.NoError(t, err)
+			require.NoError(t, os.Symlink(p, filepath.Join(bin, tool)))
+		}
+		testFileFragmentApplyConcurrently(t, []string{"PATH=" + bin})
+	})
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// FileMeta is the meta information of a file, folder, or link
+type FileMeta struct {
+	Path         string
+	Type         FileType
+	Mode         fs.FileMode
+	User         string
+	Uid          int
+	Group        string
+	Gid          int
+	Size         int64
+	Inode        uint64
+	Links        int
+	AccessTime   time.Time
+	ModifiedTime time.Time
+	ChangeTime   time.Time
+
+	// Target is the target of a symbolic link
+	Target string
+
+	// Md5Sum is the md5 checksum of the contents of a regular file in base64 encoding
+	Md5Sum string
+
+	// Sha256Sum is the sha256 checksum of the contents of a regular file in hex encoding
+	Sha256Sum string
+
+	// Sha512Sum is the sha512 checksum of the contents of a regular file in hex encoding
+	Sha512Sum string
+}
+
+type FileMetaClient interface {
+	// Get returns the meta information of the path without following a symbolic link. Checksums are computed for
+	// regular files and symbolic links to regular files. Returns ErrFileMetaNotFound if the path does not exist.
+	Get(ctx context.Context, path string) (*FileMeta, error)
+}
+
+func NewFileMetaClient(s system.System) FileMetaClient {
+	return &fileMetaClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFileMeta = errors.New("file meta data source")
+
+	ErrFileMetaNotFound = errors.Join(ErrFileMeta, errors.New("file not found"))
+
+	ErrFileMetaUnexpected = errors.Join(ErrFileMeta, errors.New("unexpected error"))
+)
+
+const (
+	codeFileMetaNotFound = 17
+)
+
+type fileMetaClient struct {
+	s system.System
+}
+
+var _ FileMetaClient = &fileMetaClient{}
+
+// fileMetaScript prints the stat of the path followed by a line for each checksum of a regular file
+const fileMetaScript = `_do() {
+  path=$1;
+  [ -e "${path}" ] || [ -L "${path}" ] || return %[2]d;
+  stat -c %[3]s "${path}" || return 1;
+  if [ -f "${path}" ]; then
+    echo "md5 $(md5sum < "${path}" | cut -d ' ' -f 1)";
+    echo "sha256 $(sha256sum < "${path}" | cut -d ' ' -f 1)";
+    echo "sha512 $(sha512sum < "${path}" | cut -d ' ' -f 1)";
+  fi;
+}; _do %[1]s;`
+
+func (c *fileMetaClient) Get(ctx context.Context, path string) (*FileMeta, error) {
+	cmd := NewCommand(fmt.Sprintf(fileMetaScript, shellescape.Quote(path), codeFileMetaNotFound, shellescape.Quote(stat.FormatJsonGnu)))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFileMeta, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileMetaNotFound:
+		return nil, ErrFileMetaNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFileMetaUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return parseFileMetaOutput(path, res.Stdout)
+}
+
+// parseFileMetaOutput parses the output of fileMetaScript
+func parseFileMetaOutput(path string, out []byte) (*FileMeta, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if !scanner.Scan() {
+		return nil, ErrFileMetaUnexpected
+	}
+
+	s, err := stat.ParseJsonFormat(scanner.Bytes())
+	if err != nil {
+		return nil, errors.Join(ErrFileMetaUnexpected, err)
+	}
+
+	m := &FileMeta{
+		Path:         path,
+		Type:         fileTypeFromStat(s.Mode),
+		Mode:         s.Mode.ToFsFileMode(),
+		User:         s.User,
+		Uid:          s.Uid,
+		Group:        s.Group,
+		Gid:          s.Gid,
+		Size:         s.Size,
+		Inode:        s.Inode,
+		Links:        s.Links,
+		AccessTime:   s.AccessTime,
+		ModifiedTime: s.ModifiedTime,
+		ChangeTime:   s.ChangeTime,
+		Target:       s.Target,
+	}
+
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "md5":
+			// Convert md5 sum from hex to base64 encoding
+			md5Hex, err := hex.DecodeString(value)
+			if err != nil {
+				return nil, errors.Join(ErrFileMetaUnexpected, err)
+			}
+			m.Md5Sum = base64.StdEncoding.EncodeToString(md5Hex)
+		case "sha256":
+			m.Sha256Sum = value
+		case "sha512":
+			m.Sha512Sum = value
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"path"
+)
+
+// FileStashDir is the folder on the remote system in which original files are stashed
+const FileStashDir = "/var/lib/terraform-provider-system/stash"
+
+var (
+	ErrFileStashNotFound = errors.Join(ErrFile, errors.New("stashed file not found"))
+)
+
+// fileStashPath returns the path in FileStashDir at which the original file at the path is stashed
+func fileStashPath(p string) string {
+	sum := sha256.Sum256([]byte(p))
+	return path.Join(FileStashDir, hex.EncodeToString(sum[:]))
+}
+
+func (c *fileClient) Stash(ctx context.Context, p string) (string, error) {
+	stashPath := fileStashPath(p)
+
+	// An existing stash is retained because it contains the original file of a previous attempt
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; stash=$2; [ -f "${path}" ] || return %[3]d; [ ! -e "${stash}" ] || return 0; mkdir -p "$(dirname "${stash}")" && chmod 700 "$(dirname "${stash}")" && cp -p "${path}" "${stash}" || { rm -f "${stash}"; return 1; }; }; _do %[1]s %[2]s;`, shellescape.Quote(p), shellescape.Quote(stashPath), codeFileNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return "", errors.Join(ErrFile, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileNotFound:
+		return "", ErrFileNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return "", errors.Join(ErrFile, err, fmt.Errorf("failed to stash %q", p))
+	}
+
+	return stashPath, nil
+}
+
+func (c *fileClient) Restore(ctx context.Context, p string, stashPath string) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; stash=$2; [ -f "${stash}" ] || return %[3]d; mv -f "${stash}" "${path}" || return 1; }; _do %[1]s %[2]s;`, shellescape.Quote(p), shellescape.Quote(stashPath), codeFileNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFile, err)
+	}
+
+	switch res.ExitCode {
+	case codeFileNotFound:
+		return ErrFileStashNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return errors.Join(ErrFile, err, fmt.Errorf("failed to restore %q", p))
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FileType string
+
+const (
+	FileTypeFile      FileType = "file"
+	FileTypeDirectory FileType = "directory"
+	FileTypeSymlink   FileType = "symlink"
+	FileTypeOther     FileType = "other"
+)
+
+// FileTypes returns the file types which can be used as filter in FilesQuery
+func FileTypes() []string {
+	return []string{
+		string(FileTypeFile),
+		string(FileTypeDirectory),
+		string(FileTypeSymlink),
+	}
+}
+
+// findType returns the argument of `find -type` for the file type
+func (t FileType) findType() string {
+	switch t {
+	case FileTypeFile:
+		return "f"
+	case FileTypeDirectory:
+		return "d"
+	case FileTypeSymlink:
+		return "l"
+	}
+	return ""
+}
+
+func fileTypeFromStat(m stat.FileMode) FileType {
+	switch {
+	case m.IsRegular():
+		return FileTypeFile
+	case m.IsDir():
+		return FileTypeDirectory
+	case m.IsSymlink():
+		return FileTypeSymlink
+	}
+	return FileTypeOther
+}
+
+// FilesQuery describes the entries of a folder to list
+type FilesQuery struct {
+	Path string
+
+	// Pattern is a glob which is matched against the base name of the entries. All entries are listed if empty.
+	Pattern string
+
+	// Recursive lists the entries of sub folders
+	Recursive bool
+
+	// MaxDepth limits the depth of sub folders if Recursive is true. The depth is not limited if not positive.
+	MaxDepth int
+
+	// Type limits the entries to the file type. All entries are listed if empty.
+	Type FileType
+
+	// Sha256Sum computes the sha256 checksum of regular files
+	Sha256Sum bool
+}
+
+// FilesEntry is an entry of a folder
+type FilesEntry struct {
+	Path         string
+	Type         FileType
+	Size         int64
+	Mode         fs.FileMode
+	User         string
+	Uid          int
+	Group        string
+	Gid          int
+	ModifiedTime time.Time
+
+	// Sha256Sum is the sha256 checksum of a regular file in hex encoding if requested in FilesQuery
+	Sha256Sum string
+}
+
+type FilesClient interface {
+	// List returns the entries of the folder which match the query sorted by path
+	List(ctx context.Context, q FilesQuery) ([]FilesEntry, error)
+}
+
+func NewFilesClient(s system.System) FilesClient {
+	return &filesClient{
+		s: s,
+	}
+}
+
+var (
+	ErrFiles = errors.New("files data source")
+
+	ErrFilesNotFound = errors.Join(ErrFiles, errors.New("folder not found"))
+
+	ErrFilesUnexpected = errors.Join(ErrFiles, errors.New("unexpected error"))
+)
+
+const (
+	codeFilesNotFound = 17
+)
+
+type filesClient struct {
+	s system.System
+}
+
+var _ FilesClient = &filesClient{}
+
+// findArgs returns the arguments of `find` which select the entries of the query
+func (q FilesQuery) findArgs() string {
+	args := []string{`-mindepth 1`}
+
+	if !q.Recursive {
+		args = append(args, `-maxdepth 1`)
+	} else if q.MaxDepth > 0 {
+		args = append(args, fmt.Sprintf(`-maxdepth %d`, q.MaxDepth))
+	}
+
+	if q.Pattern != "" {
+		args = append(args, fmt.Sprintf(`-name %s`, shellescape.Quote(q.Pattern)))
+	}
+
+	if t := q.Type.findType(); t != "" {
+		args = append(args, fmt.Sprintf(`-type %s`, t))
+	}
+
+	return strings.Join(args, " ")
+}
+
+func (c *filesClient) List(ctx context.Context, q FilesQuery) ([]FilesEntry, error) {
+	findArgs := q.findArgs()
+
+	sep, err := newRecordSeparator()
+	if err != nil {
+		return nil, errors.Join(ErrFilesUnexpected, err)
+	}
+
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; find "${path}" %[3]s -exec stat -c %[4]s {} + || return 1; }; _do %[1]s;`, shellescape.Quote(q.Path), codeFilesNotFound, findArgs, shellescape.Quote(stat.FormatJsonRecord(sep))))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFiles, err)
+	}
+
+	switch res.ExitCode {
+	case codeFilesNotFound:
+		return nil, ErrFilesNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFilesUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	stats, err := stat.ParseJsonRecordFormat(res.Stdout, sep)
+	if err != nil {
+		return nil, errors.Join(ErrFilesUnexpected, err)
+	}
+
+	var sums map[string]string
+	if q.Sha256Sum && (q.Type == "" || q.Type == FileTypeFile) {
+		sums, err = c.sha256Sums(ctx, q.Path, findArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]FilesEntry, 0, len(stats))
+	for _, s := range stats {
+		entries = append(entries, FilesEntry{
+			Path:         s.Name,
+			Type:         fileTypeFromStat(s.Mode),
+			Size:         s.Size,
+			Mode:         s.Mode.ToFsFileMode(),
+			User:         s.User,
+			Uid:          s.Uid,
+			Group:        s.Group,
+			Gid:          s.Gid,
+			ModifiedTime: s.ModifiedTime,
+			Sha256Sum:    sums[s.Name],
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries, nil
+}
+
+// filesSha256SumsScript prints the sha256 checksum and the name of each regular file selected by the find arguments
+// prefixed with the record separator. The checksum is computed from stdin because the output of sha256sum escapes
+// special characters in names unless `-z` is supported which is not the case for BusyBox.
+const filesSha256SumsScript = `_do() {
+  path=$1; sep=$2;
+  find "${path}" %[2]s -type f -exec sh -c 'sep=$1; shift; for f in "$@"; do s=$(sha256sum < "${f}") || exit 1; printf "%%s%%s %%s\n" "${sep}" "${s%%%% *}" "${f}"; done' _ "${sep}" {} + || return 1;
+}; _do %[1]s;`
+
+// sha256Sums returns the sha256 checksums of the regular files selected by findArgs by path
+func (c *filesClient) sha256Sums(ctx context.Context, path string, findArgs string) (map[string]string, error) {
+	sep, err := newRecordSeparator()
+	if err != nil {
+		return nil, errors.Join(ErrFilesUnexpected, err)
+	}
+
+	cmd := NewCommand(fmt.Sprintf(filesSha256SumsScript, shellescape.Quote(path)+" "+shellescape.Quote(sep), findArgs))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFiles, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFilesUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	records := strings.Split(res.StdoutString(), sep)
+	if strings.TrimSpace(records[0]) != "" {
+		return nil, errors.Join(ErrFilesUnexpected, fmt.Errorf("unexpected output of sha256sum %s", strconv.Quote(records[0])))
+	}
+
+	sums := map[string]string{}
+	for _, record := range records[1:] {
+		sum, name, ok := strings.Cut(strings.TrimSuffix(record, "\n"), " ")
+		if !ok || len(sum) != 64 || name == "" {
+			return nil, errors.Join(ErrFilesUnexpected, fmt.Errorf("unexpected output of sha256sum %s", strconv.Quote(record)))
+		}
+
+		sums[name] = sum
+	}
+
+	return sums, nil
+}
+
+// newRecordSeparator returns a random separator of records in the output of a command. The separator is unlikely to
+// occur in names of files.
+func newRecordSeparator() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("::%x::", b), nil
+}
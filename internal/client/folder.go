@@ -8,6 +8,7 @@ import (
 	"github.com/neuspaces/terraform-provider-system/internal/system"
 	"io/fs"
 	"strconv"
+	"strings"
 )
 
 type Folder struct {
@@ -32,9 +33,22 @@ func newFolderFromStat(s *stat.Stat) *Folder {
 
 type FolderClient interface {
 	Get(ctx context.Context, path string) (*Folder, error)
-	Create(ctx context.Context, folder Folder) error
+	// Create creates the folder and missing parent folders. Returns the paths of the created folders starting with the
+	// deepest folder.
+	Create(ctx context.Context, folder Folder) ([]string, error)
 	Update(ctx context.Context, folder Folder) error
+
+	// Delete removes the folder and its contents recursively
 	Delete(ctx context.Context, path string) error
+
+	// DeleteEmpty removes the folder only if it is empty. Returns ErrFolderNotEmpty otherwise.
+	DeleteEmpty(ctx context.Context, path string) error
+
+	// ScanTree summarizes the files and folders within the folder which deviate from the ownership and permissions of the tree
+	ScanTree(ctx context.Context, tree FolderTree) (*FolderTreeScan, error)
+
+	// ReconcileTree applies the ownership and permissions of the tree to the files and folders within the folder which deviate
+	ReconcileTree(ctx context.Context, tree FolderTree) error
 }
 
 func NewFolderClient(s system.System) FolderClient {
@@ -51,6 +65,8 @@ var (
 	ErrFolderNotFound = errors.Join(ErrFolder, errors.New("folder not found"))
 
 	ErrFolderUnexpected = errors.Join(ErrFolder, errors.New("unexpected error"))
+
+	ErrFolderNotEmpty = errors.Join(ErrFolder, errors.New("folder not empty"))
 )
 
 const (
@@ -59,6 +75,8 @@ const (
 	codeFolderPathExists = 16
 
 	codeFolderNotFound = 17
+
+	codeFolderNotEmpty = 18
 )
 
 type folderClient struct {
@@ -91,7 +109,7 @@ func (c *folderClient) Get(ctx context.Context, path string) (*Folder, error) {
 	return folder, nil
 }
 
-func (c *folderClient) Create(ctx context.Context, f Folder) error {
+func (c *folderClient) Create(ctx context.Context, f Folder) ([]string, error) {
 	pathSub := `"${path}"`
 
 	var createCmds []Command
@@ -110,23 +128,31 @@ func (c *folderClient) Create(ctx context.Context, f Folder) error {
 		createCmds = append(createCmds, &ChgrpCommand{Path: pathSub, Group: f.Group})
 	}
 
-	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ ! -e "${path}" ] || return %[2]d; { %[3]s; } || return 1; }; _do '%[1]s';`, f.Path, codeFolderPathExists, CompositeCommand(createCmds).Command()))
+	// Report the folder and the missing parent folders which are created
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ ! -e "${path}" ] || return %[2]d; p="${path}"; while [ ! -e "${p}" ]; do echo "${p}"; p="$(dirname "${p}")"; done; { %[3]s; } || return 1; }; _do '%[1]s';`, f.Path, codeFolderPathExists, CompositeCommand(createCmds).Command()))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
-		return errors.Join(ErrFolder, err)
+		return nil, errors.Join(ErrFolder, err)
 	}
 
 	switch res.ExitCode {
 	case codeFolderPathExists:
-		return ErrFolderPathExists
+		return nil, ErrFolderPathExists
 	}
 
 	err = res.Error()
 	if err != nil {
-		return errors.Join(ErrFolder, err)
+		return nil, errors.Join(ErrFolder, err)
 	}
 
-	return nil
+	var created []string
+	for _, line := range strings.Split(res.StdoutString(), "\n") {
+		if line != "" {
+			created = append(created, line)
+		}
+	}
+
+	return created, nil
 }
 
 func (c *folderClient) Update(ctx context.Context, f Folder) error {
@@ -192,3 +218,24 @@ func (c *folderClient) Delete(ctx context.Context, path string) error {
 
 	return nil
 }
+
+func (c *folderClient) DeleteEmpty(ctx context.Context, path string) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; [ -z "$(ls -A "${path}")" ] || return %[3]d; rmdir "${path}" || return 1; }; _do '%[1]s';`, path, codeFolderNotFound, codeFolderNotEmpty))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFolder, err)
+	}
+
+	switch res.ExitCode {
+	case codeFolderNotFound:
+		return ErrFolderNotFound
+	case codeFolderNotEmpty:
+		return ErrFolderNotEmpty
+	}
+
+	if res.ExitCode != 0 {
+		return errors.Join(ErrFolder, fmt.Errorf("failed to delete %q", path))
+	}
+
+	return nil
+}
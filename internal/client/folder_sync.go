@@ -0,0 +1,310 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FolderSyncFile is a regular file below the destination folder
+type FolderSyncFile struct {
+	// Path is the slash-separated path relative to the destination folder
+	Path string
+
+	Mode  fs.FileMode
+	User  string
+	Uid   int
+	Group string
+	Gid   int
+
+	// Md5Sum is the md5 checksum of the file contents in hex encoding
+	Md5Sum string
+}
+
+// FolderSyncUpload is a file which is transferred to the destination folder
+type FolderSyncUpload struct {
+	// Path is the slash-separated path relative to the destination folder
+	Path string
+
+	Mode fs.FileMode
+	Size int64
+
+	// Open returns the content of the file
+	Open func() (io.ReadCloser, error)
+}
+
+// FolderSyncAttributes are applied to a file or folder below the destination folder
+type FolderSyncAttributes struct {
+	// Path is the slash-separated path relative to the destination folder
+	Path string
+
+	// Mode is not changed if zero
+	Mode fs.FileMode
+
+	// User is not changed if empty
+	User string
+
+	// Group is not changed if empty
+	Group string
+}
+
+type FolderSyncClient interface {
+	// List returns all regular files below the destination folder
+	List(ctx context.Context, destination string) ([]FolderSyncFile, error)
+
+	// Upload transfers files to the destination folder. Missing folders are created.
+	Upload(ctx context.Context, destination string, files []FolderSyncUpload) error
+
+	// SetAttributes changes permissions and ownership of files and folders below the destination folder
+	SetAttributes(ctx context.Context, destination string, attrs []FolderSyncAttributes) error
+
+	// Delete removes files below the destination folder. Folders which become empty are removed.
+	Delete(ctx context.Context, destination string, paths []string) error
+
+	// DeleteIfEmpty removes the destination folder if it is empty
+	DeleteIfEmpty(ctx context.Context, destination string) error
+}
+
+type FolderSyncClientOpt func(c *folderSyncClient)
+
+func FolderSyncClientCompression(enabled bool) FolderSyncClientOpt {
+	return func(c *folderSyncClient) {
+		c.compress = enabled
+	}
+}
+
+func NewFolderSyncClient(s system.System, opts ...FolderSyncClientOpt) FolderSyncClient {
+	c := &folderSyncClient{
+		s: s,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+var (
+	ErrFolderSync = errors.New("folder sync resource")
+
+	ErrFolderSyncNotFound = errors.Join(ErrFolderSync, errors.New("destination folder not found"))
+
+	ErrFolderSyncUnexpected = errors.Join(ErrFolderSync, errors.New("unexpected error"))
+)
+
+type folderSyncClient struct {
+	s system.System
+
+	compress bool
+}
+
+var _ FolderSyncClient = &folderSyncClient{}
+
+// find executes a command for all regular files below the destination folder and returns the output lines
+func (c *folderSyncClient) find(ctx context.Context, destination string, exec string) ([]string, error) {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; cd "${path}" && find . -type f -exec %[3]s {} +; }; _do %[1]s;`, shellescape.Quote(destination), codeDestinationNotFound, exec))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFolderSyncUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeDestinationNotFound:
+		return nil, ErrFolderSyncNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrFolderSyncUnexpected, err)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(res.Stdout))
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+func (c *folderSyncClient) List(ctx context.Context, destination string) ([]FolderSyncFile, error) {
+	statLines, err := c.find(ctx, destination, `stat -c '%a %u %g %U %G %n'`)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*FolderSyncFile{}
+
+	for _, line := range statLines {
+		fields := strings.SplitN(line, " ", 6)
+		if len(fields) != 6 {
+			return nil, errors.Join(ErrFolderSyncUnexpected, fmt.Errorf("unexpected stat output %q", line))
+		}
+
+		mode, err := strconv.ParseUint(fields[0], 8, 32)
+		if err != nil {
+			return nil, errors.Join(ErrFolderSyncUnexpected, err)
+		}
+
+		uid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errors.Join(ErrFolderSyncUnexpected, err)
+		}
+
+		gid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Join(ErrFolderSyncUnexpected, err)
+		}
+
+		p := strings.TrimPrefix(fields[5], "./")
+		files[p] = &FolderSyncFile{
+			Path:  p,
+			Mode:  fs.FileMode(mode),
+			User:  fields[3],
+			Uid:   uid,
+			Group: fields[4],
+			Gid:   gid,
+		}
+	}
+
+	md5Lines, err := c.find(ctx, destination, `md5sum`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range md5Lines {
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, errors.Join(ErrFolderSyncUnexpected, fmt.Errorf("unexpected md5sum output %q", line))
+		}
+
+		if f, ok := files[strings.TrimPrefix(name, "./")]; ok {
+			f.Md5Sum = sum
+		}
+	}
+
+	result := make([]FolderSyncFile, 0, len(files))
+	for _, f := range files {
+		result = append(result, *f)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+// writeTar writes a tar archive of files to w
+func writeTar(w io.Writer, files []FolderSyncUpload) error {
+	tw := tar.NewWriter(w)
+
+	for _, f := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.Path,
+			Mode:     int64(f.Mode.Perm()),
+			Size:     f.Size,
+		})
+		if err != nil {
+			return err
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		_, err = io.CopyN(tw, r, f.Size)
+		_ = r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %w", f.Path, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+func (c *folderSyncClient) Upload(ctx context.Context, destination string, files []FolderSyncUpload) error {
+	if len(files) == 0 {
+		// Nothing to do because up-to-date
+		return nil
+	}
+
+	err := extractTar(ctx, c.s, destination, c.compress, func(w io.Writer) error {
+		return writeTar(w, files)
+	})
+	if err != nil {
+		return errors.Join(ErrFolderSyncUnexpected, err)
+	}
+
+	return nil
+}
+
+// runScript executes a shell script in the destination folder
+func (c *folderSyncClient) runScript(ctx context.Context, destination string, script []string) error {
+	err := runDestinationScript(ctx, c.s, destination, script)
+	if errors.Is(err, errDestinationNotFound) {
+		return ErrFolderSyncNotFound
+	}
+	if err != nil {
+		return errors.Join(ErrFolderSyncUnexpected, err)
+	}
+
+	return nil
+}
+
+func (c *folderSyncClient) SetAttributes(ctx context.Context, destination string, attrs []FolderSyncAttributes) error {
+	var script []string
+
+	for _, a := range attrs {
+		p := quoteRelative(a.Path)
+
+		cmds := CompositeCommand{}
+		if a.Mode != 0 {
+			cmds = append(cmds, &ChmodCommand{Path: p, Mode: a.Mode})
+		}
+		if a.User != "" {
+			cmds = append(cmds, &ChownCommand{Path: p, User: a.User, Group: a.Group})
+		} else if a.Group != "" {
+			cmds = append(cmds, &ChgrpCommand{Path: p, Group: a.Group})
+		}
+
+		if len(cmds) > 0 {
+			script = append(script, cmds.Command())
+		}
+	}
+
+	return c.runScript(ctx, destination, script)
+}
+
+func (c *folderSyncClient) Delete(ctx context.Context, destination string, paths []string) error {
+	err := c.runScript(ctx, destination, deleteDestinationScript(paths, nil))
+	if errors.Is(err, ErrFolderSyncNotFound) {
+		// Not interpreted as error because this is the desired state
+		return nil
+	}
+
+	return err
+}
+
+func (c *folderSyncClient) DeleteIfEmpty(ctx context.Context, destination string) error {
+	err := deleteDestinationIfEmpty(ctx, c.s, destination)
+	if err != nil {
+		return errors.Join(ErrFolderSyncUnexpected, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FolderTree describes the expected ownership and permissions of all files and folders within a folder
+type FolderTree struct {
+	Path string
+
+	// Uid is the expected owner of all entries. Ownership is not enforced if negative.
+	Uid int
+
+	// Gid is the expected group of all entries. Ownership is not enforced if negative.
+	Gid int
+
+	// DirMode is the expected mode of all folders. Not enforced if zero.
+	DirMode fs.FileMode
+
+	// FileMode is the expected mode of all regular files. Not enforced if zero.
+	FileMode fs.FileMode
+}
+
+// FolderTreeScan summarizes the entries within a folder which deviate from a FolderTree
+type FolderTreeScan struct {
+	// Deviations is the number of entries which deviate
+	Deviations int
+
+	// Digest is the sha256 checksum of the listing of deviating entries. Empty if no entries deviate.
+	Digest string
+}
+
+// deviationPredicates returns find predicates which match entries deviating from the tree. Returns an empty string if
+// the tree does not enforce any ownership or permissions.
+func (t FolderTree) deviationPredicates() string {
+	var preds []string
+
+	if t.Uid >= 0 {
+		preds = append(preds, fmt.Sprintf(`! -user %d`, t.Uid))
+	}
+
+	if t.Gid >= 0 {
+		preds = append(preds, fmt.Sprintf(`! -group %d`, t.Gid))
+	}
+
+	if t.DirMode != 0 {
+		preds = append(preds, fmt.Sprintf(`\( -type d ! -perm %o \)`, t.DirMode))
+	}
+
+	if t.FileMode != 0 {
+		preds = append(preds, fmt.Sprintf(`\( -type f ! -perm %o \)`, t.FileMode))
+	}
+
+	if len(preds) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(`\( %s \)`, strings.Join(preds, ` -o `))
+}
+
+// folderTreeScanScript prints the number and the sha256 checksum of the entries which match the find predicates. The
+// entries are listed using `stat -c` which is supported by GNU coreutils and BusyBox. The raw mode `%%f` contains the
+// type and the permissions of an entry. The path of the folder is stripped from the listing which is sorted to obtain a
+// stable digest independent of the directory order.
+const folderTreeScanScript = `_do() {
+  path=$1; [ -d "${path}" ] || return %[2]d;
+  list="$(find "${path}" -mindepth 1 %[3]s -exec stat -c '%%f %%u %%g %%n' {} + | awk -v prefix="${path%%/}/" '{ i = index($0, prefix); print substr($0, 1, i - 1) substr($0, i + length(prefix)) }' | LC_ALL=C sort)" || return 1;
+  [ -n "${list}" ] || return 0;
+  printf '%%s\n' "${list}" | wc -l | tr -d ' ';
+  printf '%%s\n' "${list}" | sha256sum | cut -d ' ' -f 1;
+}; _do %[1]s;`
+
+func (c *folderClient) ScanTree(ctx context.Context, t FolderTree) (*FolderTreeScan, error) {
+	preds := t.deviationPredicates()
+	if preds == "" {
+		return &FolderTreeScan{}, nil
+	}
+
+	cmd := NewCommand(fmt.Sprintf(folderTreeScanScript, shellescape.Quote(t.Path), codeFolderNotFound, preds))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrFolder, err)
+	}
+
+	switch res.ExitCode {
+	case codeFolderNotFound:
+		return nil, ErrFolderNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return nil, errors.Join(ErrFolder, err)
+	}
+
+	out := strings.TrimSpace(res.StdoutString())
+	if out == "" {
+		return &FolderTreeScan{}, nil
+	}
+
+	countStr, digest, ok := strings.Cut(out, "\n")
+	if !ok {
+		return nil, ErrFolderUnexpected
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return nil, errors.Join(ErrFolderUnexpected, err)
+	}
+
+	return &FolderTreeScan{
+		Deviations: count,
+		Digest:     digest,
+	}, nil
+}
+
+func (c *folderClient) ReconcileTree(ctx context.Context, t FolderTree) error {
+	// Only entries which deviate are modified. Ownership is applied before permissions because chown may clear the
+	// setuid and setgid bits.
+	var reconcileCmds []Command
+
+	if t.Uid >= 0 && t.Gid >= 0 {
+		reconcileCmds = append(reconcileCmds, NewCommand(fmt.Sprintf(`find "${path}" -mindepth 1 \( ! -user %[1]d -o ! -group %[2]d \) -exec chown -h %[1]d:%[2]d {} +`, t.Uid, t.Gid)))
+	} else if t.Uid >= 0 {
+		reconcileCmds = append(reconcileCmds, NewCommand(fmt.Sprintf(`find "${path}" -mindepth 1 ! -user %[1]d -exec chown -h %[1]d {} +`, t.Uid)))
+	} else if t.Gid >= 0 {
+		reconcileCmds = append(reconcileCmds, NewCommand(fmt.Sprintf(`find "${path}" -mindepth 1 ! -group %[1]d -exec chgrp -h %[1]d {} +`, t.Gid)))
+	}
+
+	if t.DirMode != 0 {
+		reconcileCmds = append(reconcileCmds, NewCommand(fmt.Sprintf(`find "${path}" -mindepth 1 -type d ! -perm %[1]o -exec chmod %[1]o {} +`, t.DirMode)))
+	}
+
+	if t.FileMode != 0 {
+		reconcileCmds = append(reconcileCmds, NewCommand(fmt.Sprintf(`find "${path}" -mindepth 1 -type f ! -perm %[1]o -exec chmod %[1]o {} +`, t.FileMode)))
+	}
+
+	if len(reconcileCmds) == 0 {
+		// Nothing to do because neither ownership nor permissions are enforced
+		return nil
+	}
+
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; { %[3]s; } || return 1; }; _do %[1]s;`, shellescape.Quote(t.Path), codeFolderNotFound, CompositeCommand(reconcileCmds).Command()))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrFolder, err)
+	}
+
+	switch res.ExitCode {
+	case codeFolderNotFound:
+		return ErrFolderNotFound
+	}
+
+	err = res.Error()
+	if err != nil {
+		return errors.Join(ErrFolder, err)
+	}
+
+	return nil
+}
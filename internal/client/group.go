@@ -52,6 +52,9 @@ const (
 
 type groupClient struct {
 	s system.System
+
+	// busybox caches whether the BusyBox commands are used
+	busybox *bool
 }
 
 func (c *groupClient) Get(ctx context.Context, gid int) (*Group, error) {
@@ -85,6 +88,14 @@ func (c *groupClient) Get(ctx context.Context, gid int) (*Group, error) {
 }
 
 func (c *groupClient) Create(ctx context.Context, g Group) (int, error) {
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if busybox {
+		return c.createBusybox(ctx, g)
+	}
+
 	var args []string
 
 	if g.Gid != -1 {
@@ -136,6 +147,14 @@ func (c *groupClient) Update(ctx context.Context, g Group) error {
 		return nil
 	}
 
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return err
+	}
+	if busybox {
+		return c.updateBusybox(ctx, g)
+	}
+
 	groupmodCmd := fmt.Sprintf(`groupmod %s "${group}"`, strings.Join(args, " "))
 	cmd := NewCommand(fmt.Sprintf(`_do() { gid=$1; group=$(getent group $gid | cut -d: -f1); [ ! -z "${group}" ] || return %[2]d; %[3]s; return $?; }; _do '%[1]d';`, g.Gid, codeGroupNotFound, groupmodCmd))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
@@ -161,6 +180,14 @@ func (c *groupClient) Update(ctx context.Context, g Group) error {
 }
 
 func (c *groupClient) Delete(ctx context.Context, gid int) error {
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return err
+	}
+	if busybox {
+		return c.deleteBusybox(ctx, gid)
+	}
+
 	cmd := NewCommand(fmt.Sprintf(`_do() { gid=$1; group=$(getent group $gid | cut -d: -f1); [ ! -z "${group}" ] || return %[2]d; groupdel "${group}"; return $?; }; _do '%[1]d';`, gid, codeGroupNotFound))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
@@ -183,8 +210,9 @@ func (c *groupClient) Delete(ctx context.Context, gid int) error {
 }
 
 type groupEntry struct {
-	Name string
-	Gid  int
+	Name    string
+	Gid     int
+	Members []string
 }
 
 func parseGroupEntry(data []byte) (*groupEntry, error) {
@@ -198,8 +226,14 @@ func parseGroupEntry(data []byte) (*groupEntry, error) {
 		return nil, ErrGroupUnexpected
 	}
 
+	var members []string
+	if len(parts) > 3 && parts[3] != "" {
+		members = strings.Split(parts[3], ",")
+	}
+
 	return &groupEntry{
-		Name: parts[0],
-		Gid:  groupGid,
+		Name:    parts[0],
+		Gid:     groupGid,
+		Members: members,
 	}, nil
 }
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"strconv"
+	"strings"
+)
+
+// isBusybox reports whether the group is managed using the BusyBox commands because groupadd is not available. The
+// result is cached for the lifetime of the client.
+func (c *groupClient) isBusybox(ctx context.Context) (bool, error) {
+	if c.busybox == nil {
+		busybox, err := detectBusybox(ctx, c.s, "groupadd", "addgroup")
+		if err != nil {
+			return false, errors.Join(ErrGroupUnexpected, err)
+		}
+		c.busybox = to.BoolPtr(busybox)
+	}
+
+	return *c.busybox, nil
+}
+
+// groupBusyboxCreateScript checks the preconditions of addgroup to return the exit codes of groupadd
+const groupBusyboxCreateScript = `_do() {
+  name=$1; gid=$2;
+  ! getent group "${name}" >/dev/null || return %[2]d;
+  [ -z "${gid}" ] || ! getent group "${gid}" >/dev/null || return %[3]d;
+  addgroup %[4]s "${name}" >&2 || return 1;
+  getent group "${name}";
+}; _do %[1]s;`
+
+func (c *groupClient) createBusybox(ctx context.Context, g Group) (int, error) {
+	var args []string
+
+	gid := ""
+	if g.Gid != -1 {
+		gid = strconv.Itoa(g.Gid)
+		args = append(args, fmt.Sprintf("-g %d", g.Gid))
+	}
+
+	if g.System {
+		args = append(args, "-S")
+	}
+
+	cmd := NewCommand(fmt.Sprintf(groupBusyboxCreateScript, strings.Join([]string{shellescape.Quote(g.Name), shellescape.Quote(gid)}, " "), codeGroupNameExists, codeGroupGidExists, strings.Join(args, " ")))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return -1, errors.Join(ErrGroup, err)
+	}
+
+	switch res.ExitCode {
+	case codeGroupGidExists:
+		return -1, ErrGroupGidExists
+	case codeGroupNameExists:
+		return -1, ErrGroupNameExists
+	}
+
+	if err := res.Error(); err != nil {
+		return -1, errors.Join(ErrGroupUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	createdGroup, err := parseGroupEntry(res.Stdout)
+	if err != nil {
+		return -1, ErrGroupUnexpected
+	}
+
+	return createdGroup.Gid, nil
+}
+
+// groupBusyboxUpdateScript renames the group in /etc/group and /etc/gshadow because BusyBox does not provide groupmod
+const groupBusyboxUpdateScript = busyboxFunctions + `
+_do() {
+  gid=$1; name=$2;
+  group=$(getent group "${gid}" | cut -d: -f1); [ -n "${group}" ] || return %[2]d;
+  [ "${name}" != "${group}" ] || return 0;
+  ! getent group "${name}" >/dev/null || return %[3]d;
+  _set /etc/group "${group}" 1 "${name}" && _set /etc/gshadow "${group}" 1 "${name}" || return 1;
+}; _do %[1]s;`
+
+func (c *groupClient) updateBusybox(ctx context.Context, g Group) error {
+	cmd := NewCommand(fmt.Sprintf(groupBusyboxUpdateScript, strings.Join([]string{strconv.Itoa(g.Gid), shellescape.Quote(g.Name)}, " "), codeGroupNotFound, codeGroupNameExists))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrGroup, err)
+	}
+
+	switch res.ExitCode {
+	case codeGroupNotFound:
+		return ErrGroupNotFound
+	case codeGroupNameExists:
+		return ErrGroupNameExists
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrGroupUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+func (c *groupClient) deleteBusybox(ctx context.Context, gid int) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { gid=$1; group=$(getent group $gid | cut -d: -f1); [ ! -z "${group}" ] || return %[2]d; delgroup "${group}"; return $?; }; _do '%[1]d';`, gid, codeGroupNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrGroup, err)
+	}
+
+	switch res.ExitCode {
+	case 0:
+		// Success
+		break
+	case codeGroupNotFound:
+		// Not interpreted as error because this is the desired state
+		break
+	default:
+		return errors.Join(ErrGroup, fmt.Errorf("failed to delete group with gid %d", gid), errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupClient_createBusybox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("gid", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		gid, err := NewGroupClient(local.NewSystem()).Create(ctx, Group{Name: "dev", Gid: 1010, System: true})
+		require.NoError(t, err)
+		assert.Equal(t, 1010, gid)
+
+		assert.Equal(t, "addgroup -g 1010 -S dev\n", env.read(t, "log"))
+		assert.Equal(t, testBusyboxGroup+"dev:x:1010:\n", env.read(t, "group"))
+	})
+
+	t.Run("name exists", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewGroupClient(local.NewSystem()).Create(ctx, Group{Name: "staff", Gid: -1})
+		assert.ErrorIs(t, err, ErrGroupNameExists)
+		assert.Empty(t, env.read(t, "log"))
+	})
+
+	t.Run("gid exists", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewGroupClient(local.NewSystem()).Create(ctx, Group{Name: "dev", Gid: 100})
+		assert.ErrorIs(t, err, ErrGroupGidExists)
+		assert.Empty(t, env.read(t, "log"))
+	})
+}
+
+func TestGroupClient_deleteBusybox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("existing", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		err := NewGroupClient(local.NewSystem()).Delete(ctx, 50)
+		require.NoError(t, err)
+		assert.Equal(t, "delgroup staff\n", env.read(t, "log"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		err := NewGroupClient(local.NewSystem()).Delete(ctx, 1010)
+		require.NoError(t, err)
+		assert.Empty(t, env.read(t, "log"))
+	})
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"strings"
+)
+
+// GroupMembership is the membership of users in a supplementary group
+type GroupMembership struct {
+	Group   string
+	Members []string
+}
+
+type GroupMembershipClient interface {
+	// Get returns the members of the group. Returns ErrGroupMembershipGroupNotFound if the group does not exist.
+	Get(ctx context.Context, group string) (*GroupMembership, error)
+
+	// GetUserGroups returns the names of the supplementary groups of the user
+	GetUserGroups(ctx context.Context, user string) ([]string, error)
+
+	// Add adds the users to the group. Users which are members of the group already are skipped.
+	Add(ctx context.Context, group string, users []string) error
+
+	// Remove removes the users from the group. Users which are not members of the group are skipped.
+	Remove(ctx context.Context, group string, users []string) error
+}
+
+func NewGroupMembershipClient(s system.System) GroupMembershipClient {
+	return &groupMembershipClient{
+		s: s,
+	}
+}
+
+var (
+	ErrGroupMembership = errors.New("group membership")
+
+	ErrGroupMembershipGroupNotFound = errors.Join(ErrGroupMembership, errors.New("group not found"))
+
+	ErrGroupMembershipUserNotFound = errors.Join(ErrGroupMembership, errors.New("user not found"))
+
+	ErrGroupMembershipUnexpected = errors.Join(ErrGroupMembership, errors.New("unexpected error"))
+)
+
+const (
+	codeGroupMembershipGroupNotFound = 17
+
+	codeGroupMembershipUserNotFound = 18
+)
+
+type groupMembershipClient struct {
+	s system.System
+}
+
+var _ GroupMembershipClient = &groupMembershipClient{}
+
+func (c *groupMembershipClient) Get(ctx context.Context, group string) (*GroupMembership, error) {
+	cmd := NewCommand(fmt.Sprintf(`getent group %[1]s || exit %[2]d`, shellescape.Quote(group), codeGroupMembershipGroupNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrGroupMembership, err)
+	}
+
+	switch res.ExitCode {
+	case codeGroupMembershipGroupNotFound:
+		return nil, ErrGroupMembershipGroupNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrGroupMembershipUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	parsedGroup, err := parseGroupEntry(res.Stdout)
+	if err != nil {
+		return nil, errors.Join(ErrGroupMembershipUnexpected, err)
+	}
+
+	return &GroupMembership{
+		Group:   parsedGroup.Name,
+		Members: parsedGroup.Members,
+	}, nil
+}
+
+func (c *groupMembershipClient) GetUserGroups(ctx context.Context, user string) ([]string, error) {
+	cmd := NewCommand(`getent group`)
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrGroupMembership, err)
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrGroupMembershipUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	var groups []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(res.Stdout))
+	for scanner.Scan() {
+		parsedGroup, err := parseGroupEntry(scanner.Bytes())
+		if err != nil {
+			continue
+		}
+
+		for _, member := range parsedGroup.Members {
+			if member == user {
+				groups = append(groups, parsedGroup.Name)
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Join(ErrGroupMembershipUnexpected, err)
+	}
+
+	return groups, nil
+}
+
+// groupMembershipMemberFunction defines the shell function _member which reports whether the user is a member of the
+// group
+const groupMembershipMemberFunction = `_member() {
+  getent group "$2" | cut -d: -f4 | tr ',' '\n' | grep -qxF "$1";
+};`
+
+// groupMembershipAddScript adds the users to the group using gpasswd or BusyBox addgroup
+const groupMembershipAddScript = groupMembershipMemberFunction + `
+_do() {
+  group=$1; shift;
+  getent group "${group}" >/dev/null || return %[2]d;
+  for user in "$@"; do
+    getent passwd "${user}" >/dev/null || return %[3]d;
+    _member "${user}" "${group}" && continue;
+    if command -v gpasswd >/dev/null 2>&1; then gpasswd -a "${user}" "${group}" >/dev/null || return 1;
+    else addgroup "${user}" "${group}" || return 1; fi;
+  done;
+}; _do %[1]s;`
+
+func (c *groupMembershipClient) Add(ctx context.Context, group string, users []string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	return c.execute(ctx, groupMembershipAddScript, group, users)
+}
+
+// groupMembershipRemoveScript removes the users from the group using gpasswd or BusyBox delgroup
+const groupMembershipRemoveScript = groupMembershipMemberFunction + `
+_do() {
+  group=$1; shift;
+  getent group "${group}" >/dev/null || return %[2]d;
+  for user in "$@"; do
+    _member "${user}" "${group}" || continue;
+    if command -v gpasswd >/dev/null 2>&1; then gpasswd -d "${user}" "${group}" >/dev/null || return 1;
+    else delgroup "${user}" "${group}" || return 1; fi;
+  done;
+}; _do %[1]s;`
+
+func (c *groupMembershipClient) Remove(ctx context.Context, group string, users []string) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	return c.execute(ctx, groupMembershipRemoveScript, group, users)
+}
+
+func (c *groupMembershipClient) execute(ctx context.Context, script string, group string, users []string) error {
+	args := []string{shellescape.Quote(group)}
+	for _, user := range users {
+		args = append(args, shellescape.Quote(user))
+	}
+
+	cmd := NewCommand(fmt.Sprintf(script, strings.Join(args, " "), codeGroupMembershipGroupNotFound, codeGroupMembershipUserNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrGroupMembership, err)
+	}
+
+	switch res.ExitCode {
+	case codeGroupMembershipGroupNotFound:
+		return errors.Join(ErrGroupMembershipGroupNotFound, fmt.Errorf("group %q", group))
+	case codeGroupMembershipUserNotFound:
+		return ErrGroupMembershipUserNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrGroupMembershipUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"strings"
+)
+
+// Hook is a command which is executed on the remote system after a resource changed
+type Hook struct {
+	Command string
+
+	// RunAs is the user which executes the command. The command is executed as the user of the connection if empty.
+	RunAs string
+}
+
+type HookClient interface {
+	// Run executes the command of the hook. Returns ErrHookFailed if the command exits with a non-zero exit code.
+	Run(ctx context.Context, h Hook) error
+}
+
+func NewHookClient(s system.System) HookClient {
+	return &hookClient{
+		s: s,
+	}
+}
+
+var (
+	ErrHook = errors.New("hook")
+
+	ErrHookFailed = errors.Join(ErrHook, errors.New("command failed"))
+)
+
+type hookClient struct {
+	s system.System
+}
+
+var _ HookClient = &hookClient{}
+
+func (c *hookClient) Run(ctx context.Context, h Hook) error {
+	command := h.Command
+	if h.RunAs != "" {
+		// Options after the user are supported by util-linux su and BusyBox su
+		command = fmt.Sprintf(`su -s /bin/sh %s -c %s`, shellescape.Quote(h.RunAs), shellescape.Quote(h.Command))
+	}
+
+	res, err := ExecuteCommand(ctx, c.s, NewCommand(command))
+	if err != nil {
+		return errors.Join(ErrHook, err)
+	}
+
+	if res.ExitCode != 0 {
+		err := fmt.Errorf("exit code %d", res.ExitCode)
+		if stderr := strings.TrimSpace(res.StderrString()); stderr != "" {
+			err = fmt.Errorf("exit code %d: %s", res.ExitCode, stderr)
+		}
+		return errors.Join(ErrHookFailed, err)
+	}
+
+	return nil
+}
@@ -1,27 +1,61 @@
 package client
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"github.com/alessio/shellescape"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
 	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"path"
 	"strconv"
+	"strings"
 )
 
+// LinkType is the type of link
+type LinkType string
+
+const (
+	LinkTypeSymbolic LinkType = "symbolic"
+
+	LinkTypeHard LinkType = "hard"
+)
+
+// LinkTypes returns all supported link types
+func LinkTypes() []LinkType {
+	return []LinkType{LinkTypeSymbolic, LinkTypeHard}
+}
+
 type Link struct {
 	Path   string
 	Target string
-	User   string
-	Uid    int
-	Group  string
-	Gid    int
+
+	// Type is the type of link. Defaults to a symbolic link.
+	Type LinkType
+
+	// Relative creates a symbolic link with a target which is relative to the folder of the link
+	Relative bool
+
+	// Force replaces an existing file or link at the path
+	Force bool
+
+	// Resolves is true if the target of a symbolic link exists or a hard link refers to the same file as the target.
+	// Set by Get and GetHard only.
+	Resolves bool
+
+	User  string
+	Uid   int
+	Group string
+	Gid   int
 }
 
 func newLinkFromStat(s *stat.Stat) *Link {
 	return &Link{
 		Path:   s.Name,
 		Target: s.Target,
+		Type:   LinkTypeSymbolic,
 		User:   s.User,
 		Uid:    s.Uid,
 		Group:  s.Group,
@@ -29,11 +63,66 @@ func newLinkFromStat(s *stat.Stat) *Link {
 	}
 }
 
+// LinkTarget returns the target which is written to the link
+func (l Link) LinkTarget() string {
+	if l.Relative && l.Type != LinkTypeHard {
+		return relativeTarget(l.Path, l.Target)
+	}
+	return l.Target
+}
+
+// relativeTarget returns the absolute target relative to the folder of the link. Returns target unchanged if target
+// is relative. The relative target is derived lexically without resolving symbolic links.
+func relativeTarget(linkPath string, target string) string {
+	if !path.IsAbs(target) {
+		return target
+	}
+
+	split := func(p string) []string {
+		p = strings.Trim(path.Clean(p), "/")
+		if p == "" {
+			return nil
+		}
+		return strings.Split(p, "/")
+	}
+
+	base := split(path.Dir(linkPath))
+	parts := split(target)
+
+	i := 0
+	for i < len(base) && i < len(parts) && base[i] == parts[i] {
+		i++
+	}
+
+	var rel []string
+	for range base[i:] {
+		rel = append(rel, "..")
+	}
+	rel = append(rel, parts[i:]...)
+
+	if len(rel) == 0 {
+		return "."
+	}
+
+	return strings.Join(rel, "/")
+}
+
 type LinkClient interface {
+	// Get returns the symbolic link at the path
 	Get(ctx context.Context, path string) (*Link, error)
+
+	// GetHard returns the hard link at the path. Target of the returned link is empty if the path is not a hard link
+	// of target.
+	GetHard(ctx context.Context, path string, target string) (*Link, error)
+
 	Create(ctx context.Context, l Link) error
 	Update(ctx context.Context, l Link) error
+
+	// Delete removes the symbolic link at the path
 	Delete(ctx context.Context, path string) error
+
+	// DeleteHard removes the hard link at the path
+	DeleteHard(ctx context.Context, path string) error
 }
 
 func NewLinkClient(s system.System) LinkClient {
@@ -64,8 +153,29 @@ type linkClient struct {
 	s system.System
 }
 
+// parseLinkOutput parses the output of stat in the first line and whether the target resolves in the second line
+func parseLinkOutput(data []byte) (*stat.Stat, bool, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if len(lines) != 2 {
+		return nil, false, ErrLinkUnexpected
+	}
+
+	parsedStat, err := stat.ParseJsonFormat([]byte(lines[0]))
+	if err != nil {
+		return nil, false, errors.Join(ErrLink, err)
+	}
+
+	return parsedStat, lines[1] == "1", nil
+}
+
 func (c *linkClient) Get(ctx context.Context, path string) (*Link, error) {
-	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -L "${path}" ] || return %[2]d; stat -c '%[3]s' "${path}" || return 1; }; _do '%[1]s';`, path, codeLinkNotFound, stat.FormatJsonGnu))
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -L "${path}" ] || return %[2]d; stat -c '%[3]s' "${path}" || return 1; if [ -e "${path}" ]; then echo 1; else echo 0; fi; }; _do '%[1]s';`, path, codeLinkNotFound, stat.FormatJsonGnu))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return nil, errors.Join(ErrLink, err)
@@ -80,36 +190,104 @@ func (c *linkClient) Get(ctx context.Context, path string) (*Link, error) {
 		return nil, ErrLinkUnexpected
 	}
 
-	parsedStat, err := stat.ParseJsonFormat(res.Stdout)
+	parsedStat, resolves, err := parseLinkOutput(res.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	link := newLinkFromStat(parsedStat)
+	link.Resolves = resolves
+
+	return link, nil
+}
+
+func (c *linkClient) GetHard(ctx context.Context, path string, target string) (*Link, error) {
+	// The path is a hard link of the target if both refer to the same inode on the same device
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; target=$2; [ -f "${path}" ] && [ ! -L "${path}" ] || return %[3]d; stat -c '%[4]s' "${path}" || return 1; if [ -e "${target}" ] && [ "$(stat -L -c '%%d:%%i' "${path}")" = "$(stat -L -c '%%d:%%i' "${target}")" ]; then echo 1; else echo 0; fi; }; _do %[1]s %[2]s;`, shellescape.Quote(path), shellescape.Quote(target), codeLinkNotFound, stat.FormatJsonGnu))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return nil, errors.Join(ErrLink, err)
 	}
 
+	switch res.ExitCode {
+	case codeLinkNotFound:
+		return nil, ErrLinkNotFound
+	}
+
+	if res.ExitCode != 0 || len(res.Stdout) == 0 {
+		return nil, ErrLinkUnexpected
+	}
+
+	parsedStat, resolves, err := parseLinkOutput(res.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
 	link := newLinkFromStat(parsedStat)
+	link.Type = LinkTypeHard
+	link.Resolves = resolves
+	if resolves {
+		link.Target = target
+	}
 
 	return link, nil
 }
 
-func (c *linkClient) Create(ctx context.Context, l Link) error {
-	pathSub := `"${path}"`
+// linkCommands returns the commands which create the link at `${path}`. The link is created at a temporary path and
+// moved to the path if replace is true. Replacing the link is atomic.
+func linkCommands(l Link, replace bool) []Command {
+	var lnArgs string
+	if l.Type != LinkTypeHard {
+		lnArgs = `-s `
+	}
 
-	var createCmds []Command
+	if !replace {
+		return []Command{NewCommand(fmt.Sprintf(`ln %s%s "${path}"`, lnArgs, shellescape.Quote(l.LinkTarget())))}
+	}
 
-	createCmds = append(createCmds, NewCommand(fmt.Sprintf(`ln -s '%s' %s`, l.Target, pathSub)))
+	return []Command{
+		NewCommand(`tmp="$(dirname "${path}")/.$(basename "${path}").link.$$"`),
+		NewCommand(fmt.Sprintf(`ln %s%s "${tmp}"`, lnArgs, shellescape.Quote(l.LinkTarget()))),
+		NewCommand(`{ mv -f -T "${tmp}" "${path}" || { rm -f "${tmp}"; false; }; }`),
+	}
+}
+
+// ownershipCommands returns the commands which change the ownership of the link at `${path}`. The ownership of a hard
+// link is shared with the target.
+func (l Link) ownershipCommands() []Command {
+	pathSub := `"${path}"`
+	noDereference := l.Type != LinkTypeHard
+
+	var cmds []Command
 
 	if l.Uid != -1 {
-		createCmds = append(createCmds, &ChownCommand{Path: pathSub, User: strconv.Itoa(l.Uid), NoDereference: true})
+		cmds = append(cmds, &ChownCommand{Path: pathSub, User: strconv.Itoa(l.Uid), NoDereference: noDereference})
 	} else if l.User != "" {
-		createCmds = append(createCmds, &ChownCommand{Path: pathSub, User: l.User, NoDereference: true})
+		cmds = append(cmds, &ChownCommand{Path: pathSub, User: l.User, NoDereference: noDereference})
 	}
 
 	if l.Gid != -1 {
-		createCmds = append(createCmds, &ChgrpCommand{Path: pathSub, Group: strconv.Itoa(l.Gid), NoDereference: true})
+		cmds = append(cmds, &ChgrpCommand{Path: pathSub, Group: strconv.Itoa(l.Gid), NoDereference: noDereference})
 	} else if l.Group != "" {
-		createCmds = append(createCmds, &ChgrpCommand{Path: pathSub, Group: l.Group, NoDereference: true})
+		cmds = append(cmds, &ChgrpCommand{Path: pathSub, Group: l.Group, NoDereference: noDereference})
+	}
+
+	return cmds
+}
+
+func (c *linkClient) Create(ctx context.Context, l Link) error {
+	var createCmds []Command
+
+	createCmds = append(createCmds, linkCommands(l, l.Force)...)
+	createCmds = append(createCmds, l.ownershipCommands()...)
+
+	// An existing path is replaced only if forced
+	force := 0
+	if l.Force {
+		force = 1
 	}
 
-	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ ! -e "${path}" ] || return %[2]d; { %[3]s; } || return 1; }; _do '%[1]s';`, l.Path, codeLinkPathExists, CompositeCommand(createCmds).Command()))
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; { [ ! -e "${path}" ] && [ ! -L "${path}" ]; } || [ %[3]d -eq 1 ] || return %[2]d; { %[4]s; } || return 1; }; _do %[1]s;`, shellescape.Quote(l.Path), codeLinkPathExists, force, CompositeCommand(createCmds).Command()))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return errors.Join(ErrLink, err)
@@ -129,32 +307,26 @@ func (c *linkClient) Create(ctx context.Context, l Link) error {
 }
 
 func (c *linkClient) Update(ctx context.Context, l Link) error {
-	pathSub := `"${path}"`
-
 	var updateCmds []Command
 
 	if l.Target != "" {
-		updateCmds = append(updateCmds, NewCommand(fmt.Sprintf(`ln -sf '%s' %s`, l.Target, pathSub)))
+		// The link is replaced atomically because the target of a link cannot be changed
+		updateCmds = append(updateCmds, linkCommands(l, true)...)
 	}
 
-	if l.Uid != -1 {
-		updateCmds = append(updateCmds, &ChownCommand{Path: pathSub, User: strconv.Itoa(l.Uid)})
-	} else if l.User != "" {
-		updateCmds = append(updateCmds, &ChownCommand{Path: pathSub, User: l.User})
-	}
-
-	if l.Gid != -1 {
-		updateCmds = append(updateCmds, &ChgrpCommand{Path: pathSub, Group: strconv.Itoa(l.Gid)})
-	} else if l.Group != "" {
-		updateCmds = append(updateCmds, &ChgrpCommand{Path: pathSub, Group: l.Group})
-	}
+	updateCmds = append(updateCmds, l.ownershipCommands()...)
 
 	if len(updateCmds) == 0 {
 		// Nothing to do because up-to-date
 		return nil
 	}
 
-	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -L "${path}" ] || return %[2]d; { %[3]s; } || return 1; }; _do '%[1]s';`, l.Path, codeLinkNotFound, CompositeCommand(updateCmds).Command()))
+	exists := `[ -L "${path}" ]`
+	if l.Type == LinkTypeHard {
+		exists = `[ -f "${path}" ]`
+	}
+
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; %[3]s || return %[2]d; { %[4]s; } || return 1; }; _do %[1]s;`, shellescape.Quote(l.Path), codeLinkNotFound, exists, CompositeCommand(updateCmds).Command()))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return errors.Join(ErrLink, err)
@@ -191,3 +363,22 @@ func (c *linkClient) Delete(ctx context.Context, path string) error {
 
 	return nil
 }
+
+func (c *linkClient) DeleteHard(ctx context.Context, path string) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -f "${path}" ] && [ ! -L "${path}" ] || return %[2]d; rm -f "${path}" || return 1; }; _do %[1]s;`, shellescape.Quote(path), codeLinkNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrLink, err)
+	}
+
+	switch res.ExitCode {
+	case codeLinkNotFound:
+		return ErrLinkNotFound
+	}
+
+	if res.ExitCode != 0 {
+		return errors.Join(ErrLink, fmt.Errorf("failed to delete %q", path))
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"path"
+	"strings"
+)
+
+// SudoersDir is the drop-in folder of sudo
+const SudoersDir = "/etc/sudoers.d"
+
+// SudoersRule is a drop-in file in SudoersDir
+type SudoersRule struct {
+	Name    string
+	Content string
+}
+
+// Path returns the path of the drop-in file
+func (r SudoersRule) Path() string {
+	return path.Join(SudoersDir, r.Name)
+}
+
+type SudoersRuleClient interface {
+	// Get returns the drop-in file with the name. Returns ErrSudoersRuleNotFound if the file does not exist.
+	Get(ctx context.Context, name string) (*SudoersRule, error)
+
+	// Apply validates the content using visudo and installs the drop-in file atomically. The previous file is restored
+	// if the configuration of sudo is invalid after the installation or if the user of the connection loses the
+	// privileges to execute commands using sudo.
+	Apply(ctx context.Context, r SudoersRule) error
+
+	// Delete removes the drop-in file. The file is restored if the user of the connection loses the privileges to
+	// execute commands using sudo.
+	Delete(ctx context.Context, name string) error
+}
+
+func NewSudoersRuleClient(s system.System) SudoersRuleClient {
+	return &sudoersRuleClient{
+		s: s,
+	}
+}
+
+var (
+	ErrSudoersRule = errors.New("sudoers rule resource")
+
+	ErrSudoersRuleNotFound = errors.Join(ErrSudoersRule, errors.New("sudoers rule not found"))
+
+	ErrSudoersRuleIncludeDirMissing = errors.Join(ErrSudoersRule, fmt.Errorf("sudoers does not include %s", SudoersDir))
+
+	ErrSudoersRuleInvalid = errors.Join(ErrSudoersRule, errors.New("invalid sudoers rule"))
+
+	ErrSudoersRuleLockout = errors.Join(ErrSudoersRule, errors.New("change revokes the sudo privileges of the connection"))
+
+	ErrSudoersRuleUnexpected = errors.Join(ErrSudoersRule, errors.New("unexpected error"))
+)
+
+const (
+	codeSudoersRuleNotFound = 17
+
+	codeSudoersRuleIncludeDirMissing = 18
+
+	codeSudoersRuleInvalid = 19
+
+	codeSudoersRuleLockout = 20
+)
+
+type sudoersRuleClient struct {
+	s system.System
+}
+
+var _ SudoersRuleClient = &sudoersRuleClient{}
+
+// sudoersRuleFunctions defines shell functions for the installation of drop-in files. Temporary files and backups are
+// prefixed with `.` because sudo skips files in the drop-in folder which contain a `.`. _privileged succeeds unless the
+// commands are executed using sudo by a user other than root who is no longer allowed to execute the shell using sudo.
+// _restore restores the backup of the file or removes the file if no backup exists.
+const sudoersRuleFunctions = `_privileged() {
+  [ -n "${SUDO_USER}" ] && [ "${SUDO_USER}" != "root" ] || return 0;
+  sudo -n -l -U "${SUDO_USER}" /bin/sh >/dev/null 2>&1;
+};
+_restore() {
+  if [ -f "${bak}" ]; then mv -f "${bak}" "${file}"; else rm -f "${file}"; fi;
+};`
+
+// sudoersRuleApplyScript checks that sudoers includes the drop-in folder, validates the new file in a temporary file,
+// and replaces the file using mv
+const sudoersRuleApplyScript = sudoersRuleFunctions + `
+_do() {
+  dir=$1; name=$2; file="${dir}/${name}"; tmp="${dir}/.${name}.tmp"; bak="${dir}/.${name}.bak";
+  grep -Eqs '^[[:space:]]*[#@]includedir[[:space:]]+'"${dir}"'/?[[:space:]]*$' /etc/sudoers || return %[2]d;
+  [ -d "${dir}" ] || { mkdir -m 750 "${dir}" || return 1; };
+  cat - > "${tmp}" && chown 0:0 "${tmp}" && chmod 440 "${tmp}" || { rm -f "${tmp}"; return 1; };
+  visudo -cqf "${tmp}" >&2 || { rm -f "${tmp}"; return %[3]d; };
+  rm -f "${bak}"; [ ! -f "${file}" ] || cp -p "${file}" "${bak}" || { rm -f "${tmp}"; return 1; };
+  mv -f "${tmp}" "${file}" || { rm -f "${tmp}" "${bak}"; return 1; };
+  visudo -cq >&2 || { _restore; return %[3]d; };
+  _privileged || { _restore; return %[4]d; };
+  rm -f "${bak}";
+}; _do %[1]s;`
+
+// sudoersRuleDeleteScript moves the file to a backup which is removed if the user of the connection retains the
+// privileges
+const sudoersRuleDeleteScript = sudoersRuleFunctions + `
+_do() {
+  dir=$1; name=$2; file="${dir}/${name}"; bak="${dir}/.${name}.bak";
+  [ -f "${file}" ] || return %[2]d;
+  mv -f "${file}" "${bak}" || return 1;
+  _privileged || { _restore; return %[3]d; };
+  rm -f "${bak}";
+}; _do %[1]s;`
+
+func (c *sudoersRuleClient) Get(ctx context.Context, name string) (*SudoersRule, error) {
+	r := SudoersRule{Name: name}
+
+	cmd := NewCommand(fmt.Sprintf(`_do() { [ -f "$1" ] || return %[2]d; cat "$1"; }; _do %[1]s;`, shellescape.Quote(r.Path()), codeSudoersRuleNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return nil, errors.Join(ErrSudoersRuleUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeSudoersRuleNotFound:
+		return nil, ErrSudoersRuleNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return nil, errors.Join(ErrSudoersRuleUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	r.Content = res.StdoutString()
+
+	return &r, nil
+}
+
+func (c *sudoersRuleClient) Apply(ctx context.Context, r SudoersRule) error {
+	args := []string{
+		shellescape.Quote(SudoersDir),
+		shellescape.Quote(r.Name),
+	}
+
+	cmd := NewInputCommand(fmt.Sprintf(sudoersRuleApplyScript, strings.Join(args, " "), codeSudoersRuleIncludeDirMissing, codeSudoersRuleInvalid, codeSudoersRuleLockout), strings.NewReader(r.Content))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrSudoersRuleUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeSudoersRuleIncludeDirMissing:
+		return ErrSudoersRuleIncludeDirMissing
+	case codeSudoersRuleInvalid:
+		return errors.Join(ErrSudoersRuleInvalid, errors.New(strings.TrimSpace(res.StderrString())))
+	case codeSudoersRuleLockout:
+		return ErrSudoersRuleLockout
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrSudoersRuleUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+func (c *sudoersRuleClient) Delete(ctx context.Context, name string) error {
+	args := []string{
+		shellescape.Quote(SudoersDir),
+		shellescape.Quote(name),
+	}
+
+	cmd := NewCommand(fmt.Sprintf(sudoersRuleDeleteScript, strings.Join(args, " "), codeSudoersRuleNotFound, codeSudoersRuleLockout))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrSudoersRuleUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeSudoersRuleNotFound:
+		return ErrSudoersRuleNotFound
+	case codeSudoersRuleLockout:
+		return ErrSudoersRuleLockout
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrSudoersRuleUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
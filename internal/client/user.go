@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/alessio/shellescape"
 	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
 	"github.com/neuspaces/terraform-provider-system/internal/system"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type User struct {
@@ -18,13 +20,46 @@ type User struct {
 	System *bool
 	Home   string
 	Shell  string
+
+	// CreateHome creates the home folder when the user is created or updated and the home folder does not exist. The
+	// home folder is populated from Skel or the default skeleton folder if Skel is empty.
+	CreateHome bool
+	Skel       string
+
+	// MoveHome moves the content of the home folder to the new home folder when Home is updated
+	MoveHome bool
+
+	// PasswordHash is the crypt hash of the password as stored in /etc/shadow without the lock prefix `!`
+	PasswordHash *string
+
+	// Locked reports whether the password of the user is locked
+	Locked *bool
+
+	// ExpireDate is the date on which the account expires in the format YYYY-MM-DD. A value of -1 disables the
+	// expiration.
+	ExpireDate *string
+
+	// PasswordMaxDays, PasswordMinDays, and PasswordWarnDays configure the aging of the password. A value of -1
+	// disables the respective check.
+	PasswordMaxDays  *int
+	PasswordMinDays  *int
+	PasswordWarnDays *int
+}
+
+// hasAccount reports whether any attribute of the account state is set
+func (u User) hasAccount() bool {
+	return u.PasswordHash != nil || u.Locked != nil || u.ExpireDate != nil || u.PasswordMaxDays != nil || u.PasswordMinDays != nil || u.PasswordWarnDays != nil
 }
 
 type UserClient interface {
+	// Get returns the user with the uid. The password and the aging of the account are only returned if /etc/shadow
+	// is readable.
 	Get(ctx context.Context, uid int) (*User, error)
 	Create(ctx context.Context, user User) (int, error)
 	Update(ctx context.Context, user User) error
-	Delete(ctx context.Context, uid int) error
+
+	// Delete removes the user. The home folder and the mail spool of the user are removed if removeHome is true.
+	Delete(ctx context.Context, uid int, removeHome bool) error
 }
 
 func NewUserClient(s system.System) UserClient {
@@ -44,6 +79,8 @@ var (
 
 	ErrUserGroupNotFound = errors.Join(ErrUser, errors.New("primary group not found"))
 
+	ErrUserAccount = errors.Join(ErrUser, errors.New("failed to update password or aging of account"))
+
 	ErrUserUnexpected = errors.Join(ErrUser, errors.New("unexpected error"))
 )
 
@@ -61,6 +98,9 @@ const (
 
 type userClient struct {
 	s system.System
+
+	// busybox caches whether the BusyBox commands are used
+	busybox *bool
 }
 
 func (c *userClient) Get(ctx context.Context, uid int) (*User, error) {
@@ -112,10 +152,39 @@ func (c *userClient) Get(ctx context.Context, uid int) (*User, error) {
 		Shell:  parsedUser.Shell,
 	}
 
+	shadowCmd := NewCommand(fmt.Sprintf(`getent shadow %[1]s 2>/dev/null || awk -F: -v user=%[1]s '$1 == user { print; found=1 } END { exit !found }' /etc/shadow`, shellescape.Quote(parsedUser.Name)))
+	resShadow, err := ExecuteCommand(ctx, c.s, shadowCmd)
+	if err != nil {
+		return nil, errors.Join(ErrUserUnexpected, err)
+	}
+
+	// The account state is omitted if /etc/shadow is not readable
+	if resShadow.ExitCode == 0 && len(resShadow.Stdout) > 0 {
+		parsedShadow, err := parseShadowEntry(resShadow.Stdout)
+		if err != nil {
+			return nil, err
+		}
+
+		user.PasswordHash = to.StringPtr(parsedShadow.PasswordHash)
+		user.Locked = to.BoolPtr(parsedShadow.Locked)
+		user.ExpireDate = to.StringPtr(parsedShadow.ExpireDate)
+		user.PasswordMaxDays = to.IntPtr(parsedShadow.MaxDays)
+		user.PasswordMinDays = to.IntPtr(parsedShadow.MinDays)
+		user.PasswordWarnDays = to.IntPtr(parsedShadow.WarnDays)
+	}
+
 	return user, nil
 }
 
 func (c *userClient) Create(ctx context.Context, u User) (int, error) {
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return -1, err
+	}
+	if busybox {
+		return c.createBusybox(ctx, u)
+	}
+
 	var args []string
 
 	if u.Uid != nil {
@@ -124,7 +193,15 @@ func (c *userClient) Create(ctx context.Context, u User) (int, error) {
 
 	if u.System != nil && *u.System {
 		args = append(args, "--system")
-	} else {
+	}
+
+	if u.CreateHome {
+		args = append(args, "--create-home")
+
+		if u.Skel != "" {
+			args = append(args, fmt.Sprintf("--skel %s", shellescape.Quote(u.Skel)))
+		}
+	} else if u.System == nil || !*u.System {
 		// Prevent create home directory for regular users
 		args = append(args, "--no-create-home")
 	}
@@ -177,6 +254,13 @@ func (c *userClient) Create(ctx context.Context, u User) (int, error) {
 		return -1, ErrUserUnexpected
 	}
 
+	if u.hasAccount() {
+		err = c.updateAccount(ctx, createdUser.Uid, u)
+		if err != nil {
+			return createdUser.Uid, err
+		}
+	}
+
 	return createdUser.Uid, nil
 }
 
@@ -185,6 +269,14 @@ func (c *userClient) Update(ctx context.Context, u User) error {
 		return errors.Join(ErrUserUnexpected, errors.New("update requires uid"))
 	}
 
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return err
+	}
+	if busybox {
+		return c.updateBusybox(ctx, u)
+	}
+
 	var args []string
 
 	if u.Name != "" {
@@ -195,6 +287,11 @@ func (c *userClient) Update(ctx context.Context, u User) error {
 	if u.Home != "" {
 		// Update home
 		args = append(args, fmt.Sprintf(`--home '%s'`, u.Home))
+
+		if u.MoveHome {
+			// Move the content of the current home folder to the new home folder
+			args = append(args, "--move-home")
+		}
 	}
 
 	if u.Shell != "" {
@@ -212,37 +309,186 @@ func (c *userClient) Update(ctx context.Context, u User) error {
 		}
 	}
 
-	if len(args) == 0 {
+	if len(args) > 0 {
+		usermodCmd := fmt.Sprintf(`usermod %s "${user}"`, strings.Join(args, " "))
+		cmd := NewCommand(fmt.Sprintf(`_do() { uid=$1; user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d; %[3]s; return $?; }; _do '%[1]d';`, to.Int(u.Uid), codeUserNotFound, usermodCmd))
+		res, err := ExecuteCommand(ctx, c.s, cmd)
+		if err != nil {
+			return errors.Join(ErrUserUnexpected, err)
+		}
+
+		switch res.ExitCode {
+		case 0:
+			// Success
+			break
+		case codeUserNotFound:
+			// User not found
+			return ErrUserNotFound
+		case codeUserNameExists:
+			// Username not unique
+			return ErrUserNameExists
+		default:
+			return ErrUserUnexpected
+		}
+	}
+
+	if u.CreateHome {
+		err = c.createHome(ctx, to.Int(u.Uid), u.Skel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.updateAccount(ctx, to.Int(u.Uid), u)
+}
+
+// userCreateHomeScript creates the home folder of the user if it does not exist like useradd. The folder is populated
+// from the skeleton folder and owned by the user and the primary group of the user. The mode is HOME_MODE or derived
+// from UMASK in /etc/login.defs.
+const userCreateHomeScript = `_do() {
+  uid=$1; skel=$2;
+  entry=$(getent passwd "${uid}") || return %[2]d;
+  home=$(echo "${entry}" | cut -d: -f6); gid=$(echo "${entry}" | cut -d: -f4);
+  [ -n "${home}" ] || return 1;
+  [ ! -e "${home}" ] || return 0;
+  if [ -z "${skel}" ]; then skel=$(sed -n 's/^SKEL=//p' /etc/default/useradd 2>/dev/null | tail -n 1); skel=${skel:-/etc/skel}; fi;
+  mode=$(awk '$1 == "HOME_MODE" { m = $2 } END { print m }' /etc/login.defs 2>/dev/null);
+  if [ -z "${mode}" ]; then umask=$(awk '$1 == "UMASK" { m = $2 } END { print m }' /etc/login.defs 2>/dev/null); mode=$(printf '%%o' $(( 0777 & ~0${umask:-022} ))); fi;
+  mkdir -p "${home}" && chmod "${mode}" "${home}" || return 1;
+  if [ -d "${skel}" ]; then cp -a "${skel}/." "${home}/" || return 1; fi;
+  chown -R "${uid}:${gid}" "${home}";
+}; _do %[1]s;`
+
+// createHome creates the home folder of the user with the uid if it does not exist
+func (c *userClient) createHome(ctx context.Context, uid int, skel string) error {
+	cmd := NewCommand(fmt.Sprintf(userCreateHomeScript, strings.Join([]string{strconv.Itoa(uid), shellescape.Quote(skel)}, " "), codeUserNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrUserUnexpected, err)
+	}
+
+	if res.ExitCode == codeUserNotFound {
+		return ErrUserNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrUserUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+// userAccountScript applies the password, the lock, and the aging of the account in order. The password hash is read
+// from stdin to keep it out of the command line.
+const userAccountScript = `_do() {
+  uid=$1;
+  user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d;
+  %[3]s
+}; _do '%[1]d';`
+
+func (c *userClient) updateAccount(ctx context.Context, uid int, u User) error {
+	if !u.hasAccount() {
 		return nil
 	}
 
-	usermodCmd := fmt.Sprintf(`usermod %s "${user}"`, strings.Join(args, " "))
-	cmd := NewCommand(fmt.Sprintf(`_do() { uid=$1; user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d; %[3]s; return $?; }; _do '%[1]d';`, to.Int(u.Uid), codeUserNotFound, usermodCmd))
+	var cmds []string
+	var stdin string
+
+	if u.PasswordHash != nil {
+		cmds = append(cmds, `IFS= read -r hash && printf '%s:%s\n' "${user}" "${hash}" | chpasswd -e || return 1;`)
+		stdin = to.String(u.PasswordHash) + "\n"
+	}
+
+	script := userAccountScript
+
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return err
+	}
+	if busybox {
+		busyboxCmds, err := userBusyboxAccountCommands(u)
+		if err != nil {
+			return err
+		}
+
+		cmds = append(cmds, busyboxCmds...)
+		script = busyboxFunctions + "\n" + userAccountScript
+	} else {
+		cmds = append(cmds, userAccountCommands(u)...)
+	}
+
+	cmd := NewInputCommand(fmt.Sprintf(script, uid, codeUserNotFound, strings.Join(cmds, " ")), strings.NewReader(stdin))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return errors.Join(ErrUserUnexpected, err)
 	}
 
 	switch res.ExitCode {
-	case 0:
-		// Success
-		break
 	case codeUserNotFound:
-		// User not found
 		return ErrUserNotFound
-	case codeUserNameExists:
-		// Username not unique
-		return ErrUserNameExists
-	default:
-		return ErrUserUnexpected
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrUserAccount, err, errors.New(strings.TrimSpace(res.StderrString())))
 	}
 
 	return nil
 }
 
-func (c *userClient) Delete(ctx context.Context, uid int) error {
+// userAccountCommands returns the commands which apply the lock and the aging of the account using usermod and chage
+func userAccountCommands(u User) []string {
+	var cmds []string
+
+	if u.Locked != nil {
+		if *u.Locked {
+			cmds = append(cmds, `usermod -L "${user}" || return 1;`)
+		} else {
+			cmds = append(cmds, `usermod -U "${user}" || return 1;`)
+		}
+	}
+
+	var chageArgs []string
+
+	if u.ExpireDate != nil {
+		chageArgs = append(chageArgs, fmt.Sprintf("-E %s", shellescape.Quote(to.String(u.ExpireDate))))
+	}
+
+	if u.PasswordMaxDays != nil {
+		chageArgs = append(chageArgs, fmt.Sprintf("-M %d", to.Int(u.PasswordMaxDays)))
+	}
+
+	if u.PasswordMinDays != nil {
+		chageArgs = append(chageArgs, fmt.Sprintf("-m %d", to.Int(u.PasswordMinDays)))
+	}
+
+	if u.PasswordWarnDays != nil {
+		chageArgs = append(chageArgs, fmt.Sprintf("-W %d", to.Int(u.PasswordWarnDays)))
+	}
+
+	if len(chageArgs) > 0 {
+		cmds = append(cmds, fmt.Sprintf(`chage %s "${user}" || return 1;`, strings.Join(chageArgs, " ")))
+	}
+
+	return cmds
+}
+
+func (c *userClient) Delete(ctx context.Context, uid int, removeHome bool) error {
+	busybox, err := c.isBusybox(ctx)
+	if err != nil {
+		return err
+	}
+	if busybox {
+		return c.deleteBusybox(ctx, uid, removeHome)
+	}
+
+	var args []string
+
+	if removeHome {
+		args = append(args, "--remove")
+	}
+
 	// Note: userdel will also remove the primary group of the user
-	cmd := NewCommand(fmt.Sprintf(`_do() { uid=$1; user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d; userdel "${user}"; return $?; }; _do '%[1]d';`, uid, codeUserNotFound))
+	cmd := NewCommand(fmt.Sprintf(`_do() { uid=$1; user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d; userdel %[3]s "${user}"; return $?; }; _do '%[1]d';`, uid, codeUserNotFound, strings.Join(args, " ")))
 	res, err := ExecuteCommand(ctx, c.s, cmd)
 	if err != nil {
 		return errors.Join(ErrUserUnexpected, err)
@@ -267,6 +513,7 @@ type passwdEntry struct {
 	Name  string
 	Uid   int
 	Gid   int
+	Gecos string
 	Home  string
 	Shell string
 }
@@ -291,7 +538,56 @@ func parsePasswdEntry(data []byte) (*passwdEntry, error) {
 		Name:  parts[0],
 		Uid:   uid,
 		Gid:   gid,
+		Gecos: parts[4],
 		Home:  parts[5],
 		Shell: parts[6],
 	}, nil
 }
+
+type shadowEntry struct {
+	Name         string
+	PasswordHash string
+	Locked       bool
+	MinDays      int
+	MaxDays      int
+	WarnDays     int
+	ExpireDate   string
+}
+
+// parseShadowEntry parses an entry of /etc/shadow. Empty numeric fields and an empty expiration are returned as -1.
+func parseShadowEntry(data []byte) (*shadowEntry, error) {
+	parts := strings.Split(strings.TrimSpace(string(data)), ":")
+	if len(parts) != 9 || parts[0] == "" {
+		return nil, ErrUserUnexpected
+	}
+
+	var days [4]int
+	for i, part := range []string{parts[3], parts[4], parts[5], parts[7]} {
+		if part == "" {
+			days[i] = -1
+			continue
+		}
+
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, ErrUserUnexpected
+		}
+		days[i] = v
+	}
+
+	expireDate := "-1"
+	if days[3] >= 0 {
+		// The expiration is stored as days since 1970-01-01
+		expireDate = time.Unix(int64(days[3])*24*60*60, 0).UTC().Format(time.DateOnly)
+	}
+
+	return &shadowEntry{
+		Name:         parts[0],
+		PasswordHash: strings.TrimLeft(parts[1], "!"),
+		Locked:       strings.HasPrefix(parts[1], "!"),
+		MinDays:      days[0],
+		MaxDays:      days[1],
+		WarnDays:     days[2],
+		ExpireDate:   expireDate,
+	}, nil
+}
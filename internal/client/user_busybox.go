@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// isBusybox reports whether the user is managed using the BusyBox commands because useradd is not available. The
+// result is cached for the lifetime of the client.
+func (c *userClient) isBusybox(ctx context.Context) (bool, error) {
+	if c.busybox == nil {
+		busybox, err := detectBusybox(ctx, c.s, "useradd", "adduser")
+		if err != nil {
+			return false, errors.Join(ErrUserUnexpected, err)
+		}
+		c.busybox = to.BoolPtr(busybox)
+	}
+
+	return *c.busybox, nil
+}
+
+// userBusyboxCreateScript checks the preconditions of adduser to return the exit codes of useradd. adduser creates a
+// group with the name of the user unless a group is provided. The default group of useradd (GROUP in
+// /etc/default/useradd or `users`) is used instead to match useradd --no-user-group. adduser also adds the user as
+// member of the group which is reverted because useradd only sets the primary group.
+const userBusyboxCreateScript = `_do() {
+  name=$1; uid=$2; group=$3;
+  ! getent passwd "${name}" >/dev/null || return %[2]d;
+  [ -z "${uid}" ] || ! getent passwd "${uid}" >/dev/null || return %[3]d;
+  if [ -z "${group}" ]; then group=$(sed -n 's/^GROUP=//p' /etc/default/useradd 2>/dev/null | tail -n 1); group=${group:-users}; fi;
+  group=$(getent group "${group}" | cut -d: -f1); [ -n "${group}" ] || return %[4]d;
+  adduser -D %[5]s -G "${group}" "${name}" >&2 || return 1;
+  if getent group "${group}" | cut -d: -f4 | tr ',' '\n' | grep -qxF "${name}"; then delgroup "${name}" "${group}" >&2 || return 1; fi;
+  getent passwd "${name}";
+}; _do %[1]s;`
+
+func (c *userClient) createBusybox(ctx context.Context, u User) (int, error) {
+	var args []string
+
+	uid := ""
+	if u.Uid != nil {
+		uid = strconv.Itoa(to.Int(u.Uid))
+		args = append(args, fmt.Sprintf("-u %d", to.Int(u.Uid)))
+	}
+
+	if u.System != nil && *u.System {
+		args = append(args, "-S")
+	}
+
+	// Primary group by gid or by group name
+	group := u.Group
+	if u.Gid != nil {
+		group = strconv.Itoa(to.Int(u.Gid))
+	}
+
+	if u.CreateHome {
+		if u.Skel != "" {
+			args = append(args, fmt.Sprintf("-k %s", shellescape.Quote(u.Skel)))
+		}
+	} else {
+		// adduser creates the home folder by default
+		args = append(args, "-H")
+	}
+
+	if u.Home != "" {
+		args = append(args, fmt.Sprintf("-h %s", shellescape.Quote(u.Home)))
+	}
+
+	if u.Shell != "" {
+		args = append(args, fmt.Sprintf("-s %s", shellescape.Quote(u.Shell)))
+	}
+
+	cmd := NewCommand(fmt.Sprintf(userBusyboxCreateScript, strings.Join([]string{shellescape.Quote(u.Name), shellescape.Quote(uid), shellescape.Quote(group)}, " "), codeUserNameExists, codeUserUidExists, codeUserGroupNotFound, strings.Join(args, " ")))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return -1, errors.Join(ErrUserUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeUserUidExists:
+		return -1, ErrUserUidExists
+	case codeUserGroupNotFound:
+		return -1, ErrUserGroupNotFound
+	case codeUserNameExists:
+		return -1, ErrUserNameExists
+	}
+
+	if err := res.Error(); err != nil {
+		return -1, errors.Join(ErrUserUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	createdUser, err := parsePasswdEntry(res.Stdout)
+	if err != nil {
+		return -1, ErrUserUnexpected
+	}
+
+	if u.hasAccount() {
+		err = c.updateAccount(ctx, createdUser.Uid, u)
+		if err != nil {
+			return createdUser.Uid, err
+		}
+	}
+
+	return createdUser.Uid, nil
+}
+
+// userBusyboxUpdateScript modifies /etc/passwd, /etc/shadow, and /etc/group in the order of usermod. The name is
+// changed last because the other fields are looked up by the current name.
+const userBusyboxUpdateScript = busyboxFunctions + `
+_do() {
+  uid=$1; name=$2; home=$3; move=$4; shell=$5; group=$6;
+  user=$(getent passwd "${uid}" | cut -d: -f1); [ -n "${user}" ] || return %[2]d;
+  if [ -n "${name}" ] && [ "${name}" != "${user}" ]; then ! getent passwd "${name}" >/dev/null || return %[3]d; fi;
+  if [ -n "${group}" ]; then
+    gid=$(getent group "${group}" | cut -d: -f3); [ -n "${gid}" ] || return %[4]d;
+    _set /etc/passwd "${user}" 4 "${gid}" || return 1;
+  fi;
+  if [ -n "${shell}" ]; then _set /etc/passwd "${user}" 7 "${shell}" || return 1; fi;
+  if [ -n "${home}" ]; then
+    current=$(getent passwd "${uid}" | cut -d: -f6);
+    if [ "${move}" = "true" ] && [ "${current}" != "${home}" ] && [ -d "${current}" ]; then
+      [ ! -e "${home}" ] || { echo "folder ${home} exists" >&2; return 1; };
+      mv "${current}" "${home}" || return 1;
+    fi;
+    _set /etc/passwd "${user}" 6 "${home}" || return 1;
+  fi;
+  if [ -n "${name}" ] && [ "${name}" != "${user}" ]; then
+    _set /etc/passwd "${user}" 1 "${name}" && _set /etc/shadow "${user}" 1 "${name}" && _rename "${user}" "${name}" /etc/group /etc/gshadow || return 1;
+  fi;
+}; _do %[1]s;`
+
+func (c *userClient) updateBusybox(ctx context.Context, u User) error {
+	group := u.Group
+	if u.Gid != nil {
+		group = strconv.Itoa(to.Int(u.Gid))
+	}
+
+	args := []string{
+		strconv.Itoa(to.Int(u.Uid)),
+		shellescape.Quote(u.Name),
+		shellescape.Quote(u.Home),
+		strconv.FormatBool(u.MoveHome),
+		shellescape.Quote(u.Shell),
+		shellescape.Quote(group),
+	}
+
+	cmd := NewCommand(fmt.Sprintf(userBusyboxUpdateScript, strings.Join(args, " "), codeUserNotFound, codeUserNameExists, codeUserGroupNotFound))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrUserUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case codeUserNotFound:
+		return ErrUserNotFound
+	case codeUserNameExists:
+		return ErrUserNameExists
+	case codeUserGroupNotFound:
+		return ErrUserGroupNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(ErrUserUnexpected, err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	if u.CreateHome {
+		err = c.createHome(ctx, to.Int(u.Uid), u.Skel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.updateAccount(ctx, to.Int(u.Uid), u)
+}
+
+// userBusyboxAgingFields maps the shadow fields to the aging of the account
+var userBusyboxAgingFields = []struct {
+	field int
+	value func(u User) *int
+}{
+	{field: 4, value: func(u User) *int { return u.PasswordMinDays }},
+	{field: 5, value: func(u User) *int { return u.PasswordMaxDays }},
+	{field: 6, value: func(u User) *int { return u.PasswordWarnDays }},
+}
+
+// userBusyboxAccountCommands returns the commands which apply the lock and the aging of the account using passwd and
+// by modifying /etc/shadow because BusyBox does not provide usermod and chage
+func userBusyboxAccountCommands(u User) ([]string, error) {
+	var cmds []string
+
+	if u.Locked != nil {
+		if *u.Locked {
+			cmds = append(cmds, `passwd -l "${user}" >/dev/null || return 1;`)
+		} else {
+			cmds = append(cmds, `passwd -u "${user}" >/dev/null || return 1;`)
+		}
+	}
+
+	// An empty field disables the respective check
+	shadowValue := func(v int) string {
+		if v < 0 {
+			return "''"
+		}
+		return strconv.Itoa(v)
+	}
+
+	if u.ExpireDate != nil {
+		expireDays := -1
+		if expireDate := to.String(u.ExpireDate); expireDate != "-1" {
+			t, err := time.Parse(time.DateOnly, expireDate)
+			if err != nil {
+				return nil, errors.Join(ErrUserAccount, err)
+			}
+
+			// The expiration is stored as days since 1970-01-01
+			expireDays = int(t.Unix() / (24 * 60 * 60))
+		}
+		cmds = append(cmds, fmt.Sprintf(`_set /etc/shadow "${user}" 8 %s || return 1;`, shadowValue(expireDays)))
+	}
+
+	for _, f := range userBusyboxAgingFields {
+		if v := f.value(u); v != nil {
+			cmds = append(cmds, fmt.Sprintf(`_set /etc/shadow "${user}" %d %s || return 1;`, f.field, shadowValue(*v)))
+		}
+	}
+
+	return cmds, nil
+}
+
+func (c *userClient) deleteBusybox(ctx context.Context, uid int, removeHome bool) error {
+	var args []string
+
+	if removeHome {
+		args = append(args, "--remove-home")
+	}
+
+	// Note: deluser will also remove the group of the user with the same name
+	cmd := NewCommand(fmt.Sprintf(`_do() { uid=$1; user=$(getent passwd $uid | cut -d: -f1); [ ! -z "${user}" ] || return %[2]d; deluser %[3]s "${user}"; return $?; }; _do '%[1]d';`, uid, codeUserNotFound, strings.Join(args, " ")))
+	res, err := ExecuteCommand(ctx, c.s, cmd)
+	if err != nil {
+		return errors.Join(ErrUserUnexpected, err)
+	}
+
+	switch res.ExitCode {
+	case 0:
+		// Success
+		break
+	case codeUserNotFound:
+		// Not interpreted as error because this is the desired state
+		break
+	default:
+		return errors.Join(ErrUser, fmt.Errorf("failed to delete user with uid %d", uid), errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
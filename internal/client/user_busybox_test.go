@@ -0,0 +1,213 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBusyboxPasswd = "root:x:0:0:root:/root:/bin/sh\nalice:x:1000:100::/home/alice:/bin/sh\n"
+	testBusyboxGroup  = "root:x:0:\nusers:x:100:\nstaff:x:50:\n"
+)
+
+// testBusyboxDefaultGroup returns the default group of useradd on the host which is read by the create script
+func testBusyboxDefaultGroup(t *testing.T) string {
+	data, err := os.ReadFile("/etc/default/useradd")
+	if err != nil {
+		return "users"
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "GROUP=") {
+			t.Skip("default group of useradd is configured on the host")
+		}
+	}
+	return "users"
+}
+
+func TestUserClient_createBusybox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default group", func(t *testing.T) {
+		group := testBusyboxDefaultGroup(t)
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		uid, err := NewUserClient(local.NewSystem()).Create(ctx, User{Name: "bob", Uid: to.IntPtr(1001)})
+		require.NoError(t, err)
+		assert.Equal(t, 1001, uid)
+
+		assert.Equal(t, "adduser -D -u 1001 -H -G "+group+" bob\ndelgroup bob "+group+"\n", env.read(t, "log"))
+		assert.Equal(t, testBusyboxPasswd+"bob:x:1001:100::/home/bob:/bin/sh\n", env.read(t, "passwd"))
+
+		// Membership added by adduser is reverted
+		assert.Equal(t, testBusyboxGroup, env.read(t, "group"))
+	})
+
+	t.Run("group by gid", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewUserClient(local.NewSystem()).Create(ctx, User{Name: "bob", Gid: to.IntPtr(50), CreateHome: true, Skel: "/etc/skel", Home: "/srv/bob", Shell: "/bin/ash"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "adduser -D -k /etc/skel -h /srv/bob -s /bin/ash -G staff bob\ndelgroup bob staff\n", env.read(t, "log"))
+		assert.Equal(t, testBusyboxPasswd+"bob:x:1000:50::/srv/bob:/bin/ash\n", env.read(t, "passwd"))
+	})
+
+	t.Run("name exists", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewUserClient(local.NewSystem()).Create(ctx, User{Name: "alice", Group: "users"})
+		assert.ErrorIs(t, err, ErrUserNameExists)
+		assert.Empty(t, env.read(t, "log"))
+	})
+
+	t.Run("uid exists", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewUserClient(local.NewSystem()).Create(ctx, User{Name: "bob", Uid: to.IntPtr(1000), Group: "users"})
+		assert.ErrorIs(t, err, ErrUserUidExists)
+		assert.Empty(t, env.read(t, "log"))
+	})
+
+	t.Run("group not found", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		_, err := NewUserClient(local.NewSystem()).Create(ctx, User{Name: "bob", Group: "missing"})
+		assert.ErrorIs(t, err, ErrUserGroupNotFound)
+		assert.Empty(t, env.read(t, "log"))
+	})
+}
+
+func TestUserClient_deleteBusybox(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("remove home", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		err := NewUserClient(local.NewSystem()).Delete(ctx, 1000, true)
+		require.NoError(t, err)
+		assert.Equal(t, "deluser --remove-home alice\n", env.read(t, "log"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		env := newTestBusyboxEnv(t, testBusyboxPasswd, testBusyboxGroup)
+
+		err := NewUserClient(local.NewSystem()).Delete(ctx, 1001, false)
+		require.NoError(t, err)
+		assert.Empty(t, env.read(t, "log"))
+	})
+}
+
+func TestUserClient_createHome(t *testing.T) {
+	ctx := context.Background()
+
+	home := filepath.Join(t.TempDir(), "home", "bob")
+	skel := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(skel, ".profile"), []byte("profile\n"), 0644))
+
+	passwd := fmt.Sprintf("bob:x:%d:%d::%s:/bin/sh\n", os.Getuid(), os.Getgid(), home)
+	newTestBusyboxEnv(t, passwd, testBusyboxGroup)
+
+	c := &userClient{s: local.NewSystem()}
+
+	err := c.createHome(ctx, os.Getuid(), skel)
+	require.NoError(t, err)
+
+	info, err := os.Stat(home)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	data, err := os.ReadFile(filepath.Join(home, ".profile"))
+	require.NoError(t, err)
+	assert.Equal(t, "profile\n", string(data))
+
+	// An existing home folder is not modified
+	require.NoError(t, os.Remove(filepath.Join(home, ".profile")))
+	err = c.createHome(ctx, os.Getuid(), skel)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(home, ".profile"))
+
+	err = c.createHome(ctx, 4242, skel)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserBusyboxAccountCommands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		user     User
+		expected []string
+		err      error
+	}{
+		{
+			name: "none",
+			user: User{},
+		},
+		{
+			name: "lock",
+			user: User{Locked: to.BoolPtr(true)},
+			expected: []string{
+				`passwd -l "${user}" >/dev/null || return 1;`,
+			},
+		},
+		{
+			name: "unlock",
+			user: User{Locked: to.BoolPtr(false)},
+			expected: []string{
+				`passwd -u "${user}" >/dev/null || return 1;`,
+			},
+		},
+		{
+			name: "expire date",
+			user: User{ExpireDate: to.StringPtr("2030-01-02")},
+			expected: []string{
+				`_set /etc/shadow "${user}" 8 21916 || return 1;`,
+			},
+		},
+		{
+			name: "expire disabled",
+			user: User{ExpireDate: to.StringPtr("-1")},
+			expected: []string{
+				`_set /etc/shadow "${user}" 8 '' || return 1;`,
+			},
+		},
+		{
+			name: "aging",
+			user: User{PasswordMinDays: to.IntPtr(1), PasswordMaxDays: to.IntPtr(-1), PasswordWarnDays: to.IntPtr(7)},
+			expected: []string{
+				`_set /etc/shadow "${user}" 4 1 || return 1;`,
+				`_set /etc/shadow "${user}" 5 '' || return 1;`,
+				`_set /etc/shadow "${user}" 6 7 || return 1;`,
+			},
+		},
+		{
+			name: "invalid expire date",
+			user: User{ExpireDate: to.StringPtr("02.01.2030")},
+			err:  ErrUserAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmds, err := userBusyboxAccountCommands(tt.user)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cmds)
+		})
+	}
+}
@@ -1,5 +1,18 @@
 package client
 
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alessio/shellescape"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
 func truncateBytes(b []byte, max int) []byte {
 	if b == nil {
 		return nil
@@ -11,3 +24,138 @@ func truncateBytes(b []byte, max int) []byte {
 
 	return b[:max]
 }
+
+// errDestinationNotFound is returned by the destination helpers if the destination folder does not exist
+var errDestinationNotFound = errors.New("destination folder not found")
+
+const codeDestinationNotFound = 17
+
+// extractTar creates the destination folder and extracts the tar archive which is written by write. The archive is
+// streamed to the remote system and compressed using gzip if compress is true.
+func extractTar(ctx context.Context, s system.System, destination string, compress bool, write func(w io.Writer) error) error {
+	tarArgs := `-x -o -f -`
+	if compress {
+		tarArgs = `-x -z -o -f -`
+	}
+
+	// Setup pipe to stream the archive
+	pipeReader, pipeWriter := io.Pipe()
+
+	go func() {
+		var err error
+		if compress {
+			gzipWriter, gzipErr := gzip.NewWriterLevel(pipeWriter, gzip.BestCompression)
+			if gzipErr != nil {
+				_ = pipeWriter.CloseWithError(gzipErr)
+				return
+			}
+			err = write(gzipWriter)
+			if err == nil {
+				err = gzipWriter.Close()
+			}
+		} else {
+			err = write(pipeWriter)
+		}
+		_ = pipeWriter.CloseWithError(err)
+	}()
+
+	cmd := NewInputCommand(fmt.Sprintf(`_do() { path=$1; mkdir -p "${path}" && tar %[2]s -C "${path}"; }; _do %[1]s;`, shellescape.Quote(destination), tarArgs), pipeReader)
+	res, err := ExecuteCommand(ctx, s, cmd)
+
+	// Unblock the writer in case the remote command did not consume the entire archive
+	_ = pipeReader.Close()
+
+	if err != nil {
+		return err
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+// runDestinationScript executes a shell script in the destination folder. The script is provided on stdin which avoids
+// limits on the length of the command line. Returns errDestinationNotFound if the destination folder does not exist.
+func runDestinationScript(ctx context.Context, s system.System, destination string, script []string) error {
+	if len(script) == 0 {
+		return nil
+	}
+
+	cmd := NewInputCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return %[2]d; cd "${path}" && /bin/sh -e; }; _do %[1]s;`, shellescape.Quote(destination), codeDestinationNotFound), strings.NewReader(strings.Join(script, "\n")+"\n"))
+	res, err := ExecuteCommand(ctx, s, cmd)
+	if err != nil {
+		return err
+	}
+
+	switch res.ExitCode {
+	case codeDestinationNotFound:
+		return errDestinationNotFound
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+// deleteDestinationScript returns the script which removes the files and the folders below the destination folder.
+// Folders are removed only if empty starting with the deepest folder. Parent folders of the files are removed as well
+// if they became empty.
+func deleteDestinationScript(files []string, dirs []string) []string {
+	var script []string
+
+	uniqueDirs := map[string]struct{}{}
+	for _, dir := range dirs {
+		uniqueDirs[dir] = struct{}{}
+	}
+
+	for _, p := range append(append([]string{}, files...), dirs...) {
+		for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+			uniqueDirs[dir] = struct{}{}
+		}
+	}
+
+	for _, p := range files {
+		script = append(script, fmt.Sprintf(`rm -f %s`, quoteRelative(p)))
+	}
+
+	var sortedDirs []string
+	for dir := range uniqueDirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Slice(sortedDirs, func(i, j int) bool {
+		di, dj := strings.Count(sortedDirs[i], "/"), strings.Count(sortedDirs[j], "/")
+		if di != dj {
+			return di > dj
+		}
+		return sortedDirs[i] < sortedDirs[j]
+	})
+	for _, dir := range sortedDirs {
+		script = append(script, fmt.Sprintf(`rmdir %s 2>/dev/null || true`, quoteRelative(dir)))
+	}
+
+	return script
+}
+
+// deleteDestinationIfEmpty removes the destination folder if it exists and is empty
+func deleteDestinationIfEmpty(ctx context.Context, s system.System, destination string) error {
+	cmd := NewCommand(fmt.Sprintf(`_do() { path=$1; [ -d "${path}" ] || return 0; [ -z "$(ls -A "${path}")" ] || return 0; rmdir "${path}"; }; _do %[1]s;`, shellescape.Quote(destination)))
+	res, err := ExecuteCommand(ctx, s, cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := res.Error(); err != nil {
+		return errors.Join(err, errors.New(strings.TrimSpace(res.StderrString())))
+	}
+
+	return nil
+}
+
+// quoteRelative quotes a relative path for the use in a script which is executed in the destination folder
+func quoteRelative(p string) string {
+	return shellescape.Quote("./" + p)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuspaces/terraform-provider-system/internal/system/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteDestinationScript(t *testing.T) {
+	t.Parallel()
+
+	script := deleteDestinationScript([]string{"b/c/file", "a/file", "top"}, []string{"b/d"})
+
+	assert.Equal(t, []string{
+		`rm -f ./b/c/file`,
+		`rm -f ./a/file`,
+		`rm -f ./top`,
+		`rmdir ./b/c 2>/dev/null || true`,
+		`rmdir ./b/d 2>/dev/null || true`,
+		`rmdir ./a 2>/dev/null || true`,
+		`rmdir ./b 2>/dev/null || true`,
+	}, script)
+}
+
+func TestDestinationHelpers(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s := local.NewSystem()
+
+	// Destination contains a quote which must be escaped
+	destination := filepath.Join(t.TempDir(), "it's", "dest")
+
+	for _, compress := range []bool{false, true} {
+		err := extractTar(ctx, s, destination, compress, func(w io.Writer) error {
+			tw := tar.NewWriter(w)
+			content := "hello world!"
+			for _, name := range []string{"a/file", "b/c/file"} {
+				if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+					return err
+				}
+				if _, err := tw.Write([]byte(content)); err != nil {
+					return err
+				}
+			}
+			return tw.Close()
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(destination, "b", "c", "file"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world!", string(data))
+
+		// Folder is not removed if not empty
+		require.NoError(t, deleteDestinationIfEmpty(ctx, s, destination))
+		assert.DirExists(t, destination)
+
+		require.NoError(t, runDestinationScript(ctx, s, destination, deleteDestinationScript([]string{"a/file", "b/c/file"}, nil)))
+
+		require.NoError(t, deleteDestinationIfEmpty(ctx, s, destination))
+		assert.NoDirExists(t, destination)
+	}
+
+	err := runDestinationScript(ctx, s, destination, []string{"true"})
+	assert.ErrorIs(t, err, errDestinationNotFound)
+
+	// Script is executed with -e
+	require.NoError(t, os.MkdirAll(destination, 0755))
+	err = runDestinationScript(ctx, s, destination, []string{"false", "touch ./file"})
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(destination, "file"))
+}
@@ -0,0 +1,378 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Format is the container and compression format of an archive
+type Format string
+
+const (
+	FormatTar Format = "tar"
+
+	FormatTarGz Format = "tar.gz"
+
+	FormatTarZst Format = "tar.zst"
+
+	FormatZip Format = "zip"
+)
+
+// Formats returns all supported formats
+func Formats() []Format {
+	return []Format{FormatTar, FormatTarGz, FormatTarZst, FormatZip}
+}
+
+var formatSuffixes = []struct {
+	suffix string
+	format Format
+}{
+	{".tar.gz", FormatTarGz},
+	{".tgz", FormatTarGz},
+	{".tar.zst", FormatTarZst},
+	{".tzst", FormatTarZst},
+	{".tar", FormatTar},
+	{".zip", FormatZip},
+}
+
+// DetectFormat returns the format of an archive inferred from the suffix of name
+func DetectFormat(name string) (Format, bool) {
+	lower := strings.ToLower(name)
+	for _, fs := range formatSuffixes {
+		if strings.HasSuffix(lower, fs.suffix) {
+			return fs.format, true
+		}
+	}
+	return "", false
+}
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported archive format")
+
+	ErrInvalidPath = errors.New("invalid path in archive")
+)
+
+// Options control the conversion of an archive
+type Options struct {
+	// StripComponents removes the number of leading path elements. Entries with less path elements are skipped.
+	StripComponents int
+
+	// FileMode overrides the permissions of files if not zero
+	FileMode fs.FileMode
+
+	// DirectoryMode overrides the permissions of folders if not zero
+	DirectoryMode fs.FileMode
+}
+
+// Entry is an extracted file, folder, or link
+type Entry struct {
+	// Path is the slash-separated path relative to the destination
+	Path string
+
+	IsDir bool
+}
+
+// Convert reads an archive in the provided format from r and writes the contained files, folders, and links as tar
+// archive to w. Convert returns the entries which have been written sorted by path.
+func Convert(w io.Writer, r io.Reader, format Format, opts Options) ([]Entry, error) {
+	tw := tar.NewWriter(w)
+	c := &converter{
+		tw:      tw,
+		opts:    opts,
+		written: map[string]bool{},
+		links:   map[string]bool{},
+	}
+
+	var err error
+	switch format {
+	case FormatTar:
+		err = c.fromTar(r)
+	case FormatTarGz:
+		var gr *gzip.Reader
+		gr, err = gzip.NewReader(r)
+		if err == nil {
+			err = c.fromTar(gr)
+		}
+	case FormatTarZst:
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(r)
+		if err == nil {
+			err = c.fromTar(zr)
+			zr.Close()
+		}
+	case FormatZip:
+		err = c.fromZip(r)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(c.written))
+	for p, isDir := range c.written {
+		entries = append(entries, Entry{Path: p, IsDir: isDir})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries, nil
+}
+
+type converter struct {
+	tw   *tar.Writer
+	opts Options
+
+	// written contains the paths of written entries and whether the entry is a folder
+	written map[string]bool
+
+	// links contains the paths of written symbolic links
+	links map[string]bool
+}
+
+// stripPath cleans name and removes leading path elements. Returns false if the entry is skipped.
+func (c *converter) stripPath(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "", false
+	}
+
+	parts := strings.Split(name, "/")
+	if len(parts) <= c.opts.StripComponents {
+		return "", false
+	}
+
+	return strings.Join(parts[c.opts.StripComponents:], "/"), true
+}
+
+// validateLink rejects links which point outside the destination. Absolute targets are rejected because they are
+// resolved relative to the root of the remote system instead of the destination.
+func validateLink(p string, target string) error {
+	if path.IsAbs(target) {
+		return fmt.Errorf("%w: link %q has an absolute target", ErrInvalidPath, p)
+	}
+	resolved := path.Join(path.Dir(p), target)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("%w: link %q points outside of the destination", ErrInvalidPath, p)
+	}
+	return nil
+}
+
+// validateParents rejects paths which contain a previously written symbolic link as parent because the entry would be
+// extracted to the target of the link
+func (c *converter) validateParents(p string) error {
+	for dir := path.Dir(p); dir != "."; dir = path.Dir(dir) {
+		if c.links[dir] {
+			return fmt.Errorf("%w: path %q crosses the link %q", ErrInvalidPath, p, dir)
+		}
+	}
+	return nil
+}
+
+func (c *converter) write(h *tar.Header, r io.Reader) error {
+	name := strings.TrimSuffix(h.Name, "/")
+
+	err := c.validateParents(name)
+	if err != nil {
+		return err
+	}
+	if h.Typeflag == tar.TypeLink {
+		err = c.validateParents(h.Linkname)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch h.Typeflag {
+	case tar.TypeDir:
+		if c.opts.DirectoryMode != 0 {
+			h.Mode = int64(c.opts.DirectoryMode.Perm())
+		}
+	case tar.TypeReg:
+		if c.opts.FileMode != 0 {
+			h.Mode = int64(c.opts.FileMode.Perm())
+		}
+	}
+
+	// Ownership is not retained
+	h.Uid, h.Gid, h.Uname, h.Gname = 0, 0, "", ""
+
+	err = c.tw.WriteHeader(h)
+	if err != nil {
+		return err
+	}
+
+	if r != nil {
+		_, err = io.Copy(c.tw, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.written[name] = h.Typeflag == tar.TypeDir
+	if h.Typeflag == tar.TypeSymlink {
+		// Retained even if the link is replaced by a later entry because extractors differ on whether a folder
+		// replaces an existing link
+		c.links[name] = true
+	}
+
+	return nil
+}
+
+func (c *converter) fromTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, ok := c.stripPath(h.Name)
+		if !ok {
+			continue
+		}
+
+		out := &tar.Header{
+			Name:    name,
+			Mode:    h.Mode & int64(fs.ModePerm),
+			ModTime: h.ModTime,
+		}
+
+		switch h.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			out.Typeflag = tar.TypeReg
+			out.Size = h.Size
+			err = c.write(out, tr)
+		case tar.TypeDir:
+			out.Typeflag = tar.TypeDir
+			out.Name += "/"
+			err = c.write(out, nil)
+		case tar.TypeSymlink:
+			if err := validateLink(name, h.Linkname); err != nil {
+				return err
+			}
+			out.Typeflag = tar.TypeSymlink
+			out.Linkname = h.Linkname
+			err = c.write(out, nil)
+		case tar.TypeLink:
+			// Hard links refer to another entry in the archive which is subject to strip components as well
+			linkname, ok := c.stripPath(h.Linkname)
+			if !ok {
+				continue
+			}
+			out.Typeflag = tar.TypeLink
+			out.Linkname = linkname
+			err = c.write(out, nil)
+		default:
+			// Other types such as devices or fifos are not extracted
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *converter) fromZip(r io.Reader) error {
+	// Zip archives require random access which is provided by a temporary file
+	tmp, err := os.CreateTemp("", "terraform-provider-system-*.zip")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		name, ok := c.stripPath(f.Name)
+		if !ok {
+			continue
+		}
+
+		mode := f.Mode()
+		out := &tar.Header{
+			Name:    name,
+			Mode:    int64(mode.Perm()),
+			ModTime: f.Modified,
+		}
+
+		var content io.ReadCloser
+
+		switch {
+		case mode.IsDir():
+			out.Typeflag = tar.TypeDir
+			out.Name += "/"
+			if out.Mode == 0 {
+				out.Mode = 0755
+			}
+		case mode&fs.ModeSymlink != 0:
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			target, err := io.ReadAll(rc)
+			_ = rc.Close()
+			if err != nil {
+				return err
+			}
+			if err := validateLink(name, string(target)); err != nil {
+				return err
+			}
+			out.Typeflag = tar.TypeSymlink
+			out.Linkname = string(target)
+		case mode.IsRegular():
+			out.Typeflag = tar.TypeReg
+			out.Size = int64(f.UncompressedSize64)
+			if out.Mode == 0 {
+				out.Mode = 0644
+			}
+			content, err = f.Open()
+			if err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		err = c.write(out, content)
+		if content != nil {
+			_ = content.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
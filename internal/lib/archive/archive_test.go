@@ -0,0 +1,280 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+type testEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  string
+	linkname string
+}
+
+var testEntries = []testEntry{
+	{name: "release-1.0/", typeflag: tar.TypeDir, mode: 0755},
+	{name: "release-1.0/README.md", typeflag: tar.TypeReg, mode: 0644, content: "hello world!"},
+	{name: "release-1.0/bin/", typeflag: tar.TypeDir, mode: 0755},
+	{name: "release-1.0/bin/app", typeflag: tar.TypeReg, mode: 0755, content: "#!/bin/sh\n"},
+	{name: "release-1.0/bin/app-latest", typeflag: tar.TypeSymlink, mode: 0777, linkname: "app"},
+}
+
+func testTar(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Mode:     e.mode,
+			Size:     int64(len(e.content)),
+			Linkname: e.linkname,
+		}))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func testTarGz(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(testTar(t, entries))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func testTarZst(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write(testTar(t, entries))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func testZip(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name}
+		mode := fs.FileMode(e.mode)
+		switch e.typeflag {
+		case tar.TypeDir:
+			mode |= fs.ModeDir
+		case tar.TypeSymlink:
+			mode |= fs.ModeSymlink
+		}
+		h.SetMode(mode)
+		w, err := zw.CreateHeader(h)
+		require.NoError(t, err)
+		content := e.content
+		if e.typeflag == tar.TypeSymlink {
+			content = e.linkname
+		}
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// readTar returns the headers and contents of a tar archive
+func readTar(t *testing.T, data []byte) map[string]testEntry {
+	result := map[string]testEntry{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		result[h.Name] = testEntry{
+			name:     h.Name,
+			typeflag: h.Typeflag,
+			mode:     h.Mode,
+			content:  string(content),
+			linkname: h.Linkname,
+		}
+	}
+	return result
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		ok     bool
+	}{
+		{name: "/tmp/release.tar", format: FormatTar, ok: true},
+		{name: "/tmp/release.tar.gz", format: FormatTarGz, ok: true},
+		{name: "/tmp/release.tgz", format: FormatTarGz, ok: true},
+		{name: "/tmp/release.tar.zst", format: FormatTarZst, ok: true},
+		{name: "/tmp/RELEASE.ZIP", format: FormatZip, ok: true},
+		{name: "/tmp/release.rar", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, ok := DetectFormat(tt.name)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		archive func(t *testing.T, entries []testEntry) []byte
+	}{
+		{name: "tar", format: FormatTar, archive: testTar},
+		{name: "tar.gz", format: FormatTarGz, archive: testTarGz},
+		{name: "tar.zst", format: FormatTarZst, archive: testTarZst},
+		{name: "zip", format: FormatZip, archive: testZip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			entries, err := Convert(&out, bytes.NewReader(tt.archive(t, testEntries)), tt.format, Options{
+				StripComponents: 1,
+				FileMode:        0640,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, []Entry{
+				{Path: "README.md"},
+				{Path: "bin", IsDir: true},
+				{Path: "bin/app"},
+				{Path: "bin/app-latest"},
+			}, entries)
+
+			written := readTar(t, out.Bytes())
+			require.Len(t, written, 4)
+
+			assert.Equal(t, "hello world!", written["README.md"].content)
+			assert.Equal(t, int64(0640), written["README.md"].mode)
+			assert.Equal(t, int64(0640), written["bin/app"].mode)
+			assert.Equal(t, byte(tar.TypeDir), written["bin/"].typeflag)
+			assert.Equal(t, int64(0755), written["bin/"].mode)
+			assert.Equal(t, byte(tar.TypeSymlink), written["bin/app-latest"].typeflag)
+			assert.Equal(t, "app", written["bin/app-latest"].linkname)
+		})
+	}
+}
+
+func TestConvert_invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		entries []testEntry
+		err     error
+	}{
+		{
+			name:   "unsupported format",
+			format: Format("rar"),
+			err:    ErrUnsupportedFormat,
+		},
+		{
+			name:   "link outside of destination",
+			format: FormatTar,
+			entries: []testEntry{
+				{name: "passwd", typeflag: tar.TypeSymlink, mode: 0777, linkname: "../../etc/passwd"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "link with absolute target",
+			format: FormatTar,
+			entries: []testEntry{
+				{name: "passwd", typeflag: tar.TypeSymlink, mode: 0777, linkname: "/etc/passwd"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "link with absolute target in zip",
+			format: FormatZip,
+			entries: []testEntry{
+				{name: "passwd", typeflag: tar.TypeSymlink, mode: 0777, linkname: "/etc/passwd"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "file below link",
+			format: FormatTar,
+			entries: []testEntry{
+				{name: "a/", typeflag: tar.TypeDir, mode: 0755},
+				{name: "a/b", typeflag: tar.TypeSymlink, mode: 0777, linkname: "."},
+				{name: "a/b/c/d", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "file below link in zip",
+			format: FormatZip,
+			entries: []testEntry{
+				{name: "etc", typeflag: tar.TypeSymlink, mode: 0777, linkname: "."},
+				{name: "etc/cron.d/job", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "file below link replaced by folder",
+			format: FormatTar,
+			entries: []testEntry{
+				{name: "lib", typeflag: tar.TypeSymlink, mode: 0777, linkname: "."},
+				{name: "lib/", typeflag: tar.TypeDir, mode: 0755},
+				{name: "lib/app.so", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+			},
+			err: ErrInvalidPath,
+		},
+		{
+			name:   "hard link below link",
+			format: FormatTar,
+			entries: []testEntry{
+				{name: "etc", typeflag: tar.TypeSymlink, mode: 0777, linkname: "."},
+				{name: "shadow", typeflag: tar.TypeLink, mode: 0644, linkname: "etc/shadow"},
+			},
+			err: ErrInvalidPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testTar(t, tt.entries)
+			if tt.format == FormatZip {
+				data = testZip(t, tt.entries)
+			}
+			_, err := Convert(io.Discard, bytes.NewReader(data), tt.format, Options{})
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestConvert_traversal(t *testing.T) {
+	var out bytes.Buffer
+	entries, err := Convert(&out, bytes.NewReader(testTar(t, []testEntry{
+		{name: "../../etc/cron.d/job", typeflag: tar.TypeReg, mode: 0644, content: "x"},
+	})), FormatTar, Options{})
+	require.NoError(t, err)
+
+	// Parent references are resolved within the destination
+	assert.Equal(t, []Entry{{Path: "etc/cron.d/job"}}, entries)
+}
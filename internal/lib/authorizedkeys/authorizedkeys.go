@@ -0,0 +1,246 @@
+package authorizedkeys
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Implements the format of the authorized_keys file of OpenSSH
+// https://man.openbsd.org/sshd.8#AUTHORIZED_KEYS_FILE_FORMAT
+
+var (
+	ErrInvalidKey = errors.New("invalid authorized key")
+
+	ErrInvalidOption = errors.New("invalid authorized key option")
+)
+
+// options are the supported key options. The value reports whether the option requires a quoted value.
+var options = map[string]bool{
+	"agent-forwarding":    false,
+	"cert-authority":      false,
+	"command":             true,
+	"environment":         true,
+	"expiry-time":         true,
+	"from":                true,
+	"no-agent-forwarding": false,
+	"no-port-forwarding":  false,
+	"no-pty":              false,
+	"no-touch-required":   false,
+	"no-user-rc":          false,
+	"no-x11-forwarding":   false,
+	"permitlisten":        true,
+	"permitopen":          true,
+	"port-forwarding":     false,
+	"principals":          true,
+	"pty":                 false,
+	"restrict":            false,
+	"tunnel":              true,
+	"user-rc":             false,
+	"verify-required":     false,
+	"x11-forwarding":      false,
+}
+
+var expiryTimeRegexp = regexp.MustCompile(`^\d{8}(\d{4}(\d{2})?)?Z?$`)
+
+// Key is a public key in an authorized_keys file
+type Key struct {
+	// Options restrict the key, e.g. `no-pty` or `from="10.0.0.0/8"`
+	Options []string
+
+	Type    string
+	Blob    string
+	Comment string
+}
+
+// Id identifies the key by the type and the base64 encoded public key. Options and comment are not part of the id.
+func (k Key) Id() string {
+	return k.Type + " " + k.Blob
+}
+
+// String returns the line of the key in the authorized_keys file
+func (k Key) String() string {
+	var parts []string
+
+	if len(k.Options) > 0 {
+		parts = append(parts, strings.Join(k.Options, ","))
+	}
+
+	parts = append(parts, k.Type, k.Blob)
+
+	if k.Comment != "" {
+		parts = append(parts, k.Comment)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Parse parses a line of an authorized_keys file. Leading and trailing whitespace is ignored. The public key is parsed
+// using ssh.ParseAuthorizedKey and the options are validated.
+func Parse(line string) (*Key, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, errors.Join(ErrInvalidKey, errors.New("line does not contain a key"))
+	}
+
+	if strings.Contains(line, "\n") {
+		return nil, errors.Join(ErrInvalidKey, errors.New("line contains multiple lines"))
+	}
+
+	pub, comment, opts, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidKey, err)
+	}
+
+	k := &Key{
+		Options: opts,
+		Type:    pub.Type(),
+		Blob:    base64.StdEncoding.EncodeToString(pub.Marshal()),
+		Comment: comment,
+	}
+
+	// ssh.ParseAuthorizedKey derives the key type from the public key and ignores the key type of the line
+	fields := strings.Fields(line)
+	if i := slices.Index(fields, k.Blob); i < 1 || fields[i-1] != k.Type {
+		return nil, errors.Join(ErrInvalidKey, fmt.Errorf("public key does not match key type %q", k.Type))
+	}
+
+	for _, option := range opts {
+		if err := validateOption(option); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func validateOption(option string) error {
+	name, value, hasValue := strings.Cut(option, "=")
+
+	requiresValue, ok := options[strings.ToLower(name)]
+	if !ok {
+		return errors.Join(ErrInvalidOption, fmt.Errorf("unsupported option %q", name))
+	}
+
+	if !requiresValue {
+		if hasValue {
+			return errors.Join(ErrInvalidOption, fmt.Errorf("option %q does not accept a value", name))
+		}
+		return nil
+	}
+
+	if !hasValue || len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return errors.Join(ErrInvalidOption, fmt.Errorf("option %q requires a value in double quotes", name))
+	}
+
+	if strings.ToLower(name) == "expiry-time" && !expiryTimeRegexp.MatchString(value[1:len(value)-1]) {
+		return errors.Join(ErrInvalidOption, fmt.Errorf("option %q requires a timestamp in the format YYYYMMDD[HHMM[SS]][Z]", name))
+	}
+
+	return nil
+}
+
+// splitLines splits text in lines without line terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// joinLines joins lines and terminates every line with a newline.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// List returns the keys in text. Comments, empty lines, and lines which are not valid keys are skipped.
+func List(text string) []Key {
+	var keys []Key
+
+	for _, line := range splitLines(text) {
+		k, err := Parse(line)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, *k)
+	}
+
+	return keys
+}
+
+// Set replaces the line of every key with the line of the key with the same id in text. Keys which are absent are
+// appended. Further lines with the same id are removed. Other lines are retained.
+func Set(text string, keys []Key) string {
+	pending := map[string]Key{}
+	for _, k := range keys {
+		pending[k.Id()] = k
+	}
+
+	seen := map[string]bool{}
+
+	var result []string
+	for _, line := range splitLines(text) {
+		current, err := Parse(line)
+		if err != nil {
+			result = append(result, line)
+			continue
+		}
+
+		k, ok := pending[current.Id()]
+		if !ok {
+			result = append(result, line)
+			continue
+		}
+
+		if seen[k.Id()] {
+			// Duplicate
+			continue
+		}
+		seen[k.Id()] = true
+
+		result = append(result, k.String())
+	}
+
+	for _, k := range keys {
+		if !seen[k.Id()] {
+			seen[k.Id()] = true
+			result = append(result, k.String())
+		}
+	}
+
+	return joinLines(result)
+}
+
+// Remove removes the lines of the keys with the ids from text. Other lines are retained.
+func Remove(text string, ids []string) string {
+	remove := map[string]bool{}
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	var result []string
+	for _, line := range splitLines(text) {
+		if k, err := Parse(line); err == nil && remove[k.Id()] {
+			continue
+		}
+		result = append(result, line)
+	}
+
+	return joinLines(result)
+}
+
+// Format returns the content of an authorized_keys file which only contains the keys
+func Format(keys []Key) string {
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, k.String())
+	}
+	return joinLines(lines)
+}
@@ -0,0 +1,262 @@
+package authorizedkeys_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/heredoc"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/authorizedkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const (
+	testKeyEd25519 = "AAAAC3NzaC1lZDI1NTE5AAAAIJCBvzE/j6BJ2aLiEjjjVe50IWVWV01vddbaotVWtlte"
+	testKeyEcdsa   = "AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBF4bo0IqFmyb9uaAIGElu0v2Vis8BIJmPYn+quFZd+2zryXrQiBgGnW8lDHFQH2G2Gx+SEeiUX+Lo16zx9oU7s4="
+	testKeyRsa     = "AAAAB3NzaC1yc2EAAAADAQABAAAAgQDJhi47c6PscJGSdpJxOukuDzOV5nWA7NJ0Hnmz+us0WtaFqvEZl/1ZcKie+SNED/4D9DkUPdBJ1mYTl1/vq7XyEZwACtnfzRB/bvI+2fMShfYtalJDmyyepP1mKNj984MZmat7dYXP13lTz5q+qz+1uq8TdtKCAKwZ/25bHTqS7w=="
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc         string
+		Line         string
+		Expect       *authorizedkeys.Key
+		ExpectString string
+		ExpectErr    error
+	}
+
+	tcs := []testCase{
+		{
+			Desc:         "key with comment",
+			Line:         "ssh-ed25519 " + testKeyEd25519 + " alice@laptop",
+			Expect:       &authorizedkeys.Key{Type: "ssh-ed25519", Blob: testKeyEd25519, Comment: "alice@laptop"},
+			ExpectString: "ssh-ed25519 " + testKeyEd25519 + " alice@laptop",
+		},
+		{
+			Desc:         "key without comment and surrounding whitespace",
+			Line:         "  ecdsa-sha2-nistp256\t" + testKeyEcdsa + "\n",
+			Expect:       &authorizedkeys.Key{Type: "ecdsa-sha2-nistp256", Blob: testKeyEcdsa},
+			ExpectString: "ecdsa-sha2-nistp256 " + testKeyEcdsa,
+		},
+		{
+			Desc:         "comment with spaces",
+			Line:         "ssh-rsa " + testKeyRsa + " deploy key  of ci",
+			Expect:       &authorizedkeys.Key{Type: "ssh-rsa", Blob: testKeyRsa, Comment: "deploy key  of ci"},
+			ExpectString: "ssh-rsa " + testKeyRsa + " deploy key  of ci",
+		},
+		{
+			Desc: "options",
+			Line: `from="10.0.0.0/8,192.168.1.1",no-pty,expiry-time="20301231" ssh-ed25519 ` + testKeyEd25519 + " alice@laptop",
+			Expect: &authorizedkeys.Key{
+				Options: []string{`from="10.0.0.0/8,192.168.1.1"`, "no-pty", `expiry-time="20301231"`},
+				Type:    "ssh-ed25519",
+				Blob:    testKeyEd25519,
+				Comment: "alice@laptop",
+			},
+			ExpectString: `from="10.0.0.0/8,192.168.1.1",no-pty,expiry-time="20301231" ssh-ed25519 ` + testKeyEd25519 + " alice@laptop",
+		},
+		{
+			Desc: "command with spaces and escaped quotes",
+			Line: `command="echo \"hello world\"",restrict ssh-ed25519 ` + testKeyEd25519,
+			Expect: &authorizedkeys.Key{
+				Options: []string{`command="echo \"hello world\""`, "restrict"},
+				Type:    "ssh-ed25519",
+				Blob:    testKeyEd25519,
+			},
+			ExpectString: `command="echo \"hello world\"",restrict ssh-ed25519 ` + testKeyEd25519,
+		},
+		{
+			Desc: "options are case insensitive",
+			Line: "No-Pty ssh-ed25519 " + testKeyEd25519,
+			Expect: &authorizedkeys.Key{
+				Options: []string{"No-Pty"},
+				Type:    "ssh-ed25519",
+				Blob:    testKeyEd25519,
+			},
+			ExpectString: "No-Pty ssh-ed25519 " + testKeyEd25519,
+		},
+		{
+			Desc:      "invalid: empty",
+			Line:      "  ",
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: comment",
+			Line:      "# ssh-ed25519 " + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: missing public key",
+			Line:      "ssh-ed25519",
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: unsupported key type",
+			Line:      "ssh-foo " + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: key type mismatch",
+			Line:      "ssh-rsa " + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: public key not base64",
+			Line:      "ssh-ed25519 AAAA%%%",
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: multiple lines",
+			Line:      "ssh-ed25519 " + testKeyEd25519 + "\nssh-rsa " + testKeyRsa,
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+		{
+			Desc:      "invalid: unsupported option",
+			Line:      "no-foo ssh-ed25519 " + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidOption,
+		},
+		{
+			Desc:      "invalid: option without quotes",
+			Line:      "from=10.0.0.1 ssh-ed25519 " + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidOption,
+		},
+		{
+			Desc:      "invalid: flag with value",
+			Line:      `no-pty="yes" ssh-ed25519 ` + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidOption,
+		},
+		{
+			Desc:      "invalid: expiry time",
+			Line:      `expiry-time="2030-12-31" ssh-ed25519 ` + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidOption,
+		},
+		{
+			Desc:      "invalid: unterminated quote",
+			Line:      `command="echo ssh-ed25519 ` + testKeyEd25519,
+			ExpectErr: authorizedkeys.ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			k, err := authorizedkeys.Parse(tc.Line)
+			if tc.ExpectErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, k)
+			assert.Equal(t, tc.ExpectString, k.String())
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	text := heredoc.String(`
+		# managed by terraform
+		ssh-ed25519 ` + testKeyEd25519 + ` alice@laptop
+
+		no-pty ecdsa-sha2-nistp256 ` + testKeyEcdsa + ` bob
+		not a key
+	`)
+
+	keys := authorizedkeys.List(text)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "ssh-ed25519 "+testKeyEd25519, keys[0].Id())
+	assert.Equal(t, "ecdsa-sha2-nistp256 "+testKeyEcdsa, keys[1].Id())
+	assert.Equal(t, []string{"no-pty"}, keys[1].Options)
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc   string
+		Text   string
+		Keys   []string
+		Expect string
+	}
+
+	tcs := []testCase{
+		{
+			Desc:   "empty text",
+			Text:   "",
+			Keys:   []string{"ssh-ed25519 " + testKeyEd25519 + " alice"},
+			Expect: "ssh-ed25519 " + testKeyEd25519 + " alice\n",
+		},
+		{
+			Desc: "replace options and comment in place",
+			Text: heredoc.String(`
+				# keys
+				ssh-ed25519 ` + testKeyEd25519 + ` old
+				ssh-rsa ` + testKeyRsa + `
+			`),
+			Keys: []string{"no-pty ssh-ed25519 " + testKeyEd25519 + " new"},
+			Expect: heredoc.String(`
+				# keys
+				no-pty ssh-ed25519 ` + testKeyEd25519 + ` new
+				ssh-rsa ` + testKeyRsa + `
+			`),
+		},
+		{
+			Desc: "append absent key and remove duplicate",
+			Text: heredoc.String(`
+				ssh-ed25519 ` + testKeyEd25519 + `
+				ssh-ed25519 ` + testKeyEd25519 + ` duplicate
+			`),
+			Keys: []string{"ssh-ed25519 " + testKeyEd25519, "ecdsa-sha2-nistp256 " + testKeyEcdsa},
+			Expect: heredoc.String(`
+				ssh-ed25519 ` + testKeyEd25519 + `
+				ecdsa-sha2-nistp256 ` + testKeyEcdsa + `
+			`),
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			var keys []authorizedkeys.Key
+			for _, line := range tc.Keys {
+				k, err := authorizedkeys.Parse(line)
+				require.NoError(t, err)
+				keys = append(keys, *k)
+			}
+
+			assert.Equal(t, tc.Expect, authorizedkeys.Set(tc.Text, keys))
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	text := heredoc.String(`
+		# keys
+		no-pty ssh-ed25519 ` + testKeyEd25519 + ` alice
+		ssh-rsa ` + testKeyRsa + `
+	`)
+
+	expect := heredoc.String(`
+		# keys
+		ssh-rsa ` + testKeyRsa + `
+	`)
+
+	assert.Equal(t, expect, authorizedkeys.Remove(text, []string{"ssh-ed25519 " + testKeyEd25519, "ssh-dss AAAA"}))
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	keys := authorizedkeys.List("ssh-rsa " + testKeyRsa + "\n# comment\nssh-ed25519 " + testKeyEd25519 + " alice\n")
+
+	assert.Equal(t, "ssh-rsa "+testKeyRsa+"\nssh-ed25519 "+testKeyEd25519+" alice\n", authorizedkeys.Format(keys))
+	assert.Equal(t, "", authorizedkeys.Format(nil))
+}
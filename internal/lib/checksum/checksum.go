@@ -0,0 +1,144 @@
+package checksum
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Algorithm is a hash algorithm which is supported for checksum verification
+type Algorithm string
+
+const (
+	AlgorithmSha256 Algorithm = "sha256"
+
+	AlgorithmSha512 Algorithm = "sha512"
+)
+
+// Algorithms returns all supported algorithms
+func Algorithms() []Algorithm {
+	return []Algorithm{AlgorithmSha256, AlgorithmSha512}
+}
+
+var (
+	ErrInvalid = errors.New("invalid checksum")
+
+	ErrMismatch = errors.New("checksum mismatch")
+)
+
+// Checksum is an expected checksum of a byte stream
+type Checksum struct {
+	Algorithm Algorithm
+
+	// Sum is the checksum in lowercase hex encoding
+	Sum string
+}
+
+// Parse parses a checksum in the format `algorithm:hex` like `sha256:e3b0c442...`
+func Parse(s string) (*Checksum, error) {
+	algorithm, sum, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: expected format algorithm:hex", ErrInvalid)
+	}
+
+	c := &Checksum{
+		Algorithm: Algorithm(strings.ToLower(algorithm)),
+		Sum:       strings.ToLower(sum),
+	}
+
+	h := c.New()
+	if h == nil {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalid, algorithm)
+	}
+
+	decoded, err := hex.DecodeString(c.Sum)
+	if err != nil || len(decoded) != h.Size() {
+		return nil, fmt.Errorf("%w: expected %d hex encoded bytes for algorithm %s", ErrInvalid, h.Size(), c.Algorithm)
+	}
+
+	return c, nil
+}
+
+func (c *Checksum) String() string {
+	return fmt.Sprintf("%s:%s", c.Algorithm, c.Sum)
+}
+
+// New returns a new hash.Hash of the algorithm. Returns nil if the algorithm is not supported.
+func (c *Checksum) New() hash.Hash {
+	switch c.Algorithm {
+	case AlgorithmSha256:
+		return sha256.New()
+	case AlgorithmSha512:
+		return sha512.New()
+	}
+	return nil
+}
+
+// Command returns the name of the coreutils command which calculates the checksum
+func (c *Checksum) Command() string {
+	return fmt.Sprintf("%ssum", c.Algorithm)
+}
+
+// Verify returns ErrMismatch if sum does not equal the expected checksum
+func (c *Checksum) Verify(sum []byte) error {
+	actual := hex.EncodeToString(sum)
+	if actual != c.Sum {
+		return fmt.Errorf("%w: expected %s but got %s:%s", ErrMismatch, c, c.Algorithm, actual)
+	}
+	return nil
+}
+
+// Reader verifies the checksum of the bytes read from an underlying io.Reader
+type Reader struct {
+	r        io.Reader
+	h        hash.Hash
+	checksum *Checksum
+	err      error
+}
+
+var _ io.Reader = &Reader{}
+
+// NewReader returns a Reader which returns ErrMismatch instead of io.EOF if the bytes read from r do not match c
+func NewReader(r io.Reader, c *Checksum) *Reader {
+	return &Reader{
+		r:        r,
+		h:        c.New(),
+		checksum: c,
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.r.Read(p)
+	_, _ = r.h.Write(p[:n])
+
+	if errors.Is(err, io.EOF) {
+		if verifyErr := r.checksum.Verify(r.h.Sum(nil)); verifyErr != nil {
+			r.err = verifyErr
+			return n, verifyErr
+		}
+	}
+
+	return n, err
+}
+
+// Err returns the verification error after the underlying io.Reader has been read entirely
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close closes the underlying io.Reader if it implements io.Closer
+func (r *Reader) Close() error {
+	if c, ok := r.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
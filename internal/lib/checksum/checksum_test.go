@@ -0,0 +1,112 @@
+package checksum
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+const (
+	// echo -n 'hello world!' | sha256sum
+	testSha256 = "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"
+
+	// echo -n 'hello world!' | sha512sum
+	testSha512 = "db9b1cd3262dee37756a09b9064973589847caa8e53d31a9d142ea2701b1b28abd97838bb9a27068ba305dc8d04a45a1fcf079de54d607666996b3cc54f6b67c"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *Checksum
+		err      error
+	}{
+		{
+			name:     "sha256",
+			input:    "sha256:" + testSha256,
+			expected: &Checksum{Algorithm: AlgorithmSha256, Sum: testSha256},
+		},
+		{
+			name:     "sha512 uppercase",
+			input:    "SHA512:" + strings.ToUpper(testSha512),
+			expected: &Checksum{Algorithm: AlgorithmSha512, Sum: testSha512},
+		},
+		{
+			name:  "missing algorithm",
+			input: testSha256,
+			err:   ErrInvalid,
+		},
+		{
+			name:  "unsupported algorithm",
+			input: "md5:5e1ba0e5a5e3d6e8d8c4c5e1d6e7f8a9",
+			err:   ErrInvalid,
+		},
+		{
+			name:  "invalid length",
+			input: "sha256:" + testSha256[:32],
+			err:   ErrInvalid,
+		},
+		{
+			name:  "invalid hex",
+			input: "sha256:" + strings.Repeat("x", 64),
+			err:   ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.input)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, c)
+			assert.Equal(t, string(tt.expected.Algorithm)+"sum", c.Command())
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum string
+		err      error
+	}{
+		{
+			name:     "sha256 match",
+			checksum: "sha256:" + testSha256,
+		},
+		{
+			name:     "sha512 match",
+			checksum: "sha512:" + testSha512,
+		},
+		{
+			name:     "sha256 mismatch",
+			checksum: "sha256:" + strings.Repeat("0", 64),
+			err:      ErrMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(tt.checksum)
+			require.NoError(t, err)
+
+			r := NewReader(strings.NewReader("hello world!"), c)
+			content, err := io.ReadAll(r)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.ErrorIs(t, r.Err(), tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoError(t, r.Err())
+			assert.Equal(t, "hello world!", string(content))
+		})
+	}
+}
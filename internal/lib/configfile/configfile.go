@@ -0,0 +1,118 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format is the syntax of a structured configuration file
+type Format string
+
+const (
+	FormatIni Format = "ini"
+
+	FormatJson Format = "json"
+
+	FormatYaml Format = "yaml"
+
+	FormatToml Format = "toml"
+
+	FormatDotenv Format = "dotenv"
+)
+
+// Formats returns all supported formats
+func Formats() []Format {
+	return []Format{FormatIni, FormatJson, FormatYaml, FormatToml, FormatDotenv}
+}
+
+// IsStructured returns true if values of the format are typed and represented in JSON encoding.
+// Values of formats which are not structured are plain strings.
+func (f Format) IsStructured() bool {
+	return f == FormatJson || f == FormatYaml || f == FormatToml
+}
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+
+	ErrInvalidKey = errors.New("invalid key")
+
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// Document is a parsed configuration file which allows to modify individual keys while retaining the remaining content.
+//
+// Keys are dot-separated paths. For ini files, the part before the last dot is the section and the last part is the
+// option; a key without a dot refers to an option before the first section. For dotenv files, the key is the name of
+// the variable. For json, yaml, and toml files, each part refers to a nested object.
+//
+// Values of structured formats (see Format.IsStructured) are JSON encoded. Values of other formats are plain strings.
+type Document interface {
+	// Get returns the value of key. Get returns false if the document does not contain the key.
+	Get(key string) (string, bool, error)
+
+	// Set creates or replaces the value of key
+	Set(key string, value string) error
+
+	// Delete removes the key. Delete does not fail if the document does not contain the key.
+	Delete(key string) error
+
+	// Bytes returns the serialized document
+	Bytes() ([]byte, error)
+}
+
+// Parse returns a Document of the provided format from data. Empty data results in an empty Document.
+func Parse(format Format, data []byte) (Document, error) {
+	switch format {
+	case FormatIni:
+		return parseIni(data)
+	case FormatJson:
+		return parseJson(data)
+	case FormatYaml:
+		return parseYaml(data)
+	case FormatToml:
+		return parseToml(data)
+	case FormatDotenv:
+		return parseDotenv(data)
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// NormalizeValue returns the canonical representation of a value of the provided format.
+// Values of structured formats are re-encoded as compact JSON with sorted object keys.
+func NormalizeValue(format Format, value string) (string, error) {
+	if !format.IsStructured() {
+		return value, nil
+	}
+
+	var v interface{}
+	err := json.Unmarshal([]byte(value), &v)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	}
+
+	return encodeJsonValue(v)
+}
+
+func encodeJsonValue(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	err := writeJsonValue(buf, v)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	}
+	return buf.String(), nil
+}
+
+// splitKey splits a dot-separated key into its parts
+func splitKey(key string) ([]string, error) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,464 @@
+package configfile_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/heredoc"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/configfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testOp struct {
+	Set    map[string]string
+	Delete []string
+}
+
+type testCase struct {
+	Desc   string
+	Format configfile.Format
+	Data   string
+	Op     testOp
+	Expect string
+
+	// ExpectGet contains keys and expected values after applying Op. An empty value expects that the key is absent.
+	ExpectGet map[string]string
+}
+
+func runTestCases(t *testing.T, tcs []testCase) {
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			doc, err := configfile.Parse(tc.Format, []byte(tc.Data))
+			require.NoError(t, err)
+
+			for k, v := range tc.Op.Set {
+				require.NoError(t, doc.Set(k, v))
+			}
+
+			for _, k := range tc.Op.Delete {
+				require.NoError(t, doc.Delete(k))
+			}
+
+			actual, err := doc.Bytes()
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, string(actual))
+
+			for k, expect := range tc.ExpectGet {
+				value, found, err := doc.Get(k)
+				require.NoError(t, err)
+				if expect == "" {
+					assert.False(t, found, k)
+					continue
+				}
+				assert.True(t, found, k)
+				assert.Equal(t, expect, value, k)
+			}
+		})
+	}
+}
+
+func TestIni(t *testing.T) {
+	t.Parallel()
+
+	data := heredoc.String(`
+		; global options
+		debug=false
+
+		[server]
+		host=localhost
+		port = 8080
+
+		[client]
+		timeout=30
+	`)
+
+	runTestCases(t, []testCase{
+		{
+			Desc:      "get",
+			Format:    configfile.FormatIni,
+			Data:      data,
+			Expect:    data,
+			ExpectGet: map[string]string{"debug": "false", "server.port": "8080", "client.timeout": "30", "client.port": ""},
+		},
+		{
+			Desc:   "replace retains formatting",
+			Format: configfile.FormatIni,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"server.port": "9090", "debug": "true"}},
+			Expect: "; global options\ndebug=true\n\n[server]\nhost=localhost\nport = 9090\n\n[client]\ntimeout=30\n",
+		},
+		{
+			Desc:      "add option to existing section",
+			Format:    configfile.FormatIni,
+			Data:      data,
+			Op:        testOp{Set: map[string]string{"server.tls": "on"}},
+			Expect:    "; global options\ndebug=false\n\n[server]\nhost=localhost\nport = 8080\ntls=on\n\n[client]\ntimeout=30\n",
+			ExpectGet: map[string]string{"server.tls": "on"},
+		},
+		{
+			Desc:   "add section",
+			Format: configfile.FormatIni,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"log.level": "info"}},
+			Expect: data + "\n[log]\nlevel=info\n",
+		},
+		{
+			Desc:   "delete option",
+			Format: configfile.FormatIni,
+			Data:   data,
+			Op:     testOp{Delete: []string{"server.host", "client.missing", "missing.option"}},
+			Expect: "; global options\ndebug=false\n\n[server]\nport = 8080\n\n[client]\ntimeout=30\n",
+		},
+		{
+			Desc:   "empty file",
+			Format: configfile.FormatIni,
+			Data:   "",
+			Op:     testOp{Set: map[string]string{"server.host": "example.com"}},
+			Expect: "[server]\nhost = example.com\n",
+		},
+	})
+}
+
+func TestDotenv(t *testing.T) {
+	t.Parallel()
+
+	data := heredoc.String(`
+		# database
+		DB_HOST=localhost
+		export DB_PORT=5432
+		DB_NAME="app"
+	`)
+
+	runTestCases(t, []testCase{
+		{
+			Desc:      "get",
+			Format:    configfile.FormatDotenv,
+			Data:      data,
+			Expect:    data,
+			ExpectGet: map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432", "DB_NAME": "app", "DB_USER": ""},
+		},
+		{
+			Desc:   "replace retains export",
+			Format: configfile.FormatDotenv,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"DB_PORT": "5433"}},
+			Expect: "# database\nDB_HOST=localhost\nexport DB_PORT=5433\nDB_NAME=\"app\"\n",
+		},
+		{
+			Desc:      "append and quote",
+			Format:    configfile.FormatDotenv,
+			Data:      data,
+			Op:        testOp{Set: map[string]string{"DB_PASSWORD": "p@ss word"}},
+			Expect:    data + "DB_PASSWORD='p@ss word'\n",
+			ExpectGet: map[string]string{"DB_PASSWORD": "p@ss word"},
+		},
+		{
+			Desc:      "escape special characters",
+			Format:    configfile.FormatDotenv,
+			Data:      "",
+			Op:        testOp{Set: map[string]string{"GREETING": "it's $HOME\n\"quoted\" text"}},
+			Expect:    "GREETING=\"it's \\$HOME\\n\\\"quoted\\\" text\"\n",
+			ExpectGet: map[string]string{"GREETING": "it's $HOME\n\"quoted\" text"},
+		},
+		{
+			Desc:   "delete",
+			Format: configfile.FormatDotenv,
+			Data:   data,
+			Op:     testOp{Delete: []string{"DB_HOST", "DB_USER"}},
+			Expect: "# database\nexport DB_PORT=5432\nDB_NAME=\"app\"\n",
+		},
+	})
+}
+
+func TestJson(t *testing.T) {
+	t.Parallel()
+
+	data := heredoc.String(`
+		{
+		    "log-driver": "journald",
+		    "features": {
+		        "buildkit": true
+		    },
+		    "dns": ["1.1.1.1"]
+		}
+	`)
+
+	runTestCases(t, []testCase{
+		{
+			Desc:      "get",
+			Format:    configfile.FormatJson,
+			Data:      data,
+			Expect:    "{\n    \"log-driver\": \"journald\",\n    \"features\": {\n        \"buildkit\": true\n    },\n    \"dns\": [\n        \"1.1.1.1\"\n    ]\n}\n",
+			ExpectGet: map[string]string{"log-driver": `"journald"`, "features.buildkit": "true", "features": `{"buildkit":true}`, "dns": `["1.1.1.1"]`, "missing.key": ""},
+		},
+		{
+			Desc:      "set retains order",
+			Format:    configfile.FormatJson,
+			Data:      data,
+			Op:        testOp{Set: map[string]string{"log-driver": `"local"`, "log-opts.max-size": `"10m"`, "features.buildkit": "false"}},
+			Expect:    "{\n    \"log-driver\": \"local\",\n    \"features\": {\n        \"buildkit\": false\n    },\n    \"dns\": [\n        \"1.1.1.1\"\n    ],\n    \"log-opts\": {\n        \"max-size\": \"10m\"\n    }\n}\n",
+			ExpectGet: map[string]string{"log-opts": `{"max-size":"10m"}`},
+		},
+		{
+			Desc:   "delete",
+			Format: configfile.FormatJson,
+			Data:   data,
+			Op:     testOp{Delete: []string{"features", "missing", "missing.key"}},
+			Expect: "{\n    \"log-driver\": \"journald\",\n    \"dns\": [\n        \"1.1.1.1\"\n    ]\n}\n",
+		},
+		{
+			Desc:      "empty file",
+			Format:    configfile.FormatJson,
+			Data:      "",
+			Op:        testOp{Set: map[string]string{"a": `{"z": 1, "b": "<tag>"}`}},
+			Expect:    "{\n  \"a\": {\n    \"z\": 1,\n    \"b\": \"<tag>\"\n  }\n}\n",
+			ExpectGet: map[string]string{"a": `{"b":"<tag>","z":1}`},
+		},
+	})
+}
+
+func TestYaml(t *testing.T) {
+	t.Parallel()
+
+	data := heredoc.String(`
+		# server configuration
+		server:
+		  host: localhost # listen address
+		  port: 8080
+		tags:
+		  - a
+		  - b
+	`)
+
+	runTestCases(t, []testCase{
+		{
+			Desc:      "get",
+			Format:    configfile.FormatYaml,
+			Data:      data,
+			Expect:    data,
+			ExpectGet: map[string]string{"server.host": `"localhost"`, "server.port": "8080", "tags": `["a","b"]`, "server.missing": ""},
+		},
+		{
+			Desc:   "set retains comments",
+			Format: configfile.FormatYaml,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"server.host": `"0.0.0.0"`, "server.tls": `{"enabled": true}`}},
+			Expect: heredoc.String(`
+				# server configuration
+				server:
+				  host: 0.0.0.0 # listen address
+				  port: 8080
+				  tls:
+				    enabled: true
+				tags:
+				  - a
+				  - b
+			`),
+			ExpectGet: map[string]string{"server.tls.enabled": "true"},
+		},
+		{
+			Desc:   "string which requires quotes",
+			Format: configfile.FormatYaml,
+			Data:   "",
+			Op:     testOp{Set: map[string]string{"enabled": `"true"`}},
+			Expect: "enabled: \"true\"\n",
+		},
+		{
+			Desc:   "delete",
+			Format: configfile.FormatYaml,
+			Data:   data,
+			Op:     testOp{Delete: []string{"tags", "missing.key"}},
+			Expect: "# server configuration\nserver:\n  host: localhost # listen address\n  port: 8080\n",
+		},
+	})
+}
+
+func TestToml(t *testing.T) {
+	t.Parallel()
+
+	data := heredoc.String(`
+		# example configuration
+		title = "example" # inline comment
+
+		[server]
+		  port = 8080
+		  hosts = [
+		    "a", # first
+		    "b",
+		  ]
+
+		[client]
+		retry = { count = 3, delay = 1.5 }
+	`)
+
+	runTestCases(t, []testCase{
+		{
+			Desc:      "get",
+			Format:    configfile.FormatToml,
+			Data:      data,
+			Expect:    data,
+			ExpectGet: map[string]string{"title": `"example"`, "server.port": "8080", "server.hosts": `["a","b"]`, "client.retry.count": "3", "server.host": "", "missing.key": ""},
+		},
+		{
+			Desc:   "set existing",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"title": `"new \"title\""`, "server.hosts": `["c"]`}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "new \"title\"" # inline comment
+
+				[server]
+				  port = 8080
+				  hosts = ["c"]
+
+				[client]
+				retry = { count = 3, delay = 1.5 }
+			`),
+			ExpectGet: map[string]string{"title": `"new \"title\""`, "server.hosts": `["c"]`},
+		},
+		{
+			Desc:   "set new in table",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"server.ratio": "0.5"}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "example" # inline comment
+
+				[server]
+				  port = 8080
+				  hosts = [
+				    "a", # first
+				    "b",
+				  ]
+				  ratio = 0.5
+
+				[client]
+				retry = { count = 3, delay = 1.5 }
+			`),
+		},
+		{
+			Desc:   "set new in root",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"enabled": "true"}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "example" # inline comment
+				enabled = true
+
+				[server]
+				  port = 8080
+				  hosts = [
+				    "a", # first
+				    "b",
+				  ]
+
+				[client]
+				retry = { count = 3, delay = 1.5 }
+			`),
+		},
+		{
+			Desc:   "set new table",
+			Format: configfile.FormatToml,
+			Data:   "title = \"example\"",
+			Op:     testOp{Set: map[string]string{"log.file.path": `"/var/log/app.log"`}},
+			Expect: "title = \"example\"\n\n[log.file]\npath = \"/var/log/app.log\"\n",
+		},
+		{
+			Desc:   "set in inline table",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Set: map[string]string{"client.retry.count": "5", "client.retry.backoff": `{"max":10}`}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "example" # inline comment
+
+				[server]
+				  port = 8080
+				  hosts = [
+				    "a", # first
+				    "b",
+				  ]
+
+				[client]
+				retry = { backoff = { max = 10 }, count = 5, delay = 1.5 }
+			`),
+			ExpectGet: map[string]string{"client.retry.backoff.max": "10"},
+		},
+		{
+			Desc:   "set empty",
+			Format: configfile.FormatToml,
+			Data:   "",
+			Op:     testOp{Set: map[string]string{"name": `"value"`}},
+			Expect: "name = \"value\"\n",
+		},
+		{
+			Desc:   "delete",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Delete: []string{"server.hosts", "client", "missing.key"}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "example" # inline comment
+
+				[server]
+				  port = 8080
+			`),
+			ExpectGet: map[string]string{"server.port": "8080", "client.retry.count": ""},
+		},
+		{
+			Desc:   "delete table with subtables",
+			Format: configfile.FormatToml,
+			Data:   "[a]\nx = 1\n\n[a.b]\ny = 2\n\n# comment of c\n[c]\nz = 3\n",
+			Op:     testOp{Delete: []string{"a"}},
+			Expect: "\n# comment of c\n[c]\nz = 3\n",
+		},
+		{
+			Desc:   "delete in inline table",
+			Format: configfile.FormatToml,
+			Data:   data,
+			Op:     testOp{Delete: []string{"client.retry.delay"}},
+			Expect: heredoc.String(`
+				# example configuration
+				title = "example" # inline comment
+
+				[server]
+				  port = 8080
+				  hosts = [
+				    "a", # first
+				    "b",
+				  ]
+
+				[client]
+				retry = { count = 3 }
+			`),
+		},
+	})
+}
+
+func TestToml_invalid(t *testing.T) {
+	t.Parallel()
+
+	doc, err := configfile.Parse(configfile.FormatToml, []byte("name = \"value\"\n\n[[items]]\nid = 1\n"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, doc.Set("name.nested", "1"), configfile.ErrInvalidKey)
+	assert.ErrorIs(t, doc.Set("items.id", "2"), configfile.ErrInvalidKey)
+	assert.ErrorIs(t, doc.Set("other", "null"), configfile.ErrInvalidValue)
+}
+
+func TestNormalizeValue(t *testing.T) {
+	t.Parallel()
+
+	actual, err := configfile.NormalizeValue(configfile.FormatJson, `{ "b": 1.0, "a": [true, null] }`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":[true,null],"b":1}`, actual)
+
+	actual, err = configfile.NormalizeValue(configfile.FormatIni, ` raw `)
+	require.NoError(t, err)
+	assert.Equal(t, ` raw `, actual)
+
+	_, err = configfile.NormalizeValue(configfile.FormatYaml, `not json`)
+	assert.ErrorIs(t, err, configfile.ErrInvalidValue)
+}
@@ -0,0 +1,148 @@
+package configfile
+
+import (
+	"fmt"
+	"github.com/joho/godotenv"
+	"regexp"
+	"strings"
+)
+
+// dotenvDocument is a line based representation of a dotenv file which retains comments, blank lines, and lines
+// which are not modified
+type dotenvDocument struct {
+	lines []string
+}
+
+var _ Document = &dotenvDocument{}
+
+var regexpDotenvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+var regexpDotenvPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./:,@%+-]*$`)
+
+const dotenvExportPrefix = "export "
+
+func parseDotenv(data []byte) (*dotenvDocument, error) {
+	// Validate the syntax of the entire file
+	_, err := godotenv.Unmarshal(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &dotenvDocument{
+		lines: splitLines(string(data)),
+	}, nil
+}
+
+// dotenvName returns the name of the variable if the line is an assignment
+func dotenvName(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == '#' {
+		return "", false
+	}
+
+	trimmed = strings.TrimPrefix(trimmed, dotenvExportPrefix)
+
+	idx := strings.IndexAny(trimmed, "=:")
+	if idx < 0 {
+		return "", false
+	}
+
+	return strings.TrimSpace(trimmed[:idx]), true
+}
+
+// variableLine returns the index of the line which assigns the variable. Returns -1 if not found.
+func (doc *dotenvDocument) variableLine(name string) int {
+	index := -1
+	for i, line := range doc.lines {
+		if n, ok := dotenvName(line); ok && n == name {
+			// The last assignment takes precedence
+			index = i
+		}
+	}
+	return index
+}
+
+func dotenvValidateName(name string) error {
+	if !regexpDotenvName.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, name)
+	}
+	return nil
+}
+
+// dotenvQuote returns the value in a representation which is parsed by godotenv to the original value
+func dotenvQuote(value string) string {
+	if regexpDotenvPlainValue.MatchString(value) {
+		return value
+	}
+
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`", "!", `\!`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(value) + `"`
+}
+
+func (doc *dotenvDocument) Get(key string) (string, bool, error) {
+	if err := dotenvValidateName(key); err != nil {
+		return "", false, err
+	}
+
+	index := doc.variableLine(key)
+	if index < 0 {
+		return "", false, nil
+	}
+
+	// Parse the entire document because values may reference other variables
+	env, err := godotenv.Unmarshal(joinLines(doc.lines))
+	if err != nil {
+		return "", false, err
+	}
+
+	value, ok := env[key]
+
+	return value, ok, nil
+}
+
+func (doc *dotenvDocument) Set(key string, value string) error {
+	if err := dotenvValidateName(key); err != nil {
+		return err
+	}
+
+	line := key + "=" + dotenvQuote(value)
+
+	// Verify that the value is parsed to the original value
+	if env, err := godotenv.Unmarshal(line); err != nil || env[key] != value {
+		return fmt.Errorf("%w: value of %q cannot be represented in dotenv syntax", ErrInvalidValue, key)
+	}
+
+	index := doc.variableLine(key)
+	if index < 0 {
+		doc.lines = append(doc.lines, line)
+		return nil
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(doc.lines[index]), dotenvExportPrefix) {
+		line = dotenvExportPrefix + line
+	}
+
+	doc.lines[index] = line
+
+	return nil
+}
+
+func (doc *dotenvDocument) Delete(key string) error {
+	if err := dotenvValidateName(key); err != nil {
+		return err
+	}
+
+	for index := doc.variableLine(key); index >= 0; index = doc.variableLine(key) {
+		doc.lines = append(doc.lines[:index], doc.lines[index+1:]...)
+	}
+
+	return nil
+}
+
+func (doc *dotenvDocument) Bytes() ([]byte, error) {
+	return []byte(joinLines(doc.lines)), nil
+}
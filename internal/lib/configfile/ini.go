@@ -0,0 +1,224 @@
+package configfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iniDocument is a line based representation of an ini file which retains comments, blank lines, and formatting of
+// lines which are not modified
+type iniDocument struct {
+	lines []string
+
+	// separator is used for new options and inferred from the first option in the file
+	separator string
+}
+
+var _ Document = &iniDocument{}
+
+const iniDefaultSeparator = " = "
+
+func parseIni(data []byte) (*iniDocument, error) {
+	doc := &iniDocument{
+		lines:     splitLines(string(data)),
+		separator: iniDefaultSeparator,
+	}
+
+	for _, line := range doc.lines {
+		if _, _, ok := iniOption(line); ok {
+			if !strings.Contains(line, iniDefaultSeparator) {
+				doc.separator = "="
+			}
+			break
+		}
+	}
+
+	return doc, nil
+}
+
+// iniSection returns the name of the section if the line is a section header
+func iniSection(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) < 2 || trimmed[0] != '[' || trimmed[len(trimmed)-1] != ']' {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed[1 : len(trimmed)-1]), true
+}
+
+// iniOption returns the name and the value of the option if the line is an option
+func iniOption(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' || trimmed[0] == '[' {
+		return "", "", false
+	}
+
+	name, value, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(name), strings.TrimSpace(value), true
+}
+
+// iniSplitKey splits a key into section and option
+func iniSplitKey(key string) (string, string, error) {
+	idx := strings.LastIndex(key, ".")
+	if idx < 0 {
+		if key == "" {
+			return "", "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+		return "", key, nil
+	}
+
+	section, option := key[:idx], key[idx+1:]
+	if section == "" || option == "" {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return section, option, nil
+}
+
+// sectionRange returns the index of the section header and the index after the last line of the section.
+// The global section has no header and its header index is -1. found is false if the section does not exist.
+func (doc *iniDocument) sectionRange(section string) (header int, end int, found bool) {
+	header = -1
+	if section != "" {
+		for i, line := range doc.lines {
+			if name, ok := iniSection(line); ok && name == section {
+				header = i
+				break
+			}
+		}
+		if header < 0 {
+			return -1, -1, false
+		}
+	}
+
+	for i := header + 1; i < len(doc.lines); i++ {
+		if _, ok := iniSection(doc.lines[i]); ok {
+			return header, i, true
+		}
+	}
+
+	return header, len(doc.lines), true
+}
+
+// optionLine returns the index of the line of the option within the section. Returns -1 if not found.
+func (doc *iniDocument) optionLine(section string, option string) int {
+	header, end, found := doc.sectionRange(section)
+	if !found {
+		return -1
+	}
+
+	index := -1
+	for i := header + 1; i < end; i++ {
+		if name, _, ok := iniOption(doc.lines[i]); ok && name == option {
+			// The last occurrence takes precedence
+			index = i
+		}
+	}
+
+	return index
+}
+
+func (doc *iniDocument) Get(key string) (string, bool, error) {
+	section, option, err := iniSplitKey(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	index := doc.optionLine(section, option)
+	if index < 0 {
+		return "", false, nil
+	}
+
+	_, value, _ := iniOption(doc.lines[index])
+
+	return value, true, nil
+}
+
+func (doc *iniDocument) Set(key string, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%w: ini values must not contain a newline", ErrInvalidValue)
+	}
+
+	section, option, err := iniSplitKey(key)
+	if err != nil {
+		return err
+	}
+
+	index := doc.optionLine(section, option)
+	if index >= 0 {
+		// Replace the value and retain indentation and separator of the existing line
+		line := doc.lines[index]
+		sepIdx := strings.Index(line, "=")
+		prefix := line[:sepIdx+1]
+		rest := line[sepIdx+1:]
+		prefix += rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+		doc.lines[index] = prefix + value
+		return nil
+	}
+
+	newLine := option + doc.separator + value
+
+	header, end, found := doc.sectionRange(section)
+	if !found {
+		if len(doc.lines) > 0 && strings.TrimSpace(doc.lines[len(doc.lines)-1]) != "" {
+			doc.lines = append(doc.lines, "")
+		}
+		doc.lines = append(doc.lines, fmt.Sprintf("[%s]", section), newLine)
+		return nil
+	}
+
+	// Insert after the last option of the section or after the header if the section has no options
+	insertAt := header + 1
+	for i := header + 1; i < end; i++ {
+		if _, _, ok := iniOption(doc.lines[i]); ok {
+			insertAt = i + 1
+		}
+	}
+
+	doc.lines = insertLine(doc.lines, insertAt, newLine)
+
+	return nil
+}
+
+func (doc *iniDocument) Delete(key string) error {
+	section, option, err := iniSplitKey(key)
+	if err != nil {
+		return err
+	}
+
+	for index := doc.optionLine(section, option); index >= 0; index = doc.optionLine(section, option) {
+		doc.lines = append(doc.lines[:index], doc.lines[index+1:]...)
+	}
+
+	return nil
+}
+
+func (doc *iniDocument) Bytes() ([]byte, error) {
+	return []byte(joinLines(doc.lines)), nil
+}
+
+// splitLines splits text into lines without line terminators
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// joinLines joins lines and terminates each line with a newline
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func insertLine(lines []string, index int, line string) []string {
+	lines = append(lines, "")
+	copy(lines[index+1:], lines[index:])
+	lines[index] = line
+	return lines
+}
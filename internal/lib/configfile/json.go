@@ -0,0 +1,284 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// jsonObject is a JSON object which retains the order of its members
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJsonObject() *jsonObject {
+	return &jsonObject{
+		values: map[string]interface{}{},
+	}
+}
+
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJsonValue(buf, k); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := writeJsonValue(buf, o.values[k]); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// writeJsonValue writes v to w without escaping of HTML characters
+func writeJsonValue(w *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	// Encode terminates the value with a newline
+	w.Truncate(w.Len() - 1)
+	return nil
+}
+
+// decodeJsonValue decodes a single JSON value and retains the order of object members and the representation of numbers
+func decodeJsonValue(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		o := newJsonObject()
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k, ok := kt.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected token %v", kt)
+			}
+			v, err := decodeJsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(k, v)
+		}
+		// Consume closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return o, nil
+	case json.Delim('['):
+		a := []interface{}{}
+		for dec.More() {
+			v, err := decodeJsonValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		// Consume closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+
+	return t, nil
+}
+
+// parseJsonValue parses data which must contain exactly one JSON value
+func parseJsonValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := decodeJsonValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+
+	return v, nil
+}
+
+// jsonDocument is an order preserving representation of a JSON file with an object at the top level
+type jsonDocument struct {
+	root *jsonObject
+
+	indent string
+}
+
+var _ Document = &jsonDocument{}
+
+const jsonDefaultIndent = "  "
+
+var regexpJsonIndent = regexp.MustCompile(`\{\s*?\n([ \t]+)\S`)
+
+func parseJson(data []byte) (*jsonDocument, error) {
+	doc := &jsonDocument{
+		root:   newJsonObject(),
+		indent: jsonDefaultIndent,
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return doc, nil
+	}
+
+	v, err := parseJsonValue(data)
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := v.(*jsonObject)
+	if !ok {
+		return nil, errors.New("top-level value is not an object")
+	}
+	doc.root = root
+
+	// Retain the indentation of the file
+	if m := regexpJsonIndent.FindSubmatch(data); m != nil {
+		doc.indent = string(m[1])
+	}
+
+	return doc, nil
+}
+
+// lookup returns the object which contains the last part of key. If create is true, missing objects are created.
+func (doc *jsonDocument) lookup(key string, create bool) (*jsonObject, string, error) {
+	parts, err := splitKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	o := doc.root
+	for _, part := range parts[:len(parts)-1] {
+		v, ok := o.get(part)
+		if !ok {
+			if !create {
+				return nil, "", nil
+			}
+			v = newJsonObject()
+			o.set(part, v)
+		}
+
+		next, ok := v.(*jsonObject)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: %q: %q is not an object", ErrInvalidKey, key, part)
+		}
+		o = next
+	}
+
+	return o, parts[len(parts)-1], nil
+}
+
+func (doc *jsonDocument) Get(key string) (string, bool, error) {
+	o, name, err := doc.lookup(key, false)
+	if err != nil || o == nil {
+		return "", false, err
+	}
+
+	v, ok := o.get(name)
+	if !ok {
+		return "", false, nil
+	}
+
+	buf := &bytes.Buffer{}
+	err = writeJsonValue(buf, v)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, err := NormalizeValue(FormatJson, buf.String())
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+func (doc *jsonDocument) Set(key string, value string) error {
+	v, err := parseJsonValue([]byte(value))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	}
+
+	o, name, err := doc.lookup(key, true)
+	if err != nil {
+		return err
+	}
+
+	o.set(name, v)
+
+	return nil
+}
+
+func (doc *jsonDocument) Delete(key string) error {
+	o, name, err := doc.lookup(key, false)
+	if err != nil || o == nil {
+		return err
+	}
+
+	o.delete(name)
+
+	return nil
+}
+
+func (doc *jsonDocument) Bytes() ([]byte, error) {
+	compact := &bytes.Buffer{}
+	err := writeJsonValue(compact, doc.root)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	err = json.Indent(out, compact.Bytes(), "", doc.indent)
+	if err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,873 @@
+package configfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tomlDocument is a text based representation of a TOML file which retains comments, the order of keys, and formatting
+// of statements which are not modified. Values are read from the decoded document. Modifications replace, insert, or
+// remove statements in the text which is decoded again after every modification.
+type tomlDocument struct {
+	text string
+
+	// root is the decoded document
+	root map[string]interface{}
+
+	// statements are the tables and key/value pairs of text in the order of text
+	statements []tomlStatement
+}
+
+var _ Document = &tomlDocument{}
+
+// tomlStatement is a table header or a key/value pair. Offsets refer to the text of the document.
+type tomlStatement struct {
+	// header is true for table headers and arrays of tables
+	header bool
+
+	// path is the full path of the table or the key
+	path []string
+
+	// start is the offset of the line and end is the offset after the line terminator of the last line
+	start int
+	end   int
+
+	// valueStart and valueEnd is the range of the value of a key/value pair
+	valueStart int
+	valueEnd   int
+}
+
+func parseToml(data []byte) (*tomlDocument, error) {
+	doc := &tomlDocument{}
+
+	err := doc.parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// parse decodes text and replaces the content of the document
+func (doc *tomlDocument) parse(text string) error {
+	root := map[string]interface{}{}
+
+	_, err := toml.Decode(text, &root)
+	if err != nil {
+		return err
+	}
+
+	statements, err := scanToml(text)
+	if err != nil {
+		return err
+	}
+
+	doc.text, doc.root, doc.statements = text, root, statements
+
+	return nil
+}
+
+// lookup returns the table which contains the last part of key. Returns a nil table if a parent table does not exist.
+func (doc *tomlDocument) lookup(parts []string) (map[string]interface{}, error) {
+	t := doc.root
+	for i, part := range parts[:len(parts)-1] {
+		v, ok := t[part]
+		if !ok {
+			return nil, nil
+		}
+
+		next, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %q: %q is not a table", ErrInvalidKey, strings.Join(parts, "."), strings.Join(parts[:i+1], "."))
+		}
+		t = next
+	}
+
+	return t, nil
+}
+
+func (doc *tomlDocument) Get(key string) (string, bool, error) {
+	parts, err := splitKey(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	t, err := doc.lookup(parts)
+	if err != nil || t == nil {
+		return "", false, err
+	}
+
+	v, ok := t[parts[len(parts)-1]]
+	if !ok {
+		return "", false, nil
+	}
+
+	value, err := encodeJsonValue(v)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// tomlValue converts a value decoded from JSON into a value which is supported by the TOML encoder
+func tomlValue(v interface{}) (interface{}, error) {
+	switch tv := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: null is not supported in toml", ErrInvalidValue)
+	case json.Number:
+		if i, err := tv.Int64(); err == nil {
+			return i, nil
+		}
+		return tv.Float64()
+	case []interface{}:
+		for i := range tv {
+			e, err := tomlValue(tv[i])
+			if err != nil {
+				return nil, err
+			}
+			tv[i] = e
+		}
+		return tv, nil
+	case map[string]interface{}:
+		for k := range tv {
+			e, err := tomlValue(tv[k])
+			if err != nil {
+				return nil, err
+			}
+			tv[k] = e
+		}
+		return tv, nil
+	}
+
+	return v, nil
+}
+
+// statement returns the index of the key/value pair with the path. Returns -1 if not found.
+func (doc *tomlDocument) statement(parts []string) int {
+	for i, s := range doc.statements {
+		if !s.header && tomlPathEqual(s.path, parts) {
+			return i
+		}
+	}
+	return -1
+}
+
+// inlineParent returns the index of the key/value pair which defines a parent of the path as inline table. Returns -1
+// if not found.
+func (doc *tomlDocument) inlineParent(parts []string) int {
+	for i, s := range doc.statements {
+		if !s.header && len(s.path) < len(parts) && tomlPathEqual(s.path, parts[:len(s.path)]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceValue replaces the value of the key/value pair with the inline representation of v
+func (doc *tomlDocument) replaceValue(index int, v interface{}) error {
+	value, err := tomlInline(v)
+	if err != nil {
+		return err
+	}
+
+	s := doc.statements[index]
+
+	return doc.parse(doc.text[:s.valueStart] + value + doc.text[s.valueEnd:])
+}
+
+// updateInlineParent applies modify to the nested table of the inline table which is defined by the statement
+func (doc *tomlDocument) updateInlineParent(index int, parts []string, modify func(t map[string]interface{}, name string)) error {
+	s := doc.statements[index]
+
+	parent, err := doc.lookup(s.path)
+	if err != nil {
+		return err
+	}
+
+	inline := tomlCopy(parent[s.path[len(s.path)-1]]).(map[string]interface{})
+
+	t := inline
+	for _, part := range parts[len(s.path) : len(parts)-1] {
+		next, ok := t[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			t[part] = next
+		}
+		t = next
+	}
+
+	modify(t, parts[len(parts)-1])
+
+	return doc.replaceValue(index, inline)
+}
+
+func (doc *tomlDocument) Set(key string, value string) error {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	}
+
+	v, err = tomlValue(v)
+	if err != nil {
+		return err
+	}
+
+	parts, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	// Parents must be tables
+	_, err = doc.lookup(parts)
+	if err != nil {
+		return err
+	}
+
+	if index := doc.statement(parts); index >= 0 {
+		return doc.replaceValue(index, v)
+	}
+
+	if index := doc.inlineParent(parts); index >= 0 {
+		return doc.updateInlineParent(index, parts, func(t map[string]interface{}, name string) {
+			t[name] = v
+		})
+	}
+
+	inline, err := tomlInline(v)
+	if err != nil {
+		return err
+	}
+
+	// The key is added to the table with the longest header which is a parent of the key
+	table := -1
+	for i, s := range doc.statements {
+		if s.header && len(s.path) < len(parts) && tomlPathEqual(s.path, parts[:len(s.path)]) && (table < 0 || len(s.path) > len(doc.statements[table].path)) {
+			table = i
+		}
+	}
+
+	var tablePath []string
+	if table >= 0 {
+		tablePath = doc.statements[table].path
+	}
+
+	parent, _ := doc.lookup(parts)
+	if parent == nil && len(parts)-len(tablePath) > 1 {
+		// The parent table does not exist and is added as new table at the end of the document
+		text := doc.text
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if text != "" && !strings.HasSuffix(text, "\n\n") {
+			text += "\n"
+		}
+		text += fmt.Sprintf("[%s]\n%s = %s\n", tomlKey(parts[:len(parts)-1]), tomlKey(parts[len(parts)-1:]), inline)
+		return doc.parse(text)
+	}
+
+	// Insert after the last key/value pair of the table or after the header if the table has no key/value pairs
+	insertAt, indent := 0, ""
+	if table >= 0 {
+		insertAt = doc.statements[table].end
+	}
+	for i := table + 1; i < len(doc.statements) && !doc.statements[i].header; i++ {
+		s := doc.statements[i]
+		line := doc.text[s.start:s.end]
+		insertAt, indent = s.end, line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+
+	prefix := ""
+	if insertAt > 0 && doc.text[insertAt-1] != '\n' {
+		prefix = "\n"
+	}
+
+	line := fmt.Sprintf("%s%s%s = %s\n", prefix, indent, tomlKey(parts[len(tablePath):]), inline)
+
+	return doc.parse(doc.text[:insertAt] + line + doc.text[insertAt:])
+}
+
+func (doc *tomlDocument) Delete(key string) error {
+	parts, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+
+	parent, err := doc.lookup(parts)
+	if err != nil || parent == nil {
+		return err
+	}
+	if _, ok := parent[parts[len(parts)-1]]; !ok {
+		return nil
+	}
+
+	if index := doc.inlineParent(parts); index >= 0 {
+		return doc.updateInlineParent(index, parts, func(t map[string]interface{}, name string) {
+			delete(t, name)
+		})
+	}
+
+	// Remove the key/value pairs and the tables including their content of the key and of nested keys
+	type span struct{ start, end int }
+	var spans []span
+
+	for i, s := range doc.statements {
+		if len(s.path) < len(parts) || !tomlPathEqual(s.path[:len(parts)], parts) {
+			continue
+		}
+
+		if !s.header {
+			spans = append(spans, span{s.start, s.end})
+			continue
+		}
+
+		// The table ends before the next header which is not removed. Blank lines and comments at the end of the table are
+		// retained unless the table is the last table of the document.
+		start, end := s.start, len(doc.text)
+		for j := i + 1; j < len(doc.statements); j++ {
+			if next := doc.statements[j]; next.header && (len(next.path) < len(parts) || !tomlPathEqual(next.path[:len(parts)], parts)) {
+				end = doc.statements[j-1].end
+				break
+			}
+		}
+		if end == len(doc.text) {
+			for start > 0 && strings.TrimSpace(doc.text[tomlLineStart(doc.text, start-1):start]) == "" {
+				start = tomlLineStart(doc.text, start-1)
+			}
+		}
+
+		spans = append(spans, span{start, end})
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var b strings.Builder
+	offset := 0
+	for _, sp := range spans {
+		if sp.start < offset {
+			sp.start = offset
+		}
+		if sp.end <= offset {
+			continue
+		}
+		b.WriteString(doc.text[offset:sp.start])
+		offset = sp.end
+	}
+	b.WriteString(doc.text[offset:])
+
+	return doc.parse(b.String())
+}
+
+func (doc *tomlDocument) Bytes() ([]byte, error) {
+	return []byte(doc.text), nil
+}
+
+func tomlPathEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlLineStart returns the offset of the line which contains the offset
+func tomlLineStart(text string, offset int) int {
+	return strings.LastIndexByte(text[:offset], '\n') + 1
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlKey returns the dotted key of the parts. Parts are quoted if necessary.
+func tomlKey(parts []string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		if tomlBareKey.MatchString(part) {
+			quoted[i] = part
+		} else {
+			quoted[i] = tomlString(part)
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// tomlString returns the basic string representation of s
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				_, _ = fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// tomlInline returns the inline representation of a value. Tables are represented as inline tables with sorted keys.
+func tomlInline(v interface{}) (string, error) {
+	switch tv := v.(type) {
+	case string:
+		return tomlString(tv), nil
+	case bool:
+		return strconv.FormatBool(tv), nil
+	case int64:
+		return strconv.FormatInt(tv, 10), nil
+	case float64:
+		switch {
+		case math.IsInf(tv, 1):
+			return "inf", nil
+		case math.IsInf(tv, -1):
+			return "-inf", nil
+		case math.IsNaN(tv):
+			return "nan", nil
+		}
+		s := strconv.FormatFloat(tv, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			s += ".0"
+		}
+		return s, nil
+	case time.Time:
+		switch tv.Location().String() {
+		case "datetime-local":
+			return tv.Format("2006-01-02T15:04:05.999999999"), nil
+		case "date-local":
+			return tv.Format("2006-01-02"), nil
+		case "time-local":
+			return tv.Format("15:04:05.999999999"), nil
+		}
+		return tv.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		elems := make([]string, len(tv))
+		for i, e := range tv {
+			s, err := tomlInline(e)
+			if err != nil {
+				return "", err
+			}
+			elems[i] = s
+		}
+		return "[" + strings.Join(elems, ", ") + "]", nil
+	case []map[string]interface{}:
+		elems := make([]interface{}, len(tv))
+		for i, e := range tv {
+			elems[i] = e
+		}
+		return tomlInline(elems)
+	case map[string]interface{}:
+		if len(tv) == 0 {
+			return "{}", nil
+		}
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		elems := make([]string, len(keys))
+		for i, k := range keys {
+			s, err := tomlInline(tv[k])
+			if err != nil {
+				return "", err
+			}
+			elems[i] = tomlKey([]string{k}) + " = " + s
+		}
+		return "{ " + strings.Join(elems, ", ") + " }", nil
+	}
+
+	return "", fmt.Errorf("%w: unsupported toml value %T", ErrInvalidValue, v)
+}
+
+// tomlCopy returns a deep copy of tables and arrays
+func tomlCopy(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(tv))
+		for k, e := range tv {
+			c[k] = tomlCopy(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(tv))
+		for i, e := range tv {
+			c[i] = tomlCopy(e)
+		}
+		return c
+	}
+	return v
+}
+
+// tomlScanner locates the statements of a TOML document. The document is expected to be valid.
+type tomlScanner struct {
+	text string
+	pos  int
+}
+
+// scanToml returns the table headers and key/value pairs of a valid TOML document
+func scanToml(text string) ([]tomlStatement, error) {
+	sc := &tomlScanner{text: text}
+
+	var statements []tomlStatement
+	var table []string
+
+	for sc.pos < len(text) {
+		start := sc.pos
+		sc.skipSpace()
+
+		switch {
+		case sc.eof() || sc.peek() == '\n' || sc.peek() == '\r' || sc.peek() == '#':
+			sc.skipLine()
+			continue
+		case sc.peek() == '[':
+			closing := "]"
+			sc.pos++
+			if !sc.eof() && sc.peek() == '[' {
+				closing = "]]"
+				sc.pos++
+			}
+			key, err := sc.key()
+			if err != nil {
+				return nil, err
+			}
+			sc.skipSpace()
+			if !strings.HasPrefix(text[sc.pos:], closing) {
+				return nil, sc.errorf("expected %q", closing)
+			}
+			sc.pos += len(closing)
+			sc.skipLine()
+			table = key
+			statements = append(statements, tomlStatement{header: true, path: key, start: start, end: sc.pos})
+		default:
+			key, err := sc.key()
+			if err != nil {
+				return nil, err
+			}
+			sc.skipSpace()
+			if sc.eof() || sc.peek() != '=' {
+				return nil, sc.errorf("expected \"=\"")
+			}
+			sc.pos++
+			sc.skipSpace()
+			valueStart := sc.pos
+			err = sc.value(true)
+			if err != nil {
+				return nil, err
+			}
+			valueEnd := sc.pos
+			sc.skipLine()
+			statements = append(statements, tomlStatement{
+				path:       append(append([]string{}, table...), key...),
+				start:      start,
+				end:        sc.pos,
+				valueStart: valueStart,
+				valueEnd:   valueEnd,
+			})
+		}
+	}
+
+	return statements, nil
+}
+
+func (sc *tomlScanner) eof() bool {
+	return sc.pos >= len(sc.text)
+}
+
+func (sc *tomlScanner) peek() byte {
+	return sc.text[sc.pos]
+}
+
+func (sc *tomlScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: offset %d: %s", sc.pos, fmt.Sprintf(format, args...))
+}
+
+func (sc *tomlScanner) skipSpace() {
+	for !sc.eof() && (sc.peek() == ' ' || sc.peek() == '\t') {
+		sc.pos++
+	}
+}
+
+// skipLine advances after the next line terminator
+func (sc *tomlScanner) skipLine() {
+	if i := strings.IndexByte(sc.text[sc.pos:], '\n'); i >= 0 {
+		sc.pos += i + 1
+	} else {
+		sc.pos = len(sc.text)
+	}
+}
+
+// skipSpaceAndComments skips whitespace, line terminators, and comments within arrays
+func (sc *tomlScanner) skipSpaceAndComments() {
+	for !sc.eof() {
+		switch sc.peek() {
+		case ' ', '\t', '\r', '\n':
+			sc.pos++
+		case '#':
+			sc.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// key returns the parts of a dotted key
+func (sc *tomlScanner) key() ([]string, error) {
+	var parts []string
+	for {
+		sc.skipSpace()
+		if sc.eof() {
+			return nil, sc.errorf("expected key")
+		}
+
+		switch sc.peek() {
+		case '"':
+			start := sc.pos
+			err := sc.basicString()
+			if err != nil {
+				return nil, err
+			}
+			part, err := tomlUnquote(sc.text[start:sc.pos])
+			if err != nil {
+				return nil, sc.errorf("invalid key: %s", err.Error())
+			}
+			parts = append(parts, part)
+		case '\'':
+			end := strings.IndexByte(sc.text[sc.pos+1:], '\'')
+			if end < 0 {
+				return nil, sc.errorf("unterminated key")
+			}
+			parts = append(parts, sc.text[sc.pos+1:sc.pos+1+end])
+			sc.pos += end + 2
+		default:
+			start := sc.pos
+			for !sc.eof() && (isTomlBareKeyChar(sc.peek())) {
+				sc.pos++
+			}
+			if start == sc.pos {
+				return nil, sc.errorf("expected key")
+			}
+			parts = append(parts, sc.text[start:sc.pos])
+		}
+
+		sc.skipSpace()
+		if sc.eof() || sc.peek() != '.' {
+			return parts, nil
+		}
+		sc.pos++
+	}
+}
+
+func isTomlBareKeyChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// basicString advances after a single line basic string
+func (sc *tomlScanner) basicString() error {
+	for i := sc.pos + 1; i < len(sc.text); i++ {
+		switch sc.text[i] {
+		case '\\':
+			i++
+		case '"':
+			sc.pos = i + 1
+			return nil
+		case '\n':
+			return sc.errorf("unterminated string")
+		}
+	}
+	return sc.errorf("unterminated string")
+}
+
+// multilineString advances after a multi-line string delimited by delim. Escapes are only processed in basic strings.
+func (sc *tomlScanner) multilineString(delim string) error {
+	for i := sc.pos + 3; i < len(sc.text); i++ {
+		if delim == `"""` && sc.text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(sc.text[i:], delim) {
+			// Up to two quotes are allowed before the closing delimiter
+			end := i + 3
+			for n := 0; n < 2 && end < len(sc.text) && sc.text[end] == delim[0]; n++ {
+				end++
+			}
+			sc.pos = end
+			return nil
+		}
+	}
+	return sc.errorf("unterminated string")
+}
+
+// value advances after a value. Top-level scalars end before a comment or the end of the line.
+func (sc *tomlScanner) value(topLevel bool) error {
+	if sc.eof() {
+		return sc.errorf("expected value")
+	}
+
+	switch {
+	case strings.HasPrefix(sc.text[sc.pos:], `"""`):
+		return sc.multilineString(`"""`)
+	case strings.HasPrefix(sc.text[sc.pos:], `'''`):
+		return sc.multilineString(`'''`)
+	case sc.peek() == '"':
+		return sc.basicString()
+	case sc.peek() == '\'':
+		end := strings.IndexByte(sc.text[sc.pos+1:], '\'')
+		if end < 0 {
+			return sc.errorf("unterminated string")
+		}
+		sc.pos += end + 2
+		return nil
+	case sc.peek() == '[':
+		sc.pos++
+		for {
+			sc.skipSpaceAndComments()
+			if sc.eof() {
+				return sc.errorf("unterminated array")
+			}
+			if sc.peek() == ']' {
+				sc.pos++
+				return nil
+			}
+			err := sc.value(false)
+			if err != nil {
+				return err
+			}
+			sc.skipSpaceAndComments()
+			if !sc.eof() && sc.peek() == ',' {
+				sc.pos++
+			}
+		}
+	case sc.peek() == '{':
+		sc.pos++
+		for {
+			sc.skipSpace()
+			if sc.eof() {
+				return sc.errorf("unterminated inline table")
+			}
+			if sc.peek() == '}' {
+				sc.pos++
+				return nil
+			}
+			_, err := sc.key()
+			if err != nil {
+				return err
+			}
+			sc.skipSpace()
+			if sc.eof() || sc.peek() != '=' {
+				return sc.errorf("expected \"=\"")
+			}
+			sc.pos++
+			sc.skipSpace()
+			err = sc.value(false)
+			if err != nil {
+				return err
+			}
+			sc.skipSpace()
+			if !sc.eof() && sc.peek() == ',' {
+				sc.pos++
+			}
+		}
+	}
+
+	// Scalars such as numbers, booleans, and dates. Dates may contain a space between date and time.
+	start := sc.pos
+	for !sc.eof() {
+		c := sc.peek()
+		if c == '#' || c == '\n' || c == '\r' || (!topLevel && (c == ',' || c == ']' || c == '}' || c == ' ' || c == '\t')) {
+			break
+		}
+		sc.pos++
+	}
+	for sc.pos > start && (sc.text[sc.pos-1] == ' ' || sc.text[sc.pos-1] == '\t') {
+		sc.pos--
+	}
+	if sc.pos == start {
+		return sc.errorf("expected value")
+	}
+
+	return nil
+}
+
+// tomlUnquote returns the content of a basic string
+func tomlUnquote(s string) (string, error) {
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("invalid escape")
+		}
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case '"':
+			b.WriteByte('"')
+		case '\\':
+			b.WriteByte('\\')
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", fmt.Errorf("invalid escape")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf("invalid escape")
+			}
+			b.WriteRune(rune(r))
+			i += n
+		default:
+			return "", fmt.Errorf("invalid escape")
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,203 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument is a node based representation of a YAML file with a mapping at the top level which retains the
+// order of keys and comments
+type yamlDocument struct {
+	// document is nil if the file is empty
+	document *yaml.Node
+
+	root *yaml.Node
+}
+
+var _ Document = &yamlDocument{}
+
+const yamlIndent = 2
+
+func parseYaml(data []byte) (*yamlDocument, error) {
+	doc := &yamlDocument{
+		root: &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+	}
+
+	var node yaml.Node
+	err := yaml.Unmarshal(data, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Kind == 0 || len(node.Content) == 0 {
+		// Empty document
+		return doc, nil
+	}
+
+	root := node.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("top-level value is not a mapping")
+	}
+	doc.document = &node
+	doc.root = root
+
+	return doc, nil
+}
+
+// yamlMappingValue returns the index of the value node of key in the mapping node m. Returns -1 if not found.
+func yamlMappingValue(m *yaml.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// lookup returns the mapping node which contains the last part of key. If create is true, missing mappings are created.
+func (doc *yamlDocument) lookup(key string, create bool) (*yaml.Node, string, error) {
+	parts, err := splitKey(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m := doc.root
+	for _, part := range parts[:len(parts)-1] {
+		idx := yamlMappingValue(m, part)
+		if idx < 0 {
+			if !create {
+				return nil, "", nil
+			}
+			m.Content = append(m.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part},
+				&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+			)
+			idx = len(m.Content) - 1
+		}
+
+		next := m.Content[idx]
+		if next.Kind == yaml.AliasNode {
+			next = next.Alias
+		}
+		if next.Kind != yaml.MappingNode {
+			return nil, "", fmt.Errorf("%w: %q: %q is not a mapping", ErrInvalidKey, key, part)
+		}
+		m = next
+	}
+
+	return m, parts[len(parts)-1], nil
+}
+
+func (doc *yamlDocument) Get(key string) (string, bool, error) {
+	m, name, err := doc.lookup(key, false)
+	if err != nil || m == nil {
+		return "", false, err
+	}
+
+	idx := yamlMappingValue(m, name)
+	if idx < 0 {
+		return "", false, nil
+	}
+
+	var v interface{}
+	err = m.Content[idx].Decode(&v)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, err := encodeJsonValue(v)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// yamlResetStyle removes the style of node and all descendants which results in block style
+func yamlResetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, c := range node.Content {
+		yamlResetStyle(c)
+	}
+}
+
+func (doc *yamlDocument) Set(key string, value string) error {
+	if !json.Valid([]byte(value)) {
+		return fmt.Errorf("%w: %q is not valid json", ErrInvalidValue, value)
+	}
+
+	// JSON is a subset of YAML
+	var valueDoc yaml.Node
+	err := yaml.Unmarshal([]byte(value), &valueDoc)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	}
+	valueNode := valueDoc.Content[0]
+	yamlResetStyle(valueNode)
+
+	m, name, err := doc.lookup(key, true)
+	if err != nil {
+		return err
+	}
+
+	idx := yamlMappingValue(m, name)
+	if idx < 0 {
+		m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, valueNode)
+		return nil
+	}
+
+	// Retain comments of the replaced node
+	old := m.Content[idx]
+	valueNode.HeadComment = old.HeadComment
+	valueNode.LineComment = old.LineComment
+	valueNode.FootComment = old.FootComment
+	m.Content[idx] = valueNode
+
+	return nil
+}
+
+func (doc *yamlDocument) Delete(key string) error {
+	m, name, err := doc.lookup(key, false)
+	if err != nil || m == nil {
+		return err
+	}
+
+	idx := yamlMappingValue(m, name)
+	if idx < 0 {
+		return nil
+	}
+
+	m.Content = append(m.Content[:idx-1], m.Content[idx+1:]...)
+
+	return nil
+}
+
+func (doc *yamlDocument) Bytes() ([]byte, error) {
+	if len(doc.root.Content) == 0 {
+		return []byte{}, nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(yamlIndent)
+
+	node := doc.document
+	if node == nil {
+		node = doc.root
+	}
+
+	err := enc.Encode(node)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
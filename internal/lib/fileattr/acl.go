@@ -0,0 +1,140 @@
+package fileattr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// AclTag is the tag type of an entry of a POSIX access control list
+type AclTag string
+
+const (
+	AclTagUser AclTag = "user"
+
+	AclTagGroup AclTag = "group"
+
+	AclTagMask AclTag = "mask"
+
+	AclTagOther AclTag = "other"
+)
+
+const aclDefaultPrefix = "default"
+
+// AclEntry is an entry of a POSIX access control list
+type AclEntry struct {
+	// Default is true if the entry is part of the default access control list of a folder
+	Default bool
+
+	Tag AclTag
+
+	// Qualifier is the name or id of the user or group. Empty for the owning user, owning group, mask, and other.
+	Qualifier string
+
+	// Perms are the permissions in the canonical form like `r-x`
+	Perms string
+}
+
+// IsNamed returns true if the entry applies to a specific user or group. Entries which are not named are derived from
+// the mode of the path.
+func (e AclEntry) IsNamed() bool {
+	return (e.Tag == AclTagUser || e.Tag == AclTagGroup) && e.Qualifier != ""
+}
+
+// String returns the entry in the canonical long text form like `default:user:alice:r-x`
+func (e AclEntry) String() string {
+	s := fmt.Sprintf("%s:%s:%s", e.Tag, e.Qualifier, e.Perms)
+	if e.Default {
+		s = aclDefaultPrefix + ":" + s
+	}
+	return s
+}
+
+// ParseAclEntry parses an entry in the long or short text form like `default:user:alice:r-x` or `d:u:alice:rx`
+func ParseAclEntry(s string) (AclEntry, error) {
+	var e AclEntry
+
+	parts := strings.Split(strings.TrimSpace(s), ":")
+
+	if len(parts) == 4 {
+		if parts[0] != aclDefaultPrefix && parts[0] != "d" {
+			return e, newParseError(fmt.Sprintf("invalid acl entry %q: unexpected prefix %q", s, parts[0]))
+		}
+		e.Default = true
+		parts = parts[1:]
+	}
+
+	if len(parts) != 3 {
+		return e, newParseError(fmt.Sprintf("invalid acl entry %q: expected format [default:]tag:qualifier:perms", s))
+	}
+
+	switch parts[0] {
+	case "user", "u":
+		e.Tag = AclTagUser
+	case "group", "g":
+		e.Tag = AclTagGroup
+	case "mask", "m":
+		e.Tag = AclTagMask
+	case "other", "o":
+		e.Tag = AclTagOther
+	default:
+		return e, newParseError(fmt.Sprintf("invalid acl entry %q: unexpected tag %q", s, parts[0]))
+	}
+
+	e.Qualifier = parts[1]
+	if e.Qualifier != "" && !e.IsNamed() {
+		return e, newParseError(fmt.Sprintf("invalid acl entry %q: unexpected qualifier for tag %s", s, e.Tag))
+	}
+
+	perms, err := parseAclPerms(parts[2])
+	if err != nil {
+		return e, newParseError(fmt.Sprintf("invalid acl entry %q: %s", s, err))
+	}
+	e.Perms = perms
+
+	return e, nil
+}
+
+// parseAclPerms returns the permissions like `rx` or `r-x` in the canonical form `r-x`
+func parseAclPerms(s string) (string, error) {
+	perms := []byte("---")
+	for _, c := range s {
+		switch c {
+		case 'r':
+			perms[0] = 'r'
+		case 'w':
+			perms[1] = 'w'
+		case 'x':
+			perms[2] = 'x'
+		case '-':
+		default:
+			return "", fmt.Errorf("unexpected permission %q", c)
+		}
+	}
+	return string(perms), nil
+}
+
+// ParseGetfacl parses the output of `getfacl --omit-header --absolute-names --no-effective`. Comments including
+// effective permissions are ignored.
+func ParseGetfacl(data []byte) ([]AclEntry, error) {
+	var entries []AclEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		e, err := ParseAclEntry(line)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package fileattr_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileattr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseAclEntry(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		Desc      string
+		Entry     string
+		Expect    fileattr.AclEntry
+		ExpectStr string
+		ExpectErr bool
+	}{
+		{
+			Desc:      "named user",
+			Entry:     "user:alice:r-x",
+			Expect:    fileattr.AclEntry{Tag: fileattr.AclTagUser, Qualifier: "alice", Perms: "r-x"},
+			ExpectStr: "user:alice:r-x",
+		},
+		{
+			Desc:      "default named group in short form",
+			Entry:     "d:g:ops:xr",
+			Expect:    fileattr.AclEntry{Default: true, Tag: fileattr.AclTagGroup, Qualifier: "ops", Perms: "r-x"},
+			ExpectStr: "default:group:ops:r-x",
+		},
+		{
+			Desc:      "mask",
+			Entry:     "mask::rw-",
+			Expect:    fileattr.AclEntry{Tag: fileattr.AclTagMask, Perms: "rw-"},
+			ExpectStr: "mask::rw-",
+		},
+		{
+			Desc:      "unexpected qualifier",
+			Entry:     "other:alice:r--",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "unexpected tag",
+			Entry:     "owner:alice:r--",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "unexpected permission",
+			Entry:     "user:alice:rwt",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "missing perms",
+			Entry:     "user:alice",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			actual, err := fileattr.ParseAclEntry(tc.Entry)
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, actual)
+			assert.Equal(t, tc.ExpectStr, actual.String())
+		})
+	}
+}
+
+func TestParseGetfacl(t *testing.T) {
+	t.Parallel()
+
+	output := "user::rwx\nuser:alice:r-x\t#effective:r--\ngroup::r-x\nmask::r--\nother::---\ndefault:user::rwx\ndefault:group:ops:rwx\n\n"
+
+	actual, err := fileattr.ParseGetfacl([]byte(output))
+	require.NoError(t, err)
+
+	var named []string
+	for _, e := range actual {
+		if e.IsNamed() {
+			named = append(named, e.String())
+		}
+	}
+
+	assert.Len(t, actual, 7)
+	assert.Equal(t, []string{"user:alice:r-x", "default:group:ops:rwx"}, named)
+}
@@ -0,0 +1,5 @@
+// Package fileattr parses the output of getfacl, getfattr and lsattr which describe the access control lists, extended
+// attributes and file attributes of a path.
+package fileattr
+
+const packageName = "fileattr"
@@ -0,0 +1,19 @@
+package fileattr
+
+type ParseError struct {
+	msg string
+}
+
+var _ error = &ParseError{}
+
+func newParseError(msg string) *ParseError {
+	return &ParseError{msg: msg}
+}
+
+func (p *ParseError) Error() string {
+	s := packageName + ".ParseError"
+	if p.msg != "" {
+		s = s + ": " + p.msg
+	}
+	return s
+}
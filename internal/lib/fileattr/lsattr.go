@@ -0,0 +1,70 @@
+package fileattr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Attribute is a file attribute which is managed by chattr
+type Attribute string
+
+const (
+	AttributeAppendOnly Attribute = "append_only"
+
+	AttributeImmutable Attribute = "immutable"
+
+	AttributeNoAtime Attribute = "no_atime"
+
+	AttributeNoDump Attribute = "no_dump"
+
+	AttributeSynchronous Attribute = "synchronous"
+)
+
+var attributeFlags = map[Attribute]byte{
+	AttributeAppendOnly:  'a',
+	AttributeImmutable:   'i',
+	AttributeNoAtime:     'A',
+	AttributeNoDump:      'd',
+	AttributeSynchronous: 'S',
+}
+
+// Attributes returns all supported attributes
+func Attributes() []Attribute {
+	var attrs []Attribute
+	for a := range attributeFlags {
+		attrs = append(attrs, a)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i] < attrs[j] })
+	return attrs
+}
+
+// Flag returns the flag of the attribute which is used by chattr and lsattr
+func (a Attribute) Flag() byte {
+	return attributeFlags[a]
+}
+
+// ParseLsattr parses the output of `lsattr -d` and returns the supported attributes which are set. Flags which are not
+// supported are ignored.
+func ParseLsattr(data []byte) ([]Attribute, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return nil, newParseError(fmt.Sprintf("unexpected output %q", string(data)))
+	}
+
+	flags := fields[0]
+	for _, c := range flags {
+		if c != '-' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return nil, newParseError(fmt.Sprintf("unexpected flags %q", flags))
+		}
+	}
+
+	var attrs []Attribute
+	for _, a := range Attributes() {
+		if strings.IndexByte(flags, a.Flag()) != -1 {
+			attrs = append(attrs, a)
+		}
+	}
+
+	return attrs, nil
+}
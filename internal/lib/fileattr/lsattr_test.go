@@ -0,0 +1,57 @@
+package fileattr_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileattr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseLsattr(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		Desc      string
+		Output    string
+		Expect    []fileattr.Attribute
+		ExpectErr bool
+	}{
+		{
+			Desc:   "no attributes",
+			Output: "--------------e------- /root/file.txt\n",
+			Expect: nil,
+		},
+		{
+			Desc:   "immutable and append only",
+			Output: "-----ai-------e------- /root/file with spaces.txt\n",
+			Expect: []fileattr.Attribute{fileattr.AttributeAppendOnly, fileattr.AttributeImmutable},
+		},
+		{
+			Desc:   "no atime and no dump",
+			Output: "------dA------e------- /root/folder\n",
+			Expect: []fileattr.Attribute{fileattr.AttributeNoAtime, fileattr.AttributeNoDump},
+		},
+		{
+			Desc:      "unexpected output",
+			Output:    "lsattr: Operation not supported",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "empty output",
+			Output:    "",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			actual, err := fileattr.ParseLsattr([]byte(tc.Output))
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, actual)
+		})
+	}
+}
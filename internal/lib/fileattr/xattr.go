@@ -0,0 +1,88 @@
+package fileattr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// xattrNamespaces are the namespaces of extended attributes which can be managed
+var xattrNamespaces = []string{"user.", "security."}
+
+// xattrsExcluded are extended attributes which are maintained by the kernel or by dedicated tools like chcon or setcap
+var xattrsExcluded = map[string]bool{
+	"security.capability": true,
+	"security.evm":        true,
+	"security.ima":        true,
+	"security.selinux":    true,
+}
+
+// IsManagedXattr returns true if the extended attribute is in the user or security namespace and is not maintained by
+// the kernel or by dedicated tools
+func IsManagedXattr(name string) bool {
+	if xattrsExcluded[name] {
+		return false
+	}
+	for _, ns := range xattrNamespaces {
+		if strings.HasPrefix(name, ns) && len(name) > len(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseGetfattr parses the output of `getfattr --dump --absolute-names --encoding=base64` and returns the values of
+// the extended attributes by name
+func ParseGetfattr(data []byte) (map[string][]byte, error) {
+	xattrs := map[string][]byte{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, encoded, ok := strings.Cut(line, "=")
+		if !ok {
+			// Attribute without value
+			xattrs[name] = []byte{}
+			continue
+		}
+
+		value, err := decodeXattrValue(encoded)
+		if err != nil {
+			return nil, newParseError(fmt.Sprintf("failed to parse value of extended attribute %q: %s", name, err))
+		}
+
+		xattrs[name] = value
+	}
+
+	return xattrs, nil
+}
+
+// decodeXattrValue decodes a value which is encoded by getfattr as base64 (`0s`), hex (`0x`), or quoted text
+func decodeXattrValue(s string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(s, "0s"):
+		return base64.StdEncoding.DecodeString(s[2:])
+	case strings.HasPrefix(s, "0x"):
+		return hex.DecodeString(s[2:])
+	case strings.HasPrefix(s, `"`):
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(unquoted), nil
+	}
+	return nil, fmt.Errorf("unexpected encoding")
+}
+
+// EncodeXattrValue encodes a value in the base64 form which is accepted by `setfattr --value`
+func EncodeXattrValue(value []byte) string {
+	return "0s" + base64.StdEncoding.EncodeToString(value)
+}
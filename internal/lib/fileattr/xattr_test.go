@@ -0,0 +1,81 @@
+package fileattr_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileattr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseGetfattr(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		Desc      string
+		Output    string
+		Expect    map[string][]byte
+		ExpectErr bool
+	}{
+		{
+			Desc:   "base64",
+			Output: "# file: /root/file.txt\nuser.checksum=0saGVsbG8gd29ybGQh\nsecurity.selinux=0sc3lzdGVtX3U6b2JqZWN0X3I6ZXRjX3Q6czAA\n\n",
+			Expect: map[string][]byte{
+				"user.checksum":    []byte("hello world!"),
+				"security.selinux": []byte("system_u:object_r:etc_t:s0\x00"),
+			},
+		},
+		{
+			Desc:   "text and hex",
+			Output: "# file: /root/file.txt\nuser.text=\"hello\"\nuser.hex=0x776f726c64\nuser.empty\n",
+			Expect: map[string][]byte{
+				"user.text":  []byte("hello"),
+				"user.hex":   []byte("world"),
+				"user.empty": {},
+			},
+		},
+		{
+			Desc:   "no attributes",
+			Output: "",
+			Expect: map[string][]byte{},
+		},
+		{
+			Desc:      "invalid encoding",
+			Output:    "user.invalid=hello\n",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			actual, err := fileattr.ParseGetfattr([]byte(tc.Output))
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, actual)
+		})
+	}
+}
+
+func TestEncodeXattrValue(t *testing.T) {
+	t.Parallel()
+
+	encoded := fileattr.EncodeXattrValue([]byte("hello world!"))
+	assert.Equal(t, "0saGVsbG8gd29ybGQh", encoded)
+
+	decoded, err := fileattr.ParseGetfattr([]byte("user.test=" + encoded))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello world!"), decoded["user.test"])
+}
+
+func TestIsManagedXattr(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, fileattr.IsManagedXattr("user.checksum"))
+	assert.True(t, fileattr.IsManagedXattr("security.custom"))
+	assert.False(t, fileattr.IsManagedXattr("security.selinux"))
+	assert.False(t, fileattr.IsManagedXattr("security.capability"))
+	assert.False(t, fileattr.IsManagedXattr("trusted.overlay.opaque"))
+	assert.False(t, fileattr.IsManagedXattr("user."))
+}
@@ -0,0 +1,213 @@
+package fileset
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/hashutil"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher selects relative paths by include and exclude glob patterns.
+//
+// Patterns are matched against the entire slash-separated path relative to the root similar to the terraform function
+// `fileset`. `*` matches any sequence of characters except `/`, `?` matches a single character except `/`, and `**`
+// matches any sequence of characters including `/`. A path is selected if it matches at least one include pattern and
+// no exclude pattern.
+type Matcher struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// DefaultInclude selects all paths
+const DefaultInclude = "**"
+
+// NewMatcher returns a Matcher for the provided patterns. If include is empty, all paths are included.
+func NewMatcher(include []string, exclude []string) (*Matcher, error) {
+	if len(include) == 0 {
+		include = []string{DefaultInclude}
+	}
+
+	m := &Matcher{}
+
+	for _, p := range include {
+		re, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		m.include = append(m.include, re)
+	}
+
+	for _, p := range exclude {
+		re, err := Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		m.exclude = append(m.exclude, re)
+	}
+
+	return m, nil
+}
+
+// Match returns true if the relative path is selected
+func (m *Matcher) Match(path string) bool {
+	included := false
+	for _, re := range m.include {
+		if re.MatchString(path) {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		return false
+	}
+
+	for _, re := range m.exclude {
+		if re.MatchString(path) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Compile converts a glob pattern into a regular expression which matches the entire path
+func Compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// `**/` matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in pattern %q", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// File is a regular file in a local directory tree
+type File struct {
+	// Path is the slash-separated path relative to the root
+	Path string
+
+	Mode fs.FileMode
+	Size int64
+
+	// Md5Sum is the md5 checksum of the file contents in hex encoding
+	Md5Sum string
+}
+
+// Walk returns all regular files below root which are selected by m sorted by path.
+// Symbolic links to regular files are followed.
+func Walk(root string, m *Matcher) ([]File, error) {
+	var files []File
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !m.Match(rel) {
+			return nil
+		}
+
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		md5Sum, err := fileMd5Sum(p)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, File{
+			Path:   rel,
+			Mode:   info.Mode().Perm(),
+			Size:   info.Size(),
+			Md5Sum: md5Sum,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+func fileMd5Sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	sum, err := hashutil.FromReader(md5.New(), f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(sum), nil
+}
@@ -0,0 +1,76 @@
+package fileset_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc    string
+		Include []string
+		Exclude []string
+		Path    string
+		Expect  bool
+	}
+
+	tcs := []testCase{
+		{Desc: "default include", Path: "a/b/c.txt", Expect: true},
+		{Desc: "star does not match slash", Include: []string{"*.html"}, Path: "docs/index.html", Expect: false},
+		{Desc: "star top-level", Include: []string{"*.html"}, Path: "index.html", Expect: true},
+		{Desc: "double star prefix", Include: []string{"**/*.html"}, Path: "index.html", Expect: true},
+		{Desc: "double star nested", Include: []string{"**/*.html"}, Path: "a/b/index.html", Expect: true},
+		{Desc: "double star suffix", Include: []string{"assets/**"}, Path: "assets/img/logo.png", Expect: true},
+		{Desc: "question mark", Include: []string{"file?.txt"}, Path: "file1.txt", Expect: true},
+		{Desc: "character class", Include: []string{"file[0-9].txt"}, Path: "filea.txt", Expect: false},
+		{Desc: "negated character class", Include: []string{"file[!0-9].txt"}, Path: "filea.txt", Expect: true},
+		{Desc: "dot is literal", Include: []string{"*.txt"}, Path: "filetxt", Expect: false},
+		{Desc: "exclude", Exclude: []string{"**/.git/**"}, Path: "sub/.git/config", Expect: false},
+		{Desc: "exclude does not match", Exclude: []string{"*.tmp"}, Path: "file.txt", Expect: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			m, err := fileset.NewMatcher(tc.Include, tc.Exclude)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, m.Match(tc.Path))
+		})
+	}
+}
+
+func TestCompile_invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := fileset.Compile("file[0-9")
+	assert.Error(t, err)
+
+	_, err = fileset.Compile("")
+	assert.Error(t, err)
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sub", "empty"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), []byte("hello world!"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "a.sh"), []byte(""), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "sub", "skip.tmp"), []byte(""), 0644))
+
+	m, err := fileset.NewMatcher(nil, []string{"**/*.tmp"})
+	require.NoError(t, err)
+
+	files, err := fileset.Walk(root, m)
+	require.NoError(t, err)
+
+	assert.Equal(t, []fileset.File{
+		{Path: "b.txt", Mode: 0644, Size: 12, Md5Sum: "fc3ff98e8c6a0d3087d515c0473f8677"},
+		{Path: "sub/a.sh", Mode: 0755, Size: 0, Md5Sum: "d41d8cd98f00b204e9800998ecf8427e"},
+	}, files)
+}
@@ -0,0 +1,210 @@
+package shacrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Implements the SHA-512 based crypt scheme as used in /etc/shadow
+// https://www.akkadia.org/drepper/SHA-crypt.txt
+
+const (
+	Sha512Prefix = "$6$"
+
+	DefaultRounds = 5000
+	MinRounds     = 1000
+	MaxRounds     = 999999999
+
+	// SaltMaxLen is the maximum number of salt characters which are used by the scheme
+	SaltMaxLen = 16
+)
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidHash = errors.New("invalid sha512-crypt hash")
+
+// NewSalt returns a random salt of SaltMaxLen characters
+func NewSalt() (string, error) {
+	b := make([]byte, SaltMaxLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	for i := range b {
+		b[i] = itoa64[int(b[i])%len(itoa64)]
+	}
+
+	return string(b), nil
+}
+
+// Sha512 returns the sha512-crypt hash of the password with the salt. The salt is truncated to SaltMaxLen characters.
+// The default number of rounds is used if rounds is 0. Otherwise, rounds is clamped to MinRounds and MaxRounds.
+func Sha512(password string, salt string, rounds int) string {
+	customRounds := rounds != 0
+	if !customRounds {
+		rounds = DefaultRounds
+	}
+	if rounds < MinRounds {
+		rounds = MinRounds
+	}
+	if rounds > MaxRounds {
+		rounds = MaxRounds
+	}
+
+	if len(salt) > SaltMaxLen {
+		salt = salt[:SaltMaxLen]
+	}
+
+	sum := sha512Sum([]byte(password), []byte(salt), rounds)
+
+	var b strings.Builder
+	b.WriteString(Sha512Prefix)
+	if customRounds {
+		b.WriteString("rounds=")
+		b.WriteString(strconv.Itoa(rounds))
+		b.WriteString("$")
+	}
+	b.WriteString(salt)
+	b.WriteString("$")
+	b.WriteString(sha512Encode(sum))
+
+	return b.String()
+}
+
+// Parse returns the salt and the number of rounds of a sha512-crypt hash. rounds is 0 if the hash uses the default
+// number of rounds.
+func Parse(hash string) (salt string, rounds int, err error) {
+	if !strings.HasPrefix(hash, Sha512Prefix) {
+		return "", 0, ErrInvalidHash
+	}
+
+	rest := strings.TrimPrefix(hash, Sha512Prefix)
+	if strings.HasPrefix(rest, "rounds=") {
+		roundsStr, after, found := strings.Cut(strings.TrimPrefix(rest, "rounds="), "$")
+		if !found {
+			return "", 0, ErrInvalidHash
+		}
+
+		rounds, err = strconv.Atoi(roundsStr)
+		if err != nil {
+			return "", 0, ErrInvalidHash
+		}
+
+		rest = after
+	}
+
+	salt, sum, found := strings.Cut(rest, "$")
+	if !found || len(salt) > SaltMaxLen || len(sum) != 86 {
+		return "", 0, ErrInvalidHash
+	}
+
+	return salt, rounds, nil
+}
+
+// Verify reports whether hash is the sha512-crypt hash of the password. Returns false if hash is not a sha512-crypt
+// hash.
+func Verify(password string, hash string) bool {
+	salt, rounds, err := Parse(hash)
+	if err != nil {
+		return false
+	}
+
+	return Sha512(password, salt, rounds) == hash
+}
+
+func sha512Sum(key []byte, salt []byte, rounds int) []byte {
+	// Digest B
+	h := sha512.New()
+	h.Write(key)
+	h.Write(salt)
+	h.Write(key)
+	sumB := h.Sum(nil)
+
+	// Digest A
+	h.Reset()
+	h.Write(key)
+	h.Write(salt)
+	n := len(key)
+	for ; n > sha512.Size; n -= sha512.Size {
+		h.Write(sumB)
+	}
+	h.Write(sumB[:n])
+	for n = len(key); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			h.Write(sumB)
+		} else {
+			h.Write(key)
+		}
+	}
+	sumA := h.Sum(nil)
+
+	// Byte sequence P
+	h.Reset()
+	for i := 0; i < len(key); i++ {
+		h.Write(key)
+	}
+	p := repeat(h.Sum(nil), len(key))
+
+	// Byte sequence S
+	h.Reset()
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		h.Write(salt)
+	}
+	s := repeat(h.Sum(nil), len(salt))
+
+	sumC := sumA
+	for i := 0; i < rounds; i++ {
+		h.Reset()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(sumC)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(sumC)
+		} else {
+			h.Write(p)
+		}
+		sumC = h.Sum(nil)
+	}
+
+	return sumC
+}
+
+// repeat returns the first n bytes of the concatenation of b
+func repeat(b []byte, n int) []byte {
+	r := make([]byte, 0, n)
+	for len(r) < n {
+		r = append(r, b[:min(len(b), n-len(r))]...)
+	}
+	return r
+}
+
+// sha512Encode encodes the digest in the byte order of the scheme
+func sha512Encode(sum []byte) string {
+	var b strings.Builder
+
+	for i := 0; i < 21; i++ {
+		encode24(&b, sum[(i*22)%63], sum[(i*22+21)%63], sum[(i*22+42)%63], 4)
+	}
+	encode24(&b, 0, 0, sum[63], 2)
+
+	return b.String()
+}
+
+func encode24(b *strings.Builder, b2 byte, b1 byte, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		b.WriteByte(itoa64[w&0x3f])
+		w >>= 6
+	}
+}
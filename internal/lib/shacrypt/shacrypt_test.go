@@ -0,0 +1,145 @@
+package shacrypt_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/shacrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSha512(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc     string
+		Password string
+		Salt     string
+		Rounds   int
+		Expect   string
+	}
+
+	// Test vectors from https://www.akkadia.org/drepper/SHA-crypt.txt, openssl passwd -6, and libxcrypt
+	tcs := []testCase{
+		{
+			Desc:     "default rounds",
+			Password: "Hello world!",
+			Salt:     "saltstring",
+			Expect:   "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+		},
+		{
+			Desc:     "custom rounds and truncated salt",
+			Password: "Hello world!",
+			Salt:     "saltstringsaltstring",
+			Rounds:   10000,
+			Expect:   "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+		},
+		{
+			Desc:     "rounds below minimum",
+			Password: "we have a short salt string but not a short password",
+			Salt:     "roundstoolow",
+			Rounds:   10,
+			Expect:   "$6$rounds=1000$roundstoolow$yjTuW7RnC.d35QcVTFIb6uvh/7IQ1.GFtFN3i/.jwmeWEhzjf4uD/OPCb4jRl6atJGYhLst8IyR6YAtTrriMU1",
+		},
+		{
+			Desc:     "short salt",
+			Password: "password",
+			Salt:     "abc",
+			Expect:   "$6$abc$rvqzMBuMVukmply9mZJpW0wJMdDfgUKLDrSNxf9l66h/ytQiKNAdqHSj5YPJpxWJpVjRXibQXRddCl9xYHQnd0",
+		},
+		{
+			Desc:     "password longer than digest",
+			Password: strings.Repeat("a", 150),
+			Salt:     "x",
+			Expect:   "$6$x$bV80KgoIzHU4dlR2lEtEe2zf0fk.ecwvRcVHj2C9xXDNBSWUYmTdZL2BfrCE7CxtN7mHwn8tJhOKo/MGwd9Bk.",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expect, shacrypt.Sha512(tc.Password, tc.Salt, tc.Rounds))
+			assert.True(t, shacrypt.Verify(tc.Password, tc.Expect))
+			assert.False(t, shacrypt.Verify(tc.Password+"x", tc.Expect))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc         string
+		Hash         string
+		ExpectSalt   string
+		ExpectRounds int
+		ExpectErr    bool
+	}
+
+	tcs := []testCase{
+		{
+			Desc:       "default rounds",
+			Hash:       "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+			ExpectSalt: "saltstring",
+		},
+		{
+			Desc:         "custom rounds",
+			Hash:         "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+			ExpectSalt:   "saltstringsaltst",
+			ExpectRounds: 10000,
+		},
+		{
+			// Hashes of other schemes are not parsed
+			Desc:      "invalid: other scheme yescrypt",
+			Hash:      "$y$j9T$abcdefghijklmnop$0123456789abcdefghijklmnopqrstuvwxyzABCDEFG",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "invalid: truncated",
+			Hash:      "$6$saltstring$svn8",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "invalid: locked",
+			Hash:      "!",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			salt, rounds, err := shacrypt.Parse(tc.Hash)
+			if tc.ExpectErr {
+				assert.ErrorIs(t, err, shacrypt.ErrInvalidHash)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectSalt, salt)
+			assert.Equal(t, tc.ExpectRounds, rounds)
+		})
+	}
+}
+
+func TestVerify_otherScheme(t *testing.T) {
+	t.Parallel()
+
+	// A hash of another scheme never matches
+	assert.False(t, shacrypt.Verify("Hello world!", "$y$j9T$abcdefghijklmnop$0123456789abcdefghijklmnopqrstuvwxyzABCDEFG"))
+}
+
+func TestNewSalt(t *testing.T) {
+	t.Parallel()
+
+	salt, err := shacrypt.NewSalt()
+	require.NoError(t, err)
+	assert.Len(t, salt, shacrypt.SaltMaxLen)
+
+	_, _, err = shacrypt.Parse(shacrypt.Sha512("password", salt, 0))
+	assert.NoError(t, err)
+}
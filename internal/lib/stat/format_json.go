@@ -7,7 +7,7 @@ import (
 )
 
 const (
-	FormatJsonGnu = `{"plat":"gnu","mode":"%f","name":"%N","user":"%U","uid":"%u","group":"%G","gid":"%g","size":"%s","atime":"%X","mtime":"%Y","ctime":"%Z"}`
+	FormatJsonGnu = `{"plat":"gnu","mode":"%f","name":"%N","user":"%U","uid":"%u","group":"%G","gid":"%g","size":"%s","ino":"%i","nlink":"%h","atime":"%X","mtime":"%Y","ctime":"%Z"}`
 )
 
 type jsonFormat struct {
@@ -19,6 +19,8 @@ type jsonFormat struct {
 	Group        string `json:"group"`
 	Gid          string `json:"gid"`
 	Size         string `json:"size"`
+	Inode        string `json:"ino"`
+	Links        string `json:"nlink"`
 	AccessTime   string `json:"atime"`
 	ModifiedTime string `json:"mtime"`
 	ChangedTime  string `json:"ctime"`
@@ -40,15 +42,11 @@ func ParseJsonFormat(data []byte) (*Stat, error) {
 		return nil, err
 	}
 
-	// Mode (from hex) %f
-	// Stat provides mode in 2 byte = 16 bits hex
-	modeInt, err := strconv.ParseUint(us.Mode, 16, 16)
+	mode, err := us.fileMode()
 	if err != nil {
-		return nil, newParseError(fmt.Sprintf("failed to parse mode '%s'", us.Mode))
+		return nil, err
 	}
 
-	mode := FileMode(modeInt)
-
 	// Name and target
 	var name string
 	var target string
@@ -67,12 +65,46 @@ func ParseJsonFormat(data []byte) (*Stat, error) {
 		name = unquoteSingle(us.Name)
 	}
 
+	return us.toStat(mode, name, target)
+}
+
+// fileMode returns the parsed mode of the file
+func (us *jsonFormat) fileMode() (FileMode, error) {
+	// Mode (from hex) %f
+	// Stat provides mode in 2 byte = 16 bits hex
+	modeInt, err := strconv.ParseUint(us.Mode, 16, 16)
+	if err != nil {
+		return 0, newParseError(fmt.Sprintf("failed to parse mode '%s'", us.Mode))
+	}
+
+	return FileMode(modeInt), nil
+}
+
+// toStat returns the Stat with the provided mode, name, and target
+func (us *jsonFormat) toStat(mode FileMode, name string, target string) (*Stat, error) {
 	// Size %s
 	size, err := strconv.ParseInt(us.Size, 10, 64)
 	if err != nil {
 		return nil, newParseError(fmt.Sprintf("failed to parse size %s", us.Size))
 	}
 
+	// Inode %i and hard links %h are optional
+	var inode uint64
+	if us.Inode != "" {
+		inode, err = strconv.ParseUint(us.Inode, 10, 64)
+		if err != nil {
+			return nil, newParseError(fmt.Sprintf("failed to parse inode %s", us.Inode))
+		}
+	}
+
+	var links int
+	if us.Links != "" {
+		links, err = strconv.Atoi(us.Links)
+		if err != nil {
+			return nil, newParseError(fmt.Sprintf("failed to parse hard links %s", us.Links))
+		}
+	}
+
 	// Uid %u
 	uid, err := strconv.Atoi(us.Uid)
 	if err != nil {
@@ -113,6 +145,8 @@ func ParseJsonFormat(data []byte) (*Stat, error) {
 		Group:        us.Group,
 		Gid:          gid,
 		Size:         size,
+		Inode:        inode,
+		Links:        links,
 		AccessTime:   accessTime,
 		ModifiedTime: modifiedTime,
 		ChangeTime:   changeTime,
@@ -0,0 +1,67 @@
+package stat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// FormatJsonRecord returns a format which prints the meta information in the format of FormatJsonGnu followed by the
+// name of the file. Each record is prefixed with the separator. The name is omitted from the json object which allows
+// arbitrary names including line breaks as long as the separator does not occur in a name. The format is supported by
+// `stat -c` of GNU coreutils and BusyBox which terminates each record with a line break.
+func FormatJsonRecord(separator string) string {
+	return separator + `{"plat":"gnu","mode":"%f","name":"","user":"%U","uid":"%u","group":"%G","gid":"%g","size":"%s","ino":"%i","nlink":"%h","atime":"%X","mtime":"%Y","ctime":"%Z"}%n`
+}
+
+// ParseJsonRecordFormat parses the provided output of `stat -c` for any number of files which is formatted using
+// FormatJsonRecord with the separator. The Target of symlinks is not provided by the format.
+func ParseJsonRecordFormat(data []byte, separator string) ([]*Stat, error) {
+	if separator == "" {
+		return nil, newParseError("empty separator")
+	}
+
+	records := bytes.Split(data, []byte(separator))
+
+	// Output starts with a separator which results in an empty first record
+	if len(bytes.TrimSpace(records[0])) != 0 {
+		return nil, newParseError("output does not start with separator")
+	}
+	records = records[1:]
+
+	stats := make([]*Stat, 0, len(records))
+
+	for _, record := range records {
+		// Each record is terminated by a line break
+		if !bytes.HasSuffix(record, []byte("\n")) {
+			return nil, newParseError("unterminated record")
+		}
+		record = record[:len(record)-1]
+
+		// The json object is followed by the name
+		dec := json.NewDecoder(bytes.NewReader(record))
+		var us jsonFormat
+		if err := dec.Decode(&us); err != nil {
+			return nil, newParseError(fmt.Sprintf("invalid record: %s", err.Error()))
+		}
+
+		name := string(record[dec.InputOffset():])
+		if name == "" {
+			return nil, newParseError("empty name")
+		}
+
+		mode, err := us.fileMode()
+		if err != nil {
+			return nil, err
+		}
+
+		s, err := us.toStat(mode, name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
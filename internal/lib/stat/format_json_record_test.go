@@ -0,0 +1,97 @@
+package stat_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/stat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseJsonRecordFormat(t *testing.T) {
+	t.Parallel()
+
+	const sep = "::a1b2c3::"
+
+	type testCase struct {
+		Desc      string
+		Data      string
+		ExpectErr bool
+		Assert    func(t *testing.T, s []*stat.Stat)
+	}
+
+	tcs := []testCase{
+		{
+			Desc: "empty output",
+			Data: "",
+			Assert: func(t *testing.T, s []*stat.Stat) {
+				assert.Len(t, s, 0)
+			},
+		},
+		{
+			Desc: "regular file and folder",
+			Data: sep + `{"plat":"gnu","mode":"81a4","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"6","ino":"3416818","nlink":"2","atime":"1628450121","mtime":"1628448200","ctime":"1628448202"}/root/file.txt` + "\n" +
+				sep + `{"plat":"gnu","mode":"41ed","name":"","user":"someone","uid":"1000","group":"users","gid":"100","size":"4096","atime":"1628450121","mtime":"1628448200","ctime":"1628448202"}/root/folder` + "\n",
+			Assert: func(t *testing.T, s []*stat.Stat) {
+				require.Len(t, s, 2)
+				assert.Equal(t, "/root/file.txt", s[0].Name)
+				assert.Equal(t, true, s[0].Mode.IsRegular())
+				assert.Equal(t, int64(6), s[0].Size)
+				assert.Equal(t, uint64(3416818), s[0].Inode)
+				assert.Equal(t, 2, s[0].Links)
+				assert.Equal(t, "2021-08-08 18:43:20 +0000 UTC", s[0].ModifiedTime.UTC().String())
+				assert.Equal(t, "/root/folder", s[1].Name)
+				assert.Equal(t, true, s[1].Mode.IsDir())
+				assert.Equal(t, "someone", s[1].User)
+				assert.Equal(t, 1000, s[1].Uid)
+			},
+		},
+		{
+			Desc: "names with special characters",
+			Data: sep + `{"plat":"gnu","mode":"a1ff","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"7","atime":"1628450121","mtime":"1628448200","ctime":"1628448202"}/root/a "b"` + "\n{c}\n",
+			Assert: func(t *testing.T, s []*stat.Stat) {
+				require.Len(t, s, 1)
+				assert.Equal(t, "/root/a \"b\"\n{c}", s[0].Name)
+				assert.Equal(t, true, s[0].Mode.IsSymlink())
+				assert.Equal(t, false, s[0].Mode.IsRegular())
+			},
+		},
+		{
+			Desc:      "missing separator",
+			Data:      `{"plat":"gnu","mode":"81a4","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"6","atime":"1","mtime":"1","ctime":"1"}/root/file.txt` + "\n",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "unterminated record",
+			Data:      sep + `{"plat":"gnu","mode":"81a4","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"6","atime":"1","mtime":"1","ctime":"1"}/root/file.txt`,
+			ExpectErr: true,
+		},
+		{
+			Desc:      "missing name",
+			Data:      sep + `{"plat":"gnu","mode":"81a4","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"6","atime":"1","mtime":"1","ctime":"1"}` + "\n",
+			ExpectErr: true,
+		},
+		{
+			Desc:      "invalid mode",
+			Data:      sep + `{"plat":"gnu","mode":"xyz","name":"","user":"root","uid":"0","group":"root","gid":"0","size":"6","atime":"1","mtime":"1","ctime":"1"}/root/file.txt` + "\n",
+			ExpectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := stat.ParseJsonRecordFormat([]byte(tc.Data), sep)
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			if tc.Assert != nil {
+				tc.Assert(t, s)
+			}
+		})
+	}
+}
@@ -48,6 +48,18 @@ func ParseTerseFormat(data []byte) (*Stat, error) {
 
 	mode := FileMode(modeInt)
 
+	// Inode %i
+	inode, err := strconv.ParseUint(parts[7], 10, 64)
+	if err != nil {
+		return nil, newParseError(fmt.Sprintf("failed to parse inode %s", parts[7]))
+	}
+
+	// Hard links %h
+	links, err := strconv.Atoi(parts[8])
+	if err != nil {
+		return nil, newParseError(fmt.Sprintf("failed to parse hard links %s", parts[8]))
+	}
+
 	// Access time %X
 	accessTime, err := parseUnixEpochUTC(parts[11])
 	if err != nil {
@@ -70,6 +82,8 @@ func ParseTerseFormat(data []byte) (*Stat, error) {
 		Mode:         mode,
 		Name:         name,
 		Size:         size,
+		Inode:        inode,
+		Links:        links,
 		AccessTime:   accessTime,
 		ModifiedTime: modifiedTime,
 		ChangeTime:   changeTime,
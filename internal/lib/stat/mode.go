@@ -25,15 +25,15 @@ const (
 )
 
 func (m FileMode) IsRegular() bool {
-	return m&ModeRegularFile == ModeRegularFile
+	return m&ModeType == ModeRegularFile
 }
 
 func (m FileMode) IsDir() bool {
-	return m&ModeDirectory == ModeDirectory
+	return m&ModeType == ModeDirectory
 }
 
 func (m FileMode) IsSymlink() bool {
-	return m&ModeSymlink == ModeSymlink
+	return m&ModeType == ModeSymlink
 }
 
 func (m FileMode) Perm() FileMode {
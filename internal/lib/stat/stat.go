@@ -20,6 +20,8 @@ type Stat struct {
 	Group        string
 	Gid          int
 	Size         int64
+	Inode        uint64
+	Links        int
 	AccessTime   time.Time
 	ModifiedTime time.Time
 	ChangeTime   time.Time
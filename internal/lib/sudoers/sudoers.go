@@ -0,0 +1,284 @@
+package sudoers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Renders and parses rules in the format of sudoers
+// https://www.sudo.ws/docs/man/sudoers.man/#SUDOERS_FILE_FORMAT
+
+// ErrUnsupported reports content which has not been rendered by Rule.Render
+var ErrUnsupported = errors.New("unsupported sudoers content")
+
+var (
+	// NameRegexp matches the file names in the drop-in folder which are included by sudo. sudo skips files which
+	// contain a `.` or end with `~`.
+	NameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+	// IdentifierRegexp matches user names, group names, host names, uids (`#1000`), gids, and aliases which do not
+	// require escaping
+	IdentifierRegexp = regexp.MustCompile(`^#?[A-Za-z0-9_][A-Za-z0-9_.+@-]*\$?$`)
+
+	// EnvVarRegexp matches names of environment variables including a trailing wildcard
+	EnvVarRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\*?$`)
+)
+
+// Rule is a user specification of sudoers
+type Rule struct {
+	// Users are names of users, uids prefixed with `#`, or aliases
+	Users []string
+
+	// Groups are names of groups without the `%` prefix
+	Groups []string
+
+	// Hosts on which the rule applies. `ALL` if empty.
+	Hosts []string
+
+	// RunAsUsers are the users as which the commands may be executed
+	RunAsUsers []string
+
+	// RunAsGroups are the groups as which the commands may be executed
+	RunAsGroups []string
+
+	// Commands are the commands with arguments or `ALL`
+	Commands []string
+
+	// NoPasswd allows executing the commands without password
+	NoPasswd bool
+
+	// SetEnv allows the user to override the environment
+	SetEnv bool
+
+	// EnvKeep are the environment variables which are retained
+	EnvKeep []string
+}
+
+// userList returns the users and groups as User_List
+func (r Rule) userList() string {
+	var list []string
+	list = append(list, r.Users...)
+	for _, g := range r.Groups {
+		list = append(list, "%"+g)
+	}
+	return strings.Join(list, ",")
+}
+
+// Render returns the content of a drop-in file of the rule. header is written as comment before the rule.
+func (r Rule) Render(header string) string {
+	var b strings.Builder
+
+	if header != "" {
+		for _, line := range strings.Split(header, "\n") {
+			b.WriteString(strings.TrimRight("# "+line, " "))
+			b.WriteString("\n")
+		}
+	}
+
+	users := r.userList()
+
+	if len(r.EnvKeep) > 0 {
+		b.WriteString(fmt.Sprintf("Defaults:%s env_keep += \"%s\"\n", users, strings.Join(r.EnvKeep, " ")))
+	}
+
+	hosts := "ALL"
+	if len(r.Hosts) > 0 {
+		hosts = strings.Join(r.Hosts, ",")
+	}
+
+	runAs := ""
+	if len(r.RunAsUsers) > 0 || len(r.RunAsGroups) > 0 {
+		runAs = strings.Join(r.RunAsUsers, ",")
+		if len(r.RunAsGroups) > 0 {
+			runAs += ":" + strings.Join(r.RunAsGroups, ",")
+		}
+		runAs = "(" + runAs + ") "
+	}
+
+	var tags string
+	if r.NoPasswd {
+		tags += "NOPASSWD: "
+	}
+	if r.SetEnv {
+		tags += "SETENV: "
+	}
+
+	var commands []string
+	for _, c := range r.Commands {
+		commands = append(commands, EscapeCommand(c))
+	}
+
+	b.WriteString(fmt.Sprintf("%s %s=%s%s%s\n", users, hosts, runAs, tags, strings.Join(commands, ", ")))
+
+	return b.String()
+}
+
+// Parse returns the rule of the content of a drop-in file which has been rendered by Render. Comments are ignored.
+// Content which has not been rendered by Render results in ErrUnsupported.
+func Parse(content string) (*Rule, error) {
+	var r *Rule
+	var defaults, envKeep []string
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || line == "#" || strings.HasPrefix(line, "# "):
+			// Empty line or comment. `#` followed by a digit is a uid.
+			continue
+		case strings.HasPrefix(line, "Defaults:"):
+			if defaults != nil {
+				return nil, fmt.Errorf("%w: multiple defaults", ErrUnsupported)
+			}
+			users, env, found := strings.Cut(strings.TrimPrefix(line, "Defaults:"), ` env_keep += "`)
+			if !found || !strings.HasSuffix(env, `"`) {
+				return nil, fmt.Errorf("%w: unsupported defaults %q", ErrUnsupported, line)
+			}
+			defaults = strings.Split(users, ",")
+			envKeep = strings.Fields(strings.TrimSuffix(env, `"`))
+		default:
+			if r != nil {
+				return nil, fmt.Errorf("%w: multiple rules", ErrUnsupported)
+			}
+			parsed, err := parseRule(line)
+			if err != nil {
+				return nil, err
+			}
+			r = parsed
+		}
+	}
+
+	if r == nil {
+		return nil, fmt.Errorf("%w: no rule", ErrUnsupported)
+	}
+
+	if defaults != nil {
+		if strings.Join(defaults, ",") != r.userList() {
+			return nil, fmt.Errorf("%w: defaults do not apply to the users of the rule", ErrUnsupported)
+		}
+		r.EnvKeep = envKeep
+	}
+
+	return r, nil
+}
+
+// parseRule parses a user specification in the format of Render
+func parseRule(line string) (*Rule, error) {
+	r := &Rule{}
+
+	users, rest, found := strings.Cut(line, " ")
+	if !found {
+		return nil, fmt.Errorf("%w: unsupported rule %q", ErrUnsupported, line)
+	}
+
+	for _, u := range strings.Split(users, ",") {
+		if g, isGroup := strings.CutPrefix(u, "%"); isGroup {
+			r.Groups = append(r.Groups, g)
+		} else {
+			r.Users = append(r.Users, u)
+		}
+	}
+
+	hosts, spec, found := strings.Cut(rest, "=")
+	if !found {
+		return nil, fmt.Errorf("%w: unsupported rule %q", ErrUnsupported, line)
+	}
+	if hosts != "ALL" {
+		r.Hosts = strings.Split(hosts, ",")
+	}
+
+	if strings.HasPrefix(spec, "(") {
+		runAs, remainder, found := strings.Cut(strings.TrimPrefix(spec, "("), ") ")
+		if !found {
+			return nil, fmt.Errorf("%w: unsupported rule %q", ErrUnsupported, line)
+		}
+		runAsUsers, runAsGroups, _ := strings.Cut(runAs, ":")
+		if runAsUsers != "" {
+			r.RunAsUsers = strings.Split(runAsUsers, ",")
+		}
+		if runAsGroups != "" {
+			r.RunAsGroups = strings.Split(runAsGroups, ",")
+		}
+		spec = remainder
+	}
+
+	if remainder, found := strings.CutPrefix(spec, "NOPASSWD: "); found {
+		r.NoPasswd = true
+		spec = remainder
+	}
+	if remainder, found := strings.CutPrefix(spec, "SETENV: "); found {
+		r.SetEnv = true
+		spec = remainder
+	}
+
+	r.Commands = splitCommands(spec)
+	for _, c := range r.Commands {
+		if err := ValidateCommand(c); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupported, err.Error())
+		}
+	}
+
+	return r, nil
+}
+
+// splitCommands splits a Cmnd_List at unescaped commas and reverts EscapeCommand
+func splitCommands(list string) []string {
+	var commands []string
+	var b strings.Builder
+
+	for i := 0; i < len(list); i++ {
+		switch {
+		case list[i] == '\\' && i+1 < len(list):
+			i++
+			b.WriteByte(list[i])
+		case list[i] == ',':
+			commands = append(commands, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(list[i])
+		}
+	}
+
+	return append(commands, strings.TrimSpace(b.String()))
+}
+
+// commandEscaper escapes the characters which have a special meaning in the arguments of a command
+var commandEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `:`, `\:`, `=`, `\=`)
+
+// EscapeCommand escapes the special characters of sudoers in the arguments of a command. The path of the command is not
+// escaped.
+func EscapeCommand(command string) string {
+	command = strings.TrimSpace(command)
+
+	path, args, found := strings.Cut(command, " ")
+	if !found {
+		return command
+	}
+
+	return path + " " + commandEscaper.Replace(strings.TrimLeft(args, " "))
+}
+
+// ValidateCommand reports an error if command is neither `ALL`, `sudoedit` with arguments, nor an absolute path of a
+// command with optional arguments
+func ValidateCommand(command string) error {
+	command = strings.TrimSpace(command)
+
+	if strings.ContainsAny(command, "\n\r") {
+		return fmt.Errorf("command must not contain line breaks")
+	}
+
+	path, _, _ := strings.Cut(command, " ")
+
+	switch {
+	case command == "ALL":
+		return nil
+	case path == "sudoedit":
+		return nil
+	case strings.HasPrefix(path, "/"):
+		return nil
+	}
+
+	return fmt.Errorf("command must be `ALL`, `sudoedit`, or an absolute path")
+}
@@ -0,0 +1,204 @@
+package sudoers_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/heredoc"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/sudoers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRuleRender(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc   string
+		Rule   sudoers.Rule
+		Header string
+		Expect string
+	}
+
+	tcs := []testCase{
+		{
+			Desc: "minimal",
+			Rule: sudoers.Rule{
+				Users:    []string{"alice"},
+				Commands: []string{"ALL"},
+			},
+			Expect: "alice ALL=ALL\n",
+		},
+		{
+			Desc: "users, groups, and runas",
+			Rule: sudoers.Rule{
+				Users:       []string{"alice", "#1001"},
+				Groups:      []string{"admins"},
+				Hosts:       []string{"web1", "web2"},
+				RunAsUsers:  []string{"ALL"},
+				RunAsGroups: []string{"ALL"},
+				Commands:    []string{"ALL"},
+				NoPasswd:    true,
+			},
+			Expect: "alice,#1001,%admins web1,web2=(ALL:ALL) NOPASSWD: ALL\n",
+		},
+		{
+			Desc: "runas group only",
+			Rule: sudoers.Rule{
+				Groups:      []string{"deploy"},
+				RunAsGroups: []string{"www-data"},
+				Commands:    []string{"/usr/bin/id"},
+			},
+			Expect: "%deploy ALL=(:www-data) /usr/bin/id\n",
+		},
+		{
+			Desc: "escaped command arguments, tags, env, and header",
+			Rule: sudoers.Rule{
+				Users:      []string{"deploy"},
+				RunAsUsers: []string{"root"},
+				Commands: []string{
+					"/usr/bin/systemctl restart nginx",
+					"/usr/bin/env  FOO=a,b:c",
+				},
+				NoPasswd: true,
+				SetEnv:   true,
+				EnvKeep:  []string{"HTTP_PROXY", "LC_*"},
+			},
+			Header: "Managed by terraform\n\nDo not edit",
+			Expect: heredoc.String(`
+				# Managed by terraform
+				#
+				# Do not edit
+				Defaults:deploy env_keep += "HTTP_PROXY LC_*"
+				deploy ALL=(root) NOPASSWD: SETENV: /usr/bin/systemctl restart nginx, /usr/bin/env FOO\=a\,b\:c
+			`),
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Expect, tc.Rule.Render(tc.Header))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc   string
+		Rule   sudoers.Rule
+		Header string
+	}
+
+	tcs := []testCase{
+		{
+			Desc: "minimal",
+			Rule: sudoers.Rule{
+				Users:    []string{"alice"},
+				Commands: []string{"ALL"},
+			},
+		},
+		{
+			Desc: "uid, groups, hosts, and runas",
+			Rule: sudoers.Rule{
+				Users:       []string{"#1001"},
+				Groups:      []string{"admins", "ops"},
+				Hosts:       []string{"web1", "web2"},
+				RunAsUsers:  []string{"ALL"},
+				RunAsGroups: []string{"ALL"},
+				Commands:    []string{"ALL"},
+				NoPasswd:    true,
+			},
+		},
+		{
+			Desc: "runas group only",
+			Rule: sudoers.Rule{
+				Groups:      []string{"deploy"},
+				RunAsGroups: []string{"www-data"},
+				Commands:    []string{"/usr/bin/id"},
+			},
+		},
+		{
+			Desc: "escaped command arguments, tags, env, and header",
+			Rule: sudoers.Rule{
+				Users:      []string{"deploy"},
+				RunAsUsers: []string{"root"},
+				Commands: []string{
+					"/usr/bin/systemctl restart nginx",
+					`/usr/bin/env FOO=a,b:c\d`,
+					"sudoedit /etc/hosts",
+				},
+				NoPasswd: true,
+				SetEnv:   true,
+				EnvKeep:  []string{"HTTP_PROXY", "LC_*"},
+			},
+			Header: "Managed by terraform\n\nDo not edit",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := sudoers.Parse(tc.Rule.Render(tc.Header))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Rule, *actual)
+		})
+	}
+}
+
+func TestParse_unsupported(t *testing.T) {
+	t.Parallel()
+
+	unsupported := []string{
+		"",
+		"# Managed by terraform\n",
+		"alice ALL=ALL\nbob ALL=ALL\n",
+		"alice ALL\n",
+		"alice ALL=(root /usr/bin/id\n",
+		"alice ALL=id\n",
+		"Defaults:alice env_keep += \"HOME\"\nbob ALL=ALL\n",
+		"Defaults:alice requiretty\nalice ALL=ALL\n",
+	}
+
+	for _, content := range unsupported {
+		_, err := sudoers.Parse(content)
+		assert.ErrorIs(t, err, sudoers.ErrUnsupported, content)
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	t.Parallel()
+
+	valid := []string{"ALL", "/usr/bin/systemctl restart nginx", "sudoedit /etc/hosts", "/usr/bin/apt-get *"}
+	for _, c := range valid {
+		assert.NoError(t, sudoers.ValidateCommand(c), c)
+	}
+
+	invalid := []string{"", "systemctl restart nginx", "/usr/bin/id\nALL ALL=(ALL) ALL", "all"}
+	for _, c := range invalid {
+		assert.Error(t, sudoers.ValidateCommand(c), c)
+	}
+}
+
+func TestRegexp(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, sudoers.NameRegexp.MatchString("10-deploy_rule"))
+	assert.False(t, sudoers.NameRegexp.MatchString("deploy.conf"))
+	assert.False(t, sudoers.NameRegexp.MatchString("deploy~"))
+
+	assert.True(t, sudoers.IdentifierRegexp.MatchString("alice"))
+	assert.True(t, sudoers.IdentifierRegexp.MatchString("#1000"))
+	assert.True(t, sudoers.IdentifierRegexp.MatchString("ADMINS"))
+	assert.True(t, sudoers.IdentifierRegexp.MatchString("machine$"))
+	assert.False(t, sudoers.IdentifierRegexp.MatchString("alice,bob"))
+	assert.False(t, sudoers.IdentifierRegexp.MatchString("alice ALL"))
+	assert.False(t, sudoers.IdentifierRegexp.MatchString("%admins"))
+
+	assert.True(t, sudoers.EnvVarRegexp.MatchString("LC_*"))
+	assert.False(t, sudoers.EnvVarRegexp.MatchString("A B"))
+}
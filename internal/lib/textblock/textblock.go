@@ -0,0 +1,210 @@
+package textblock
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUnterminatedBlock = errors.New("begin marker without matching end marker")
+
+	ErrDuplicateBlock = errors.New("multiple blocks with identical markers")
+)
+
+// Markers are the lines which enclose a block. A marker matches a line when the line equals the marker after trimming
+// trailing whitespace.
+type Markers struct {
+	Begin string
+	End   string
+}
+
+// Position defines where a block is inserted when it does not yet exist in the text.
+type Position int
+
+const (
+	// PositionEnd inserts the block after the last line
+	PositionEnd Position = iota
+
+	// PositionBeginning inserts the block before the first line
+	PositionBeginning
+
+	// PositionAfter inserts the block after the last line which matches a pattern
+	PositionAfter
+
+	// PositionBefore inserts the block before the first line which matches a pattern
+	PositionBefore
+)
+
+// Insert describes the position of a new block. Pattern is required for PositionAfter and PositionBefore. If Pattern
+// does not match any line, the block is inserted at the end.
+type Insert struct {
+	Position Position
+	Pattern  *regexp.Regexp
+}
+
+// location of a block in a slice of lines
+type location struct {
+	begin int
+	end   int
+}
+
+// splitLines splits text in lines without line terminators.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// joinLines joins lines and terminates every line with a newline.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// joinLinesLike joins lines and terminates the last line with a newline only if text does. This retains a last line
+// without newline, so that only the separator before the block is added or removed.
+func joinLinesLike(lines []string, text string) string {
+	joined := joinLines(lines)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		return strings.TrimSuffix(joined, "\n")
+	}
+	return joined
+}
+
+func isMarker(line string, marker string) bool {
+	return strings.TrimRight(line, " \t\r") == marker
+}
+
+func find(lines []string, m Markers) (*location, error) {
+	var loc *location
+
+	for i := 0; i < len(lines); i++ {
+		if !isMarker(lines[i], m.Begin) {
+			continue
+		}
+
+		end := -1
+		for j := i + 1; j < len(lines); j++ {
+			if isMarker(lines[j], m.End) {
+				end = j
+				break
+			}
+		}
+
+		if end == -1 {
+			return nil, ErrUnterminatedBlock
+		}
+
+		if loc != nil {
+			return nil, ErrDuplicateBlock
+		}
+
+		loc = &location{begin: i, end: end}
+		i = end
+	}
+
+	return loc, nil
+}
+
+// Find returns the body of the block enclosed by the Markers in text. The body does not include the marker lines.
+// Find returns false if the text does not contain the block.
+func Find(text string, m Markers) (string, bool, error) {
+	lines := splitLines(text)
+
+	loc, err := find(lines, m)
+	if err != nil {
+		return "", false, err
+	}
+
+	if loc == nil {
+		return "", false, nil
+	}
+
+	return joinLines(lines[loc.begin+1 : loc.end]), true, nil
+}
+
+// Set replaces the body of the block enclosed by the Markers in text. If the text does not contain the block, the block
+// is inserted according to Insert. Set terminates the body with a newline if it is missing. If the text does not end
+// with a newline, neither does the result.
+func Set(text string, m Markers, body string, ins Insert) (string, error) {
+	lines := splitLines(text)
+
+	loc, err := find(lines, m)
+	if err != nil {
+		return "", err
+	}
+
+	block := []string{m.Begin}
+	block = append(block, splitLines(body)...)
+	block = append(block, m.End)
+
+	var result []string
+
+	if loc != nil {
+		// Replace existing block
+		result = append(result, lines[:loc.begin]...)
+		result = append(result, block...)
+		result = append(result, lines[loc.end+1:]...)
+
+		return joinLinesLike(result, text), nil
+	}
+
+	at := insertIndex(lines, ins)
+
+	result = append(result, lines[:at]...)
+	result = append(result, block...)
+	result = append(result, lines[at:]...)
+
+	return joinLinesLike(result, text), nil
+}
+
+// Remove removes the block enclosed by the Markers including the marker lines from text.
+// Remove returns false if the text does not contain the block. If the text does not end with a newline, neither does
+// the result.
+func Remove(text string, m Markers) (string, bool, error) {
+	lines := splitLines(text)
+
+	loc, err := find(lines, m)
+	if err != nil {
+		return "", false, err
+	}
+
+	if loc == nil {
+		return text, false, nil
+	}
+
+	var result []string
+	result = append(result, lines[:loc.begin]...)
+	result = append(result, lines[loc.end+1:]...)
+
+	return joinLinesLike(result, text), true, nil
+}
+
+func insertIndex(lines []string, ins Insert) int {
+	switch ins.Position {
+	case PositionBeginning:
+		return 0
+	case PositionAfter:
+		if ins.Pattern != nil {
+			for i := len(lines) - 1; i >= 0; i-- {
+				if ins.Pattern.MatchString(lines[i]) {
+					return i + 1
+				}
+			}
+		}
+	case PositionBefore:
+		if ins.Pattern != nil {
+			for i := 0; i < len(lines); i++ {
+				if ins.Pattern.MatchString(lines[i]) {
+					return i
+				}
+			}
+		}
+	}
+
+	return len(lines)
+}
@@ -0,0 +1,222 @@
+package textblock_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/heredoc"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/textblock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+var testMarkers = textblock.Markers{
+	Begin: "# BEGIN terraform test",
+	End:   "# END terraform test",
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc        string
+		Text        string
+		Expect      string
+		ExpectFound bool
+		ExpectErr   error
+	}
+
+	tcs := []testCase{
+		{
+			Desc: "block in the middle",
+			Text: heredoc.String(`
+				first
+				# BEGIN terraform test
+				managed 1
+				managed 2
+				# END terraform test
+				last
+			`),
+			Expect:      "managed 1\nmanaged 2\n",
+			ExpectFound: true,
+		},
+		{
+			Desc: "empty block",
+			Text: heredoc.String(`
+				# BEGIN terraform test
+				# END terraform test
+			`),
+			Expect:      "",
+			ExpectFound: true,
+		},
+		{
+			Desc:        "markers with trailing whitespace",
+			Text:        "# BEGIN terraform test  \nmanaged\n# END terraform test\t\n",
+			Expect:      "managed\n",
+			ExpectFound: true,
+		},
+		{
+			Desc:        "no block",
+			Text:        "first\nlast\n",
+			ExpectFound: false,
+		},
+		{
+			Desc:        "empty text",
+			Text:        "",
+			ExpectFound: false,
+		},
+		{
+			Desc:      "unterminated block",
+			Text:      "# BEGIN terraform test\nmanaged\n",
+			ExpectErr: textblock.ErrUnterminatedBlock,
+		},
+		{
+			Desc:      "duplicate block",
+			Text:      "# BEGIN terraform test\n# END terraform test\n# BEGIN terraform test\n# END terraform test\n",
+			ExpectErr: textblock.ErrDuplicateBlock,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			actual, found, err := textblock.Find(tc.Text, testMarkers)
+
+			if tc.ExpectErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectFound, found)
+			assert.Equal(t, tc.Expect, actual)
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc   string
+		Text   string
+		Body   string
+		Insert textblock.Insert
+		Expect string
+	}
+
+	text := heredoc.String(`
+		Port 22
+		PermitRootLogin no
+		Match User backup
+		  ForceCommand internal-sftp
+	`)
+
+	tcs := []testCase{
+		{
+			Desc:   "insert at end",
+			Text:   text,
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionEnd},
+			Expect: text + "# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\n",
+		},
+		{
+			Desc:   "insert at beginning",
+			Text:   text,
+			Body:   "PasswordAuthentication no\n",
+			Insert: textblock.Insert{Position: textblock.PositionBeginning},
+			Expect: "# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\n" + text,
+		},
+		{
+			Desc:   "insert before match",
+			Text:   text,
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionBefore, Pattern: regexp.MustCompile(`^Match `)},
+			Expect: "Port 22\nPermitRootLogin no\n# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\nMatch User backup\n  ForceCommand internal-sftp\n",
+		},
+		{
+			Desc:   "insert after match",
+			Text:   text,
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionAfter, Pattern: regexp.MustCompile(`^Port `)},
+			Expect: "Port 22\n# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\nPermitRootLogin no\nMatch User backup\n  ForceCommand internal-sftp\n",
+		},
+		{
+			Desc:   "insert after without match falls back to end",
+			Text:   text,
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionAfter, Pattern: regexp.MustCompile(`^Nothing`)},
+			Expect: text + "# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\n",
+		},
+		{
+			Desc:   "insert at end of text without trailing newline",
+			Text:   "Port 22",
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionEnd},
+			Expect: "Port 22\n# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test",
+		},
+		{
+			Desc:   "insert at beginning of text without trailing newline",
+			Text:   "Port 22",
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionBeginning},
+			Expect: "# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\nPort 22",
+		},
+		{
+			Desc:   "insert into empty text",
+			Text:   "",
+			Body:   "PasswordAuthentication no",
+			Insert: textblock.Insert{Position: textblock.PositionEnd},
+			Expect: "# BEGIN terraform test\nPasswordAuthentication no\n# END terraform test\n",
+		},
+		{
+			Desc:   "replace existing block in place",
+			Text:   "Port 22\n# BEGIN terraform test\nold\n# END terraform test\nPermitRootLogin no\n",
+			Body:   "new 1\nnew 2\n",
+			Insert: textblock.Insert{Position: textblock.PositionBeginning},
+			Expect: "Port 22\n# BEGIN terraform test\nnew 1\nnew 2\n# END terraform test\nPermitRootLogin no\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Desc, func(t *testing.T) {
+			actual, err := textblock.Set(tc.Text, testMarkers, tc.Body, tc.Insert)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, actual)
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	actual, found, err := textblock.Remove("Port 22\n# BEGIN terraform test\nmanaged\n# END terraform test\nPermitRootLogin no\n", testMarkers)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Port 22\nPermitRootLogin no\n", actual)
+
+	actual, found, err = textblock.Remove("Port 22\n", testMarkers)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Equal(t, "Port 22\n", actual)
+
+	// The last line without newline is retained
+	actual, found, err = textblock.Remove("Port 22\n# BEGIN terraform test\nmanaged\n# END terraform test", testMarkers)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "Port 22", actual)
+}
+
+func TestSetRemove_withoutTrailingNewline(t *testing.T) {
+	t.Parallel()
+
+	for _, position := range []textblock.Position{textblock.PositionEnd, textblock.PositionBeginning} {
+		text := "Port 22\nPermitRootLogin no"
+
+		inserted, err := textblock.Set(text, testMarkers, "managed\n", textblock.Insert{Position: position})
+		require.NoError(t, err)
+
+		actual, found, err := textblock.Remove(inserted, testMarkers)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, text, actual)
+	}
+}
@@ -0,0 +1,614 @@
+package yescrypt
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Implements the yescrypt crypt scheme as used in /etc/shadow by libxcrypt
+// https://www.openwall.com/yescrypt/
+//
+// Only the flavor used by libxcrypt is supported: yescrypt in read-write mode with the default pwxform settings and
+// without ROM. The implementation follows the reference implementation of yescrypt 1.1.0.
+
+const (
+	Prefix = "$y$"
+
+	// DefaultParams are the encoded parameters which are used by libxcrypt by default: N = 4096, r = 32, p = 1
+	DefaultParams = "j9T"
+
+	// SaltLen is the number of random bytes of a new salt
+	SaltLen = 16
+
+	// MaxMemory is the maximum number of bytes which may be allocated to compute a hash
+	MaxMemory = 1 << 30
+)
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const (
+	flagRW      = 0x002
+	flagPrehash = 0x10000000
+
+	// flagsDefault is the read-write flavor with 6 pwxform rounds, 4 gathers, 2 simple lanes, and 12 KiB S-boxes
+	flagsDefault = 0x0b6
+	flavorMask   = 0x3fc
+)
+
+const (
+	pwxSimple = 2
+	pwxGather = 4
+	pwxRounds = 6
+	sWidth    = 8
+
+	// sWords is the number of words of the three S-boxes
+	sWords = 3 * (1 << sWidth) * pwxSimple * 2
+	// sMask masks the byte offset of an S-box entry
+	sMask = ((1 << sWidth) - 1) * pwxSimple * 8
+)
+
+var (
+	ErrInvalidHash = errors.New("invalid yescrypt hash")
+	ErrUnsupported = errors.New("unsupported yescrypt parameters")
+)
+
+type params struct {
+	flags uint32
+	n     uint64
+	r     uint32
+	p     uint32
+	t     uint32
+}
+
+// NewSetting returns a setting with the default parameters and a random salt of SaltLen bytes
+func NewSetting() (string, error) {
+	b := make([]byte, SaltLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return Prefix + DefaultParams + "$" + encode64(b), nil
+}
+
+// Hash returns the yescrypt hash of the password with the parameters and the salt of the setting. The setting is
+// either a setting as returned by NewSetting or Parse or an existing yescrypt hash.
+func Hash(password string, setting string) (string, error) {
+	setting, err := Parse(setting)
+	if err != nil {
+		return "", err
+	}
+
+	p, salt, err := decodeSetting(setting)
+	if err != nil {
+		return "", err
+	}
+
+	return setting + "$" + encode64(kdf([]byte(password), salt, p)), nil
+}
+
+// Parse returns the setting of a yescrypt hash which consists of the prefix, the parameters, and the salt
+func Parse(hash string) (setting string, err error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		return "", ErrInvalidHash
+	}
+
+	// The setting ends before the hash if present
+	setting = hash
+	if strings.Count(hash[len(Prefix):], "$") > 1 {
+		setting = hash[:strings.LastIndexByte(hash, '$')]
+	}
+
+	if _, _, err := decodeSetting(setting); err != nil {
+		return "", err
+	}
+
+	return setting, nil
+}
+
+// Verify reports whether hash is the yescrypt hash of the password. Returns false if hash is not a yescrypt hash or
+// uses unsupported parameters.
+func Verify(password string, hash string) bool {
+	h, err := Hash(password, hash)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(h), []byte(hash))
+}
+
+// decodeSetting decodes the parameters and the salt of a setting without a trailing hash
+func decodeSetting(setting string) (params, []byte, error) {
+	src := strings.TrimPrefix(setting, Prefix)
+	p := params{p: 1}
+	var ok bool
+
+	var flavor uint32
+	if flavor, src, ok = decode64Uint32(src, 0); !ok {
+		return p, nil, ErrInvalidHash
+	}
+	if flavor < flagRW || flavor > flagRW+(flavorMask>>2) {
+		return p, nil, ErrUnsupported
+	}
+	p.flags = flagRW + ((flavor - flagRW) << 2)
+
+	var nLog2 uint32
+	if nLog2, src, ok = decode64Uint32(src, 1); !ok || nLog2 > 63 {
+		return p, nil, ErrInvalidHash
+	}
+	p.n = uint64(1) << nLog2
+
+	if p.r, src, ok = decode64Uint32(src, 1); !ok {
+		return p, nil, ErrInvalidHash
+	}
+
+	if !strings.HasPrefix(src, "$") {
+		var have uint32
+		if have, src, ok = decode64Uint32(src, 1); !ok {
+			return p, nil, ErrInvalidHash
+		}
+		if have&1 != 0 {
+			if p.p, src, ok = decode64Uint32(src, 2); !ok {
+				return p, nil, ErrInvalidHash
+			}
+		}
+		if have&2 != 0 {
+			if p.t, src, ok = decode64Uint32(src, 1); !ok {
+				return p, nil, ErrInvalidHash
+			}
+		}
+		// Hash upgrades and ROM are not supported
+		if have&^3 != 0 {
+			return p, nil, ErrUnsupported
+		}
+	}
+
+	saltStr, found := strings.CutPrefix(src, "$")
+	if !found || strings.Contains(saltStr, "$") {
+		return p, nil, ErrInvalidHash
+	}
+
+	salt, ok := decode64(saltStr)
+	if !ok {
+		return p, nil, ErrInvalidHash
+	}
+
+	if p.flags != flagsDefault {
+		return p, nil, ErrUnsupported
+	}
+	if p.n < 4 || p.n/uint64(p.p) < 4 || uint64(p.r)*uint64(p.p) >= 1<<30 {
+		return p, nil, ErrUnsupported
+	}
+	if p.n > MaxMemory/128/uint64(p.r) || uint64(p.r)*uint64(p.p) > MaxMemory/128 {
+		return p, nil, ErrUnsupported
+	}
+
+	return p, salt, nil
+}
+
+func kdf(password []byte, salt []byte, p params) []byte {
+	// Large costs are preceded by a pre-hash of the password with a fraction of the memory
+	if p.n/uint64(p.p) >= 0x100 && p.n/uint64(p.p)*uint64(p.r) >= 0x20000 {
+		password = kdfBody(password, salt, p.flags|flagPrehash, p.n>>6, p.r, p.p, 0)
+	}
+
+	return kdfBody(password, salt, p.flags, p.n, p.r, p.p, p.t)
+}
+
+func kdfBody(password []byte, salt []byte, flags uint32, n uint64, r uint32, p uint32, t uint32) []byte {
+	key := "yescrypt"
+	if flags&flagPrehash != 0 {
+		key = "yescrypt-prehash"
+	}
+	password = hmacSum([]byte(key), password)
+
+	blocks := pbkdf2.Key(password, salt, 1, 128*int(r)*int(p), sha256.New)
+
+	// The password of the final key derivation is the first 32 bytes of the initial blocks
+	password = append([]byte(nil), blocks[:32]...)
+
+	b := make([]uint32, len(blocks)/4)
+	for i := range b {
+		b[i] = binary.LittleEndian.Uint32(blocks[i*4:])
+	}
+
+	password = smix(b, r, n, p, t, flags, password)
+
+	for i := range b {
+		binary.LittleEndian.PutUint32(blocks[i*4:], b[i])
+	}
+
+	dk := pbkdf2.Key(password, blocks, 1, 32, sha256.New)
+	if flags&flagPrehash != 0 {
+		return dk
+	}
+
+	// The final steps match those of SCRAM (RFC 5802)
+	clientKey := hmacSum(dk, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+
+	return storedKey[:]
+}
+
+type pwxformCtx struct {
+	s0, s1, s2 []uint32
+	w          uint32
+}
+
+// smix computes B = SMix(B) for all p blocks and returns the updated password of the final key derivation. The words
+// of b are in the order of the byte sequence.
+func smix(b []uint32, r uint32, n uint64, p uint32, t uint32, flags uint32, password []byte) []byte {
+	s := uint64(32 * r)
+	v := make([]uint32, s*n)
+	xy := make([]uint32, 2*s)
+	sBoxes := make([]uint32, sWords*int(p))
+	ctxs := make([]*pwxformCtx, p)
+
+	nChunk := n / uint64(p)
+	nLoopAll := nChunk
+	if t <= 1 {
+		if t != 0 {
+			nLoopAll *= 2
+		}
+		nLoopAll = (nLoopAll + 2) / 3
+	} else {
+		nLoopAll *= uint64(t - 1)
+	}
+	nLoopRW := nLoopAll / uint64(p)
+
+	nChunk &^= 1
+	nLoopAll = (nLoopAll + 1) &^ 1
+	nLoopRW = (nLoopRW + 1) &^ 1
+
+	vChunk := uint64(0)
+	for i := uint64(0); i < uint64(p); i++ {
+		np := nChunk
+		if i == uint64(p)-1 {
+			np = n - vChunk
+		}
+		bp := b[i*s : (i+1)*s]
+		vp := v[vChunk*s : (vChunk+np)*s]
+
+		// The S-boxes are initialized using the first 128 bytes of the block
+		si := sBoxes[i*sWords : (i+1)*sWords]
+		smix1(bp[:32], 1, sWords/32, 0, si, xy, nil)
+		ctxs[i] = &pwxformCtx{
+			s2: si[:sWords/3],
+			s1: si[sWords/3 : sWords/3*2],
+			s0: si[sWords/3*2:],
+		}
+
+		if i == 0 {
+			last := make([]byte, 64)
+			for k, w := range bp[s-16:] {
+				binary.LittleEndian.PutUint32(last[k*4:], w)
+			}
+			password = hmacSum(last, password)
+		}
+
+		smix1(bp, r, np, flags, vp, xy, ctxs[i])
+		smix2(bp, r, p2floor(np), nLoopRW, flags, vp, xy, ctxs[i])
+
+		vChunk += nChunk
+	}
+
+	for i := uint64(0); i < uint64(p); i++ {
+		smix2(b[i*s:(i+1)*s], r, n, nLoopAll-nLoopRW, flags&^flagRW, v, xy, ctxs[i])
+	}
+
+	return password
+}
+
+// smix1 computes the first loop of SMix which fills V sequentially
+func smix1(b []uint32, r uint32, n uint64, flags uint32, v []uint32, xy []uint32, ctx *pwxformCtx) {
+	s := uint64(32 * r)
+	x, y := xy[:s], xy[s:2*s]
+
+	shuffle(x, b)
+	for i := uint64(0); i < n; i++ {
+		copy(v[i*s:(i+1)*s], x)
+		if flags&flagRW != 0 && i > 1 {
+			j := wrap(integerify(x, r), i)
+			xor(x, v[j*s:(j+1)*s])
+		}
+		blockmix(x, y, r, ctx)
+	}
+	unshuffle(b, x)
+}
+
+// smix2 computes the second loop of SMix which reads V at data-dependent positions and updates V in read-write mode
+func smix2(b []uint32, r uint32, n uint64, nLoop uint64, flags uint32, v []uint32, xy []uint32, ctx *pwxformCtx) {
+	s := uint64(32 * r)
+	x, y := xy[:s], xy[s:2*s]
+
+	shuffle(x, b)
+	for i := uint64(0); i < nLoop; i++ {
+		j := integerify(x, r) & (n - 1)
+		xor(x, v[j*s:(j+1)*s])
+		if flags&flagRW != 0 {
+			copy(v[j*s:(j+1)*s], x)
+		}
+		blockmix(x, y, r, ctx)
+	}
+	unshuffle(b, x)
+}
+
+func blockmix(b []uint32, y []uint32, r uint32, ctx *pwxformCtx) {
+	if ctx == nil {
+		blockmixSalsa8(b, y, r)
+		return
+	}
+	blockmixPwxform(b, r, ctx)
+}
+
+func blockmixSalsa8(b []uint32, y []uint32, r uint32) {
+	x := make([]uint32, 16)
+	copy(x, b[(2*r-1)*16:])
+
+	for i := uint32(0); i < 2*r; i++ {
+		xor(x, b[i*16:(i+1)*16])
+		salsa20(x, 8)
+		copy(y[i*16:], x)
+	}
+
+	for i := uint32(0); i < r; i++ {
+		copy(b[i*16:(i+1)*16], y[(2*i)*16:])
+		copy(b[(i+r)*16:(i+r+1)*16], y[(2*i+1)*16:])
+	}
+}
+
+// blockmixPwxform processes the block in 64-byte sub-blocks which equals the size of a salsa20 block with the
+// supported pwxform settings
+func blockmixPwxform(b []uint32, r uint32, ctx *pwxformCtx) {
+	r1 := 2 * r
+
+	x := make([]uint32, 16)
+	copy(x, b[(r1-1)*16:])
+
+	for i := uint32(0); i < r1; i++ {
+		xor(x, b[i*16:(i+1)*16])
+		ctx.pwxform(x)
+		copy(b[i*16:], x)
+	}
+
+	salsa20(b[(r1-1)*16:r1*16], 2)
+}
+
+func (c *pwxformCtx) pwxform(x []uint32) {
+	s0, s1, s2, w := c.s0, c.s1, c.s2, c.w
+
+	for i := 0; i < pwxRounds; i++ {
+		for j := 0; j < pwxGather; j++ {
+			lane := x[j*pwxSimple*2 : (j+1)*pwxSimple*2]
+			p0 := s0[(lane[0]&sMask)/4:]
+			p1 := s1[(lane[1]&sMask)/4:]
+
+			for k := 0; k < pwxSimple; k++ {
+				v := uint64(lane[2*k+1]) * uint64(lane[2*k])
+				v += uint64(p0[2*k+1])<<32 | uint64(p0[2*k])
+				v ^= uint64(p1[2*k+1])<<32 | uint64(p1[2*k])
+				lane[2*k] = uint32(v)
+				lane[2*k+1] = uint32(v >> 32)
+			}
+
+			if i != 0 && i != pwxRounds-1 {
+				copy(s2[w:], lane)
+				w += pwxSimple * 2
+			}
+		}
+	}
+
+	c.s0, c.s1, c.s2 = s2, s0, s1
+	c.w = w & (sWords/3 - 1)
+}
+
+// salsa20 applies the salsa20 core to a block in the shuffled word order
+func salsa20(b []uint32, rounds int) {
+	var x [16]uint32
+	for i := range x {
+		x[i*5%16] = b[i]
+	}
+
+	for i := 0; i < rounds; i += 2 {
+		// Columns
+		x[4] ^= rotl(x[0]+x[12], 7)
+		x[8] ^= rotl(x[4]+x[0], 9)
+		x[12] ^= rotl(x[8]+x[4], 13)
+		x[0] ^= rotl(x[12]+x[8], 18)
+
+		x[9] ^= rotl(x[5]+x[1], 7)
+		x[13] ^= rotl(x[9]+x[5], 9)
+		x[1] ^= rotl(x[13]+x[9], 13)
+		x[5] ^= rotl(x[1]+x[13], 18)
+
+		x[14] ^= rotl(x[10]+x[6], 7)
+		x[2] ^= rotl(x[14]+x[10], 9)
+		x[6] ^= rotl(x[2]+x[14], 13)
+		x[10] ^= rotl(x[6]+x[2], 18)
+
+		x[3] ^= rotl(x[15]+x[11], 7)
+		x[7] ^= rotl(x[3]+x[15], 9)
+		x[11] ^= rotl(x[7]+x[3], 13)
+		x[15] ^= rotl(x[11]+x[7], 18)
+
+		// Rows
+		x[1] ^= rotl(x[0]+x[3], 7)
+		x[2] ^= rotl(x[1]+x[0], 9)
+		x[3] ^= rotl(x[2]+x[1], 13)
+		x[0] ^= rotl(x[3]+x[2], 18)
+
+		x[6] ^= rotl(x[5]+x[4], 7)
+		x[7] ^= rotl(x[6]+x[5], 9)
+		x[4] ^= rotl(x[7]+x[6], 13)
+		x[5] ^= rotl(x[4]+x[7], 18)
+
+		x[11] ^= rotl(x[10]+x[9], 7)
+		x[8] ^= rotl(x[11]+x[10], 9)
+		x[9] ^= rotl(x[8]+x[11], 13)
+		x[10] ^= rotl(x[9]+x[8], 18)
+
+		x[12] ^= rotl(x[15]+x[14], 7)
+		x[13] ^= rotl(x[12]+x[15], 9)
+		x[14] ^= rotl(x[13]+x[12], 13)
+		x[15] ^= rotl(x[14]+x[13], 18)
+	}
+
+	for i := range x {
+		b[i] += x[i*5%16]
+	}
+}
+
+func rotl(v uint32, n uint) uint32 {
+	return v<<n | v>>(32-n)
+}
+
+// shuffle copies the words of b into x in the shuffled order of the reference implementation
+func shuffle(x []uint32, b []uint32) {
+	for k := 0; k < len(b); k += 16 {
+		for i := 0; i < 16; i++ {
+			x[k+i] = b[k+i*5%16]
+		}
+	}
+}
+
+// unshuffle reverses shuffle
+func unshuffle(b []uint32, x []uint32) {
+	for k := 0; k < len(b); k += 16 {
+		for i := 0; i < 16; i++ {
+			b[k+i*5%16] = x[k+i]
+		}
+	}
+}
+
+// integerify returns the first 64 bits of the last 64-byte sub-block in the shuffled order
+func integerify(x []uint32, r uint32) uint64 {
+	last := x[(2*r-1)*16:]
+	return uint64(last[13])<<32 | uint64(last[0])
+}
+
+func p2floor(x uint64) uint64 {
+	for y := x & (x - 1); y != 0; y = x & (x - 1) {
+		x = y
+	}
+	return x
+}
+
+func wrap(x uint64, i uint64) uint64 {
+	n := p2floor(i)
+	return (x & (n - 1)) + (i - n)
+}
+
+func xor(dst []uint32, src []uint32) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+func hmacSum(key []byte, message []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// encode64 encodes groups of 3 bytes in little-endian order
+func encode64(src []byte) string {
+	var b strings.Builder
+
+	for i := 0; i < len(src); {
+		var value, bits uint32
+		for ; bits < 24 && i < len(src); i++ {
+			value |= uint32(src[i]) << bits
+			bits += 8
+		}
+		for n := uint32(0); n < bits; n += 6 {
+			b.WriteByte(itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+
+	return b.String()
+}
+
+// decode64 reverses encode64. Returns false if the encoding is invalid.
+func decode64(src string) ([]byte, bool) {
+	var dst []byte
+
+	for len(src) > 0 {
+		var value, bits uint32
+		for ; bits < 24 && len(src) > 0; src = src[1:] {
+			c := strings.IndexByte(itoa64, src[0])
+			if c < 0 {
+				return nil, false
+			}
+			value |= uint32(c) << bits
+			bits += 6
+		}
+
+		// A group encodes at least one byte and unused bits must be zero
+		if bits < 12 {
+			return nil, false
+		}
+		for ; bits >= 8; bits -= 8 {
+			dst = append(dst, byte(value))
+			value >>= 8
+		}
+		if value != 0 {
+			return nil, false
+		}
+	}
+
+	if len(dst) > 64 {
+		return nil, false
+	}
+
+	return dst, true
+}
+
+// decode64Uint32 decodes a variable-length encoded integer which is at least min. Returns the remaining string.
+func decode64Uint32(src string, min uint32) (uint32, string, bool) {
+	if len(src) == 0 {
+		return 0, src, false
+	}
+
+	c := uint64(strings.IndexByte(itoa64, src[0]))
+	if c > 63 {
+		return 0, src, false
+	}
+	src = src[1:]
+
+	start, end, chars, bits := uint64(0), uint64(47), 1, uint64(0)
+	value := uint64(min)
+	for c > end {
+		value += (end + 1 - start) << bits
+		start = end + 1
+		end = start + (62-end)/2
+		chars++
+		bits += 6
+	}
+	value += (c - start) << bits
+
+	for ; chars > 1; chars-- {
+		if len(src) == 0 {
+			return 0, src, false
+		}
+		c := uint64(strings.IndexByte(itoa64, src[0]))
+		if c > 63 {
+			return 0, src, false
+		}
+		src = src[1:]
+		bits -= 6
+		value += c << bits
+	}
+
+	if value > math.MaxUint32 {
+		return 0, src, false
+	}
+
+	return uint32(value), src, true
+}
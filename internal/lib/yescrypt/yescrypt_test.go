@@ -0,0 +1,211 @@
+package yescrypt_test
+
+import (
+	"github.com/neuspaces/terraform-provider-system/internal/lib/yescrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc     string
+		Password string
+		Setting  string
+		Expect   string
+	}
+
+	// Test vectors from libxcrypt
+	tcs := []testCase{
+		{
+			Desc:     "default parameters",
+			Password: "secret",
+			Setting:  "$y$j9T$abcdefghijklmnop",
+			Expect:   "$y$j9T$abcdefghijklmnop$3dL1LkYnZM.OVXuVdnnaKVDlLYT92dRwOzDZ5XiVCe.",
+		},
+		{
+			Desc:     "default parameters and salt of mkpasswd",
+			Password: "secret",
+			Setting:  "$y$j9T$F5Jx5fExrKuPp53xLKQ..1",
+			Expect:   "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$GmcwIgvdUC9qLWcKCi6gklUa1dM3ziD43YxYNURLKy0",
+		},
+		{
+			Desc:     "empty password",
+			Password: "",
+			Setting:  "$y$j9T$abcdefghijklmnop",
+			Expect:   "$y$j9T$abcdefghijklmnop$pLCmL.WFP4llpuknjZB0lCp8KKEVl43CyXQupwneDY0",
+		},
+		{
+			Desc:     "long password",
+			Password: strings.Repeat("a", 200),
+			Setting:  "$y$j9T$abcdefghijklmnop",
+			Expect:   "$y$j9T$abcdefghijklmnop$eWgl3yeNS1Bc2lNyQta1DM9ntSbdqhUlSdvrXR5McA.",
+		},
+		{
+			Desc:     "non-ascii password",
+			Password: "pässwörd ✓",
+			Setting:  "$y$j9T$abcdefghijklmnop",
+			Expect:   "$y$j9T$abcdefghijklmnop$f9qVmkWgEfv.LWPrrFOHKCEA7W7Le3u9gBY5HWOhL1/",
+		},
+		{
+			Desc:     "empty salt",
+			Password: "secret",
+			Setting:  "$y$j9T$",
+			Expect:   "$y$j9T$$kqN0Js9nF7eERE.51024UC4tcxd3UwTViEvi9nw9G41",
+		},
+		{
+			Desc:     "low cost without pre-hash",
+			Password: "secret",
+			Setting:  "$y$j94$abcd",
+			Expect:   "$y$j94$abcd$vHrpoY4ui40ggQYKt5kWuKV2eGxo.RlsFGjEVsyDq09",
+		},
+		{
+			Desc:     "custom cost",
+			Password: "secret",
+			Setting:  "$y$j75$saltsaltsalt",
+			Expect:   "$y$j75$saltsaltsalt$jyf/lxrpdyAIshWRo1x5DRC6KIE7sNRRtjcomR3PgHA",
+		},
+		{
+			Desc:     "parallelism",
+			Password: "secret",
+			Setting:  "$y$j9T..$abcdefgh",
+			Expect:   "$y$j9T..$abcdefgh$TkUiCYdmIW5nvQu27HHrxkGDpCIRiEr1XkA3jnJUhm5",
+		},
+		{
+			Desc:     "time",
+			Password: "secret",
+			Setting:  "$y$j8T/0$abcdefgh",
+			Expect:   "$y$j8T/0$abcdefgh$aHoRqEAM29NWOS.l2sDPA8TYmPuVbzHLgBALPKqpaZ9",
+		},
+		{
+			Desc:     "parallelism and time",
+			Password: "secret",
+			Setting:  "$y$j7T0./$abcdefgh",
+			Expect:   "$y$j7T0./$abcdefgh$RPmpBU/TVnHgRZSxc8ZjMtUNlgjyGDHTLG2ijvSbJF8",
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			hash, err := yescrypt.Hash(tc.Password, tc.Setting)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, hash)
+
+			// The setting of an existing hash derives the same hash
+			hash, err = yescrypt.Hash(tc.Password, tc.Expect)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expect, hash)
+
+			assert.True(t, yescrypt.Verify(tc.Password, tc.Expect))
+			assert.False(t, yescrypt.Verify(tc.Password+"x", tc.Expect))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		Desc          string
+		Hash          string
+		ExpectSetting string
+		ExpectErr     error
+	}
+
+	tcs := []testCase{
+		{
+			Desc:          "hash",
+			Hash:          "$y$j9T$abcdefghijklmnop$3dL1LkYnZM.OVXuVdnnaKVDlLYT92dRwOzDZ5XiVCe.",
+			ExpectSetting: "$y$j9T$abcdefghijklmnop",
+		},
+		{
+			Desc:          "setting",
+			Hash:          "$y$j9T$abcdefghijklmnop",
+			ExpectSetting: "$y$j9T$abcdefghijklmnop",
+		},
+		{
+			Desc:          "setting with trailing separator",
+			Hash:          "$y$j9T$abcdefghijklmnop$",
+			ExpectSetting: "$y$j9T$abcdefghijklmnop",
+		},
+		{
+			Desc:      "invalid: other scheme sha512-crypt",
+			Hash:      "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+			ExpectErr: yescrypt.ErrInvalidHash,
+		},
+		{
+			Desc:      "invalid: salt with unused bits",
+			Hash:      "$y$j9T$ab$3dL1LkYnZM.OVXuVdnnaKVDlLYT92dRwOzDZ5XiVCe.",
+			ExpectErr: yescrypt.ErrInvalidHash,
+		},
+		{
+			Desc:      "invalid: truncated parameters",
+			Hash:      "$y$j",
+			ExpectErr: yescrypt.ErrInvalidHash,
+		},
+		{
+			Desc:      "invalid: locked",
+			Hash:      "!$y$j9T$abcdefghijklmnop$3dL1LkYnZM.OVXuVdnnaKVDlLYT92dRwOzDZ5XiVCe.",
+			ExpectErr: yescrypt.ErrInvalidHash,
+		},
+		{
+			Desc:      "unsupported: classic scrypt",
+			Hash:      "$y$.9T$abcdefghijklmnop",
+			ExpectErr: yescrypt.ErrUnsupported,
+		},
+		{
+			Desc:      "unsupported: other flavor",
+			Hash:      "$y$i9T$abcdefghijklmnop",
+			ExpectErr: yescrypt.ErrUnsupported,
+		},
+		{
+			Desc:      "unsupported: memory exceeds maximum",
+			Hash:      "$y$jGT$abcdefghijklmnop",
+			ExpectErr: yescrypt.ErrUnsupported,
+		},
+	}
+
+	for _, tc := range tcs {
+		tc := tc
+		t.Run(tc.Desc, func(t *testing.T) {
+			t.Parallel()
+
+			setting, err := yescrypt.Parse(tc.Hash)
+			if tc.ExpectErr != nil {
+				assert.ErrorIs(t, err, tc.ExpectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectSetting, setting)
+		})
+	}
+}
+
+func TestVerify_otherScheme(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, yescrypt.Verify("Hello world!", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"))
+}
+
+func TestNewSetting(t *testing.T) {
+	t.Parallel()
+
+	setting, err := yescrypt.NewSetting()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(setting, yescrypt.Prefix+yescrypt.DefaultParams+"$"))
+
+	parsed, err := yescrypt.Parse(setting)
+	require.NoError(t, err)
+	assert.Equal(t, setting, parsed)
+
+	hash, err := yescrypt.Hash("password", setting)
+	require.NoError(t, err)
+	assert.True(t, yescrypt.Verify("password", hash))
+}
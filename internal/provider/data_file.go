@@ -2,13 +2,17 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
 	"io"
 	"path"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/neuspaces/terraform-provider-system/internal/client"
 	"github.com/neuspaces/terraform-provider-system/internal/validate"
 )
@@ -16,16 +20,23 @@ import (
 const dataFileName = "system_file"
 
 const (
-	dataFileAttrId       = "id"
-	dataFileAttrPath     = resourceFileAttrPath
-	dataFileAttrMode     = resourceFileAttrMode
-	dataFileAttrUser     = resourceFileAttrUser
-	dataFileAttrUid      = resourceFileAttrUid
-	dataFileAttrGroup    = resourceFileAttrGroup
-	dataFileAttrGid      = resourceFileAttrGid
-	dataFileAttrContent  = resourceFileAttrContent
-	dataFileAttrMd5Sum   = resourceFileAttrMd5Sum
-	dataFileAttrBasename = resourceFileAttrBasename
+	dataFileAttrId            = "id"
+	dataFileAttrPath          = resourceFileAttrPath
+	dataFileAttrMode          = resourceFileAttrMode
+	dataFileAttrUser          = resourceFileAttrUser
+	dataFileAttrUid           = resourceFileAttrUid
+	dataFileAttrGroup         = resourceFileAttrGroup
+	dataFileAttrGid           = resourceFileAttrGid
+	dataFileAttrContent       = resourceFileAttrContent
+	dataFileAttrContentBase64 = resourceFileAttrContentBase64
+	dataFileAttrContentLimit  = "content_limit"
+	dataFileAttrMd5Sum        = resourceFileAttrMd5Sum
+	dataFileAttrSha256Sum     = resourceFileAttrSha256Sum
+	dataFileAttrBasename      = resourceFileAttrBasename
+)
+
+const (
+	dataFileContentLimitDefault = 1048576 // bytes
 )
 
 func dataFile() *schema.Resource {
@@ -80,11 +91,29 @@ func dataFile() *schema.Resource {
 				Computed:    true,
 				Sensitive:   true,
 			},
+			dataFileAttrContentBase64: {
+				Description: fmt.Sprintf("Base64 encoded content of the file. Use instead of `%s` for binary files which are not valid UTF-8.", dataFileAttrContent),
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			dataFileAttrContentLimit: {
+				Description:  fmt.Sprintf("Maximum size of the file in bytes. Define a reasonable limit to prevent unintended growth of the terraform state. If the file exceeds this limit, the data source fails. Defaults to `%d`.", dataFileContentLimitDefault),
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      dataFileContentLimitDefault,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
 			dataFileAttrMd5Sum: {
 				Description: "MD5 checksum of the remote file contents on the system in base64 encoding.",
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
+			dataFileAttrSha256Sum: {
+				Description: "SHA-256 checksum of the remote file contents on the system in hex encoding.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			dataFileAttrBasename: {
 				Description: fmt.Sprintf("Base name of the file. Returns the last element of path. Example: Given the attribute `%[1]s` is `/path/to/file.txt`, the `%[2]s` is `file.txt`.", dataFileAttrPath, dataFileAttrBasename),
 				Type:        schema.TypeString,
@@ -105,6 +134,7 @@ func dataFileSetResourceData(r *client.File, d *schema.ResourceData) diag.Diagno
 	_ = d.Set(dataFileAttrGid, r.Gid)
 
 	_ = d.Set(dataFileAttrMd5Sum, r.Md5Sum)
+	_ = d.Set(dataFileAttrSha256Sum, r.Sha256Sum)
 	_ = d.Set(dataFileAttrBasename, path.Base(r.Path))
 
 	if r.Content != nil {
@@ -113,8 +143,10 @@ func dataFileSetResourceData(r *client.File, d *schema.ResourceData) diag.Diagno
 			return diag.FromErr(err)
 		}
 		_ = d.Set(dataFileAttrContent, string(content))
+		_ = d.Set(dataFileAttrContentBase64, base64.StdEncoding.EncodeToString(content))
 	} else {
 		_ = d.Set(dataFileAttrContent, nil)
+		_ = d.Set(dataFileAttrContentBase64, nil)
 	}
 
 	return nil
@@ -127,11 +159,15 @@ func dataFileRead(ctx context.Context, d *schema.ResourceData, meta interface{})
 	}
 
 	includeContentOpt := client.FileClientIncludeContent(true)
-	c := client.NewFileClient(p.System, includeContentOpt, client.FileClientCompression(true))
+	contentLimitOpt := client.FileClientContentLimit(int64(d.Get(dataFileAttrContentLimit).(int)))
+	c := client.NewFileClient(p.System, includeContentOpt, contentLimitOpt, client.FileClientCompression(true))
 
 	filePath := d.Get(dataFileAttrPath).(string)
 
 	r, err := c.Get(ctx, filePath)
+	if errors.Is(err, client.ErrFileContentLimit) {
+		return newDetailedDiagnostic(diag.Error, "file exceeds limit", fmt.Sprintf("the size of %q exceeds the limit of %d bytes", filePath, d.Get(dataFileAttrContentLimit).(int)), cty.GetAttrPath(dataFileAttrContentLimit))
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
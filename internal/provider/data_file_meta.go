@@ -2,9 +2,11 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
 	"path"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -15,15 +17,27 @@ import (
 const dataFileMetaName = "system_file_meta"
 
 const (
-	dataFileMetaAttrId       = "id"
-	dataFileMetaAttrPath     = resourceFileAttrPath
-	dataFileMetaAttrMode     = resourceFileAttrMode
-	dataFileMetaAttrUser     = resourceFileAttrUser
-	dataFileMetaAttrUid      = resourceFileAttrUid
-	dataFileMetaAttrGroup    = resourceFileAttrGroup
-	dataFileMetaAttrGid      = resourceFileAttrGid
-	dataFileMetaAttrMd5Sum   = resourceFileAttrMd5Sum
-	dataFileMetaAttrBasename = resourceFileAttrBasename
+	dataFileMetaAttrId           = "id"
+	dataFileMetaAttrPath         = resourceFileAttrPath
+	dataFileMetaAttrAllowMissing = "allow_missing"
+	dataFileMetaAttrExists       = "exists"
+	dataFileMetaAttrType         = "type"
+	dataFileMetaAttrMode         = resourceFileAttrMode
+	dataFileMetaAttrUser         = resourceFileAttrUser
+	dataFileMetaAttrUid          = resourceFileAttrUid
+	dataFileMetaAttrGroup        = resourceFileAttrGroup
+	dataFileMetaAttrGid          = resourceFileAttrGid
+	dataFileMetaAttrSize         = "size"
+	dataFileMetaAttrInode        = "inode"
+	dataFileMetaAttrLinks        = "links"
+	dataFileMetaAttrAtime        = "atime"
+	dataFileMetaAttrMtime        = "mtime"
+	dataFileMetaAttrCtime        = "ctime"
+	dataFileMetaAttrTarget       = "target"
+	dataFileMetaAttrMd5Sum       = resourceFileAttrMd5Sum
+	dataFileMetaAttrSha256Sum    = resourceFileAttrSha256Sum
+	dataFileMetaAttrSha512Sum    = "sha512sum"
+	dataFileMetaAttrBasename     = resourceFileAttrBasename
 )
 
 func dataFileMeta() *schema.Resource {
@@ -47,6 +61,22 @@ func dataFileMeta() *schema.Resource {
 				ForceNew:         true,
 				ValidateDiagFunc: validate.AbsolutePath(),
 			},
+			dataFileMetaAttrAllowMissing: {
+				Description: fmt.Sprintf("If `true`, a missing file sets `%s` to `false` instead of failing. Defaults to `false`.", dataFileMetaAttrExists),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			dataFileMetaAttrExists: {
+				Description: "`true` if the file exists.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			dataFileMetaAttrType: {
+				Description: "Type of the file. One of `file`, `directory`, `symlink`, or `other`. Symbolic links are not followed.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			dataFileMetaAttrMode: {
 				Description: "Permissions of the file in octal format like `755`.",
 				Type:        schema.TypeString,
@@ -72,8 +102,53 @@ func dataFileMeta() *schema.Resource {
 				Type:        schema.TypeInt,
 				Computed:    true,
 			},
+			dataFileMetaAttrSize: {
+				Description: "Size of the file in bytes.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			dataFileMetaAttrInode: {
+				Description: "Inode number of the file.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			dataFileMetaAttrLinks: {
+				Description: "Number of hard links to the file.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			dataFileMetaAttrAtime: {
+				Description: "Time of the last access of the file in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFileMetaAttrMtime: {
+				Description: "Time of the last modification of the file contents in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFileMetaAttrCtime: {
+				Description: "Time of the last status change of the file in RFC 3339 format.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFileMetaAttrTarget: {
+				Description: "Target of the symbolic link. Empty if the file is not a symbolic link.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			dataFileMetaAttrMd5Sum: {
-				Description: "MD5 checksum of the remote file contents on the system in base64 encoding.",
+				Description: "MD5 checksum of the remote file contents on the system in base64 encoding. Empty if the file is not a regular file.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFileMetaAttrSha256Sum: {
+				Description: "SHA-256 checksum of the remote file contents on the system in hex encoding. Empty if the file is not a regular file.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFileMetaAttrSha512Sum: {
+				Description: "SHA-512 checksum of the remote file contents on the system in hex encoding. Empty if the file is not a regular file.",
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
@@ -86,17 +161,28 @@ func dataFileMeta() *schema.Resource {
 	}
 }
 
-func dataFileMetaSetResourceData(r *client.File, d *schema.ResourceData) diag.Diagnostics {
+func dataFileMetaSetResourceData(r *client.FileMeta, d *schema.ResourceData) diag.Diagnostics {
 	d.SetId(r.Path)
 
 	_ = d.Set(dataFileMetaAttrPath, r.Path)
+	_ = d.Set(dataFileMetaAttrExists, true)
+	_ = d.Set(dataFileMetaAttrType, string(r.Type))
 	_ = d.Set(dataFileMetaAttrMode, filemode.Mode(r.Mode).String())
 	_ = d.Set(dataFileMetaAttrUser, r.User)
 	_ = d.Set(dataFileMetaAttrUid, r.Uid)
 	_ = d.Set(dataFileMetaAttrGroup, r.Group)
 	_ = d.Set(dataFileMetaAttrGid, r.Gid)
+	_ = d.Set(dataFileMetaAttrSize, int(r.Size))
+	_ = d.Set(dataFileMetaAttrInode, int(r.Inode))
+	_ = d.Set(dataFileMetaAttrLinks, r.Links)
+	_ = d.Set(dataFileMetaAttrAtime, r.AccessTime.UTC().Format(time.RFC3339))
+	_ = d.Set(dataFileMetaAttrMtime, r.ModifiedTime.UTC().Format(time.RFC3339))
+	_ = d.Set(dataFileMetaAttrCtime, r.ChangeTime.UTC().Format(time.RFC3339))
+	_ = d.Set(dataFileMetaAttrTarget, r.Target)
 
 	_ = d.Set(dataFileMetaAttrMd5Sum, r.Md5Sum)
+	_ = d.Set(dataFileMetaAttrSha256Sum, r.Sha256Sum)
+	_ = d.Set(dataFileMetaAttrSha512Sum, r.Sha512Sum)
 	_ = d.Set(dataFileMetaAttrBasename, path.Base(r.Path))
 
 	return nil
@@ -108,11 +194,20 @@ func dataFileMetaRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diagErr
 	}
 
-	c := client.NewFileClient(p.System)
+	c := client.NewFileMetaClient(p.System)
 
 	filePath := d.Get(dataFileMetaAttrPath).(string)
 
 	r, err := c.Get(ctx, filePath)
+	if errors.Is(err, client.ErrFileMetaNotFound) && d.Get(dataFileMetaAttrAllowMissing).(bool) {
+		// A missing file is represented by the attribute `exists`
+		d.SetId(filePath)
+
+		_ = d.Set(dataFileMetaAttrExists, false)
+		_ = d.Set(dataFileMetaAttrBasename, path.Base(filePath))
+
+		return nil
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
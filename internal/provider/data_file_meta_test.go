@@ -1,6 +1,7 @@
 package provider_test
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -36,9 +37,93 @@ func TestAccDataFileMeta_read(t *testing.T) {
 						resource.TestCheckResourceAttr("data.system_file_meta.test", "uid", "0"),
 						resource.TestCheckResourceAttr("data.system_file_meta.test", "group", "root"),
 						resource.TestCheckResourceAttr("data.system_file_meta.test", "gid", "0"),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "exists", "true"),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "type", "file"),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "size", "12"),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "links", "1"),
+						resource.TestCheckResourceAttrSet("data.system_file_meta.test", "inode"),
+						resource.TestCheckResourceAttrSet("data.system_file_meta.test", "mtime"),
+						// echo -n 'hello world!' | sha256sum
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "sha256sum", "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+						// echo -n 'hello world!' | sha512sum
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "sha512sum", "db9b1cd3262dee37756a09b9064973589847caa8e53d31a9d142ea2701b1b28abd97838bb9a27068ba305dc8d04a45a1fcf079de54d607666996b3cc54f6b67c"),
 					),
 				},
 			},
 		})
 	})
 }
+
+func TestAccDataFileMeta_read_link(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		linkPath := testRunLinkPath(target, testConfig.linkName)
+		targetPath := testRunLinkPath(target, testConfig.targetName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", targetPath,
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						testAccLinkBlock("test", linkPath, targetPath,
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file_meta", "test",
+							tfbuild.AttributeTraversal("path", tfbuild.TraversalResourceAttribute("system_link", "test", "path")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "type", "symlink"),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "target", targetPath),
+						// echo -n 'hello world!' | sha256sum
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "sha256sum", "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccDataFileMeta_allow_missing(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_file_meta", "test",
+							tfbuild.AttributeString("path", testRunFilePath(target, testConfig.fileName)),
+							tfbuild.AttributeBool("allow_missing", true),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "id", testRunFilePath(target, testConfig.fileName)),
+						resource.TestCheckResourceAttr("data.system_file_meta.test", "exists", "false"),
+						resource.TestCheckNoResourceAttr("data.system_file_meta.test", "sha256sum"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_file_meta", "test",
+							tfbuild.AttributeString("path", testRunFilePath(target, testConfig.fileName)),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`file meta data source\s+file not found`),
+				},
+			},
+		})
+	})
+}
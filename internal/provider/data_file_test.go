@@ -1,6 +1,7 @@
 package provider_test
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -44,3 +45,61 @@ func TestAccDataFile_read_content(t *testing.T) {
 		})
 	})
 }
+
+func TestAccDataFile_read_content_base64(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							// printf '\x00\xff\xfehello' | base64
+							tfbuild.AttributeString("content_base64", "AP/+aGVsbG8="),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeTraversal("path", tfbuild.TraversalResourceAttribute("system_file", "test", "path")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.test", "content_base64", "AP/+aGVsbG8="),
+						// printf '\x00\xff\xfehello' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("data.system_file.test", "md5sum", "nrK0ysuhMbuzbuvpzlBkKg=="),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccDataFile_fail_content_limit(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeTraversal("path", tfbuild.TraversalResourceAttribute("system_file", "test", "path")),
+							tfbuild.AttributeInt("content_limit", 8),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`file exceeds limit`),
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"path"
+	"time"
+)
+
+const dataFilesName = "system_files"
+
+const (
+	dataFilesAttrId        = "id"
+	dataFilesAttrPath      = "path"
+	dataFilesAttrPattern   = "pattern"
+	dataFilesAttrRecursive = "recursive"
+	dataFilesAttrMaxDepth  = "max_depth"
+	dataFilesAttrType      = "type"
+	dataFilesAttrSha256Sum = "sha256sum"
+	dataFilesAttrFiles     = "files"
+
+	dataFilesAttrFilesPath      = "path"
+	dataFilesAttrFilesBasename  = "basename"
+	dataFilesAttrFilesType      = "type"
+	dataFilesAttrFilesSize      = "size"
+	dataFilesAttrFilesMode      = "mode"
+	dataFilesAttrFilesUser      = "user"
+	dataFilesAttrFilesUid       = "uid"
+	dataFilesAttrFilesGroup     = "group"
+	dataFilesAttrFilesGid       = "gid"
+	dataFilesAttrFilesMtime     = "mtime"
+	dataFilesAttrFilesSha256Sum = "sha256sum"
+)
+
+func dataFiles() *schema.Resource {
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` lists the entries of a folder on the remote system.", dataFilesName),
+
+		ReadContext: dataFilesRead,
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			dataFilesAttrId: {
+				Description: "ID of the data source",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataFilesAttrPath: {
+				Description:      "Absolute path to the folder.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			dataFilesAttrPattern: {
+				Description: "Glob pattern like `*.conf` which is matched against the base name of the entries. Lists all entries if not set.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			dataFilesAttrRecursive: {
+				Description: "List the entries of sub folders. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			dataFilesAttrMaxDepth: {
+				Description:  fmt.Sprintf("Maximum depth of sub folders to list if `%s` is `true`. A depth of `1` lists only the entries of the folder. Unlimited if not set.", dataFilesAttrRecursive),
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				RequiredWith: []string{dataFilesAttrRecursive},
+			},
+			dataFilesAttrType: {
+				Description:  "Type of the entries to list. Supported types are `file`, `directory`, and `symlink`. Lists all types if not set.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(client.FileTypes(), false),
+			},
+			dataFilesAttrSha256Sum: {
+				Description: "Compute the SHA-256 checksum of regular files. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			dataFilesAttrFiles: {
+				Description: "Entries of the folder sorted by path.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						dataFilesAttrFilesPath: {
+							Description: "Absolute path to the entry.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesBasename: {
+							Description: "Base name of the entry.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesType: {
+							Description: "Type of the entry. One of `file`, `directory`, `symlink`, or `other`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesSize: {
+							Description: "Size of the entry in bytes.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataFilesAttrFilesMode: {
+							Description: "Permissions of the entry in octal format like `755`.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesUser: {
+							Description: "Name of the user who owns the entry",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesUid: {
+							Description: "ID of the user who owns the entry",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataFilesAttrFilesGroup: {
+							Description: "Name of the group that owns the entry",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesGid: {
+							Description: "ID of the group that owns the entry",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataFilesAttrFilesMtime: {
+							Description: "Time of the last modification of the entry in RFC 3339 format.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataFilesAttrFilesSha256Sum: {
+							Description: fmt.Sprintf("SHA-256 checksum of the contents of a regular file in hex encoding. Empty unless `%s` is `true`.", dataFilesAttrSha256Sum),
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenFilesEntries(entries []client.FilesEntry) []interface{} {
+	l := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		l = append(l, map[string]interface{}{
+			dataFilesAttrFilesPath:      e.Path,
+			dataFilesAttrFilesBasename:  path.Base(e.Path),
+			dataFilesAttrFilesType:      string(e.Type),
+			dataFilesAttrFilesSize:      int(e.Size),
+			dataFilesAttrFilesMode:      filemode.Mode(e.Mode).String(),
+			dataFilesAttrFilesUser:      e.User,
+			dataFilesAttrFilesUid:       e.Uid,
+			dataFilesAttrFilesGroup:     e.Group,
+			dataFilesAttrFilesGid:       e.Gid,
+			dataFilesAttrFilesMtime:     e.ModifiedTime.UTC().Format(time.RFC3339),
+			dataFilesAttrFilesSha256Sum: e.Sha256Sum,
+		})
+	}
+	return l
+}
+
+func dataFilesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFilesClient(p.System)
+
+	q := client.FilesQuery{
+		Path:      d.Get(dataFilesAttrPath).(string),
+		Pattern:   d.Get(dataFilesAttrPattern).(string),
+		Recursive: d.Get(dataFilesAttrRecursive).(bool),
+		MaxDepth:  d.Get(dataFilesAttrMaxDepth).(int),
+		Type:      client.FileType(d.Get(dataFilesAttrType).(string)),
+		Sha256Sum: d.Get(dataFilesAttrSha256Sum).(bool),
+	}
+
+	entries, err := c.List(ctx, q)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(q.Path)
+
+	_ = d.Set(dataFilesAttrFiles, flattenFilesEntries(entries))
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package provider_test
+
+import (
+	"path"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+)
+
+func TestAccDataFiles_list(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						testAccFileBlock("a", path.Join(folderPath, "a.conf"),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+						testAccFileBlock("b", path.Join(folderPath, "b.txt"),
+							tfbuild.AttributeString("content", "hello universe!"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+						testAccFolderBlock("sub", path.Join(folderPath, "sub"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+						testAccFileBlock("c", path.Join(folderPath, "sub", "c.conf"),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "sub")),
+						),
+						tfbuild.Data("system_files", "flat",
+							tfbuild.AttributeString("path", folderPath),
+							tfbuild.AttributeString("pattern", "*.conf"),
+							tfbuild.AttributeBool("sha256sum", true),
+							tfbuild.DependsOn(
+								tfbuild.TraversalResource("system_file", "a"),
+								tfbuild.TraversalResource("system_file", "b"),
+								tfbuild.TraversalResource("system_file", "c"),
+							),
+						),
+						tfbuild.Data("system_files", "recursive",
+							tfbuild.AttributeString("path", folderPath),
+							tfbuild.AttributeString("pattern", "*.conf"),
+							tfbuild.AttributeBool("recursive", true),
+							tfbuild.AttributeString("type", "file"),
+							tfbuild.DependsOn(
+								tfbuild.TraversalResource("system_file", "a"),
+								tfbuild.TraversalResource("system_file", "b"),
+								tfbuild.TraversalResource("system_file", "c"),
+							),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.#", "1"),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.path", path.Join(folderPath, "a.conf")),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.basename", "a.conf"),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.type", "file"),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.size", "12"),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.mode", "640"),
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.user", "root"),
+						// echo -n 'hello world!' | sha256sum
+						resource.TestCheckResourceAttr("data.system_files.flat", "files.0.sha256sum", "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+						resource.TestCheckResourceAttr("data.system_files.recursive", "files.#", "2"),
+						resource.TestCheckResourceAttr("data.system_files.recursive", "files.1.path", path.Join(folderPath, "sub", "c.conf")),
+						resource.TestCheckResourceAttr("data.system_files.recursive", "files.1.sha256sum", ""),
+					),
+				},
+			},
+		})
+	})
+}
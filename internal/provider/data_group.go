@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"strconv"
+)
+
+const dataGroupName = "system_group"
+
+const (
+	dataGroupAttrId      = "id"
+	dataGroupAttrName    = "name"
+	dataGroupAttrGid     = "gid"
+	dataGroupAttrMembers = "members"
+	dataGroupAttrSystem  = "system"
+)
+
+func dataGroup() *schema.Resource {
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` retrieves an existing group on the remote system by name or gid.", dataGroupName),
+
+		ReadContext: dataGroupRead,
+
+		Schema: map[string]*schema.Schema{
+			dataGroupAttrId: {
+				Description: "ID of the data source. The gid of the group.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataGroupAttrName: {
+				Description:  fmt.Sprintf("Name of the group. Exactly one of `%s` or `%s` is required.", dataGroupAttrName, dataGroupAttrGid),
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{dataGroupAttrName, dataGroupAttrGid},
+			},
+			dataGroupAttrGid: {
+				Description:  fmt.Sprintf("ID of the group. Exactly one of `%s` or `%s` is required.", dataGroupAttrName, dataGroupAttrGid),
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				ExactlyOneOf: []string{dataGroupAttrName, dataGroupAttrGid},
+			},
+			dataGroupAttrMembers: {
+				Description: "Names of the users which are supplementary members of the group in the order of the group database. Users with the group as primary group are not included.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			dataGroupAttrSystem: {
+				Description: "`true` if the group is a system group with a gid up to `SYS_GID_MAX` of `/etc/login.defs` or the group with gid 65534 (`nogroup` or `nobody`).",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAccountsClient(p.System)
+
+	key := d.Get(dataGroupAttrName).(string)
+	keyPath := cty.GetAttrPath(dataGroupAttrName)
+	if key == "" {
+		key = strconv.Itoa(d.Get(dataGroupAttrGid).(int))
+		keyPath = cty.GetAttrPath(dataGroupAttrGid)
+	}
+
+	g, err := c.GetGroup(ctx, key)
+	if err != nil {
+		if errors.Is(err, client.ErrAccountsGroupNotFound) {
+			return newDetailedDiagnostic(diag.Error, "group not found", fmt.Sprintf("group %s does not exist on the remote system", key), keyPath)
+		}
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(g.Gid))
+
+	_ = d.Set(dataGroupAttrName, g.Name)
+	_ = d.Set(dataGroupAttrGid, g.Gid)
+	_ = d.Set(dataGroupAttrMembers, g.Members)
+	_ = d.Set(dataGroupAttrSystem, g.System)
+
+	return nil
+}
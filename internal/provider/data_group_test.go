@@ -0,0 +1,78 @@
+package provider_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"regexp"
+	"testing"
+)
+
+func TestAccDataGroup_lookup(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+		groupA := testRunGroupName(testConfig.userName, "a")
+		groupB := testRunGroupName(testConfig.userName, "b")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("a", groupA),
+						testAccGroupBlock("b", groupB),
+						testAccUserBlock("test", userName,
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "a", "name")),
+							tfbuild.Attribute("groups", tfbuild.StringList(groupB)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_group", "b")),
+						),
+						tfbuild.Data("system_group", "by_name",
+							tfbuild.AttributeString("name", groupB),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "test")),
+						),
+						tfbuild.Data("system_group", "by_gid",
+							tfbuild.AttributeTraversal("gid", tfbuild.TraversalResourceAttribute("system_group", "a", "gid")),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttrPair("data.system_group.by_name", "gid", "system_group.b", "gid"),
+						resource.TestCheckResourceAttr("data.system_group.by_name", "members.#", "1"),
+						resource.TestCheckResourceAttr("data.system_group.by_name", "members.0", userName),
+						resource.TestCheckResourceAttr("data.system_group.by_name", "system", "false"),
+						resource.TestCheckResourceAttr("data.system_group.by_gid", "name", groupA),
+						resource.TestCheckResourceAttr("data.system_group.by_gid", "members.#", "0"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccDataGroup_notFound(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_group", "test",
+							tfbuild.AttributeString("name", testRunGroupName(testConfig.userName, "missing")),
+						),
+					))),
+					ExpectError: regexp.MustCompile(`group not found`),
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+)
+
+const dataGroupsName = "system_groups"
+
+const (
+	dataGroupsAttrId     = "id"
+	dataGroupsAttrSystem = "system"
+	dataGroupsAttrMinGid = "min_gid"
+	dataGroupsAttrMaxGid = "max_gid"
+	dataGroupsAttrGroups = "groups"
+
+	dataGroupsAttrGroupsName    = "name"
+	dataGroupsAttrGroupsGid     = "gid"
+	dataGroupsAttrGroupsMembers = "members"
+	dataGroupsAttrGroupsSystem  = "system"
+)
+
+func dataGroups() *schema.Resource {
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` lists the groups on the remote system.", dataGroupsName),
+
+		ReadContext: dataGroupsRead,
+
+		Schema: map[string]*schema.Schema{
+			dataGroupsAttrId: {
+				Description: "ID of the data source",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataGroupsAttrSystem: {
+				Description: "Set to `true` to list only system groups with a gid up to `SYS_GID_MAX` of `/etc/login.defs` and the group with gid 65534 (`nogroup` or `nobody`) or to `false` to list only regular groups. Lists all groups if not set.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			dataGroupsAttrMinGid: {
+				Description:  "Lists only groups with a gid greater than or equal to the value.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			dataGroupsAttrMaxGid: {
+				Description:  "Lists only groups with a gid less than or equal to the value.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			dataGroupsAttrGroups: {
+				Description: "Groups sorted by gid.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						dataGroupsAttrGroupsName: {
+							Description: "Name of the group.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataGroupsAttrGroupsGid: {
+							Description: "ID of the group.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataGroupsAttrGroupsMembers: {
+							Description: "Names of the users which are supplementary members of the group.",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						dataGroupsAttrGroupsSystem: {
+							Description: "`true` if the group is a system group.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenGroupAccounts(groups []client.GroupAccount) []interface{} {
+	l := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		l = append(l, map[string]interface{}{
+			dataGroupsAttrGroupsName:    g.Name,
+			dataGroupsAttrGroupsGid:     g.Gid,
+			dataGroupsAttrGroupsMembers: g.Members,
+			dataGroupsAttrGroupsSystem:  g.System,
+		})
+	}
+	return l
+}
+
+func dataGroupsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAccountsClient(p.System)
+
+	q := expandAccountsQuery(d, dataGroupsAttrSystem, dataGroupsAttrMinGid, dataGroupsAttrMaxGid)
+
+	groups, err := c.ListGroups(ctx, q)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := accountsQueryId(q)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+
+	_ = d.Set(dataGroupsAttrGroups, flattenGroupAccounts(groups))
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package provider_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"testing"
+)
+
+func TestAccDataGroups_filter(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		groupName := testRunGroupName(testConfig.userName, "a")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", groupName),
+						tfbuild.Data("system_groups", "range",
+							tfbuild.AttributeTraversal("min_gid", tfbuild.TraversalResourceAttribute("system_group", "test", "gid")),
+							tfbuild.AttributeTraversal("max_gid", tfbuild.TraversalResourceAttribute("system_group", "test", "gid")),
+						),
+						tfbuild.Data("system_groups", "system",
+							tfbuild.AttributeBool("system", true),
+							tfbuild.AttributeInt("max_gid", 0),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_groups.range", "groups.#", "1"),
+						resource.TestCheckResourceAttr("data.system_groups.range", "groups.0.name", groupName),
+						resource.TestCheckResourceAttr("data.system_groups.range", "groups.0.system", "false"),
+						resource.TestCheckResourceAttr("data.system_groups.system", "groups.#", "1"),
+						resource.TestCheckResourceAttr("data.system_groups.system", "groups.0.name", "root"),
+					),
+				},
+			},
+		})
+	})
+}
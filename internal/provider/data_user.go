@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"strconv"
+)
+
+const dataUserName = "system_user"
+
+const (
+	dataUserAttrId     = "id"
+	dataUserAttrName   = "name"
+	dataUserAttrUid    = "uid"
+	dataUserAttrGroup  = "group"
+	dataUserAttrGid    = "gid"
+	dataUserAttrGecos  = "gecos"
+	dataUserAttrHome   = "home"
+	dataUserAttrShell  = "shell"
+	dataUserAttrSystem = "system"
+)
+
+func dataUser() *schema.Resource {
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` retrieves an existing user on the remote system by name or uid.", dataUserName),
+
+		ReadContext: dataUserRead,
+
+		Schema: map[string]*schema.Schema{
+			dataUserAttrId: {
+				Description: "ID of the data source. The uid of the user.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUserAttrName: {
+				Description:  fmt.Sprintf("Name of the user. Exactly one of `%s` or `%s` is required.", dataUserAttrName, dataUserAttrUid),
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{dataUserAttrName, dataUserAttrUid},
+			},
+			dataUserAttrUid: {
+				Description:  fmt.Sprintf("ID of the user. Exactly one of `%s` or `%s` is required.", dataUserAttrName, dataUserAttrUid),
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				ExactlyOneOf: []string{dataUserAttrName, dataUserAttrUid},
+			},
+			dataUserAttrGroup: {
+				Description: "Name of the primary group of the user. Empty if the primary group does not exist.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUserAttrGid: {
+				Description: "ID of the primary group of the user.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			dataUserAttrGecos: {
+				Description: "GECOS field of the user which usually contains the full name.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUserAttrHome: {
+				Description: "Path to the home folder of the user.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUserAttrShell: {
+				Description: "Login shell of the user.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUserAttrSystem: {
+				Description: "`true` if the user is a system user with a uid up to `SYS_UID_MAX` of `/etc/login.defs` or the user `nobody` (65534).",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func dataUserRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAccountsClient(p.System)
+
+	key := d.Get(dataUserAttrName).(string)
+	keyPath := cty.GetAttrPath(dataUserAttrName)
+	if key == "" {
+		key = strconv.Itoa(d.Get(dataUserAttrUid).(int))
+		keyPath = cty.GetAttrPath(dataUserAttrUid)
+	}
+
+	u, err := c.GetUser(ctx, key)
+	if err != nil {
+		if errors.Is(err, client.ErrAccountsUserNotFound) {
+			return newDetailedDiagnostic(diag.Error, "user not found", fmt.Sprintf("user %s does not exist on the remote system", key), keyPath)
+		}
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(u.Uid))
+
+	_ = d.Set(dataUserAttrName, u.Name)
+	_ = d.Set(dataUserAttrUid, u.Uid)
+	_ = d.Set(dataUserAttrGroup, u.Group)
+	_ = d.Set(dataUserAttrGid, u.Gid)
+	_ = d.Set(dataUserAttrGecos, u.Gecos)
+	_ = d.Set(dataUserAttrHome, u.Home)
+	_ = d.Set(dataUserAttrShell, u.Shell)
+	_ = d.Set(dataUserAttrSystem, u.System)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package provider_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"regexp"
+	"testing"
+)
+
+func TestAccDataUser_lookup(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+		groupName := testRunGroupName(testConfig.userName, "a")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", groupName),
+						testAccUserBlock("test", userName,
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("home", "/home/"+userName),
+							tfbuild.AttributeString("shell", "/bin/sh"),
+						),
+						tfbuild.Data("system_user", "by_name",
+							tfbuild.AttributeTraversal("name", tfbuild.TraversalResourceAttribute("system_user", "test", "name")),
+						),
+						tfbuild.Data("system_user", "by_uid",
+							tfbuild.AttributeTraversal("uid", tfbuild.TraversalResourceAttribute("system_user", "test", "uid")),
+						),
+						tfbuild.Data("system_user", "root",
+							tfbuild.AttributeString("name", "root"),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttrPair("data.system_user.by_name", "uid", "system_user.test", "uid"),
+						resource.TestCheckResourceAttrPair("data.system_user.by_name", "gid", "system_user.test", "gid"),
+						resource.TestCheckResourceAttr("data.system_user.by_name", "group", groupName),
+						resource.TestCheckResourceAttr("data.system_user.by_name", "home", "/home/"+userName),
+						resource.TestCheckResourceAttr("data.system_user.by_name", "shell", "/bin/sh"),
+						resource.TestCheckResourceAttr("data.system_user.by_name", "system", "false"),
+						resource.TestCheckResourceAttr("data.system_user.by_uid", "name", userName),
+						resource.TestCheckResourceAttr("data.system_user.root", "id", "0"),
+						resource.TestCheckResourceAttr("data.system_user.root", "uid", "0"),
+						resource.TestCheckResourceAttr("data.system_user.root", "system", "true"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccDataUser_notFound(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_user", "test",
+							tfbuild.AttributeString("name", testRunUserName(testConfig.userName, "missing")),
+						),
+					))),
+					ExpectError: regexp.MustCompile(`user not found`),
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+)
+
+const dataUsersName = "system_users"
+
+const (
+	dataUsersAttrId     = "id"
+	dataUsersAttrSystem = "system"
+	dataUsersAttrMinUid = "min_uid"
+	dataUsersAttrMaxUid = "max_uid"
+	dataUsersAttrUsers  = "users"
+
+	dataUsersAttrUsersName   = "name"
+	dataUsersAttrUsersUid    = "uid"
+	dataUsersAttrUsersGroup  = "group"
+	dataUsersAttrUsersGid    = "gid"
+	dataUsersAttrUsersGecos  = "gecos"
+	dataUsersAttrUsersHome   = "home"
+	dataUsersAttrUsersShell  = "shell"
+	dataUsersAttrUsersSystem = "system"
+)
+
+func dataUsers() *schema.Resource {
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` lists the users on the remote system.", dataUsersName),
+
+		ReadContext: dataUsersRead,
+
+		Schema: map[string]*schema.Schema{
+			dataUsersAttrId: {
+				Description: "ID of the data source",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			dataUsersAttrSystem: {
+				Description: "Set to `true` to list only system users with a uid up to `SYS_UID_MAX` of `/etc/login.defs` and the user `nobody` (65534) or to `false` to list only regular users. Lists all users if not set.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+			},
+			dataUsersAttrMinUid: {
+				Description:  "Lists only users with a uid greater than or equal to the value.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			dataUsersAttrMaxUid: {
+				Description:  "Lists only users with a uid less than or equal to the value.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			dataUsersAttrUsers: {
+				Description: "Users sorted by uid.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						dataUsersAttrUsersName: {
+							Description: "Name of the user.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataUsersAttrUsersUid: {
+							Description: "ID of the user.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataUsersAttrUsersGroup: {
+							Description: "Name of the primary group of the user. Empty if the primary group does not exist.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataUsersAttrUsersGid: {
+							Description: "ID of the primary group of the user.",
+							Type:        schema.TypeInt,
+							Computed:    true,
+						},
+						dataUsersAttrUsersGecos: {
+							Description: "GECOS field of the user which usually contains the full name.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataUsersAttrUsersHome: {
+							Description: "Path to the home folder of the user.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataUsersAttrUsersShell: {
+							Description: "Login shell of the user.",
+							Type:        schema.TypeString,
+							Computed:    true,
+						},
+						dataUsersAttrUsersSystem: {
+							Description: "`true` if the user is a system user.",
+							Type:        schema.TypeBool,
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// expandAccountsQuery returns the query from the filter attributes of system_users or system_groups
+func expandAccountsQuery(d *schema.ResourceData, systemAttr, minIdAttr, maxIdAttr string) client.AccountsQuery {
+	var q client.AccountsQuery
+
+	// Use deprecated GetOkExists because GetOk does not distinguish between false and not set
+	if v, exists := d.GetOkExists(systemAttr); exists {
+		q.System = to.BoolPtr(v.(bool))
+	}
+
+	if v, exists := d.GetOkExists(minIdAttr); exists {
+		q.MinId = to.IntPtr(v.(int))
+	}
+
+	if v, exists := d.GetOkExists(maxIdAttr); exists {
+		q.MaxId = to.IntPtr(v.(int))
+	}
+
+	return q
+}
+
+// accountsQueryId returns the id of a data source from the query
+func accountsQueryId(q client.AccountsQuery) (string, error) {
+	format := func(v interface{}) string {
+		switch v := v.(type) {
+		case *bool:
+			if v != nil {
+				return fmt.Sprintf("%t", *v)
+			}
+		case *int:
+			if v != nil {
+				return fmt.Sprintf("%d", *v)
+			}
+		}
+		return ""
+	}
+
+	return dataIdFromAttrValues(format(q.System), format(q.MinId), format(q.MaxId))
+}
+
+func flattenUserAccounts(users []client.UserAccount) []interface{} {
+	l := make([]interface{}, 0, len(users))
+	for _, u := range users {
+		l = append(l, map[string]interface{}{
+			dataUsersAttrUsersName:   u.Name,
+			dataUsersAttrUsersUid:    u.Uid,
+			dataUsersAttrUsersGroup:  u.Group,
+			dataUsersAttrUsersGid:    u.Gid,
+			dataUsersAttrUsersGecos:  u.Gecos,
+			dataUsersAttrUsersHome:   u.Home,
+			dataUsersAttrUsersShell:  u.Shell,
+			dataUsersAttrUsersSystem: u.System,
+		})
+	}
+	return l
+}
+
+func dataUsersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAccountsClient(p.System)
+
+	q := expandAccountsQuery(d, dataUsersAttrSystem, dataUsersAttrMinUid, dataUsersAttrMaxUid)
+
+	users, err := c.ListUsers(ctx, q)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, err := accountsQueryId(q)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+
+	_ = d.Set(dataUsersAttrUsers, flattenUserAccounts(users))
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package provider_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"testing"
+)
+
+func TestAccDataUsers_filter(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", userName,
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+						),
+						tfbuild.Data("system_users", "range",
+							tfbuild.AttributeTraversal("min_uid", tfbuild.TraversalResourceAttribute("system_user", "test", "uid")),
+							tfbuild.AttributeTraversal("max_uid", tfbuild.TraversalResourceAttribute("system_user", "test", "uid")),
+						),
+						tfbuild.Data("system_users", "system",
+							tfbuild.AttributeBool("system", true),
+							tfbuild.AttributeInt("max_uid", 0),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_users.range", "users.#", "1"),
+						resource.TestCheckResourceAttr("data.system_users.range", "users.0.name", userName),
+						resource.TestCheckResourceAttrPair("data.system_users.range", "users.0.gid", "system_user.test", "gid"),
+						resource.TestCheckResourceAttr("data.system_users.range", "users.0.system", "false"),
+						resource.TestCheckResourceAttr("data.system_users.system", "users.#", "1"),
+						resource.TestCheckResourceAttr("data.system_users.system", "users.0.name", "root"),
+					),
+				},
+			},
+		})
+	})
+}
@@ -38,17 +38,26 @@ func init() {
 
 func providerResources() map[string]*schema.Resource {
 	return map[string]*schema.Resource{
-		resourceFileName:           resourceFile(),
-		resourceFolderName:         resourceFolder(),
-		resourceLinkName:           resourceLink(),
-		resourceUserName:           resourceUser(),
-		resourceGroupName:          resourceGroup(),
-		resourceServiceOpenrcName:  resourceServiceOpenrc(),
-		resourceServiceSystemdName: resourceServiceSystemd(),
-		resourceSystemdUnitName:    resourceSystemdUnit(),
-		resourcePackagesApkName:    resourcePackagesApk(),
-		resourcePackagesAptName:    resourcePackagesApt(),
-		resourcePackagesSnapName:   resourcePackagesSnap(),
+		resourceFileName:            resourceFile(),
+		resourceFileBlockName:       resourceFileBlock(),
+		resourceFileDownloadName:    resourceFileDownload(),
+		resourceFileFragmentName:    resourceFileFragment(),
+		resourceConfigKeysName:      resourceConfigKeys(),
+		resourceFolderName:          resourceFolder(),
+		resourceFolderSyncName:      resourceFolderSync(),
+		resourceArchiveName:         resourceArchive(),
+		resourceLinkName:            resourceLink(),
+		resourceUserName:            resourceUser(),
+		resourceGroupName:           resourceGroup(),
+		resourceGroupMembershipName: resourceGroupMembership(),
+		resourceAuthorizedKeysName:  resourceAuthorizedKeys(),
+		resourceSudoersRuleName:     resourceSudoersRule(),
+		resourceServiceOpenrcName:   resourceServiceOpenrc(),
+		resourceServiceSystemdName:  resourceServiceSystemd(),
+		resourceSystemdUnitName:     resourceSystemdUnit(),
+		resourcePackagesApkName:     resourcePackagesApk(),
+		resourcePackagesAptName:     resourcePackagesApt(),
+		resourcePackagesSnapName:    resourcePackagesSnap(),
 	}
 }
 
@@ -59,6 +68,11 @@ func providerDataSources() map[string]*schema.Resource {
 		dataCommandName:  dataCommand(),
 		dataFileName:     dataFile(),
 		dataFileMetaName: dataFileMeta(),
+		dataFilesName:    dataFiles(),
+		dataUserName:     dataUser(),
+		dataUsersName:    dataUsers(),
+		dataGroupName:    dataGroup(),
+		dataGroupsName:   dataGroups(),
 	}
 }
 
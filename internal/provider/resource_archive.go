@@ -0,0 +1,417 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/archive"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
+	"github.com/neuspaces/terraform-provider-system/internal/source"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+const resourceArchiveName = "system_archive"
+
+const (
+	resourceArchiveAttrId              = "id"
+	resourceArchiveAttrSource          = "source"
+	resourceArchiveAttrSourceChecksum  = "source_checksum"
+	resourceArchiveAttrFormat          = "format"
+	resourceArchiveAttrDestination     = "destination"
+	resourceArchiveAttrStripComponents = "strip_components"
+	resourceArchiveAttrFileMode        = "file_mode"
+	resourceArchiveAttrDirectoryMode   = "directory_mode"
+	resourceArchiveAttrUser            = "user"
+	resourceArchiveAttrGroup           = "group"
+	resourceArchiveAttrFiles           = "files"
+	resourceArchiveAttrFolders         = "folders"
+)
+
+func resourceArchive() *schema.Resource {
+	sources := newSourceRegistry()
+
+	sr := &SyncResource{
+		CreateContext: resourceArchiveCreateFactory(sources),
+		ReadContext:   resourceArchiveRead,
+		UpdateContext: resourceArchiveUpdateFactory(sources),
+		DeleteContext: resourceArchiveDelete,
+	}
+
+	var formats []string
+	for _, f := range archive.Formats() {
+		formats = append(formats, string(f))
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` extracts an archive to a folder on the remote system.", resourceArchiveName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		// Importer is intentionally not configured
+		// The extracted files and folders are derived from the source archive which is not recorded on the remote system
+		// Create will extract the archive into an existing destination and record the extracted entries
+
+		CustomizeDiff: resourceArchiveCustomizeDiff,
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceArchiveAttrId: {
+				Description: "ID of the archive",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceArchiveAttrSource: {
+				Description:      "Path or url of the archive. Supports local paths, `file://`, `http://`, and `https://` urls. The archive is extracted again if the checksum or ETag of the source changes.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: sourceValidateDiagFunc(sources),
+				StateFunc:        sourceStateFunc(sources),
+			},
+			resourceArchiveAttrSourceChecksum: {
+				Description:      fmt.Sprintf("Expected checksum of the archive in the format `sha256:[hex]` or `sha512:[hex]`. The archive is verified before any file is extracted. Nothing is extracted if the checksum of `%s` does not match.", resourceArchiveAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.Checksum(),
+			},
+			resourceArchiveAttrFormat: {
+				Description:  fmt.Sprintf("Format of the archive. One of `%s`. Defaults to the format inferred from the file extension of `%s`.", strings.Join(formats, "`, `"), resourceArchiveAttrSource),
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(formats, false),
+			},
+			resourceArchiveAttrDestination: {
+				Description:      "Path to the folder on the remote system to which the archive is extracted. Must be an absolute path. The folder is created if it does not exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceArchiveAttrStripComponents: {
+				Description:  "Number of leading path elements which are removed from the paths of the archive entries. Entries with fewer path elements are not extracted. Defaults to `0`.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			resourceArchiveAttrFileMode: {
+				Description:      "Permissions of the extracted files in octal format like `644`. Defaults to the permissions in the archive.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceArchiveAttrDirectoryMode: {
+				Description:      "Permissions of the extracted folders in octal format like `755`. Defaults to the permissions in the archive.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceArchiveAttrUser: {
+				Description: "Name or ID of the user who owns the extracted files and folders. Defaults to the user of the connection.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			resourceArchiveAttrGroup: {
+				Description: "Name or ID of the group that owns the extracted files and folders. Defaults to the primary group of the user of the connection.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			resourceArchiveAttrFiles: {
+				Description: "Set of paths of the extracted files and links relative to the destination folder.",
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			resourceArchiveAttrFolders: {
+				Description: "Set of paths of the extracted folders relative to the destination folder.",
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// resourceArchiveFormat returns the configured format or the format inferred from the source url
+func resourceArchiveFormat(d *schema.ResourceData) (archive.Format, error) {
+	if v := d.Get(resourceArchiveAttrFormat).(string); v != "" {
+		return archive.Format(v), nil
+	}
+
+	sourceStr := d.Get(resourceArchiveAttrSource).(string)
+	name := sourceStr
+	if u, err := url.Parse(sourceStr); err == nil && u.Path != "" {
+		name = u.Path
+	}
+
+	f, ok := archive.DetectFormat(name)
+	if !ok {
+		return "", fmt.Errorf("unable to infer the format of archive %q: set attribute `%s` explicitly", sourceStr, resourceArchiveAttrFormat)
+	}
+
+	return f, nil
+}
+
+// resourceArchiveEntries returns the entries of the archive which have been extracted previously
+func resourceArchiveEntries(files interface{}, folders interface{}) []client.ArchiveEntry {
+	var entries []client.ArchiveEntry
+
+	if s, ok := files.(*schema.Set); ok {
+		for _, v := range s.List() {
+			entries = append(entries, client.ArchiveEntry{Path: v.(string)})
+		}
+	}
+
+	if s, ok := folders.(*schema.Set); ok {
+		for _, v := range s.List() {
+			entries = append(entries, client.ArchiveEntry{Path: v.(string), IsDir: true})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries
+}
+
+// resourceArchiveExtractAttrs are the attributes which cause the archive to be extracted again
+var resourceArchiveExtractAttrs = []string{
+	resourceArchiveAttrSource,
+	resourceArchiveAttrSourceChecksum,
+	resourceArchiveAttrFormat,
+	resourceArchiveAttrStripComponents,
+	resourceArchiveAttrFileMode,
+	resourceArchiveAttrDirectoryMode,
+	resourceArchiveAttrUser,
+	resourceArchiveAttrGroup,
+}
+
+func resourceArchiveCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChanges(resourceArchiveExtractAttrs...) {
+		return nil
+	}
+
+	// The extracted entries are known after the archive has been extracted again
+	if err := d.SetNewComputed(resourceArchiveAttrFiles); err != nil {
+		return err
+	}
+
+	return d.SetNewComputed(resourceArchiveAttrFolders)
+}
+
+// resourceArchiveVerify reads the archive into a temporary file and verifies the checksum. The verification completes
+// before the extraction starts because a partially extracted archive cannot be reverted.
+func resourceArchiveVerify(r io.Reader, checksumStr string) (*os.File, error) {
+	c, err := checksum.Parse(checksumStr)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "terraform-provider-system-*.archive")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(tmp, checksum.NewReader(r, c))
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return tmp, nil
+}
+
+func resourceArchiveExtract(ctx context.Context, sources *source.Registry, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	ac := client.NewArchiveClient(p.System, client.ArchiveClientCompression(true))
+
+	destination := d.Get(resourceArchiveAttrDestination).(string)
+	user := d.Get(resourceArchiveAttrUser).(string)
+	group := d.Get(resourceArchiveAttrGroup).(string)
+
+	format, err := resourceArchiveFormat(d)
+	if err != nil {
+		return newDetailedDiagnostic(diag.Error, "failed to detect archive format", err.Error(), cty.GetAttrPath(resourceArchiveAttrSource))
+	}
+
+	opts := archive.Options{
+		StripComponents: d.Get(resourceArchiveAttrStripComponents).(int),
+	}
+	if v := d.Get(resourceArchiveAttrFileMode).(string); v != "" {
+		opts.FileMode = filemode.MustParse(v)
+	}
+	if v := d.Get(resourceArchiveAttrDirectoryMode).(string); v != "" {
+		opts.DirectoryMode = filemode.MustParse(v)
+	}
+
+	sourceStr := d.Get(resourceArchiveAttrSource).(string)
+	s, err := sources.Open(sourceStr)
+	if err != nil {
+		return newDetailedDiagnostic(diag.Error, fmt.Sprintf("failed to open url %q", sourceStr), err.Error(), cty.GetAttrPath(resourceArchiveAttrSource))
+	}
+	defer func() {
+		_ = s.Close()
+	}()
+
+	var r io.Reader = s
+
+	if v := d.Get(resourceArchiveAttrSourceChecksum).(string); v != "" {
+		verified, err := resourceArchiveVerify(s, v)
+		if err != nil {
+			return newDetailedDiagnostic(diag.Error, "source checksum mismatch", err.Error(), cty.GetAttrPath(resourceArchiveAttrSourceChecksum))
+		}
+		defer func() {
+			_ = verified.Close()
+			_ = os.Remove(verified.Name())
+		}()
+
+		r = verified
+	}
+
+	var extracted []archive.Entry
+	err = ac.Extract(ctx, destination, func(w io.Writer) error {
+		var convertErr error
+		extracted, convertErr = archive.Convert(w, r, format, opts)
+		return convertErr
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var entries []client.ArchiveEntry
+	var files, folders []string
+	current := map[string]struct{}{}
+	for _, e := range extracted {
+		entries = append(entries, client.ArchiveEntry{Path: e.Path, IsDir: e.IsDir})
+		current[e.Path] = struct{}{}
+		if e.IsDir {
+			folders = append(folders, e.Path)
+		} else {
+			files = append(files, e.Path)
+		}
+	}
+
+	err = ac.Chown(ctx, destination, entries, user, group)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Delete previously extracted entries which no longer exist in the archive
+	oldFiles, _ := d.GetChange(resourceArchiveAttrFiles)
+	oldFolders, _ := d.GetChange(resourceArchiveAttrFolders)
+
+	var deletes []client.ArchiveEntry
+	for _, e := range resourceArchiveEntries(oldFiles, oldFolders) {
+		if _, ok := current[e.Path]; !ok {
+			deletes = append(deletes, e)
+		}
+	}
+
+	err = ac.Delete(ctx, destination, deletes)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_ = d.Set(resourceArchiveAttrFiles, files)
+	_ = d.Set(resourceArchiveAttrFolders, folders)
+
+	return nil
+}
+
+func resourceArchiveCreateFactory(sources *source.Registry) schema.CreateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		diagErr := resourceArchiveExtract(ctx, sources, d, meta)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		d.SetId(d.Get(resourceArchiveAttrDestination).(string))
+
+		return resourceArchiveRead(ctx, d, meta)
+	}
+}
+
+func resourceArchiveRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	ac := client.NewArchiveClient(p.System)
+
+	err := ac.Exists(ctx, d.Get(resourceArchiveAttrDestination).(string))
+	if errors.Is(err, client.ErrArchiveNotFound) {
+		// Folder has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceArchiveUpdateFactory(sources *source.Registry) schema.UpdateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		// The archive is extracted again only if the source or the attributes of the extracted entries change
+		if d.HasChanges(resourceArchiveExtractAttrs...) {
+			diagErr := resourceArchiveExtract(ctx, sources, d, meta)
+			if diagErr != nil {
+				return diagErr
+			}
+		}
+
+		return resourceArchiveRead(ctx, d, meta)
+	}
+}
+
+func resourceArchiveDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	ac := client.NewArchiveClient(p.System)
+
+	destination := d.Get(resourceArchiveAttrDestination).(string)
+
+	err := ac.Delete(ctx, destination, resourceArchiveEntries(d.Get(resourceArchiveAttrFiles), d.Get(resourceArchiveAttrFolders)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = ac.DeleteIfEmpty(ctx, destination)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
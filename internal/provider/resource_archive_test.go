@@ -0,0 +1,147 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	testArchiveId uint32
+)
+
+type testArchiveConfig struct {
+	folderName string
+}
+
+func newTestArchiveConfig() testArchiveConfig {
+	id := atomic.AddUint32(&testArchiveId, 1)
+
+	return testArchiveConfig{
+		folderName: fmt.Sprintf("archive-%d", id),
+	}
+}
+
+func TestAccArchive_zip(t *testing.T) {
+	testConfig := newTestArchiveConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFilePath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_archive", "test",
+							tfbuild.AttributeString("source", "./test/archive/release-1.0.zip"),
+							// sha256sum ./internal/provider/test/archive/release-1.0.zip
+							tfbuild.AttributeString("source_checksum", "sha256:9d66706544eeb9caacd879289599bfe64ad68081defbddacc37e96fc1879f6eb"),
+							tfbuild.AttributeString("destination", folderPath),
+							tfbuild.AttributeInt("strip_components", 1),
+							tfbuild.AttributeString("file_mode", "640"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", folderPath+"/README.md"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_archive", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_archive.test", "id", folderPath),
+						resource.TestCheckResourceAttr("system_archive.test", "files.#", "3"),
+						resource.TestCheckTypeSetElemAttr("system_archive.test", "files.*", "bin/app"),
+						resource.TestCheckResourceAttr("system_archive.test", "folders.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_archive.test", "folders.*", "bin"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "hello world!"),
+						resource.TestCheckResourceAttr("data.system_file.test", "mode", "640"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccArchive_fail_source_checksum(t *testing.T) {
+	testConfig := newTestArchiveConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFilePath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_archive", "test",
+							tfbuild.AttributeString("source", "./test/archive/release-1.0.tar.gz"),
+							tfbuild.AttributeString("source_checksum", "sha512:"+strings.Repeat("0", 128)),
+							tfbuild.AttributeString("destination", folderPath),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`source checksum mismatch`),
+				},
+			},
+		})
+	})
+}
+
+func TestAccArchive_update(t *testing.T) {
+	testConfig := newTestArchiveConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFilePath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_archive", "test",
+							tfbuild.AttributeString("source", "./test/archive/release-1.0.tar.gz"),
+							tfbuild.AttributeString("destination", folderPath),
+							tfbuild.AttributeInt("strip_components", 1),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_archive.test", "files.#", "3"),
+						resource.TestCheckTypeSetElemAttr("system_archive.test", "files.*", "bin/legacy"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_archive", "test",
+							tfbuild.AttributeString("source", "./test/archive/release-1.1.tar.gz"),
+							tfbuild.AttributeString("destination", folderPath),
+							tfbuild.AttributeInt("strip_components", 1),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", folderPath+"/bin/app"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_archive", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_archive.test", "files.#", "2"),
+						resource.TestCheckTypeSetElemAttr("system_archive.test", "files.*", "README.md"),
+						resource.TestCheckTypeSetElemAttr("system_archive.test", "files.*", "bin/app"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "#!/bin/sh\necho 1.1\n"),
+					),
+				},
+			},
+		})
+	})
+}
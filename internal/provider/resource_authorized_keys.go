@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/authorizedkeys"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"sort"
+)
+
+const resourceAuthorizedKeysName = "system_authorized_keys"
+
+const (
+	resourceAuthorizedKeysAttrId        = "id"
+	resourceAuthorizedKeysAttrUser      = "user"
+	resourceAuthorizedKeysAttrKeys      = "keys"
+	resourceAuthorizedKeysAttrExclusive = "exclusive"
+	resourceAuthorizedKeysAttrPath      = "path"
+)
+
+func resourceAuthorizedKeys() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceAuthorizedKeysCreate,
+		ReadContext:   resourceAuthorizedKeysRead,
+		UpdateContext: resourceAuthorizedKeysUpdate,
+		DeleteContext: resourceAuthorizedKeysDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages the public keys in the `~/.ssh/authorized_keys` file of a user on the remote system.", resourceAuthorizedKeysName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceAuthorizedKeysImportState,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceAuthorizedKeysAttrId: {
+				Description: "ID of the authorized keys",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceAuthorizedKeysAttrUser: {
+				Description:      "Name of the user. User must exist. The home folder of the user is resolved using `getent`.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+			},
+			resourceAuthorizedKeysAttrKeys: {
+				Description: "Set of public keys in the format of the `authorized_keys` file, e.g. `no-pty,from=\"10.0.0.0/8\" ssh-ed25519 AAAA... alice@example.com`. Supported options are the options of OpenSSH such as `command`, `environment`, `expiry-time`, `from`, `no-pty`, `permitopen`, and `restrict`. Keys are identified by the key type and the public key. A change of the options or the comment of a key on the remote system is detected as drift.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validate.AuthorizedKeyWithOptions(),
+				},
+			},
+			resourceAuthorizedKeysAttrExclusive: {
+				Description: "If `true`, keys in the `authorized_keys` file which are not managed by the resource are removed. If `false`, keys which are not managed by the resource are retained. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceAuthorizedKeysAttrPath: {
+				Description: "Path of the `authorized_keys` file",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// resourceAuthorizedKeysParse parses the keys of the set. Returns the keys and the configured lines by key id.
+func resourceAuthorizedKeysParse(s *schema.Set) ([]authorizedkeys.Key, map[string]string, diag.Diagnostics) {
+	lines, err := setToStringSlice(s)
+	if err != nil {
+		return nil, nil, diag.FromErr(err)
+	}
+
+	var keys []authorizedkeys.Key
+	byId := map[string]string{}
+
+	for _, line := range lines {
+		k, err := authorizedkeys.Parse(line)
+		if err != nil {
+			return nil, nil, newDetailedDiagnostic(diag.Error, "invalid authorized key format", err.Error(), cty.GetAttrPath(resourceAuthorizedKeysAttrKeys))
+		}
+
+		if _, ok := byId[k.Id()]; ok {
+			return nil, nil, newDetailedDiagnostic(diag.Error, "duplicate key", fmt.Sprintf("the public key %q is defined more than once", k.Id()), cty.GetAttrPath(resourceAuthorizedKeysAttrKeys))
+		}
+
+		keys = append(keys, *k)
+		byId[k.Id()] = line
+	}
+
+	return keys, byId, nil
+}
+
+// resourceAuthorizedKeysById returns the lines of the set by key id. Lines of the state may contain keys with the same
+// id if the file contains duplicates. Only the first line of every id is returned.
+func resourceAuthorizedKeysById(s *schema.Set) map[string]string {
+	byId := map[string]string{}
+
+	for _, v := range s.List() {
+		k, err := authorizedkeys.Parse(v.(string))
+		if err != nil {
+			continue
+		}
+		if _, ok := byId[k.Id()]; !ok {
+			byId[k.Id()] = v.(string)
+		}
+	}
+
+	return byId
+}
+
+func resourceAuthorizedKeysDiagnostics(err error, user string) diag.Diagnostics {
+	switch {
+	case errors.Is(err, client.ErrAuthorizedKeysUserNotFound):
+		return newDetailedDiagnostic(diag.Error, "user not found", fmt.Sprintf("the user %q does not exist", user), cty.GetAttrPath(resourceAuthorizedKeysAttrUser))
+	case errors.Is(err, client.ErrAuthorizedKeysHomeNotFound):
+		return newDetailedDiagnostic(diag.Error, "home folder not found", fmt.Sprintf("the home folder of the user %q does not exist", user), cty.GetAttrPath(resourceAuthorizedKeysAttrUser))
+	case errors.Is(err, client.ErrAuthorizedKeysSymlink):
+		return newDetailedDiagnostic(diag.Error, "symbolic link not supported", fmt.Sprintf("~/.ssh or ~/.ssh/authorized_keys of the user %q is a symbolic link which is not followed for security reasons", user), cty.GetAttrPath(resourceAuthorizedKeysAttrUser))
+	}
+
+	return diag.FromErr(err)
+}
+
+// resourceAuthorizedKeysApply sets the managed keys and removes the keys which have been removed from the resource
+func resourceAuthorizedKeysApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAuthorizedKeysClient(p.System)
+
+	user := d.Get(resourceAuthorizedKeysAttrUser).(string)
+
+	o, n := d.GetChange(resourceAuthorizedKeysAttrKeys)
+
+	keys, byId, diagErr := resourceAuthorizedKeysParse(n.(*schema.Set))
+	if diagErr != nil {
+		return diagErr
+	}
+
+	var remove []string
+	for id := range resourceAuthorizedKeysById(o.(*schema.Set)) {
+		if _, ok := byId[id]; !ok {
+			remove = append(remove, id)
+		}
+	}
+	sort.Strings(remove)
+
+	err := c.Apply(ctx, client.AuthorizedKeysChange{
+		User:      user,
+		Set:       keys,
+		Remove:    remove,
+		Exclusive: d.Get(resourceAuthorizedKeysAttrExclusive).(bool),
+	})
+	if err != nil {
+		return resourceAuthorizedKeysDiagnostics(err, user)
+	}
+
+	return nil
+}
+
+func resourceAuthorizedKeysCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceAuthorizedKeysApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	d.SetId(d.Get(resourceAuthorizedKeysAttrUser).(string))
+
+	return resourceAuthorizedKeysRead(ctx, d, meta)
+}
+
+func resourceAuthorizedKeysRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAuthorizedKeysClient(p.System)
+
+	r, err := c.Get(ctx, d.Get(resourceAuthorizedKeysAttrUser).(string))
+	if errors.Is(err, client.ErrAuthorizedKeysUserNotFound) {
+		// User has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	byId := resourceAuthorizedKeysById(d.Get(resourceAuthorizedKeysAttrKeys).(*schema.Set))
+
+	exclusive := d.Get(resourceAuthorizedKeysAttrExclusive).(bool)
+
+	// Keys are compared individually. A key with changed options or comment results in a diff. Unmanaged keys result in
+	// a diff only in exclusive mode.
+	var keys []string
+	for _, k := range r.Keys {
+		line, ok := byId[k.Id()]
+		if !ok {
+			if exclusive {
+				keys = append(keys, k.String())
+			}
+			continue
+		}
+
+		// Retain the configured line if it is equivalent to the line in the file
+		if configured, err := authorizedkeys.Parse(line); err == nil && configured.String() == k.String() {
+			keys = append(keys, line)
+		} else {
+			keys = append(keys, k.String())
+		}
+	}
+
+	_ = d.Set(resourceAuthorizedKeysAttrKeys, stringSliceToSet(keys))
+	_ = d.Set(resourceAuthorizedKeysAttrPath, r.Path)
+
+	return nil
+}
+
+func resourceAuthorizedKeysUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceAuthorizedKeysApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return resourceAuthorizedKeysRead(ctx, d, meta)
+}
+
+func resourceAuthorizedKeysDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewAuthorizedKeysClient(p.System)
+
+	// Only the managed keys are removed
+	var remove []string
+	for id := range resourceAuthorizedKeysById(d.Get(resourceAuthorizedKeysAttrKeys).(*schema.Set)) {
+		remove = append(remove, id)
+	}
+	sort.Strings(remove)
+
+	err := c.Apply(ctx, client.AuthorizedKeysChange{
+		User:   d.Get(resourceAuthorizedKeysAttrUser).(string),
+		Remove: remove,
+	})
+	if err != nil && !errors.Is(err, client.ErrAuthorizedKeysUserNotFound) {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceAuthorizedKeysImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return nil, fmt.Errorf("unable to get provider from meta")
+	}
+
+	// Expect import id in the format `user`. All keys of the authorized_keys file are imported.
+	r, err := client.NewAuthorizedKeysClient(p.System).Get(ctx, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	seen := map[string]bool{}
+	for _, k := range r.Keys {
+		if seen[k.Id()] {
+			// Duplicates are removed on the next apply
+			continue
+		}
+		seen[k.Id()] = true
+		keys = append(keys, k.String())
+	}
+
+	d.SetId(r.User)
+	_ = d.Set(resourceAuthorizedKeysAttrUser, r.User)
+	_ = d.Set(resourceAuthorizedKeysAttrKeys, stringSliceToSet(keys))
+	_ = d.Set(resourceAuthorizedKeysAttrExclusive, false)
+
+	return []*schema.ResourceData{d}, nil
+}
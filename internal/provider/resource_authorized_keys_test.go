@@ -0,0 +1,84 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"testing"
+)
+
+const (
+	testAuthorizedKeyEd25519 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJCBvzE/j6BJ2aLiEjjjVe50IWVWV01vddbaotVWtlte alice@laptop"
+	testAuthorizedKeyEcdsa   = "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBF4bo0IqFmyb9uaAIGElu0v2Vis8BIJmPYn+quFZd+2zryXrQiBgGnW8lDHFQH2G2Gx+SEeiUX+Lo16zx9oU7s4= bob"
+)
+
+func TestAccAuthorizedKeys_create(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+		keyWithOptions := `no-pty,from="10.0.0.0/8" ` + testAuthorizedKeyEcdsa
+
+		userConfig := []tfbuild.FileElement{
+			acctest.ProviderConfigBlock(target.Configs.Default()),
+			testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+			testAccUserBlock("test", userName,
+				tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+				tfbuild.AttributeString("home", fmt.Sprintf("/home/%s", userName)),
+				tfbuild.AttributeBool("create_home", true),
+				tfbuild.AttributeBool("remove_home_on_destroy", true),
+			),
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(append(userConfig,
+						testAccAuthorizedKeysBlock("test",
+							tfbuild.AttributeTraversal("user", tfbuild.TraversalResourceAttribute("system_user", "test", "name")),
+							tfbuild.Attribute("keys", tfbuild.StringList(testAuthorizedKeyEd25519, keyWithOptions)),
+						),
+					)...))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_authorized_keys.test", "id", userName),
+						resource.TestCheckResourceAttr("system_authorized_keys.test", "path", fmt.Sprintf("/home/%s/.ssh/authorized_keys", userName)),
+						resource.TestCheckResourceAttr("system_authorized_keys.test", "keys.#", "2"),
+						resource.TestCheckTypeSetElemAttr("system_authorized_keys.test", "keys.*", testAuthorizedKeyEd25519),
+						resource.TestCheckTypeSetElemAttr("system_authorized_keys.test", "keys.*", keyWithOptions),
+					),
+				},
+				{
+					// Remove a key in exclusive mode
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(append(userConfig,
+						testAccAuthorizedKeysBlock("test",
+							tfbuild.AttributeTraversal("user", tfbuild.TraversalResourceAttribute("system_user", "test", "name")),
+							tfbuild.Attribute("keys", tfbuild.StringList(keyWithOptions)),
+							tfbuild.AttributeBool("exclusive", true),
+						),
+					)...))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_authorized_keys.test", "keys.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_authorized_keys.test", "keys.*", keyWithOptions),
+						resource.TestCheckResourceAttr("system_authorized_keys.test", "exclusive", "true"),
+					),
+				},
+				{
+					ImportState:             true,
+					ResourceName:            "system_authorized_keys.test",
+					ImportStateId:           userName,
+					ImportStateVerify:       true,
+					ImportStateVerifyIgnore: []string{"exclusive"},
+				},
+			},
+		})
+	})
+}
+
+func testAccAuthorizedKeysBlock(name string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	return tfbuild.Resource("system_authorized_keys", name, attrs...)
+}
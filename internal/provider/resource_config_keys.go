@@ -0,0 +1,306 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/configfile"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"sort"
+	"strings"
+)
+
+const resourceConfigKeysName = "system_config_keys"
+
+const (
+	resourceConfigKeysAttrId         = "id"
+	resourceConfigKeysAttrPath       = "path"
+	resourceConfigKeysAttrFormat     = "format"
+	resourceConfigKeysAttrKeys       = "keys"
+	resourceConfigKeysAttrRemoveKeys = "remove_keys"
+)
+
+func resourceConfigKeys() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceConfigKeysCreate,
+		ReadContext:   resourceConfigKeysRead,
+		UpdateContext: resourceConfigKeysUpdate,
+		DeleteContext: resourceConfigKeysDelete,
+	}
+
+	var formats []string
+	for _, f := range configfile.Formats() {
+		formats = append(formats, string(f))
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages individual keys in a structured configuration file on the remote system. Keys of the file which are not managed by the resource are retained.", resourceConfigKeysName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceConfigKeysImportState,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceConfigKeysAttrId: {
+				Description: "ID of the resource",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceConfigKeysAttrPath: {
+				Description:      "Path to the configuration file. Must be an absolute path. The file is created if it does not exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceConfigKeysAttrFormat: {
+				Description:  fmt.Sprintf("Format of the configuration file. Supported values are `%s`, `%s`, `%s`, `%s`, and `%s`.", configfile.FormatIni, configfile.FormatJson, configfile.FormatYaml, configfile.FormatToml, configfile.FormatDotenv),
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(formats, false),
+			},
+			resourceConfigKeysAttrKeys: {
+				Description: fmt.Sprintf("Map of keys and values which are set in the file. Keys are dot-separated paths. Values of the formats `%s`, `%s`, and `%s` are JSON encoded, e.g. using `jsonencode()`. Values of the formats `%s` and `%s` are plain strings.", configfile.FormatJson, configfile.FormatYaml, configfile.FormatToml, configfile.FormatIni, configfile.FormatDotenv),
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			resourceConfigKeysAttrRemoveKeys: {
+				Description: "Set of keys which are removed from the file.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func resourceConfigKeysGetResourceData(d *schema.ResourceData) (*client.ConfigKeys, diag.Diagnostics) {
+	r := &client.ConfigKeys{
+		Path:   d.Get(resourceConfigKeysAttrPath).(string),
+		Format: configfile.Format(d.Get(resourceConfigKeysAttrFormat).(string)),
+		Set:    map[string]string{},
+	}
+
+	for k, v := range d.Get(resourceConfigKeysAttrKeys).(map[string]interface{}) {
+		value := v.(string)
+		if _, err := configfile.NormalizeValue(r.Format, value); err != nil {
+			return nil, newDetailedDiagnostic(diag.Error, fmt.Sprintf("invalid value of key %q", k), err.Error(), cty.GetAttrPath(resourceConfigKeysAttrKeys))
+		}
+		r.Set[k] = value
+	}
+
+	for _, v := range d.Get(resourceConfigKeysAttrRemoveKeys).(*schema.Set).List() {
+		k := v.(string)
+		if _, ok := r.Set[k]; ok {
+			return nil, newDetailedDiagnostic(diag.Error, fmt.Sprintf("key %q is set and removed", k), fmt.Sprintf("a key must not be defined in `%s` and `%s`", resourceConfigKeysAttrKeys, resourceConfigKeysAttrRemoveKeys), cty.GetAttrPath(resourceConfigKeysAttrRemoveKeys))
+		}
+		r.Remove = append(r.Remove, k)
+	}
+	sort.Strings(r.Remove)
+
+	return r, nil
+}
+
+func resourceConfigKeysCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewConfigKeysClient(p.System)
+
+	r, diagErr := resourceConfigKeysGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	err := c.Apply(ctx, *r)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(r.Path)
+
+	return resourceConfigKeysRead(ctx, d, meta)
+}
+
+func resourceConfigKeysRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewConfigKeysClient(p.System)
+
+	path := d.Get(resourceConfigKeysAttrPath).(string)
+	format := configfile.Format(d.Get(resourceConfigKeysAttrFormat).(string))
+	configured := d.Get(resourceConfigKeysAttrKeys).(map[string]interface{})
+	removeKeys := d.Get(resourceConfigKeysAttrRemoveKeys).(*schema.Set)
+
+	// Only managed keys are read which allows other tools to manage the remaining keys of the file
+	var keys []string
+	for k := range configured {
+		keys = append(keys, k)
+	}
+	for _, k := range removeKeys.List() {
+		keys = append(keys, k.(string))
+	}
+
+	values, err := c.Get(ctx, path, format, keys)
+	if errors.Is(err, client.ErrConfigKeysFileNotFound) {
+		// File has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	keysState := map[string]interface{}{}
+	for k, v := range configured {
+		value, ok := values[k]
+		if !ok {
+			// Key is absent which results in a diff
+			continue
+		}
+
+		// Retain the configured value if it is equivalent to the value in the file
+		if normalized, err := configfile.NormalizeValue(format, v.(string)); err == nil && normalized == value {
+			value = v.(string)
+		}
+
+		keysState[k] = value
+	}
+
+	var removeKeysState []interface{}
+	for _, k := range removeKeys.List() {
+		if _, ok := values[k.(string)]; ok {
+			// Key is present which results in a diff
+			continue
+		}
+		removeKeysState = append(removeKeysState, k)
+	}
+
+	_ = d.Set(resourceConfigKeysAttrKeys, keysState)
+	_ = d.Set(resourceConfigKeysAttrRemoveKeys, removeKeysState)
+
+	return nil
+}
+
+func resourceConfigKeysUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewConfigKeysClient(p.System)
+
+	r, diagErr := resourceConfigKeysGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	// Keys which are no longer managed are removed from the file
+	if d.HasChange(resourceConfigKeysAttrKeys) {
+		oldKeys, _ := d.GetChange(resourceConfigKeysAttrKeys)
+		for k := range oldKeys.(map[string]interface{}) {
+			if _, ok := r.Set[k]; !ok {
+				r.Remove = append(r.Remove, k)
+			}
+		}
+	}
+
+	err := c.Apply(ctx, *r)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceConfigKeysRead(ctx, d, meta)
+}
+
+func resourceConfigKeysDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewConfigKeysClient(p.System)
+
+	r, diagErr := resourceConfigKeysGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	var keys []string
+	for k := range r.Set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	err := c.Delete(ctx, r.Path, r.Format, keys)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceConfigKeysImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// Expect import id in the format `path:format:key,key`. Only the listed keys are imported.
+	importIdParts := strings.Split(d.Id(), ":")
+	if len(importIdParts) != 3 {
+		return nil, fmt.Errorf("unexpected import id format, expected `path:format:key,key`")
+	}
+
+	path, format := importIdParts[0], importIdParts[1]
+
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path must be an absolute path")
+	}
+
+	supported := false
+	for _, f := range configfile.Formats() {
+		if string(f) == format {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	// The values are read from the file by resourceConfigKeysRead
+	keys := map[string]interface{}{}
+	for _, k := range strings.Split(importIdParts[2], ",") {
+		if k != "" {
+			keys[k] = ""
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one key is required")
+	}
+
+	d.SetId(path)
+	_ = d.Set(resourceConfigKeysAttrPath, path)
+	_ = d.Set(resourceConfigKeysAttrFormat, format)
+	_ = d.Set(resourceConfigKeysAttrKeys, keys)
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,135 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/zclconf/go-cty/cty"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	testConfigKeysId uint32
+)
+
+type testConfigKeysConfig struct {
+	fileName string
+}
+
+func newTestConfigKeysConfig() testConfigKeysConfig {
+	id := atomic.AddUint32(&testConfigKeysId, 1)
+
+	return testConfigKeysConfig{
+		fileName: fmt.Sprintf("config-keys-%d", id),
+	}
+}
+
+func TestAccConfigKeys_json(t *testing.T) {
+	testConfig := newTestConfigKeysConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName+".json")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_config_keys", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.AttributeString("format", "json"),
+							tfbuild.AttributeValue("keys", cty.MapVal(map[string]cty.Value{
+								"log-driver":        cty.StringVal(`"local"`),
+								"log-opts.max-size": cty.StringVal(`"10m"`),
+							})),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_config_keys", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_config_keys.test", "id", filePath),
+						resource.TestCheckResourceAttr("system_config_keys.test", "keys.log-driver", `"local"`),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "{\n  \"log-driver\": \"local\",\n  \"log-opts\": {\n    \"max-size\": \"10m\"\n  }\n}\n"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_config_keys", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.AttributeString("format", "json"),
+							tfbuild.AttributeValue("keys", cty.MapVal(map[string]cty.Value{
+								"log-driver": cty.StringVal(`"journald"`),
+							})),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_config_keys", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_config_keys.test", "keys.log-driver", `"journald"`),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "{\n  \"log-driver\": \"journald\",\n  \"log-opts\": {}\n}\n"),
+					),
+				},
+				{
+					ImportState:       true,
+					ResourceName:      "system_config_keys.test",
+					ImportStateId:     fmt.Sprintf("%s:json:log-driver", filePath),
+					ImportStateVerify: true,
+				},
+			},
+		})
+	})
+}
+
+func TestAccConfigKeys_ini(t *testing.T) {
+	testConfig := newTestConfigKeysConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName+".ini")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+						),
+						tfbuild.Resource("system_config_keys", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.AttributeString("format", "ini"),
+							tfbuild.AttributeValue("keys", cty.MapVal(map[string]cty.Value{
+								"server.port": cty.StringVal("8080"),
+							})),
+							tfbuild.AttributeValue("remove_keys", cty.SetVal([]cty.Value{
+								cty.StringVal("server.host"),
+							})),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_config_keys", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_config_keys.test", "keys.server.port", "8080"),
+						resource.TestCheckResourceAttr("system_config_keys.test", "remove_keys.#", "1"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "hello world!\n\n[server]\nport = 8080\n"),
+					),
+				},
+			},
+		})
+	})
+}
@@ -3,15 +3,21 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
 	"github.com/neuspaces/terraform-provider-system/internal/source"
 	"github.com/neuspaces/terraform-provider-system/internal/validate"
 	"io"
+	"net/url"
 	"path"
 	"strings"
 )
@@ -19,32 +25,65 @@ import (
 const resourceFileName = "system_file"
 
 const (
-	resourceFileAttrId               = "id"
-	resourceFileAttrPath             = "path"
-	resourceFileAttrMode             = "mode"
-	resourceFileAttrUser             = "user"
-	resourceFileAttrUid              = "uid"
-	resourceFileAttrGroup            = "group"
-	resourceFileAttrGid              = "gid"
-	resourceFileAttrContent          = "content"
-	resourceFileAttrContentSensitive = "content_sensitive"
-	resourceFileAttrSource           = "source"
-	resourceFileAttrMd5Sum           = "md5sum"
-	resourceFileAttrBasename         = "basename"
+	resourceFileAttrId                     = "id"
+	resourceFileAttrPath                   = "path"
+	resourceFileAttrMode                   = "mode"
+	resourceFileAttrUser                   = "user"
+	resourceFileAttrUid                    = "uid"
+	resourceFileAttrGroup                  = "group"
+	resourceFileAttrGid                    = "gid"
+	resourceFileAttrContent                = "content"
+	resourceFileAttrContentSensitive       = "content_sensitive"
+	resourceFileAttrContentBase64          = "content_base64"
+	resourceFileAttrContentBase64Sensitive = "content_base64_sensitive"
+	resourceFileAttrSource                 = "source"
+	resourceFileAttrSourceChecksum         = "source_checksum"
+	resourceFileAttrDownloadOnRemote       = "download_on_remote"
+	resourceFileAttrMd5Sum                 = "md5sum"
+	resourceFileAttrSha256Sum              = "sha256sum"
+	resourceFileAttrBasename               = "basename"
+	resourceFileAttrContentPolicy          = "content_policy"
+	resourceFileAttrAdoptExisting          = "adopt_existing"
+	resourceFileAttrRestoreOnDestroy       = "restore_on_destroy"
 )
 
-func resourceFile() *schema.Resource {
-	// Configure source registry
-	sources, err := source.NewRegistry(
-		source.WithClients(
-			source.NewMetaCache(source.NewFileClient()),
-			source.NewMetaCache(source.NewHttpClient()),
-		),
-		source.WithDefaultScheme(source.FileScheme),
-	)
-	if err != nil {
-		panic(err)
+const (
+	resourceFileContentPolicyEnforce             = "enforce"
+	resourceFileContentPolicyCreateOnly          = "create_only"
+	resourceFileContentPolicyIgnoreChangesRemote = "ignore_changes_remote"
+)
+
+// resourceFileOnChangeAttrs are the attributes which trigger the command of `on_change` if changed
+var resourceFileOnChangeAttrs = []string{resourceFileAttrMd5Sum, resourceFileAttrMode, resourceFileAttrUid, resourceFileAttrGid}
+
+// resourceFileContentAttrs are the mutually exclusive attributes which provide the content of the file
+var resourceFileContentAttrs = []string{
+	resourceFileAttrContent,
+	resourceFileAttrContentSensitive,
+	resourceFileAttrContentBase64,
+	resourceFileAttrContentBase64Sensitive,
+	resourceFileAttrSource,
+}
+
+// resourceFileContentConflicts returns the content attributes which conflict with the attribute
+func resourceFileContentConflicts(attr string) []string {
+	var conflicts []string
+	for _, a := range resourceFileContentAttrs {
+		if a != attr {
+			conflicts = append(conflicts, a)
+		}
 	}
+	return conflicts
+}
+
+var resourceFileContentPolicies = []string{
+	resourceFileContentPolicyEnforce,
+	resourceFileContentPolicyCreateOnly,
+	resourceFileContentPolicyIgnoreChangesRemote,
+}
+
+func resourceFile() *schema.Resource {
+	sources := newSourceRegistry()
 
 	return &schema.Resource{
 		Description: fmt.Sprintf("`%s` manages a file on the remote system.", resourceFileName),
@@ -54,13 +93,15 @@ func resourceFile() *schema.Resource {
 		UpdateContext: resourceFileUpdateFactory(sources),
 		DeleteContext: resourceFileDelete,
 
+		CustomizeDiff: onChangeCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceFileImportState,
 		},
 
 		SchemaVersion: 1,
 
-		Schema: map[string]*schema.Schema{
+		Schema: withOnChangeSchema("the content, mode, or owner of the file", withFileAttrsSchema(fileAttrsKindFile, map[string]*schema.Schema{
 			resourceFileAttrId: {
 				Description: "ID of the file",
 				Type:        schema.TypeString,
@@ -109,88 +150,68 @@ func resourceFile() *schema.Resource {
 				ConflictsWith: []string{resourceFileAttrGroup},
 			},
 			resourceFileAttrContent: {
-				Description: fmt.Sprintf("Content of the file. Only recommended for small text-based payloads such as configuration files etc. The content will be stored in plain-text in the terraform state. Mutually exclusive with attributes `%[2]s` and `%[3]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrSource),
-				Type:        schema.TypeString,
-				Optional:    true,
-				Sensitive:   false,
-				ConflictsWith: []string{
-					resourceFileAttrContentSensitive,
-					resourceFileAttrSource,
-				},
+				Description:      fmt.Sprintf("Content of the file. Only recommended for small text-based payloads such as configuration files etc. The content will be stored in plain-text in the terraform state. Mutually exclusive with attributes `%[2]s`, `%[3]s`, `%[4]s`, and `%[5]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrContentBase64, resourceFileAttrContentBase64Sensitive, resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        false,
+				ConflictsWith:    resourceFileContentConflicts(resourceFileAttrContent),
+				DiffSuppressFunc: resourceFileContentDiffSuppress,
 			},
 			resourceFileAttrContentSensitive: {
-				Description: fmt.Sprintf("Content of the file similar to `%[1]s` attribute but with enabled sensitive flag. Prefer `%[2]s` to `%[1]s` to avoid leak of the content in the terraform log output. Mutually exclusive with attributes `%[1]s` and `%[3]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrSource),
-				Type:        schema.TypeString,
-				Optional:    true,
-				Sensitive:   true,
-				ConflictsWith: []string{
-					resourceFileAttrContent,
+				Description:      fmt.Sprintf("Content of the file similar to `%[1]s` attribute but with enabled sensitive flag. Prefer `%[2]s` to `%[1]s` to avoid leak of the content in the terraform log output. Mutually exclusive with attributes `%[1]s`, `%[3]s`, `%[4]s`, and `%[5]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrContentBase64, resourceFileAttrContentBase64Sensitive, resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ConflictsWith:    resourceFileContentConflicts(resourceFileAttrContentSensitive),
+				DiffSuppressFunc: resourceFileContentDiffSuppress,
+			},
+			resourceFileAttrContentBase64: {
+				Description:      fmt.Sprintf("Base64 encoded content of the file. Use for binary payloads such as keystores which are not valid UTF-8. The content will be stored in base64 encoding in the terraform state. Mutually exclusive with attributes `%[1]s`, `%[2]s`, `%[4]s`, and `%[5]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrContentBase64, resourceFileAttrContentBase64Sensitive, resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        false,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsBase64),
+				ConflictsWith:    resourceFileContentConflicts(resourceFileAttrContentBase64),
+				DiffSuppressFunc: resourceFileContentDiffSuppress,
+			},
+			resourceFileAttrContentBase64Sensitive: {
+				Description:      fmt.Sprintf("Base64 encoded content of the file similar to `%[3]s` attribute but with enabled sensitive flag. Mutually exclusive with attributes `%[1]s`, `%[2]s`, `%[3]s`, and `%[5]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrContentBase64, resourceFileAttrContentBase64Sensitive, resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsBase64),
+				ConflictsWith:    resourceFileContentConflicts(resourceFileAttrContentBase64Sensitive),
+				DiffSuppressFunc: resourceFileContentDiffSuppress,
+			},
+			resourceFileAttrSource: {
+				Description:      fmt.Sprintf("Path to a local file to upload as the file. Mutually exclusive with attributes `%[1]s`, `%[2]s`, `%[3]s`, and `%[4]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrContentBase64, resourceFileAttrContentBase64Sensitive, resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        false,
+				ForceNew:         true,
+				ValidateDiagFunc: sourceValidateDiagFunc(sources),
+				StateFunc:        sourceStateFunc(sources),
+				ConflictsWith:    resourceFileContentConflicts(resourceFileAttrSource),
+				DiffSuppressFunc: resourceFileContentDiffSuppress,
+			},
+			resourceFileAttrSourceChecksum: {
+				Description:      fmt.Sprintf("Expected checksum of the contents of `%[1]s` in the format `sha256:[hex]` or `sha512:[hex]`. The checksum is verified when the source is read and on the remote system before the file is placed at the path. The file is not created or changed if the checksum does not match.", resourceFileAttrSource),
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.Checksum(),
+				RequiredWith: []string{
 					resourceFileAttrSource,
 				},
 			},
-			resourceFileAttrSource: {
-				Description: fmt.Sprintf("Path to a local file to upload as the file. Mutually exclusive with attributes `%[1]s` and `%[2]s`.", resourceFileAttrContent, resourceFileAttrContentSensitive, resourceFileAttrSource),
-				Type:        schema.TypeString,
+			resourceFileAttrDownloadOnRemote: {
+				Description: fmt.Sprintf("Fetch the `http://` or `https://` url in `%[1]s` on the remote system instead of transferring the contents through the provider. Requires `curl`, `wget`, or `busybox` on the remote system. Defaults to `false`.", resourceFileAttrSource),
+				Type:        schema.TypeBool,
 				Optional:    true,
-				Sensitive:   false,
+				Default:     false,
 				ForceNew:    true,
-				ValidateDiagFunc: func(val interface{}, path cty.Path) diag.Diagnostics {
-					valUrl, err := validate.ExpectUrl(val, path)
-					if err != nil {
-						return err
-					}
-
-					// Attempt to open
-					s, openErr := sources.OpenUrl(valUrl)
-					if openErr != nil {
-						return []diag.Diagnostic{
-							{
-								Severity:      diag.Error,
-								Summary:       fmt.Sprintf("failed to open url %q", valUrl.String()),
-								Detail:        openErr.Error(),
-								AttributePath: path,
-							},
-						}
-					}
-
-					_ = s.Close()
-
-					return nil
-				},
-				// StateFunc stores the etag of the referenced source in the state in the form etag=[etag]
-				StateFunc: func(val interface{}) string {
-					// Expect a string
-					valStr, valIsStr := val.(string)
-					if !valIsStr {
-						panic(fmt.Sprintf("[ERROR] StateFunc of attribute `%[2]s` in resource `%[1]s` expects a string but got %+v", resourceFileName, resourceFileAttrSource, val))
-					}
-
-					// Pass-through etag
-					if strings.HasPrefix(valStr, "etag=") {
-						return valStr
-					}
-
-					// Get etag from source meta struct
-					s, err := sources.Open(valStr)
-					if err != nil {
-						panic(err)
-					}
-					defer func() {
-						_ = s.Close()
-					}()
-
-					m, err := s.Meta()
-					if err != nil {
-						panic(err)
-					}
-
-					stateStr := fmt.Sprintf("etag=%s", m.ETag())
-
-					return stateStr
-				},
-				ConflictsWith: []string{
-					resourceFileAttrContent,
-					resourceFileAttrContentSensitive,
+				RequiredWith: []string{
+					resourceFileAttrSource,
 				},
 			},
 			resourceFileAttrMd5Sum: {
@@ -198,15 +219,110 @@ func resourceFile() *schema.Resource {
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
+			resourceFileAttrSha256Sum: {
+				Description: "SHA-256 checksum of the remote file contents on the system in hex encoding.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 			resourceFileAttrBasename: {
 				Description: fmt.Sprintf("Base name of the file. Returns the last element of path. Example: Given the attribute `%[1]s` is `/path/to/file.txt`, the `%[2]s` is `file.txt`.", resourceFileAttrPath, resourceFileAttrBasename),
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
-		},
+			resourceFileAttrContentPolicy: {
+				Description:      fmt.Sprintf("Policy which controls how the content of the file is managed. `%[1]s` writes the configured content and reverts changes on the remote system. `%[2]s` writes the content only when the file is created; later changes of the configured content and changes on the remote system are ignored. `%[3]s` writes changes of the configured content but ignores changes on the remote system. Ownership and permissions are always enforced. Defaults to `%[1]s`.", resourceFileContentPolicyEnforce, resourceFileContentPolicyCreateOnly, resourceFileContentPolicyIgnoreChangesRemote),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          resourceFileContentPolicyEnforce,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(resourceFileContentPolicies, false)),
+			},
+			resourceFileAttrAdoptExisting: {
+				Description: fmt.Sprintf("Take over a file which already exists at the path instead of failing. The checksum of the original content is recorded. If `%[1]s` is `%[2]s`, the content of an adopted file is retained. Defaults to `false`.", resourceFileAttrContentPolicy, resourceFileContentPolicyCreateOnly),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceFileAttrRestoreOnDestroy: {
+				Description: fmt.Sprintf("Restore the original file including its mode and ownership when the resource is destroyed. An existing file is adopted like with `%[1]s` and stashed in `%[2]s` on the remote system when the resource is created. The file is deleted on destroy if it did not exist before. Defaults to `false`.", resourceFileAttrAdoptExisting, client.FileStashDir),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			internalDataSchemaKey: internalDataSchema(),
+		})),
 	}
 }
 
+type resourceFileInternalData struct {
+	// Adopted is true if the file existed before the resource has been created
+	Adopted bool `json:"adopted,omitempty"`
+
+	// OriginalSha256Sum is the sha256 checksum of the content of the adopted file in hex encoding
+	OriginalSha256Sum string `json:"original_sha256sum,omitempty"`
+
+	// StashPath is the path on the remote system at which the original file is stashed until the resource is destroyed
+	StashPath string `json:"stash_path,omitempty"`
+}
+
+// resourceFileContentEnforced returns true if changes of the content on the remote system are reverted
+func resourceFileContentEnforced(d *schema.ResourceData) bool {
+	policy := d.Get(resourceFileAttrContentPolicy).(string)
+	return policy == "" || policy == resourceFileContentPolicyEnforce
+}
+
+// resourceFileContentDiffSuppress suppresses changes of the configured content of an existing file if the content is
+// only written on create
+func resourceFileContentDiffSuppress(_, _, _ string, d *schema.ResourceData) bool {
+	return d.Id() != "" && d.Get(resourceFileAttrContentPolicy).(string) == resourceFileContentPolicyCreateOnly
+}
+
+// resourceFileAdopt takes over the existing file at the path if attribute `adopt_existing` or `restore_on_destroy` is
+// set. The original file is stashed if attribute `restore_on_destroy` is set. Returns nil if the file does not exist or
+// must not be adopted.
+func resourceFileAdopt(ctx context.Context, c client.FileClient, d *schema.ResourceData) (*resourceFileInternalData, diag.Diagnostics) {
+	restore := d.Get(resourceFileAttrRestoreOnDestroy).(bool)
+	if !d.Get(resourceFileAttrAdoptExisting).(bool) && !restore {
+		return nil, nil
+	}
+
+	existing, err := c.Get(ctx, d.Get(resourceFileAttrPath).(string))
+	if errors.Is(err, client.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	adopted := &resourceFileInternalData{
+		Adopted:           true,
+		OriginalSha256Sum: existing.Sha256Sum,
+	}
+
+	if restore {
+		adopted.StashPath, err = c.Stash(ctx, existing.Path)
+		if err != nil {
+			return nil, newDetailedDiagnostic(diag.Error, "failed to stash the original file", err.Error(), cty.GetAttrPath(resourceFileAttrRestoreOnDestroy))
+		}
+	}
+
+	tflog.Info(ctx, "adopt existing file", map[string]interface{}{
+		"path":       existing.Path,
+		"sha256sum":  existing.Sha256Sum,
+		"stash_path": adopted.StashPath,
+	})
+
+	return adopted, nil
+}
+
+// resourceFileSetAdopted records the adopted file in the internal data
+func resourceFileSetAdopted(d *schema.ResourceData, adopted *resourceFileInternalData) diag.Diagnostics {
+	if adopted == nil {
+		return nil
+	}
+
+	return setInternalData(d, adopted)
+}
+
 func resourceFileGetResourceData(sources *source.Registry, d *schema.ResourceData) (*client.File, diag.Diagnostics) {
 	r := &client.File{
 		Path:    d.Get(resourceFileAttrPath).(string),
@@ -249,6 +365,18 @@ func resourceFileGetResourceData(sources *source.Registry, d *schema.ResourceDat
 		if content != "" {
 			r.Content = bytes.NewReader([]byte(content))
 		}
+	} else if d.HasChange(resourceFileAttrContentBase64) || d.HasChange(resourceFileAttrContentBase64Sensitive) {
+		contentBase64 := d.Get(resourceFileAttrContentBase64).(string)
+		if contentBase64 == "" {
+			contentBase64 = d.Get(resourceFileAttrContentBase64Sensitive).(string)
+		}
+		if contentBase64 != "" {
+			content, err := base64.StdEncoding.DecodeString(contentBase64)
+			if err != nil {
+				return nil, diag.FromErr(err)
+			}
+			r.Content = bytes.NewReader(content)
+		}
 	} else if d.HasChange(resourceFileAttrSource) {
 		sourceUrlStr := d.Get(resourceFileAttrSource).(string)
 		s, err := sources.Open(sourceUrlStr)
@@ -257,6 +385,18 @@ func resourceFileGetResourceData(sources *source.Registry, d *schema.ResourceDat
 		}
 
 		r.Content = s
+
+		if v := d.Get(resourceFileAttrSourceChecksum).(string); v != "" {
+			c, err := checksum.Parse(v)
+			if err != nil {
+				_ = s.Close()
+				return nil, newDetailedDiagnostic(diag.Error, "invalid checksum", err.Error(), cty.GetAttrPath(resourceFileAttrSourceChecksum))
+			}
+
+			// Verify the checksum when the source is read and on the remote system
+			r.Content = checksum.NewReader(s, c)
+			r.Checksum = c
+		}
 	}
 
 	return r, nil
@@ -271,6 +411,7 @@ func resourceFileSetResourceData(r *client.File, d *schema.ResourceData) diag.Di
 	_ = d.Set(resourceFileAttrGid, r.Gid)
 
 	_ = d.Set(resourceFileAttrMd5Sum, r.Md5Sum)
+	_ = d.Set(resourceFileAttrSha256Sum, r.Sha256Sum)
 	_ = d.Set(resourceFileAttrBasename, path.Base(r.Path))
 
 	if r.Content != nil {
@@ -284,18 +425,38 @@ func resourceFileSetResourceData(r *client.File, d *schema.ResourceData) diag.Di
 			_ = d.Set(resourceFileAttrContent, string(content))
 		} else if _, hasContentSensitive := d.GetOk(resourceFileAttrContentSensitive); hasContentSensitive {
 			_ = d.Set(resourceFileAttrContentSensitive, string(content))
+		} else if _, hasContentBase64 := d.GetOk(resourceFileAttrContentBase64); hasContentBase64 {
+			_ = d.Set(resourceFileAttrContentBase64, base64.StdEncoding.EncodeToString(content))
+		} else if _, hasContentBase64Sensitive := d.GetOk(resourceFileAttrContentBase64Sensitive); hasContentBase64Sensitive {
+			_ = d.Set(resourceFileAttrContentBase64Sensitive, base64.StdEncoding.EncodeToString(content))
 		} else {
 			// Store in `content` attribute if ambiguous (applies to import)
 			_ = d.Set(resourceFileAttrContent, string(content))
 		}
-	} else {
+	} else if resourceFileContentEnforced(d) {
 		_ = d.Set(resourceFileAttrContent, nil)
 		_ = d.Set(resourceFileAttrContentSensitive, nil)
+		_ = d.Set(resourceFileAttrContentBase64, nil)
+		_ = d.Set(resourceFileAttrContentBase64Sensitive, nil)
 	}
 
 	return nil
 }
 
+// resourceFileContentDiagnostics returns the diagnostics of a failed transfer. A checksum mismatch of the source read by
+// the provider takes precedence over the error returned by the remote system.
+func resourceFileContentDiagnostics(r *client.File, err error) diag.Diagnostics {
+	if cr, ok := r.Content.(*checksum.Reader); ok && cr.Err() != nil {
+		err = cr.Err()
+	}
+
+	if errors.Is(err, checksum.ErrMismatch) || errors.Is(err, client.ErrFileChecksum) {
+		return newDetailedDiagnostic(diag.Error, "source checksum mismatch", err.Error(), cty.GetAttrPath(resourceFileAttrSourceChecksum))
+	}
+
+	return diag.FromErr(err)
+}
+
 func resourceFileCreateFactory(sources *source.Registry) schema.CreateContextFunc {
 	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 		p, diagErr := providerFromMeta(meta)
@@ -305,14 +466,39 @@ func resourceFileCreateFactory(sources *source.Registry) schema.CreateContextFun
 
 		c := client.NewFileClient(p.System, client.FileClientCompression(true))
 
+		adopted, diagErr := resourceFileAdopt(ctx, c, d)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		// The content of an adopted file is retained if the content is only written on create
+		retainContent := adopted != nil && d.Get(resourceFileAttrContentPolicy).(string) == resourceFileContentPolicyCreateOnly
+
+		if d.Get(resourceFileAttrDownloadOnRemote).(bool) && !retainContent {
+			return resourceFileCreateFromSource(ctx, sources, d, meta, c, adopted)
+		}
+
 		r, diagErr := resourceFileGetResourceData(sources, d)
 		if diagErr != nil {
 			return diagErr
 		}
 
-		err := c.Create(ctx, *r)
+		if retainContent {
+			if contentCloser, isCloser := r.Content.(io.Closer); isCloser {
+				_ = contentCloser.Close()
+			}
+			r.Content = nil
+			r.Checksum = nil
+		}
+
+		var err error
+		if adopted != nil {
+			err = c.Update(ctx, *r)
+		} else {
+			err = c.Create(ctx, *r)
+		}
 		if err != nil {
-			return diag.FromErr(err)
+			return resourceFileContentDiagnostics(r, err)
 		}
 
 		// Close source if source is an io.Closer
@@ -325,13 +511,113 @@ func resourceFileCreateFactory(sources *source.Registry) schema.CreateContextFun
 
 		d.SetId(r.Path)
 
+		diagErr = resourceFileSetAdopted(d, adopted)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		diagErr = fileAttrsApply(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFile, r.Path, d, false)
+		if diagErr != nil {
+			return diagErr
+		}
+
 		diagErr = resourceFileRead(ctx, d, meta)
 		if diagErr != nil {
 			return diagErr
 		}
 
-		return nil
+		return onChangeRun(ctx, p.System, d, resourceFileOnChangeAttrs...)
+	}
+}
+
+// resourceFileDownloadAttempts is the maximum number of attempts to fetch a source on the remote system
+const resourceFileDownloadAttempts = 3
+
+// resourceFileCreateFromSource creates the file with a source which is fetched by the remote system. The download replaces the
+// adopted file if not nil.
+func resourceFileCreateFromSource(ctx context.Context, sources *source.Registry, d *schema.ResourceData, meta interface{}, c client.FileClient, adopted *resourceFileInternalData) diag.Diagnostics {
+	sourceUrlStr := d.Get(resourceFileAttrSource).(string)
+
+	sourceUrl, err := url.Parse(sourceUrlStr)
+	if err != nil || (sourceUrl.Scheme != source.HttpScheme && sourceUrl.Scheme != source.HttpsScheme) {
+		return newDetailedDiagnostic(diag.Error, fmt.Sprintf("attribute `%s` requires a http or https url", resourceFileAttrDownloadOnRemote), fmt.Sprintf("url %q cannot be fetched by the remote system", sourceUrlStr), cty.GetAttrPath(resourceFileAttrSource))
+	}
+
+	// Only the meta of the source is retrieved by the provider
+	s, err := sources.Open(sourceUrlStr)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	m, err := s.Meta()
+	_ = s.Close()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Verify the size if known. The etag of a http source is opaque and not verified; the contents are only verified
+	// with the checksum of `source_checksum`.
+	dl := client.FileDownload{
+		Url:      sourceUrlStr,
+		Size:     m.Size(),
+		Attempts: resourceFileDownloadAttempts,
+		Replace:  adopted != nil,
+	}
+
+	r, diagErr := resourceFileGetResourceData(sources, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	dl.Checksum = r.Checksum
+
+	// Content is fetched by the remote system
+	if contentCloser, isCloser := r.Content.(io.Closer); isCloser {
+		_ = contentCloser.Close()
+	}
+	r.Content = nil
+
+	var diags diag.Diagnostics
+
+	result, err := c.Download(ctx, *r, dl)
+	if result != nil {
+		tflog.Info(ctx, "download on remote system", map[string]interface{}{
+			"url":      sourceUrlStr,
+			"tool":     result.Tool,
+			"size":     result.Size,
+			"failures": len(result.Failures),
+		})
+
+		for _, failure := range result.Failures {
+			diags = append(diags, newDetailedDiagnostic(diag.Warning, fmt.Sprintf("download of %q on the remote system: %s", sourceUrlStr, failure), result.Stderr, cty.GetAttrPath(resourceFileAttrSource))...)
+		}
+	}
+	if err != nil {
+		return append(diags, newDetailedDiagnostic(diag.Error, fmt.Sprintf("failed to download %q on the remote system", sourceUrlStr), err.Error(), cty.GetAttrPath(resourceFileAttrSource))...)
+	}
+
+	d.SetId(r.Path)
+
+	diagErr = resourceFileSetAdopted(d, adopted)
+	if diagErr != nil {
+		return append(diags, diagErr...)
+	}
+
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return append(diags, diagErr...)
 	}
+
+	diagErr = fileAttrsApply(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFile, r.Path, d, false)
+	if diagErr != nil {
+		return append(diags, diagErr...)
+	}
+
+	diagErr = resourceFileRead(ctx, d, meta)
+	if diagErr != nil {
+		return append(diags, diagErr...)
+	}
+
+	return append(diags, onChangeRun(ctx, p.System, d, resourceFileOnChangeAttrs...)...)
 }
 
 func resourceFileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -342,10 +628,13 @@ func resourceFileRead(ctx context.Context, d *schema.ResourceData, meta interfac
 
 	_, hasContent := d.GetOk(resourceFileAttrContent)
 	_, hasContentSensitive := d.GetOk(resourceFileAttrContentSensitive)
+	_, hasContentBase64 := d.GetOk(resourceFileAttrContentBase64)
+	_, hasContentBase64Sensitive := d.GetOk(resourceFileAttrContentBase64Sensitive)
 	_, hasSource := d.GetOk(resourceFileAttrSource)
 
-	// Include content when attributes `content` or `content_sensitive` are set or when attribute `source` is not set
-	includeContentOpt := client.FileClientIncludeContent((hasContent || hasContentSensitive) && !hasSource)
+	// Include content when one of the content attributes is set or when attribute `source` is not set.
+	// Content is not included if changes on the remote system are ignored.
+	includeContentOpt := client.FileClientIncludeContent((hasContent || hasContentSensitive || hasContentBase64 || hasContentBase64Sensitive) && !hasSource && resourceFileContentEnforced(d))
 	c := client.NewFileClient(p.System, includeContentOpt, client.FileClientCompression(true))
 
 	id := d.Id()
@@ -360,6 +649,11 @@ func resourceFileRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diagErr
 	}
 
+	diagErr = fileAttrsRead(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFile, id, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
 	return nil
 }
 
@@ -371,18 +665,34 @@ func resourceFileUpdateFactory(sources *source.Registry) schema.UpdateContextFun
 		}
 
 		c := client.NewFileClient(p.System)
+		ac := client.NewFileAttrsClient(p.System)
 
 		r, diagErr := resourceFileGetResourceData(sources, d)
 		if diagErr != nil {
 			return diagErr
 		}
 
+		unlocked, diagErr := fileAttrsUnlock(ctx, ac, fileAttrsKindFile, r.Path, d)
+		if diagErr != nil {
+			return diagErr
+		}
+
 		err := c.Update(ctx, *r)
 		if err != nil {
-			return diag.FromErr(err)
+			return resourceFileContentDiagnostics(r, err)
 		}
 
-		return resourceFileRead(ctx, d, meta)
+		diagErr = fileAttrsApply(ctx, ac, fileAttrsKindFile, r.Path, d, unlocked)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		diagErr = resourceFileRead(ctx, d, meta)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		return onChangeRun(ctx, p.System, d, resourceFileOnChangeAttrs...)
 	}
 }
 
@@ -396,12 +706,46 @@ func resourceFileDelete(ctx context.Context, d *schema.ResourceData, meta interf
 
 	id := d.Id()
 
+	_, diagErr = fileAttrsUnlock(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFile, id, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	var internalData resourceFileInternalData
+	_, diagErr = getInternalData(d, &internalData)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	var diags diag.Diagnostics
+
+	if internalData.StashPath != "" {
+		if d.Get(resourceFileAttrRestoreOnDestroy).(bool) {
+			err := c.Restore(ctx, id, internalData.StashPath)
+			if err == nil {
+				return nil
+			}
+
+			if !errors.Is(err, client.ErrFileStashNotFound) {
+				return diag.FromErr(err)
+			}
+
+			diags = append(diags, newDetailedDiagnostic(diag.Warning, "original file cannot be restored", fmt.Sprintf("the stashed file %q does not exist; %q is deleted instead", internalData.StashPath, id), cty.GetAttrPath(resourceFileAttrRestoreOnDestroy))...)
+		} else {
+			// The original file is discarded because restore has been disabled after the resource was created
+			err := c.Delete(ctx, internalData.StashPath)
+			if err != nil && !errors.Is(err, client.ErrFileNotFound) {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
 	err := c.Delete(ctx, id)
 	if err != nil {
-		return diag.FromErr(err)
+		return append(diags, diag.FromErr(err)...)
 	}
 
-	return nil
+	return diags
 }
 
 func resourceFileImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
@@ -421,11 +765,20 @@ func resourceFileImportState(ctx context.Context, d *schema.ResourceData, m inte
 		} else if importIdParts[1] == "content_sensitive" {
 			// Import with `content_sensitive` attribute
 			d.Set(resourceFileAttrContentSensitive, "_")
+		} else if importIdParts[1] == "content_base64" {
+			// Import with `content_base64` attribute
+			d.Set(resourceFileAttrContentBase64, "_")
+		} else if importIdParts[1] == "content_base64_sensitive" {
+			// Import with `content_base64_sensitive` attribute
+			d.Set(resourceFileAttrContentBase64Sensitive, "_")
 		} else {
 			return nil, fmt.Errorf("unexpected import id format")
 		}
 	}
 
+	_ = d.Set(resourceFileAttrContentPolicy, resourceFileContentPolicyEnforce)
+	_ = d.Set(resourceFileAttrAdoptExisting, false)
+
 	d.SetId(importIdParts[0])
 
 	return []*schema.ResourceData{d}, nil
@@ -0,0 +1,288 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"regexp"
+	"strings"
+)
+
+const resourceFileBlockName = "system_file_block"
+
+const (
+	resourceFileBlockAttrId            = "id"
+	resourceFileBlockAttrPath          = "path"
+	resourceFileBlockAttrName          = "name"
+	resourceFileBlockAttrMarkerBegin   = "marker_begin"
+	resourceFileBlockAttrMarkerEnd     = "marker_end"
+	resourceFileBlockAttrContent       = "content"
+	resourceFileBlockAttrInsertPattern = "insert_pattern"
+	resourceFileBlockAttrInsertPos     = "insert_position"
+)
+
+const (
+	resourceFileBlockMarkerNamePlaceholder = "{name}"
+
+	resourceFileBlockMarkerBeginDefault = "# BEGIN terraform " + resourceFileBlockMarkerNamePlaceholder
+
+	resourceFileBlockMarkerEndDefault = "# END terraform " + resourceFileBlockMarkerNamePlaceholder
+)
+
+var regexpFileBlockName = regexp.MustCompile(`^[^:\n]+$`)
+
+var regexpFileBlockMarker = regexp.MustCompile(`^[^\n]+$`)
+
+func resourceFileBlock() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceFileBlockCreate,
+		ReadContext:   resourceFileBlockRead,
+		UpdateContext: resourceFileBlockUpdate,
+		DeleteContext: resourceFileBlockDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages a block of lines enclosed by marker lines in a file on the remote system.", resourceFileBlockName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceFileBlockImportState,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceFileBlockAttrId: {
+				Description: "ID of the block",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileBlockAttrPath: {
+				Description:      "Path to the file which contains the block. Must be an absolute path. The file must exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceFileBlockAttrName: {
+				Description:      fmt.Sprintf("Name of the block which is unique within the file. The name replaces the placeholder `%[1]s` in the attributes `%[2]s` and `%[3]s`. Must not contain a colon or a newline.", resourceFileBlockMarkerNamePlaceholder, resourceFileBlockAttrMarkerBegin, resourceFileBlockAttrMarkerEnd),
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.StringMatch(regexpFileBlockName, "invalid block name"),
+			},
+			resourceFileBlockAttrMarkerBegin: {
+				Description:      fmt.Sprintf("Template of the line which marks the beginning of the block. The placeholder `%[1]s` is replaced with the attribute `%[2]s`. Defaults to `%[3]s`.", resourceFileBlockMarkerNamePlaceholder, resourceFileBlockAttrName, resourceFileBlockMarkerBeginDefault),
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Default:          resourceFileBlockMarkerBeginDefault,
+				ValidateDiagFunc: validate.StringMatch(regexpFileBlockMarker, "invalid marker"),
+			},
+			resourceFileBlockAttrMarkerEnd: {
+				Description:      fmt.Sprintf("Template of the line which marks the end of the block. The placeholder `%[1]s` is replaced with the attribute `%[2]s`. Defaults to `%[3]s`.", resourceFileBlockMarkerNamePlaceholder, resourceFileBlockAttrName, resourceFileBlockMarkerEndDefault),
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Default:          resourceFileBlockMarkerEndDefault,
+				ValidateDiagFunc: validate.StringMatch(regexpFileBlockMarker, "invalid marker"),
+			},
+			resourceFileBlockAttrContent: {
+				Description: "Content of the block without the marker lines. Content of the file outside of the block is not managed.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			resourceFileBlockAttrInsertPos: {
+				Description:  fmt.Sprintf("Position at which the block is inserted if the block does not exist in the file. Supported values are `%[1]s`, `%[2]s`, `%[3]s`, and `%[4]s`. Positions `%[3]s` and `%[4]s` require the attribute `%[5]s`. An existing block is updated in place. Defaults to `%[1]s`.", client.FileBlockPositionEnd, client.FileBlockPositionBeginning, client.FileBlockPositionAfter, client.FileBlockPositionBefore, resourceFileBlockAttrInsertPattern),
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      string(client.FileBlockPositionEnd),
+				ValidateFunc: validation.StringInSlice([]string{string(client.FileBlockPositionEnd), string(client.FileBlockPositionBeginning), string(client.FileBlockPositionAfter), string(client.FileBlockPositionBefore)}, false),
+			},
+			resourceFileBlockAttrInsertPattern: {
+				Description:  fmt.Sprintf("Regular expression which matches a line in the file. With `%[1]s = \"%[2]s\"` the block is inserted after the last matching line, with `%[1]s = \"%[3]s\"` the block is inserted before the first matching line. If no line matches, the block is inserted at the end of the file.", resourceFileBlockAttrInsertPos, client.FileBlockPositionAfter, client.FileBlockPositionBefore),
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+		},
+	}
+}
+
+func resourceFileBlockId(path string, name string) string {
+	return fmt.Sprintf("%s:%s", path, name)
+}
+
+// resourceFileBlockMarker renders a marker template with the name of the block
+func resourceFileBlockMarker(template string, defaultTemplate string, name string) string {
+	if template == "" {
+		template = defaultTemplate
+	}
+	return strings.ReplaceAll(template, resourceFileBlockMarkerNamePlaceholder, name)
+}
+
+func resourceFileBlockGetResourceData(d *schema.ResourceData) (*client.FileBlock, diag.Diagnostics) {
+	name := d.Get(resourceFileBlockAttrName).(string)
+
+	r := &client.FileBlock{
+		Path:        d.Get(resourceFileBlockAttrPath).(string),
+		MarkerBegin: resourceFileBlockMarker(d.Get(resourceFileBlockAttrMarkerBegin).(string), resourceFileBlockMarkerBeginDefault, name),
+		MarkerEnd:   resourceFileBlockMarker(d.Get(resourceFileBlockAttrMarkerEnd).(string), resourceFileBlockMarkerEndDefault, name),
+		Content:     d.Get(resourceFileBlockAttrContent).(string),
+		Insert: client.FileBlockInsert{
+			Position: client.FileBlockPosition(d.Get(resourceFileBlockAttrInsertPos).(string)),
+			Pattern:  d.Get(resourceFileBlockAttrInsertPattern).(string),
+		},
+	}
+
+	if (r.Insert.Position == client.FileBlockPositionAfter || r.Insert.Position == client.FileBlockPositionBefore) && r.Insert.Pattern == "" {
+		return nil, newDetailedDiagnostic(diag.Error, fmt.Sprintf("attribute `%s` is required", resourceFileBlockAttrInsertPattern), fmt.Sprintf("`%s = %q` requires a pattern", resourceFileBlockAttrInsertPos, r.Insert.Position), nil)
+	}
+
+	if r.MarkerBegin == r.MarkerEnd {
+		return nil, newShortDiagnostic(diag.Error, fmt.Sprintf("attributes `%s` and `%s` must differ", resourceFileBlockAttrMarkerBegin, resourceFileBlockAttrMarkerEnd))
+	}
+
+	return r, nil
+}
+
+func resourceFileBlockSetResourceData(r *client.FileBlock, d *schema.ResourceData) diag.Diagnostics {
+	_ = d.Set(resourceFileBlockAttrPath, r.Path)
+
+	// The block body is always terminated with a newline in the file
+	// Retain the configured content if it only lacks the trailing newline
+	content := r.Content
+	if configured, ok := d.Get(resourceFileBlockAttrContent).(string); ok && configured+"\n" == content {
+		content = configured
+	}
+
+	_ = d.Set(resourceFileBlockAttrContent, content)
+
+	return nil
+}
+
+func resourceFileBlockCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileBlockClient(p.System)
+
+	r, diagErr := resourceFileBlockGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	err := c.Apply(ctx, *r)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(resourceFileBlockId(r.Path, d.Get(resourceFileBlockAttrName).(string)))
+
+	return resourceFileBlockRead(ctx, d, meta)
+}
+
+func resourceFileBlockRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileBlockClient(p.System)
+
+	name := d.Get(resourceFileBlockAttrName).(string)
+
+	args := client.FileBlock{
+		Path:        d.Get(resourceFileBlockAttrPath).(string),
+		MarkerBegin: resourceFileBlockMarker(d.Get(resourceFileBlockAttrMarkerBegin).(string), resourceFileBlockMarkerBeginDefault, name),
+		MarkerEnd:   resourceFileBlockMarker(d.Get(resourceFileBlockAttrMarkerEnd).(string), resourceFileBlockMarkerEndDefault, name),
+	}
+
+	r, err := c.Get(ctx, args)
+	if errors.Is(err, client.ErrFileBlockNotFound) || errors.Is(err, client.ErrFileBlockFileNotFound) {
+		// Block has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	diagErr = resourceFileBlockSetResourceData(r, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return nil
+}
+
+func resourceFileBlockUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileBlockClient(p.System)
+
+	r, diagErr := resourceFileBlockGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	err := c.Apply(ctx, *r)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return resourceFileBlockRead(ctx, d, meta)
+}
+
+func resourceFileBlockDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileBlockClient(p.System)
+
+	r, diagErr := resourceFileBlockGetResourceData(d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	err := c.Delete(ctx, *r)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceFileBlockImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	importId := d.Id()
+
+	// Expect import id in the format `path:name`
+	sep := strings.LastIndex(importId, ":")
+	if sep <= 0 || sep == len(importId)-1 {
+		return nil, fmt.Errorf("unexpected import id format, expected `path:name`")
+	}
+
+	_ = d.Set(resourceFileBlockAttrPath, importId[:sep])
+	_ = d.Set(resourceFileBlockAttrName, importId[sep+1:])
+	_ = d.Set(resourceFileBlockAttrMarkerBegin, resourceFileBlockMarkerBeginDefault)
+	_ = d.Set(resourceFileBlockAttrMarkerEnd, resourceFileBlockMarkerEndDefault)
+	_ = d.Set(resourceFileBlockAttrInsertPos, string(client.FileBlockPositionEnd))
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,165 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	testFileBlockId uint32
+)
+
+type testFileBlockConfig struct {
+	fileName  string
+	blockName string
+}
+
+func newTestFileBlockConfig() testFileBlockConfig {
+	id := atomic.AddUint32(&testFileBlockId, 1)
+
+	return testFileBlockConfig{
+		fileName:  fmt.Sprintf("file-block-%d", id),
+		blockName: fmt.Sprintf("block%d", id),
+	}
+}
+
+func TestAccFileBlock_create(t *testing.T) {
+	testConfig := newTestFileBlockConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+						),
+						testAccFileBlockBlock("test", filePath, testConfig.blockName,
+							tfbuild.AttributeString("content", "managed line\n"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file_block", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_block.test", "id", fmt.Sprintf("%s:%s", filePath, testConfig.blockName)),
+						resource.TestCheckResourceAttr("system_file_block.test", "content", "managed line\n"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", fmt.Sprintf("hello world!\n# BEGIN terraform %[1]s\nmanaged line\n# END terraform %[1]s\n", testConfig.blockName)),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFileBlock_update_content(t *testing.T) {
+	testConfig := newTestFileBlockConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+						),
+						testAccFileBlockBlock("test", filePath, testConfig.blockName,
+							tfbuild.AttributeString("content", "first"),
+							tfbuild.AttributeString("insert_position", "beginning"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_block.test", "content", "first"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+						),
+						testAccFileBlockBlock("test", filePath, testConfig.blockName,
+							tfbuild.AttributeString("content", "second\nthird\n"),
+							tfbuild.AttributeString("insert_position", "beginning"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file_block", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_block.test", "content", "second\nthird\n"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", fmt.Sprintf("# BEGIN terraform %[1]s\nsecond\nthird\n# END terraform %[1]s\nhello world!\n", testConfig.blockName)),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFileBlock_custom_markers(t *testing.T) {
+	testConfig := newTestFileBlockConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+						),
+						testAccFileBlockBlock("test", filePath, testConfig.blockName,
+							tfbuild.AttributeString("marker_begin", "; >>> {name}"),
+							tfbuild.AttributeString("marker_end", "; <<< {name}"),
+							tfbuild.AttributeString("content", "managed line\n"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file_block", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.test", "content", fmt.Sprintf("hello world!\n; >>> %[1]s\nmanaged line\n; <<< %[1]s\n", testConfig.blockName)),
+					),
+				},
+			},
+		})
+	})
+}
+
+func testAccFileBlockBlock(name string, path string, blockName string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	resourceAttrs := []tfbuild.BlockElement{
+		tfbuild.AttributeString("path", path),
+		tfbuild.AttributeString("name", blockName),
+	}
+	resourceAttrs = append(resourceAttrs, attrs...)
+
+	return tfbuild.Resource("system_file_block", name, resourceAttrs...)
+}
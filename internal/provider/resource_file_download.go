@@ -0,0 +1,276 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const resourceFileDownloadName = "system_file_download"
+
+const (
+	resourceFileDownloadAttrId           = "id"
+	resourceFileDownloadAttrSource       = "source"
+	resourceFileDownloadAttrDestination  = "destination"
+	resourceFileDownloadAttrType         = "type"
+	resourceFileDownloadAttrMode         = "mode"
+	resourceFileDownloadAttrMd5Sum       = "md5sum"
+	resourceFileDownloadAttrSourceMd5Sum = "source_md5sum"
+	resourceFileDownloadAttrSha256Sum    = "sha256sum"
+)
+
+// resourceFileDownloadArchiveMode is the mode of the local archive of a folder
+const resourceFileDownloadArchiveMode fs.FileMode = 0600
+
+func resourceFileDownload() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceFileDownloadCreate,
+		ReadContext:   resourceFileDownloadRead,
+		DeleteContext: resourceFileDownloadDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` downloads a file or a folder from the remote system to the local system which runs terraform.", resourceFileDownloadName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		// Importer is intentionally not configured
+		// The local destination does not record the remote source which it has been downloaded from
+		// Create will download the source again and replace an existing destination
+
+		CustomizeDiff: resourceFileDownloadCustomizeDiff,
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceFileDownloadAttrId: {
+				Description: "ID of the download",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileDownloadAttrSource: {
+				Description:      "Path to the file or folder on the remote system. Must be an absolute path. Symbolic links are followed.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceFileDownloadAttrDestination: {
+				Description:      "Path to the file on the local system. Missing parent folders are created. A file is stored as is. A folder is stored as a gzip compressed tar archive.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+			},
+			resourceFileDownloadAttrType: {
+				Description: fmt.Sprintf("Type of the source. Either `%s` or `%s`.", client.FileTypeFile, client.FileTypeDirectory),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileDownloadAttrMode: {
+				Description: fmt.Sprintf("Mode of the local file. Equals the mode of the source file. The archive of a folder has mode `%s`.", filemode.Mode(resourceFileDownloadArchiveMode).String()),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileDownloadAttrMd5Sum: {
+				Description: "Base64 encoded md5 checksum of the downloaded source. For a folder, the checksum covers the names and contents of the regular files within the folder.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileDownloadAttrSourceMd5Sum: {
+				Description: fmt.Sprintf("Base64 encoded md5 checksum of the source on the remote system. The source is downloaded again if `%s` differs from `%s` or if the local file has been changed or removed.", resourceFileDownloadAttrSourceMd5Sum, resourceFileDownloadAttrMd5Sum),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileDownloadAttrSha256Sum: {
+				Description: "Hex encoded sha256 checksum of the local file.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceFileDownloadCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileFetchClient(p.System)
+
+	source := d.Get(resourceFileDownloadAttrSource).(string)
+	destination := d.Get(resourceFileDownloadAttrDestination).(string)
+
+	f, err := c.Get(ctx, source)
+	if errors.Is(err, client.ErrFileFetchNotFound) || errors.Is(err, client.ErrFileFetchUnsupported) {
+		return newDetailedDiagnostic(diag.Error, "source not found", fmt.Sprintf("%q is neither a file nor a folder", source), cty.GetAttrPath(resourceFileDownloadAttrSource))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	mode := f.Mode
+	if f.Type == client.FileTypeDirectory {
+		mode = resourceFileDownloadArchiveMode
+	}
+
+	sha256Sum, err := resourceFileDownloadWrite(destination, mode, func(w io.Writer) error {
+		return c.Fetch(ctx, *f, w)
+	})
+	if errors.Is(err, client.ErrFileFetchChecksum) {
+		return newDetailedDiagnostic(diag.Error, "download verification failed", err.Error(), cty.GetAttrPath(resourceFileDownloadAttrSource))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(destination)
+
+	_ = d.Set(resourceFileDownloadAttrMd5Sum, f.Md5Sum)
+	_ = d.Set(resourceFileDownloadAttrSha256Sum, sha256Sum)
+
+	return resourceFileDownloadRead(ctx, d, meta)
+}
+
+func resourceFileDownloadRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileFetchClient(p.System)
+
+	f, err := c.Get(ctx, d.Get(resourceFileDownloadAttrSource).(string))
+	if errors.Is(err, client.ErrFileFetchNotFound) || errors.Is(err, client.ErrFileFetchUnsupported) {
+		// Source has been removed on the remote system
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	mode := f.Mode
+	if f.Type == client.FileTypeDirectory {
+		mode = resourceFileDownloadArchiveMode
+	}
+
+	_ = d.Set(resourceFileDownloadAttrType, string(f.Type))
+	_ = d.Set(resourceFileDownloadAttrMode, filemode.Mode(mode).String())
+	_ = d.Set(resourceFileDownloadAttrSourceMd5Sum, f.Md5Sum)
+
+	// A local file which has been changed or removed outside of terraform invalidates the download
+	localSha256Sum, err := resourceFileDownloadLocalSha256Sum(d.Get(resourceFileDownloadAttrDestination).(string))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return diag.FromErr(err)
+	}
+	if localSha256Sum != d.Get(resourceFileDownloadAttrSha256Sum).(string) {
+		_ = d.Set(resourceFileDownloadAttrMd5Sum, "")
+	}
+
+	return nil
+}
+
+func resourceFileDownloadDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	err := os.Remove(d.Get(resourceFileDownloadAttrDestination).(string))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceFileDownloadCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// Download again if the source changed on the remote system or the local file is outdated
+	sourceMd5Sum := d.Get(resourceFileDownloadAttrSourceMd5Sum).(string)
+	if sourceMd5Sum != "" && sourceMd5Sum != d.Get(resourceFileDownloadAttrMd5Sum).(string) {
+		if err := d.SetNew(resourceFileDownloadAttrMd5Sum, sourceMd5Sum); err != nil {
+			return err
+		}
+		return d.ForceNew(resourceFileDownloadAttrMd5Sum)
+	}
+
+	return nil
+}
+
+// resourceFileDownloadWrite writes the local file at path atomically with the data provided by fetch. Returns the hex
+// encoded sha256 checksum of the written data.
+func resourceFileDownloadWrite(path string, mode fs.FileMode, fetch func(w io.Writer) error) (string, error) {
+	dir := filepath.Dir(path)
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf(".%s.*", filepath.Base(path)))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	err = fetch(io.MultiWriter(tmp, h))
+	if err != nil {
+		return "", err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Chmod(tmp.Name(), mode)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// resourceFileDownloadLocalSha256Sum returns the hex encoded sha256 checksum of the local file at path
+func resourceFileDownloadLocalSha256Sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
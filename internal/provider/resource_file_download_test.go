@@ -0,0 +1,156 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccFileDownload_file(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+		localPath := filepath.Join(t.TempDir(), "downloads", "file.txt")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						testAccFileDownloadBlock("test", filePath, localPath,
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_download.test", "id", localPath),
+						resource.TestCheckResourceAttr("system_file_download.test", "type", "file"),
+						resource.TestCheckResourceAttr("system_file_download.test", "mode", "640"),
+						// printf 'hello world!' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file_download.test", "md5sum", "/D/5joxqDTCH1RXARz+Gdw=="),
+						resource.TestCheckResourceAttr("system_file_download.test", "source_md5sum", "/D/5joxqDTCH1RXARz+Gdw=="),
+						resource.TestCheckResourceAttr("system_file_download.test", "sha256sum", "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+						testCheckLocalFile(localPath, "hello world!", 0640),
+					),
+				},
+				{
+					// The file is downloaded again after the content changed on the remote system
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.AttributeString("content", "hello terraform!"),
+						),
+						testAccFileDownloadBlock("test", filePath, localPath,
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					ExpectNonEmptyPlan: true,
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.AttributeString("content", "hello terraform!"),
+						),
+						testAccFileDownloadBlock("test", filePath, localPath,
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						// printf 'hello terraform!' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file_download.test", "md5sum", "A4KL2+0oO5Y4VA0zlAMLFg=="),
+						testCheckLocalFile(localPath, "hello terraform!", 0640),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFileDownload_folder(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+		localPath := filepath.Join(t.TempDir(), "folder.tar.gz")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						testAccFileBlock("test", path.Join(folderPath, "file.txt"),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+						testAccFileDownloadBlock("test", folderPath, localPath,
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_download.test", "type", "directory"),
+						resource.TestCheckResourceAttr("system_file_download.test", "mode", "600"),
+						resource.TestCheckResourceAttrSet("system_file_download.test", "md5sum"),
+						resource.TestCheckResourceAttrPair("system_file_download.test", "md5sum", "system_file_download.test", "source_md5sum"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func testAccFileDownloadBlock(name string, source string, destination string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	resourceAttrs := []tfbuild.BlockElement{
+		tfbuild.AttributeString("source", source),
+		tfbuild.AttributeString("destination", destination),
+	}
+	resourceAttrs = append(resourceAttrs, attrs...)
+
+	return tfbuild.Resource("system_file_download", name, resourceAttrs...)
+}
+
+// testCheckLocalFile verifies the content and the mode of a file on the local system
+func testCheckLocalFile(path string, content string, mode os.FileMode) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().Perm() != mode {
+			return fmt.Errorf("expected mode %o of %q but got %o", mode, path, info.Mode().Perm())
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if string(b) != content {
+			return fmt.Errorf("expected content %q of %q but got %q", content, path, string(b))
+		}
+
+		return nil
+	}
+}
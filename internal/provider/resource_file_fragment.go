@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"regexp"
+	"strings"
+)
+
+const resourceFileFragmentName = "system_file_fragment"
+
+const (
+	resourceFileFragmentAttrId       = "id"
+	resourceFileFragmentAttrTarget   = "target"
+	resourceFileFragmentAttrName     = "name"
+	resourceFileFragmentAttrOrder    = "order"
+	resourceFileFragmentAttrContent  = "content"
+	resourceFileFragmentAttrRendered = "rendered"
+)
+
+var regexpFileFragmentName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func resourceFileFragment() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceFileFragmentCreate,
+		ReadContext:   resourceFileFragmentRead,
+		UpdateContext: resourceFileFragmentUpdate,
+		DeleteContext: resourceFileFragmentDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages a fragment of a file on the remote system which is assembled from all fragments of the file.", resourceFileFragmentName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		CustomizeDiff: resourceFileFragmentCustomizeDiff,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceFileFragmentImportState,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceFileFragmentAttrId: {
+				Description: "ID of the fragment",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFileFragmentAttrTarget: {
+				Description:      "Path to the file which is assembled from the fragments. Must be an absolute path. The folder of the file must exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceFileFragmentAttrName: {
+				Description:      "Name of the fragment which is unique within the target. Must only contain letters, digits, `_`, `-`, and `.`.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.StringMatch(regexpFileFragmentName, "invalid fragment name"),
+			},
+			resourceFileFragmentAttrOrder: {
+				Description:  fmt.Sprintf("Position of the fragment in the target. Fragments are assembled in ascending order. Fragments with the same order are assembled in order of their names. Must be between `0` and `%d`. Defaults to `0`.", client.FileFragmentMaxOrder),
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, client.FileFragmentMaxOrder),
+			},
+			resourceFileFragmentAttrContent: {
+				Description: "Content of the fragment. The content is inserted verbatim. Terminate the content with a newline to separate the fragment from the next fragment.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			resourceFileFragmentAttrRendered: {
+				Description: "Whether the target equals the concatenation of all fragments. The target is assembled again if the target has been changed outside of terraform.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceFileFragmentId(target string, name string) string {
+	return fmt.Sprintf("%s:%s", target, name)
+}
+
+func resourceFileFragmentGetResourceData(d *schema.ResourceData) client.FileFragment {
+	return client.FileFragment{
+		Target:  d.Get(resourceFileFragmentAttrTarget).(string),
+		Name:    d.Get(resourceFileFragmentAttrName).(string),
+		Order:   d.Get(resourceFileFragmentAttrOrder).(int),
+		Content: d.Get(resourceFileFragmentAttrContent).(string),
+	}
+}
+
+func resourceFileFragmentApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileFragmentClient(p.System)
+
+	err := c.Apply(ctx, resourceFileFragmentGetResourceData(d))
+	if errors.Is(err, client.ErrFileFragmentTargetFolderNotFound) {
+		return newDetailedDiagnostic(diag.Error, "folder not found", fmt.Sprintf("the folder of %q does not exist", d.Get(resourceFileFragmentAttrTarget).(string)), cty.GetAttrPath(resourceFileFragmentAttrTarget))
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceFileFragmentCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceFileFragmentApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	d.SetId(resourceFileFragmentId(d.Get(resourceFileFragmentAttrTarget).(string), d.Get(resourceFileFragmentAttrName).(string)))
+
+	return resourceFileFragmentRead(ctx, d, meta)
+}
+
+func resourceFileFragmentRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileFragmentClient(p.System)
+
+	r, err := c.Get(ctx, d.Get(resourceFileFragmentAttrTarget).(string), d.Get(resourceFileFragmentAttrName).(string))
+	if errors.Is(err, client.ErrFileFragmentNotFound) {
+		// Fragment has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_ = d.Set(resourceFileFragmentAttrOrder, r.Order)
+	_ = d.Set(resourceFileFragmentAttrContent, r.Content)
+	_ = d.Set(resourceFileFragmentAttrRendered, r.Rendered)
+
+	return nil
+}
+
+func resourceFileFragmentUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceFileFragmentApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return resourceFileFragmentRead(ctx, d, meta)
+}
+
+func resourceFileFragmentDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewFileFragmentClient(p.System)
+
+	err := c.Delete(ctx, d.Get(resourceFileFragmentAttrTarget).(string), d.Get(resourceFileFragmentAttrName).(string))
+	if err != nil && !errors.Is(err, client.ErrFileFragmentNotFound) {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceFileFragmentCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" {
+		return nil
+	}
+
+	// A target which deviates from the fragments is shown as drift
+	if rendered, ok := d.GetOk(resourceFileFragmentAttrRendered); !ok || !rendered.(bool) {
+		return d.SetNewComputed(resourceFileFragmentAttrRendered)
+	}
+
+	return nil
+}
+
+func resourceFileFragmentImportState(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	importId := d.Id()
+
+	// Expect import id in the format `target:name`
+	sep := strings.LastIndex(importId, ":")
+	if sep <= 0 || sep == len(importId)-1 {
+		return nil, fmt.Errorf("unexpected import id format, expected `target:name`")
+	}
+
+	_ = d.Set(resourceFileFragmentAttrTarget, importId[:sep])
+	_ = d.Set(resourceFileFragmentAttrName, importId[sep+1:])
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,171 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"testing"
+)
+
+func TestAccFileFragment_create(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("second", filePath, "second",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "second line\n"),
+						),
+						testAccFileFragmentBlock("first", filePath, "first",
+							tfbuild.AttributeInt("order", 10),
+							tfbuild.AttributeString("content", "first line\n"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_fragment.first", "id", fmt.Sprintf("%s:first", filePath)),
+						resource.TestCheckResourceAttr("system_file_fragment.first", "order", "10"),
+						resource.TestCheckResourceAttr("system_file_fragment.first", "rendered", "true"),
+						resource.TestCheckResourceAttr("system_file_fragment.second", "rendered", "true"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("second", filePath, "second",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "second line\n"),
+						),
+						testAccFileFragmentBlock("first", filePath, "first",
+							tfbuild.AttributeInt("order", 10),
+							tfbuild.AttributeString("content", "first line\n"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "first line\nsecond line\n"),
+					),
+				},
+				{
+					ImportState:       true,
+					ResourceName:      "system_file_fragment.first",
+					ImportStateId:     fmt.Sprintf("%s:first", filePath),
+					ImportStateVerify: true,
+				},
+			},
+		})
+	})
+}
+
+func TestAccFileFragment_update_order(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("a", filePath, "a",
+							tfbuild.AttributeInt("order", 10),
+							tfbuild.AttributeString("content", "a\n"),
+						),
+						testAccFileFragmentBlock("b", filePath, "b",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "b\n"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_fragment.a", "order", "10"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("a", filePath, "a",
+							tfbuild.AttributeInt("order", 30),
+							tfbuild.AttributeString("content", "a\n"),
+						),
+						testAccFileFragmentBlock("b", filePath, "b",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "b\n"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file_fragment.a", "order", "30"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("a", filePath, "a",
+							tfbuild.AttributeInt("order", 30),
+							tfbuild.AttributeString("content", "a\n"),
+						),
+						testAccFileFragmentBlock("b", filePath, "b",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "b\n"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "b\na\n"),
+					),
+				},
+				{
+					// Deleting a fragment renders the target from the remaining fragments
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("b", filePath, "b",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "b\n"),
+						),
+					)),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileFragmentBlock("b", filePath, "b",
+							tfbuild.AttributeInt("order", 20),
+							tfbuild.AttributeString("content", "b\n"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", filePath),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "b\n"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func testAccFileFragmentBlock(name string, target string, fragmentName string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	resourceAttrs := []tfbuild.BlockElement{
+		tfbuild.AttributeString("target", target),
+		tfbuild.AttributeString("name", fragmentName),
+	}
+	resourceAttrs = append(resourceAttrs, attrs...)
+
+	return tfbuild.Resource("system_file_fragment", name, resourceAttrs...)
+}
@@ -2,12 +2,14 @@ package provider_test
 
 import (
 	"fmt"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/osrelease"
 	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"github.com/zclconf/go-cty/cty"
 	"path"
 	"regexp"
 	"strconv"
@@ -101,6 +103,36 @@ func TestAccFile_create_content_sensitive(t *testing.T) {
 	})
 }
 
+func TestAccFile_create_content_base64(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("mode", "644"),
+							// printf '\x00\xff\xfehello' | base64
+							tfbuild.AttributeString("content_base64_sensitive", "AP/+aGVsbG8="),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "id", testRunFilePath(target, testConfig.fileName)),
+						resource.TestCheckResourceAttr("system_file.test", "content_base64_sensitive", "AP/+aGVsbG8="),
+						// printf '\x00\xff\xfehello' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file.test", "md5sum", "nrK0ysuhMbuzbuvpzlBkKg=="),
+					),
+				},
+			},
+		})
+	})
+}
+
 func TestAccFile_create_source_file(t *testing.T) {
 	testConfig := newTestFileConfig()
 
@@ -169,6 +201,152 @@ func TestAccFile_create_source_file_without_schema(t *testing.T) {
 	})
 }
 
+func TestAccFile_create_source_checksum(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+							// sha256sum ./internal/provider/test/hello-world.txt
+							tfbuild.AttributeString("source_checksum", "sha256:7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "id", testRunFilePath(target, testConfig.fileName)),
+						resource.TestCheckResourceAttr("system_file.test", "md5sum", "/D/5joxqDTCH1RXARz+Gdw=="),
+						resource.TestCheckResourceAttr("system_file.test", "sha256sum", "7509e5bda0c762d2bac7f90d758b5b2263fa01ccbc542ab5e3df163be08e6ca9"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_acl_xattrs(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.AttributeValue("acl", cty.SetVal([]cty.Value{
+								cty.StringVal("user:someone:r--"),
+							})),
+							tfbuild.AttributeValue("xattrs", cty.MapVal(map[string]cty.Value{
+								"user.origin": cty.StringVal("terraform"),
+							})),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "mode", "640"),
+						resource.TestCheckResourceAttr("system_file.test", "acl.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_file.test", "acl.*", "user:someone:r--"),
+						resource.TestCheckResourceAttr("system_file.test", "xattrs.%", "1"),
+						resource.TestCheckResourceAttr("system_file.test", "xattrs.user.origin", "terraform"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("mode", "640"),
+							tfbuild.AttributeValue("acl", cty.SetVal([]cty.Value{
+								cty.StringVal("user:someone:rw-"),
+							})),
+							tfbuild.AttributeValue("xattrs", cty.MapVal(map[string]cty.Value{
+								"user.owner": cty.StringVal("ops"),
+							})),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "mode", "640"),
+						resource.TestCheckTypeSetElemAttr("system_file.test", "acl.*", "user:someone:rw-"),
+						resource.TestCheckResourceAttr("system_file.test", "xattrs.%", "1"),
+						resource.TestCheckResourceAttr("system_file.test", "xattrs.user.owner", "ops"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_fail_acl_default(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeValue("acl", cty.SetVal([]cty.Value{
+								cty.StringVal("default:user:someone:r--"),
+							})),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`only supported on folders`),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_fail_source_checksum(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("source", "./test/hello-world.txt"),
+							tfbuild.AttributeString("source_checksum", "sha256:0000000000000000000000000000000000000000000000000000000000000000"),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`source checksum mismatch`),
+				},
+				{
+					// The file must not exist after the failed apply
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_file_meta", "test",
+							tfbuild.AttributeString("path", testRunFilePath(target, testConfig.fileName)),
+						),
+					)),
+					ExpectError: regexp.MustCompile(`file not found`),
+				},
+			},
+		})
+	})
+}
+
 func TestAccFile_create_source_http(t *testing.T) {
 	testConfig := newTestFileConfig()
 
@@ -241,6 +419,37 @@ func TestAccFile_create_source_https(t *testing.T) {
 	})
 }
 
+func TestAccFile_create_source_download_on_remote(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", testRunFilePath(target, testConfig.fileName),
+							tfbuild.AttributeString("mode", "600"),
+							tfbuild.AttributeString("source", "https://releases.hashicorp.com/terraform/1.6.3/terraform_1.6.3_SHA256SUMS"),
+							tfbuild.AttributeBool("download_on_remote", true),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "id", testRunFilePath(target, testConfig.fileName)),
+						resource.TestCheckResourceAttr("system_file.test", "mode", "600"),
+						resource.TestCheckResourceAttr("system_file.test", "download_on_remote", "true"),
+						// curl -s 'https://releases.hashicorp.com/terraform/1.6.3/terraform_1.6.3_SHA256SUMS' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file.test", "md5sum", "5TkHdh91Xu5JW7tB6Id3NA=="),
+					),
+				},
+			},
+		})
+	})
+}
+
 func TestAccFile_update_mode(t *testing.T) {
 	testConfig := newTestFileConfig()
 
@@ -654,6 +863,203 @@ func TestAccFile_fail_existing(t *testing.T) {
 	})
 }
 
+func TestAccFile_adopt_existing(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						// The existing file is created outside of the file resource
+						tfbuild.Data("system_command", "existing",
+							tfbuild.AttributeString("command", fmt.Sprintf("[ -f '%[1]s' ] || echo existing > '%[1]s'", filePath)),
+						),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeBool("adopt_existing", true),
+							tfbuild.DependsOn(hcl.Traversal{hcl.TraverseRoot{Name: "data"}, hcl.TraverseAttr{Name: "system_command"}, hcl.TraverseAttr{Name: "existing"}}),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "id", filePath),
+						resource.TestCheckResourceAttr("system_file.test", "content", "hello world!"),
+						// echo -n 'hello world!' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file.test", "md5sum", "/D/5joxqDTCH1RXARz+Gdw=="),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_content_policy_create_only(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		config := func(content string) string {
+			return tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				tfbuild.Data("system_command", "existing",
+					tfbuild.AttributeString("command", fmt.Sprintf("[ -f '%[1]s' ] || echo existing > '%[1]s'", filePath)),
+				),
+				testAccFileBlock("test", filePath,
+					tfbuild.AttributeString("content", content),
+					tfbuild.AttributeString("mode", "600"),
+					tfbuild.AttributeString("content_policy", "create_only"),
+					tfbuild.AttributeBool("adopt_existing", true),
+					tfbuild.DependsOn(hcl.Traversal{hcl.TraverseRoot{Name: "data"}, hcl.TraverseAttr{Name: "system_command"}, hcl.TraverseAttr{Name: "existing"}}),
+				),
+			))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					// The content of the adopted file is retained
+					Config: config("hello world!"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "id", filePath),
+						resource.TestCheckResourceAttr("system_file.test", "mode", "600"),
+						// echo existing | sha256sum
+						resource.TestCheckResourceAttr("system_file.test", "sha256sum", "d32cf044872a37e6439d9055f90a0da11f1e0b07fa4e79d6ec710764ce1e206a"),
+					),
+				},
+				{
+					// Changes of the configured content are ignored
+					Config:   config("hello universe!"),
+					PlanOnly: true,
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_content_policy_ignore_changes_remote(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("content_policy", "ignore_changes_remote"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "content", "hello world!"),
+					),
+				},
+				{
+					// The content is changed on the remote system after the file has been created
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("content_policy", "ignore_changes_remote"),
+						),
+						tfbuild.Data("system_command", "change",
+							tfbuild.AttributeString("command", fmt.Sprintf("echo changed > '%s'", filePath)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "content", "hello world!"),
+					),
+				},
+				{
+					// Changes of the configured content are written
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("content", "hello universe!"),
+							tfbuild.AttributeString("content_policy", "ignore_changes_remote"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "content", "hello universe!"),
+						// echo -n 'hello universe!' | openssl dgst -binary -md5 | openssl base64
+						resource.TestCheckResourceAttr("system_file.test", "md5sum", "w0Y+MwVOASL+sUYDnI0Eww=="),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_restore_on_destroy(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						// The original file is created outside of the file resource
+						tfbuild.Data("system_command", "existing",
+							tfbuild.AttributeString("command", fmt.Sprintf("[ -f '%[1]s' ] || { printf existing > '%[1]s' && chmod 600 '%[1]s'; }", filePath)),
+						),
+						testAccFileBlock("test", filePath,
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.AttributeString("mode", "644"),
+							tfbuild.AttributeBool("restore_on_destroy", true),
+							tfbuild.DependsOn(hcl.Traversal{hcl.TraverseRoot{Name: "data"}, hcl.TraverseAttr{Name: "system_command"}, hcl.TraverseAttr{Name: "existing"}}),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "content", "hello world!"),
+						resource.TestCheckResourceAttr("system_file.test", "mode", "644"),
+					),
+				},
+				{
+					// Destroy the file resource
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+					)),
+				},
+				{
+					// The original file has been restored
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Data("system_file", "restored",
+							tfbuild.AttributeString("path", filePath),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.restored", "content", "existing"),
+						resource.TestCheckResourceAttr("data.system_file.restored", "mode", "600"),
+					),
+				},
+			},
+		})
+	})
+}
+
 func TestAccFile_import(t *testing.T) {
 	testConfig := newTestFileConfig()
 
@@ -825,3 +1231,97 @@ func testAccFileBlock(name string, path string, attrs ...tfbuild.BlockElement) t
 
 	return tfbuild.Resource("system_file", name, resourceAttrs...)
 }
+
+func TestAccFile_on_change(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+		logPath := testRunFilePath(target, testConfig.fileName+".log")
+
+		config := func(content string) string {
+			return tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				testAccFileBlock("test", filePath,
+					tfbuild.AttributeString("content", content),
+					tfbuild.InnerBlock("on_change",
+						tfbuild.AttributeString("command", fmt.Sprintf("echo changed >> %s", logPath)),
+					),
+				),
+				tfbuild.Data("system_file", "log",
+					tfbuild.AttributeString("path", logPath),
+					tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+				),
+			))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: config("hello world!"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", "changed\n"),
+					),
+				},
+				{
+					// The command is not executed without changes
+					Config: config("hello world!"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", "changed\n"),
+					),
+				},
+				{
+					Config: config("hello universe!"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", "changed\nchanged\n"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFile_on_change_fail(t *testing.T) {
+	testConfig := newTestFileConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		filePath := testRunFilePath(target, testConfig.fileName)
+
+		config := func(content string, command string) string {
+			return tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				testAccFileBlock("test", filePath,
+					tfbuild.AttributeString("content", content),
+					tfbuild.InnerBlock("on_change",
+						tfbuild.AttributeString("command", command),
+					),
+				),
+			))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: config("hello world!", "true"),
+				},
+				{
+					Config:      config("hello universe!", "echo failure >&2; exit 3"),
+					ExpectError: regexp.MustCompile(`exit code 3: failure`),
+				},
+				{
+					// The failed command is executed again
+					Config: config("hello universe!", "true"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_file.test", "on_change_pending", "false"),
+					),
+				},
+			},
+		})
+	})
+}
@@ -2,9 +2,11 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/neuspaces/terraform-provider-system/internal/client"
 	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
 	"github.com/neuspaces/terraform-provider-system/internal/validate"
@@ -14,16 +16,46 @@ import (
 const resourceFolderName = "system_folder"
 
 const (
-	resourceFolderAttrId       = "id"
-	resourceFolderAttrPath     = "path"
-	resourceFolderAttrMode     = "mode"
-	resourceFolderAttrUser     = "user"
-	resourceFolderAttrUid      = "uid"
-	resourceFolderAttrGroup    = "group"
-	resourceFolderAttrGid      = "gid"
-	resourceFolderAttrBasename = "basename"
+	resourceFolderAttrId           = "id"
+	resourceFolderAttrPath         = "path"
+	resourceFolderAttrMode         = "mode"
+	resourceFolderAttrUser         = "user"
+	resourceFolderAttrUid          = "uid"
+	resourceFolderAttrGroup        = "group"
+	resourceFolderAttrGid          = "gid"
+	resourceFolderAttrBasename     = "basename"
+	resourceFolderAttrDeletePolicy = "delete_policy"
+
+	resourceFolderAttrRecursiveOwner    = "recursive_owner"
+	resourceFolderAttrRecursiveDirMode  = "recursive_dir_mode"
+	resourceFolderAttrRecursiveFileMode = "recursive_file_mode"
+	resourceFolderAttrRecursiveDigest   = "recursive_digest"
 )
 
+// resourceFolderOnChangeAttrs are the attributes which trigger the command of `on_change` if changed
+var resourceFolderOnChangeAttrs = []string{resourceFolderAttrMode, resourceFolderAttrUid, resourceFolderAttrGid}
+
+const (
+	// resourceFolderDeletePolicyEmptyOnly removes the folder only if it is empty and fails otherwise
+	resourceFolderDeletePolicyEmptyOnly = "empty_only"
+
+	// resourceFolderDeletePolicyIfCreated removes the folder and its parent folders only if created by the resource and empty
+	resourceFolderDeletePolicyIfCreated = "if_created"
+
+	// resourceFolderDeletePolicyRecursive removes the folder including its contents
+	resourceFolderDeletePolicyRecursive = "recursive"
+
+	// resourceFolderDeletePolicyRetain does not remove the folder
+	resourceFolderDeletePolicyRetain = "retain"
+)
+
+var resourceFolderDeletePolicies = []string{
+	resourceFolderDeletePolicyEmptyOnly,
+	resourceFolderDeletePolicyIfCreated,
+	resourceFolderDeletePolicyRecursive,
+	resourceFolderDeletePolicyRetain,
+}
+
 func resourceFolder() *schema.Resource {
 	return &schema.Resource{
 		Description: fmt.Sprintf("`%s` manages a folder on the remote system.", resourceFolderName),
@@ -33,13 +65,15 @@ func resourceFolder() *schema.Resource {
 		UpdateContext: resourceFolderUpdate,
 		DeleteContext: resourceFolderDelete,
 
+		CustomizeDiff: resourceFolderCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
 		SchemaVersion: 1,
 
-		Schema: map[string]*schema.Schema{
+		Schema: withOnChangeSchema("the mode or owner of the folder", withFileAttrsSchema(fileAttrsKindFolder, map[string]*schema.Schema{
 			resourceFolderAttrId: {
 				Description: "ID of the folder",
 				Type:        schema.TypeString,
@@ -92,10 +126,53 @@ func resourceFolder() *schema.Resource {
 				Type:        schema.TypeString,
 				Computed:    true,
 			},
-		},
+			resourceFolderAttrDeletePolicy: {
+				Description:  fmt.Sprintf("Behavior when the resource is destroyed. `%[1]s` removes the folder only if it is empty and fails otherwise. `%[2]s` removes the folder and the parent folders which have been created by the resource if they are empty; other folders are retained. `%[3]s` removes the folder including all contents. `%[4]s` does not remove the folder. Defaults to `%[1]s`.", resourceFolderDeletePolicyEmptyOnly, resourceFolderDeletePolicyIfCreated, resourceFolderDeletePolicyRecursive, resourceFolderDeletePolicyRetain),
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      resourceFolderDeletePolicyEmptyOnly,
+				ValidateFunc: validation.StringInSlice(resourceFolderDeletePolicies, false),
+			},
+			resourceFolderAttrRecursiveOwner: {
+				Description: "Apply the user and group of the folder to all files and folders within the folder. Only entries which deviate are modified. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceFolderAttrRecursiveDirMode: {
+				Description:      "Permissions of all folders within the folder in octal format like `755`. Only folders which deviate are modified.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceFolderAttrRecursiveFileMode: {
+				Description:      "Permissions of all regular files within the folder in octal format like `644`. Only files which deviate are modified.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceFolderAttrRecursiveDigest: {
+				Description: fmt.Sprintf("Digest of the files and folders within the folder which deviate from `%[1]s`, `%[2]s`, and `%[3]s`. Empty if all files and folders comply. A non-empty digest indicates drift which is reconciled on the next apply.", resourceFolderAttrRecursiveOwner, resourceFolderAttrRecursiveDirMode, resourceFolderAttrRecursiveFileMode),
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			internalDataSchemaKey: internalDataSchema(),
+		})),
 	}
 }
 
+// resourceFolderRecursiveAttrs are the attributes which enforce ownership and permissions within the folder
+var resourceFolderRecursiveAttrs = []string{
+	resourceFolderAttrRecursiveOwner,
+	resourceFolderAttrRecursiveDirMode,
+	resourceFolderAttrRecursiveFileMode,
+}
+
+type resourceFolderInternalData struct {
+	// Created contains the paths of the folder and its parent folders which have been created by the resource starting with the deepest folder
+	Created []string `json:"created,omitempty"`
+}
+
 func resourceFolderGetResourceData(d *schema.ResourceData) (*client.Folder, diag.Diagnostics) {
 	r := &client.Folder{
 		Path:  d.Get(resourceFolderAttrPath).(string),
@@ -141,6 +218,46 @@ func resourceFolderSetResourceData(r *client.Folder, d *schema.ResourceData) dia
 	return nil
 }
 
+// resourceFolderGetTree returns the expected ownership and permissions within the folder. The ownership is derived from
+// the folder r as read from the remote system.
+func resourceFolderGetTree(r *client.Folder, d *schema.ResourceData) client.FolderTree {
+	t := client.FolderTree{
+		Path: r.Path,
+		Uid:  -1,
+		Gid:  -1,
+	}
+
+	if d.Get(resourceFolderAttrRecursiveOwner).(bool) {
+		t.Uid = r.Uid
+		t.Gid = r.Gid
+	}
+
+	if v, ok := d.GetOk(resourceFolderAttrRecursiveDirMode); ok {
+		t.DirMode = filemode.MustParse(v.(string))
+	}
+
+	if v, ok := d.GetOk(resourceFolderAttrRecursiveFileMode); ok {
+		t.FileMode = filemode.MustParse(v.(string))
+	}
+
+	return t
+}
+
+// resourceFolderReconcileTree applies the ownership and permissions to the files and folders within the folder
+func resourceFolderReconcileTree(ctx context.Context, c client.FolderClient, d *schema.ResourceData) diag.Diagnostics {
+	r, err := c.Get(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = c.ReconcileTree(ctx, resourceFolderGetTree(r, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
 func resourceFolderCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	p, diagErr := providerFromMeta(meta)
 	if diagErr != nil {
@@ -154,14 +271,36 @@ func resourceFolderCreate(ctx context.Context, d *schema.ResourceData, meta inte
 		return diagErr
 	}
 
-	err := c.Create(ctx, *r)
+	created, err := c.Create(ctx, *r)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(r.Path)
 
-	return resourceFolderRead(ctx, d, meta)
+	diagErr = setInternalData(d, &resourceFolderInternalData{
+		Created: created,
+	})
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = resourceFolderReconcileTree(ctx, c, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = fileAttrsApply(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFolder, r.Path, d, false)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = resourceFolderRead(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return onChangeRun(ctx, p.System, d, resourceFolderOnChangeAttrs...)
 }
 
 func resourceFolderRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -184,6 +323,18 @@ func resourceFolderRead(ctx context.Context, d *schema.ResourceData, meta interf
 		return diagErr
 	}
 
+	scan, err := c.ScanTree(ctx, resourceFolderGetTree(r, d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_ = d.Set(resourceFolderAttrRecursiveDigest, scan.Digest)
+
+	diagErr = fileAttrsRead(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFolder, id, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
 	return nil
 }
 
@@ -194,18 +345,56 @@ func resourceFolderUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 	}
 
 	c := client.NewFolderClient(p.System)
+	ac := client.NewFileAttrsClient(p.System)
 
 	r, diagErr := resourceFolderGetResourceData(d)
 	if diagErr != nil {
 		return diagErr
 	}
 
+	unlocked, diagErr := fileAttrsUnlock(ctx, ac, fileAttrsKindFolder, r.Path, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
 	err := c.Update(ctx, *r)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return resourceFolderRead(ctx, d, meta)
+	// Ownership within the folder is derived from the folder and must be reconciled if the owner of the folder changes
+	if d.HasChanges(resourceFolderRecursiveAttrs...) || d.HasChanges(resourceFolderAttrRecursiveDigest, resourceFolderAttrUser, resourceFolderAttrUid, resourceFolderAttrGroup, resourceFolderAttrGid) {
+		diagErr = resourceFolderReconcileTree(ctx, c, d)
+		if diagErr != nil {
+			return diagErr
+		}
+	}
+
+	diagErr = fileAttrsApply(ctx, ac, fileAttrsKindFolder, r.Path, d, unlocked)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = resourceFolderRead(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return onChangeRun(ctx, p.System, d, resourceFolderOnChangeAttrs...)
+}
+
+func resourceFolderCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	err := onChangeCustomizeDiff(ctx, d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Id() == "" || d.Get(resourceFolderAttrRecursiveDigest).(string) == "" {
+		return nil
+	}
+
+	// Files or folders within the folder deviate and are reconciled on apply
+	return d.SetNew(resourceFolderAttrRecursiveDigest, "")
 }
 
 func resourceFolderDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -218,9 +407,53 @@ func resourceFolderDelete(ctx context.Context, d *schema.ResourceData, meta inte
 
 	id := d.Id()
 
-	err := c.Delete(ctx, id)
-	if err != nil {
-		return diag.FromErr(err)
+	deletePolicy := d.Get(resourceFolderAttrDeletePolicy).(string)
+	if deletePolicy != resourceFolderDeletePolicyRetain {
+		_, diagErr = fileAttrsUnlock(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindFolder, id, d)
+		if diagErr != nil {
+			return diagErr
+		}
+	}
+
+	switch deletePolicy {
+	case resourceFolderDeletePolicyRetain:
+		return nil
+	case resourceFolderDeletePolicyRecursive:
+		err := c.Delete(ctx, id)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	case resourceFolderDeletePolicyIfCreated:
+		var internalData resourceFolderInternalData
+		_, diagErr = getInternalData(d, &internalData)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		// Remove created folders starting with the deepest folder until a folder is not empty
+		for _, created := range internalData.Created {
+			err := c.DeleteEmpty(ctx, created)
+			if errors.Is(err, client.ErrFolderNotFound) {
+				continue
+			}
+			if errors.Is(err, client.ErrFolderNotEmpty) {
+				return newDetailedDiagnostic(diag.Warning, fmt.Sprintf("folder %q is retained because it is not empty", created), fmt.Sprintf("The folder has been created by the resource but contains files which are not managed by the resource. Set attribute `%s` to `%s` to remove the folder including its contents.", resourceFolderAttrDeletePolicy, resourceFolderDeletePolicyRecursive), nil)
+			}
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	default:
+		err := c.DeleteEmpty(ctx, id)
+		if errors.Is(err, client.ErrFolderNotFound) {
+			return nil
+		}
+		if errors.Is(err, client.ErrFolderNotEmpty) {
+			return newDetailedDiagnostic(diag.Error, fmt.Sprintf("folder %q is not empty", id), fmt.Sprintf("The folder is not removed because attribute `%s` is `%s`. Remove the contents of the folder or set attribute `%s` to `%s` or `%s`.", resourceFolderAttrDeletePolicy, resourceFolderDeletePolicyEmptyOnly, resourceFolderAttrDeletePolicy, resourceFolderDeletePolicyRecursive, resourceFolderDeletePolicyRetain), nil)
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,541 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileset"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const resourceFolderSyncName = "system_folder_sync"
+
+const (
+	resourceFolderSyncAttrId               = "id"
+	resourceFolderSyncAttrSource           = "source"
+	resourceFolderSyncAttrDestination      = "destination"
+	resourceFolderSyncAttrInclude          = "include"
+	resourceFolderSyncAttrExclude          = "exclude"
+	resourceFolderSyncAttrFileMode         = "file_mode"
+	resourceFolderSyncAttrDirectoryMode    = "directory_mode"
+	resourceFolderSyncAttrUser             = "user"
+	resourceFolderSyncAttrGroup            = "group"
+	resourceFolderSyncAttrDeleteExtraneous = "delete_extraneous"
+	resourceFolderSyncAttrFiles            = "files"
+	resourceFolderSyncAttrContentHash      = "content_hash"
+)
+
+func resourceFolderSync() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceFolderSyncCreate,
+		ReadContext:   resourceFolderSyncRead,
+		UpdateContext: resourceFolderSyncUpdate,
+		DeleteContext: resourceFolderSyncDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` synchronizes the files of a local folder to a folder on the remote system.", resourceFolderSyncName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceFolderSyncImportState,
+		},
+
+		CustomizeDiff: resourceFolderSyncCustomizeDiff,
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceFolderSyncAttrId: {
+				Description: "ID of the folder sync",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceFolderSyncAttrSource: {
+				Description: "Path to the local folder which contains the files to synchronize.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			resourceFolderSyncAttrDestination: {
+				Description:      "Path to the folder on the remote system. Must be an absolute path. The folder is created if it does not exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceFolderSyncAttrInclude: {
+				Description: fmt.Sprintf("List of glob patterns which select the files to synchronize. Patterns are matched against the path relative to `%[1]s` similar to the `fileset` function. Defaults to `%[2]s`.", resourceFolderSyncAttrSource, fileset.DefaultInclude),
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			resourceFolderSyncAttrExclude: {
+				Description: fmt.Sprintf("List of glob patterns which exclude files from the synchronization. Excluded files on the remote system are not deleted by `%s`.", resourceFolderSyncAttrDeleteExtraneous),
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			resourceFolderSyncAttrFileMode: {
+				Description:      "Permissions of the files in octal format like `644`. Defaults to the permissions of the local files.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceFolderSyncAttrDirectoryMode: {
+				Description:      "Permissions of the folders which contain synchronized files in octal format like `755`. Defaults to the umask of the system for new folders.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: validate.FileMode(),
+			},
+			resourceFolderSyncAttrUser: {
+				Description: "Name or ID of the user who owns the synchronized files and folders. Defaults to the user of the connection.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			resourceFolderSyncAttrGroup: {
+				Description: "Name or ID of the group that owns the synchronized files and folders. Defaults to the primary group of the user of the connection.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			resourceFolderSyncAttrDeleteExtraneous: {
+				Description: fmt.Sprintf("Delete files in the remote folder which do not exist in the local folder. Only files which are selected by `%[1]s` and not excluded by `%[2]s` are deleted. Defaults to `false`.", resourceFolderSyncAttrInclude, resourceFolderSyncAttrExclude),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceFolderSyncAttrFiles: {
+				Description: "Set of paths of the synchronized files relative to the destination folder.",
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			resourceFolderSyncAttrContentHash: {
+				Description: "SHA-256 checksum in hex encoding which aggregates the paths, contents, permissions, and ownership of the synchronized files.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// resourceFolderSyncEntry is a synchronized file which contributes to the aggregate content hash
+type resourceFolderSyncEntry struct {
+	Path   string
+	Md5Sum string
+	Mode   fs.FileMode
+	User   string
+	Group  string
+}
+
+// resourceFolderSyncContentHash calculates the aggregate content hash of entries
+func resourceFolderSyncContentHash(entries []resourceFolderSyncEntry) string {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	h := sha256.New()
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00%o\x00%s\x00%s\n", e.Path, e.Md5Sum, e.Mode.Perm(), e.User, e.Group)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// resourceFolderSyncOwner returns the owner of a remote file in the representation of the configured owner.
+// Returns an empty string if the owner is not configured.
+func resourceFolderSyncOwner(configured string, name string, id int) string {
+	if configured == "" {
+		return ""
+	}
+
+	if _, err := strconv.Atoi(configured); err == nil {
+		return strconv.Itoa(id)
+	}
+
+	return name
+}
+
+type resourceFolderSyncConfig struct {
+	Source           string
+	Destination      string
+	Matcher          *fileset.Matcher
+	FileMode         fs.FileMode
+	DirectoryMode    fs.FileMode
+	User             string
+	Group            string
+	DeleteExtraneous bool
+}
+
+type resourceDataGetter interface {
+	Get(key string) interface{}
+}
+
+func resourceFolderSyncGetConfig(d resourceDataGetter) (*resourceFolderSyncConfig, error) {
+	c := &resourceFolderSyncConfig{
+		Source:           d.Get(resourceFolderSyncAttrSource).(string),
+		Destination:      d.Get(resourceFolderSyncAttrDestination).(string),
+		User:             d.Get(resourceFolderSyncAttrUser).(string),
+		Group:            d.Get(resourceFolderSyncAttrGroup).(string),
+		DeleteExtraneous: d.Get(resourceFolderSyncAttrDeleteExtraneous).(bool),
+	}
+
+	if v := d.Get(resourceFolderSyncAttrFileMode).(string); v != "" {
+		c.FileMode = filemode.MustParse(v)
+	}
+
+	if v := d.Get(resourceFolderSyncAttrDirectoryMode).(string); v != "" {
+		c.DirectoryMode = filemode.MustParse(v)
+	}
+
+	var include, exclude []string
+	for _, v := range d.Get(resourceFolderSyncAttrInclude).([]interface{}) {
+		include = append(include, v.(string))
+	}
+	for _, v := range d.Get(resourceFolderSyncAttrExclude).([]interface{}) {
+		exclude = append(exclude, v.(string))
+	}
+
+	m, err := fileset.NewMatcher(include, exclude)
+	if err != nil {
+		return nil, err
+	}
+	c.Matcher = m
+
+	return c, nil
+}
+
+// localFiles returns the files in the local source folder
+func (c *resourceFolderSyncConfig) localFiles() ([]fileset.File, error) {
+	info, err := os.Stat(c.Source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("source %q is not a folder", c.Source)
+	}
+
+	return fileset.Walk(c.Source, c.Matcher)
+}
+
+// desiredEntry returns the desired state of a local file on the remote system
+func (c *resourceFolderSyncConfig) desiredEntry(f fileset.File) resourceFolderSyncEntry {
+	mode := f.Mode
+	if c.FileMode != 0 {
+		mode = c.FileMode
+	}
+
+	return resourceFolderSyncEntry{
+		Path:   f.Path,
+		Md5Sum: f.Md5Sum,
+		Mode:   mode,
+		User:   c.User,
+		Group:  c.Group,
+	}
+}
+
+// remoteEntry returns the actual state of a remote file
+func (c *resourceFolderSyncConfig) remoteEntry(f client.FolderSyncFile) resourceFolderSyncEntry {
+	return resourceFolderSyncEntry{
+		Path:   f.Path,
+		Md5Sum: f.Md5Sum,
+		Mode:   f.Mode,
+		User:   resourceFolderSyncOwner(c.User, f.User, f.Uid),
+		Group:  resourceFolderSyncOwner(c.Group, f.Group, f.Gid),
+	}
+}
+
+func resourceFolderSyncCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown(resourceFolderSyncAttrSource) {
+		_ = d.SetNewComputed(resourceFolderSyncAttrFiles)
+		_ = d.SetNewComputed(resourceFolderSyncAttrContentHash)
+		return nil
+	}
+
+	c, err := resourceFolderSyncGetConfig(d)
+	if err != nil {
+		return err
+	}
+
+	files, err := c.localFiles()
+	if err != nil {
+		return err
+	}
+
+	var entries []resourceFolderSyncEntry
+	var paths []interface{}
+	for _, f := range files {
+		entries = append(entries, c.desiredEntry(f))
+		paths = append(paths, f.Path)
+	}
+
+	contentHash := resourceFolderSyncContentHash(entries)
+
+	if d.Get(resourceFolderSyncAttrContentHash).(string) != contentHash {
+		if err := d.SetNew(resourceFolderSyncAttrContentHash, contentHash); err != nil {
+			return err
+		}
+		if err := d.SetNew(resourceFolderSyncAttrFiles, paths); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceFolderSyncApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	fc := client.NewFolderSyncClient(p.System, client.FolderSyncClientCompression(true))
+
+	c, err := resourceFolderSyncGetConfig(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	localFiles, err := c.localFiles()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	remoteFiles, err := fc.List(ctx, c.Destination)
+	if err != nil && !errors.Is(err, client.ErrFolderSyncNotFound) {
+		return diag.FromErr(err)
+	}
+
+	remoteByPath := map[string]client.FolderSyncFile{}
+	for _, f := range remoteFiles {
+		remoteByPath[f.Path] = f
+	}
+
+	var uploads []client.FolderSyncUpload
+	var attrs []client.FolderSyncAttributes
+	localPaths := map[string]struct{}{}
+	dirs := map[string]struct{}{}
+
+	for _, f := range localFiles {
+		f := f
+		localPaths[f.Path] = struct{}{}
+
+		for dir := path.Dir(f.Path); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = struct{}{}
+		}
+
+		desired := c.desiredEntry(f)
+
+		remote, exists := remoteByPath[f.Path]
+		upload := !exists || remote.Md5Sum != f.Md5Sum
+		if upload {
+			uploads = append(uploads, client.FolderSyncUpload{
+				Path: f.Path,
+				Mode: desired.Mode,
+				Size: f.Size,
+				Open: func() (io.ReadCloser, error) {
+					return os.Open(filepath.Join(c.Source, filepath.FromSlash(f.Path)))
+				},
+			})
+		}
+
+		// Uploaded files are created with the permissions and ownership of the connection
+		actual := c.remoteEntry(remote)
+		a := client.FolderSyncAttributes{Path: f.Path}
+		if upload || actual.Mode.Perm() != desired.Mode {
+			a.Mode = desired.Mode
+		}
+		if upload || actual.User != desired.User || actual.Group != desired.Group {
+			a.User = desired.User
+			a.Group = desired.Group
+		}
+		if a.Mode != 0 || a.User != "" || a.Group != "" {
+			attrs = append(attrs, a)
+		}
+	}
+
+	if c.DirectoryMode != 0 || c.User != "" || c.Group != "" {
+		for dir := range dirs {
+			attrs = append(attrs, client.FolderSyncAttributes{
+				Path:  dir,
+				Mode:  c.DirectoryMode,
+				User:  c.User,
+				Group: c.Group,
+			})
+		}
+	}
+
+	// Delete previously synchronized files which no longer exist in the source
+	var deletes []string
+	oldFiles, _ := d.GetChange(resourceFolderSyncAttrFiles)
+	if oldFilesSet, ok := oldFiles.(*schema.Set); ok {
+		for _, v := range oldFilesSet.List() {
+			if _, ok := localPaths[v.(string)]; !ok {
+				deletes = append(deletes, v.(string))
+			}
+		}
+	}
+
+	if c.DeleteExtraneous {
+		for _, f := range remoteFiles {
+			if _, ok := localPaths[f.Path]; !ok && c.Matcher.Match(f.Path) {
+				deletes = append(deletes, f.Path)
+			}
+		}
+	}
+
+	err = fc.Upload(ctx, c.Destination, uploads)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = fc.SetAttributes(ctx, c.Destination, attrs)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = fc.Delete(ctx, c.Destination, deletes)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var files []string
+	for p := range localPaths {
+		files = append(files, p)
+	}
+	sort.Strings(files)
+
+	_ = d.Set(resourceFolderSyncAttrFiles, files)
+
+	return nil
+}
+
+func resourceFolderSyncCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceFolderSyncApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	d.SetId(d.Get(resourceFolderSyncAttrDestination).(string))
+
+	return resourceFolderSyncRead(ctx, d, meta)
+}
+
+func resourceFolderSyncRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	fc := client.NewFolderSyncClient(p.System)
+
+	c, err := resourceFolderSyncGetConfig(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	remoteFiles, err := fc.List(ctx, c.Destination)
+	if errors.Is(err, client.ErrFolderSyncNotFound) {
+		// Folder has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	managed := map[string]struct{}{}
+	for _, v := range d.Get(resourceFolderSyncAttrFiles).(*schema.Set).List() {
+		managed[v.(string)] = struct{}{}
+	}
+
+	// The content hash covers the synchronized files which exist on the remote system and extraneous files which
+	// would be deleted. Files which are missing or extraneous result in a diff of the content hash.
+	var entries []resourceFolderSyncEntry
+	for _, f := range remoteFiles {
+		_, isManaged := managed[f.Path]
+		if isManaged || (c.DeleteExtraneous && c.Matcher.Match(f.Path)) {
+			entries = append(entries, c.remoteEntry(f))
+		}
+	}
+
+	_ = d.Set(resourceFolderSyncAttrContentHash, resourceFolderSyncContentHash(entries))
+
+	return nil
+}
+
+func resourceFolderSyncUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceFolderSyncApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return resourceFolderSyncRead(ctx, d, meta)
+}
+
+func resourceFolderSyncDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	fc := client.NewFolderSyncClient(p.System)
+
+	destination := d.Get(resourceFolderSyncAttrDestination).(string)
+
+	var paths []string
+	for _, v := range d.Get(resourceFolderSyncAttrFiles).(*schema.Set).List() {
+		paths = append(paths, v.(string))
+	}
+	sort.Strings(paths)
+
+	err := fc.Delete(ctx, destination, paths)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = fc.DeleteIfEmpty(ctx, destination)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceFolderSyncImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	// Expect import id in the format `destination`
+	destination := d.Id()
+	if !strings.HasPrefix(destination, "/") {
+		return nil, fmt.Errorf("destination must be an absolute path")
+	}
+
+	// No remote file is considered synchronized, so that the next apply does not delete remote files which are not
+	// in the source folder. The next apply only transfers files which differ and records the synchronized files.
+	_ = d.Set(resourceFolderSyncAttrDestination, destination)
+	_ = d.Set(resourceFolderSyncAttrDeleteExtraneous, false)
+
+	return []*schema.ResourceData{d}, nil
+}
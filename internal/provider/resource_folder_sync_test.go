@@ -0,0 +1,117 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/zclconf/go-cty/cty"
+	"sync/atomic"
+	"testing"
+)
+
+var (
+	testFolderSyncId uint32
+)
+
+type testFolderSyncConfig struct {
+	folderName string
+}
+
+func newTestFolderSyncConfig() testFolderSyncConfig {
+	id := atomic.AddUint32(&testFolderSyncId, 1)
+
+	return testFolderSyncConfig{
+		folderName: fmt.Sprintf("folder-sync-%d", id),
+	}
+}
+
+func TestAccFolderSync_create(t *testing.T) {
+	testConfig := newTestFolderSyncConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFilePath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_folder_sync", "test",
+							tfbuild.AttributeString("source", "./test/folder-sync"),
+							tfbuild.AttributeString("destination", folderPath),
+							tfbuild.AttributeString("file_mode", "640"),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", folderPath+"/assets/app.js"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder_sync", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder_sync.test", "id", folderPath),
+						resource.TestCheckResourceAttr("system_folder_sync.test", "files.#", "3"),
+						resource.TestCheckTypeSetElemAttr("system_folder_sync.test", "files.*", "assets/app.js"),
+						resource.TestCheckResourceAttrSet("system_folder_sync.test", "content_hash"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "console.log(\"hello world!\");\n"),
+						resource.TestCheckResourceAttr("data.system_file.test", "mode", "640"),
+					),
+				},
+				{
+					ImportState:             true,
+					ResourceName:            "system_folder_sync.test",
+					ImportStateId:           folderPath,
+					ImportStateVerify:       true,
+					ImportStateVerifyIgnore: []string{"source", "file_mode", "files", "content_hash"},
+				},
+			},
+		})
+	})
+}
+
+func TestAccFolderSync_exclude(t *testing.T) {
+	testConfig := newTestFolderSyncConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFilePath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_folder_sync", "test",
+							tfbuild.AttributeString("source", "./test/folder-sync"),
+							tfbuild.AttributeString("destination", folderPath),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder_sync.test", "files.#", "3"),
+					),
+				},
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						tfbuild.Resource("system_folder_sync", "test",
+							tfbuild.AttributeString("source", "./test/folder-sync"),
+							tfbuild.AttributeString("destination", folderPath),
+							tfbuild.AttributeValue("exclude", cty.ListVal([]cty.Value{
+								cty.StringVal("**/*.css"),
+							})),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder_sync.test", "files.#", "2"),
+						resource.TestCheckTypeSetElemAttr("system_folder_sync.test", "files.*", "index.html"),
+						resource.TestCheckTypeSetElemAttr("system_folder_sync.test", "files.*", "assets/app.js"),
+					),
+				},
+			},
+		})
+	})
+}
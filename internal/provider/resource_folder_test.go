@@ -5,6 +5,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/zclconf/go-cty/cty"
 	"path"
 	"sync/atomic"
 	"testing"
@@ -95,6 +96,185 @@ func TestAccFolder_update_mode(t *testing.T) {
 	})
 }
 
+func TestAccFolder_delete_policy_if_created(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		parentPath := testRunFolderPath(target, testConfig.folderName)
+		folderPath := path.Join(parentPath, "nested")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("delete_policy", "if_created"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.test", "id", folderPath),
+						resource.TestCheckResourceAttr("system_folder.test", "delete_policy", "if_created"),
+					),
+				},
+				{
+					// Destroy the folder before the parent folder is created in the next step
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+					)),
+				},
+				{
+					// The parent folder has been created by the destroyed resource and must not exist anymore
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("parent", parentPath,
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.parent", "id", parentPath),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFolder_delete_policy_empty_only(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath),
+						testAccFileBlock("test", path.Join(folderPath, "file.txt"),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.test", "delete_policy", "empty_only"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFolder_recursive(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+		archivePath := path.Join(folderPath, "app")
+
+		// The archive does not manage the modes of the extracted entries which are reconciled by the folder
+		archiveBlock := tfbuild.Resource("system_archive", "test",
+			tfbuild.AttributeString("source", "./test/archive/release-1.0.tar.gz"),
+			tfbuild.AttributeString("destination", archivePath),
+			tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+		)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					// Extract the archive with the modes of the archive
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						archiveBlock,
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.test", "recursive_digest", ""),
+					),
+				},
+				{
+					// Reconcile the extracted entries
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeBool("recursive_owner", true),
+							tfbuild.AttributeString("recursive_dir_mode", "750"),
+							tfbuild.AttributeString("recursive_file_mode", "640"),
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						archiveBlock,
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", path.Join(archivePath, "README.md")),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+						tfbuild.Data("system_file_meta", "bin",
+							tfbuild.AttributeString("path", path.Join(archivePath, "bin")),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.test", "recursive_digest", ""),
+						resource.TestCheckResourceAttr("data.system_file.test", "mode", "640"),
+						resource.TestCheckResourceAttr("data.system_file_meta.bin", "mode", "750"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccFolder_acl_default(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("mode", "750"),
+							tfbuild.AttributeValue("acl", cty.SetVal([]cty.Value{
+								cty.StringVal("user:someone:r-x"),
+								cty.StringVal("default:user:someone:r-x"),
+							})),
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						testAccFileBlock("test", path.Join(folderPath, "file.txt"),
+							tfbuild.AttributeString("content", "hello world!"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_folder.test", "mode", "750"),
+						resource.TestCheckResourceAttr("system_folder.test", "acl.#", "2"),
+						resource.TestCheckTypeSetElemAttr("system_folder.test", "acl.*", "default:user:someone:r-x"),
+						// The file inherits the default access control list of the folder
+						resource.TestCheckTypeSetElemAttr("system_file.test", "acl.*", "user:someone:r-x"),
+					),
+				},
+			},
+		})
+	})
+}
+
 func testRunFolderPath(target acctest.Target, p string) string {
 	return path.Join(target.BasePath, p)
 }
@@ -107,3 +287,40 @@ func testAccFolderBlock(name string, path string, attrs ...tfbuild.BlockElement)
 
 	return tfbuild.Resource("system_folder", name, resourceAttrs...)
 }
+
+func TestAccFolder_on_change_run_as(t *testing.T) {
+	testConfig := newTestFolderConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		folderPath := testRunFolderPath(target, testConfig.folderName)
+		logPath := path.Join("/tmp", testConfig.folderName+".log")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", folderPath,
+							tfbuild.AttributeString("mode", "755"),
+							tfbuild.InnerBlock("on_change",
+								tfbuild.AttributeString("command", fmt.Sprintf("id -un > %s", logPath)),
+								tfbuild.AttributeString("run_as", "someone"),
+							),
+						),
+						tfbuild.Data("system_file", "log",
+							tfbuild.AttributeString("path", logPath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", "someone\n"),
+						resource.TestCheckResourceAttr("data.system_file.log", "user", "someone"),
+					),
+				},
+			},
+		})
+	})
+}
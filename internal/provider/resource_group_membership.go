@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+)
+
+const resourceGroupMembershipName = "system_group_membership"
+
+const (
+	resourceGroupMembershipAttrId      = "id"
+	resourceGroupMembershipAttrGroup   = "group"
+	resourceGroupMembershipAttrMembers = "members"
+)
+
+func resourceGroupMembership() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceGroupMembershipCreate,
+		ReadContext:   resourceGroupMembershipRead,
+		UpdateContext: resourceGroupMembershipUpdate,
+		DeleteContext: resourceGroupMembershipDelete,
+	}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages the membership of users in a supplementary group on the remote system. Members of the group which are not managed by the resource are retained.", resourceGroupMembershipName),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceGroupMembershipImportState,
+		},
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceGroupMembershipAttrId: {
+				Description: "ID of the group membership",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceGroupMembershipAttrGroup: {
+				Description:      "Name of the group. Group must exist.",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+			},
+			resourceGroupMembershipAttrMembers: {
+				Description: "Set of names of the users which are members of the group. Users must exist.",
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+				},
+			},
+		},
+	}
+}
+
+// resourceGroupMembershipApply adds the members which are missing in the group and removes the members which have been
+// removed from the resource
+func resourceGroupMembershipApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewGroupMembershipClient(p.System)
+
+	group := d.Get(resourceGroupMembershipAttrGroup).(string)
+
+	o, n := d.GetChange(resourceGroupMembershipAttrMembers)
+
+	removed, err := setToStringSlice(o.(*schema.Set).Difference(n.(*schema.Set)))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	added, err := setToStringSlice(n.(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = c.Remove(ctx, group, removed)
+	if err != nil {
+		return resourceGroupMembershipDiagnostics(err, group)
+	}
+
+	err = c.Add(ctx, group, added)
+	if err != nil {
+		return resourceGroupMembershipDiagnostics(err, group)
+	}
+
+	return nil
+}
+
+func resourceGroupMembershipDiagnostics(err error, group string) diag.Diagnostics {
+	switch {
+	case errors.Is(err, client.ErrGroupMembershipGroupNotFound):
+		return newDetailedDiagnostic(diag.Error, "group not found", fmt.Sprintf("the group %q does not exist", group), cty.GetAttrPath(resourceGroupMembershipAttrGroup))
+	case errors.Is(err, client.ErrGroupMembershipUserNotFound):
+		return newDetailedDiagnostic(diag.Error, "user not found", "a member of the group does not exist", cty.GetAttrPath(resourceGroupMembershipAttrMembers))
+	}
+
+	return diag.FromErr(err)
+}
+
+func resourceGroupMembershipCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceGroupMembershipApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	d.SetId(d.Get(resourceGroupMembershipAttrGroup).(string))
+
+	return resourceGroupMembershipRead(ctx, d, meta)
+}
+
+func resourceGroupMembershipRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewGroupMembershipClient(p.System)
+
+	r, err := c.Get(ctx, d.Get(resourceGroupMembershipAttrGroup).(string))
+	if errors.Is(err, client.ErrGroupMembershipGroupNotFound) {
+		// Group has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Only the managed members are retained in the state
+	_ = d.Set(resourceGroupMembershipAttrMembers, stringSliceToSet(r.Members).Intersection(d.Get(resourceGroupMembershipAttrMembers).(*schema.Set)))
+
+	return nil
+}
+
+func resourceGroupMembershipUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceGroupMembershipApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return resourceGroupMembershipRead(ctx, d, meta)
+}
+
+func resourceGroupMembershipDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewGroupMembershipClient(p.System)
+
+	members, err := setToStringSlice(d.Get(resourceGroupMembershipAttrMembers).(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = c.Remove(ctx, d.Get(resourceGroupMembershipAttrGroup).(string), members)
+	if err != nil && !errors.Is(err, client.ErrGroupMembershipGroupNotFound) {
+		return diag.FromErr(err)
+	}
+
+	return nil
+}
+
+func resourceGroupMembershipImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return nil, fmt.Errorf("unable to get provider from meta")
+	}
+
+	// Expect import id in the format `group`. All members of the group are imported.
+	r, err := client.NewGroupMembershipClient(p.System).Get(ctx, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	_ = d.Set(resourceGroupMembershipAttrGroup, r.Group)
+	_ = d.Set(resourceGroupMembershipAttrMembers, stringSliceToSet(r.Members))
+
+	return []*schema.ResourceData{d}, nil
+}
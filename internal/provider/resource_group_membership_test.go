@@ -0,0 +1,85 @@
+package provider_test
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"testing"
+)
+
+func TestAccGroupMembership_create(t *testing.T) {
+	testConfig := newTestGroupConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		groupName := testRunGroupName(testConfig.groupName, "a")
+		userA := testRunUserName(testConfig.groupName, "a")
+		userB := testRunUserName(testConfig.groupName, "b")
+
+		usersConfig := []tfbuild.FileElement{
+			acctest.ProviderConfigBlock(target.Configs.Default()),
+			testAccGroupBlock("test", groupName),
+			testAccUserBlock("a", userA,
+				tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+			),
+			testAccUserBlock("b", userB,
+				tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+			),
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					// Two resources contribute members to the same group
+					Config: tfbuild.FileString(tfbuild.File(append(usersConfig,
+						testAccGroupMembershipBlock("a", groupName,
+							tfbuild.Attribute("members", tfbuild.StringList(userA)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "a")),
+						),
+						testAccGroupMembershipBlock("b", groupName,
+							tfbuild.Attribute("members", tfbuild.StringList(userB)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "b")),
+						),
+					)...)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_group_membership.a", "id", groupName),
+						resource.TestCheckResourceAttr("system_group_membership.a", "members.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_group_membership.a", "members.*", userA),
+						resource.TestCheckResourceAttr("system_group_membership.b", "members.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_group_membership.b", "members.*", userB),
+					),
+				},
+				{
+					// Removing a resource retains the members of the other resource
+					Config: tfbuild.FileString(tfbuild.File(append(usersConfig,
+						testAccGroupMembershipBlock("a", groupName,
+							tfbuild.Attribute("members", tfbuild.StringList(userA)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "a")),
+						),
+					)...)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_group_membership.a", "members.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_group_membership.a", "members.*", userA),
+					),
+				},
+				{
+					ImportState:       true,
+					ResourceName:      "system_group_membership.a",
+					ImportStateId:     groupName,
+					ImportStateVerify: true,
+				},
+			},
+		})
+	})
+}
+
+func testAccGroupMembershipBlock(name string, group string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	resourceAttrs := []tfbuild.BlockElement{
+		tfbuild.AttributeString("group", group),
+	}
+	resourceAttrs = append(resourceAttrs, attrs...)
+
+	return tfbuild.Resource("system_group_membership", name, resourceAttrs...)
+}
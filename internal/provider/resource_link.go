@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/neuspaces/terraform-provider-system/internal/client"
 	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"path"
 )
 
 const resourceLinkName = "system_link"
@@ -19,24 +21,34 @@ const (
 	resourceLinkAttrUid    = "uid"
 	resourceLinkAttrGroup  = "group"
 	resourceLinkAttrGid    = "gid"
+
+	resourceLinkAttrType     = "type"
+	resourceLinkAttrForce    = "force"
+	resourceLinkAttrRelative = "relative"
+	resourceLinkAttrResolves = "resolves"
 )
 
+// resourceLinkOnChangeAttrs are the attributes which trigger the command of `on_change` if changed
+var resourceLinkOnChangeAttrs = []string{resourceLinkAttrTarget, resourceLinkAttrUid, resourceLinkAttrGid}
+
 func resourceLink() *schema.Resource {
 	return &schema.Resource{
-		Description: fmt.Sprintf("`%s` manages a symbolic link on the remote system.", resourceLinkName),
+		Description: fmt.Sprintf("`%s` manages a symbolic link or a hard link on the remote system.", resourceLinkName),
 
 		CreateContext: resourceLinkCreate,
 		ReadContext:   resourceLinkRead,
 		UpdateContext: resourceLinkUpdate,
 		DeleteContext: resourceLinkDelete,
 
+		CustomizeDiff: resourceLinkCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
 
 		SchemaVersion: 1,
 
-		Schema: map[string]*schema.Schema{
+		Schema: withOnChangeSchema("the target or owner of the link", withFileAttrsSchema(fileAttrsKindLink, map[string]*schema.Schema{
 			resourceLinkAttrId: {
 				Description: "ID of the link",
 				Type:        schema.TypeString,
@@ -50,10 +62,35 @@ func resourceLink() *schema.Resource {
 				ValidateDiagFunc: validate.AbsolutePath(),
 			},
 			resourceLinkAttrTarget: {
-				Description: "Target of the link. Can be either an absolute or a relative path. Target is not required to exist when link is created.",
+				Description: "Target of the link. Can be either an absolute or a relative path. Target of a symbolic link is not required to exist when link is created. Target of a hard link must be an existing file. A hard link which does not refer to the target anymore is detected as drift.",
 				Type:        schema.TypeString,
 				Required:    true,
 			},
+			resourceLinkAttrType: {
+				Description:  fmt.Sprintf("Type of the link. `%[1]s` creates a symbolic link. `%[2]s` creates a hard link. Defaults to `%[1]s`.", client.LinkTypeSymbolic, client.LinkTypeHard),
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      string(client.LinkTypeSymbolic),
+				ValidateFunc: validation.StringInSlice(resourceLinkTypes(), false),
+			},
+			resourceLinkAttrForce: {
+				Description: "Replace an existing file or link at the path when the link is created. The link is created at a temporary path and moved to the path atomically. Requires `mv -T` on the remote system. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceLinkAttrRelative: {
+				Description: fmt.Sprintf("Create a symbolic link with a target relative to the folder of the link. An absolute `%[1]s` is converted to a relative target lexically. Attribute `%[1]s` retains the configured value. Only supported by symbolic links. Defaults to `false`.", resourceLinkAttrTarget),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceLinkAttrResolves: {
+				Description: "Whether the target of a symbolic link exists or a hard link refers to the target. A link which does not resolve is shown as a change in the plan.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
 			resourceLinkAttrUser: {
 				Description:   "Name of the user who owns the link. Does *not* change the user owning the target.",
 				Type:          schema.TypeString,
@@ -82,21 +119,33 @@ func resourceLink() *schema.Resource {
 				Computed:      true,
 				ConflictsWith: []string{resourceLinkAttrGroup},
 			},
-		},
+		})),
+	}
+}
+
+func resourceLinkTypes() []string {
+	var types []string
+	for _, t := range client.LinkTypes() {
+		types = append(types, string(t))
 	}
+	return types
 }
 
 func resourceLinkGetResourceData(d *schema.ResourceData) (*client.Link, diag.Diagnostics) {
 	r := &client.Link{
-		Path:   d.Get(resourceLinkAttrPath).(string),
-		Target: "",
-		User:   "",
-		Uid:    -1,
-		Group:  "",
-		Gid:    -1,
+		Path:     d.Get(resourceLinkAttrPath).(string),
+		Target:   "",
+		Type:     client.LinkType(d.Get(resourceLinkAttrType).(string)),
+		Relative: d.Get(resourceLinkAttrRelative).(bool),
+		Force:    d.Get(resourceLinkAttrForce).(bool),
+		User:     "",
+		Uid:      -1,
+		Group:    "",
+		Gid:      -1,
 	}
 
-	if d.HasChange(resourceLinkAttrTarget) {
+	// The link is replaced if the target or the form of the target changes
+	if d.HasChanges(resourceLinkAttrTarget, resourceLinkAttrRelative) {
 		r.Target = d.Get(resourceLinkAttrTarget).(string)
 	}
 
@@ -120,8 +169,21 @@ func resourceLinkGetResourceData(d *schema.ResourceData) (*client.Link, diag.Dia
 }
 
 func resourceLinkSetResourceData(r *client.Link, d *schema.ResourceData) diag.Diagnostics {
+	target := r.Target
+	if r.Type == client.LinkTypeSymbolic && d.Get(resourceLinkAttrRelative).(bool) {
+		// The configured target is retained if the link has been created from the configured target
+		configured := client.Link{Path: r.Path, Target: d.Get(resourceLinkAttrTarget).(string), Relative: true}
+		if configured.LinkTarget() == r.Target {
+			target = configured.Target
+		} else if !path.IsAbs(r.Target) {
+			target = path.Join(path.Dir(r.Path), r.Target)
+		}
+	}
+
 	_ = d.Set(resourceLinkAttrPath, r.Path)
-	_ = d.Set(resourceLinkAttrTarget, r.Target)
+	_ = d.Set(resourceLinkAttrTarget, target)
+	_ = d.Set(resourceLinkAttrType, string(r.Type))
+	_ = d.Set(resourceLinkAttrResolves, r.Resolves)
 	_ = d.Set(resourceLinkAttrUser, r.User)
 	_ = d.Set(resourceLinkAttrUid, r.Uid)
 	_ = d.Set(resourceLinkAttrGroup, r.Group)
@@ -150,7 +212,17 @@ func resourceLinkCreate(ctx context.Context, d *schema.ResourceData, meta interf
 
 	d.SetId(r.Path)
 
-	return resourceLinkRead(ctx, d, meta)
+	diagErr = fileAttrsApply(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindLink, r.Path, d, false)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = resourceLinkRead(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return onChangeRun(ctx, p.System, d, resourceLinkOnChangeAttrs...)
 }
 
 func resourceLinkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -163,7 +235,13 @@ func resourceLinkRead(ctx context.Context, d *schema.ResourceData, meta interfac
 
 	id := d.Id()
 
-	r, err := c.Get(ctx, id)
+	var r *client.Link
+	var err error
+	if client.LinkType(d.Get(resourceLinkAttrType).(string)) == client.LinkTypeHard {
+		r, err = c.GetHard(ctx, id, d.Get(resourceLinkAttrTarget).(string))
+	} else {
+		r, err = c.Get(ctx, id)
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -173,6 +251,11 @@ func resourceLinkRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diagErr
 	}
 
+	diagErr = fileAttrsRead(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindLink, id, d)
+	if diagErr != nil {
+		return diagErr
+	}
+
 	return nil
 }
 
@@ -194,7 +277,18 @@ func resourceLinkUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.FromErr(err)
 	}
 
-	return resourceLinkRead(ctx, d, meta)
+	// The link is replaced if the target changes
+	diagErr = fileAttrsApply(ctx, client.NewFileAttrsClient(p.System), fileAttrsKindLink, r.Path, d, d.HasChange(resourceLinkAttrTarget))
+	if diagErr != nil {
+		return diagErr
+	}
+
+	diagErr = resourceLinkRead(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return onChangeRun(ctx, p.System, d, resourceLinkOnChangeAttrs...)
 }
 
 func resourceLinkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -207,10 +301,37 @@ func resourceLinkDelete(ctx context.Context, d *schema.ResourceData, meta interf
 
 	id := d.Id()
 
-	err := c.Delete(ctx, id)
+	var err error
+	if client.LinkType(d.Get(resourceLinkAttrType).(string)) == client.LinkTypeHard {
+		err = c.DeleteHard(ctx, id)
+	} else {
+		err = c.Delete(ctx, id)
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	return nil
 }
+
+func resourceLinkCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Get(resourceLinkAttrRelative).(bool) && client.LinkType(d.Get(resourceLinkAttrType).(string)) == client.LinkTypeHard {
+		return fmt.Errorf("attribute `%s` is only supported by links of type `%s`", resourceLinkAttrRelative, client.LinkTypeSymbolic)
+	}
+
+	err := onChangeCustomizeDiff(ctx, d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	// A link which does not resolve is shown as drift
+	if resolves, ok := d.GetOk(resourceLinkAttrResolves); !ok || !resolves.(bool) {
+		return d.SetNewComputed(resourceLinkAttrResolves)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package provider_test
 
 import (
 	"fmt"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
@@ -110,6 +111,152 @@ func TestAccLink_update_group(t *testing.T) {
 	t.Skip()
 }
 
+func TestAccLink_create_relative(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		linkPath := testRunLinkPath(target, testConfig.linkName, "/link")
+		targetPath := testRunLinkPath(target, testConfig.targetName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFolderBlock("test", testRunLinkPath(target, testConfig.linkName),
+							tfbuild.AttributeString("delete_policy", "recursive"),
+						),
+						testAccFileBlock("test", targetPath,
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						testAccLinkBlock("test", linkPath, targetPath,
+							tfbuild.AttributeBool("relative", true),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_folder", "test"), tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", linkPath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_link", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_link.test", "target", targetPath),
+						resource.TestCheckResourceAttr("system_link.test", "relative", "true"),
+						resource.TestCheckResourceAttr("system_link.test", "resolves", "true"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "hello world!"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccLink_create_force(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		linkPath := testRunLinkPath(target, testConfig.linkName)
+		targetPath := testRunLinkPath(target, testConfig.targetName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						// The existing file is created outside of the link resource
+						tfbuild.Data("system_command", "existing",
+							tfbuild.AttributeString("command", fmt.Sprintf("[ -L '%[1]s' ] || echo existing > '%[1]s'", linkPath)),
+						),
+						testAccFileBlock("test", targetPath,
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						testAccLinkBlock("test", linkPath, targetPath,
+							tfbuild.AttributeBool("force", true),
+							tfbuild.DependsOn(
+								hcl.Traversal{hcl.TraverseRoot{Name: "data"}, hcl.TraverseAttr{Name: "system_command"}, hcl.TraverseAttr{Name: "existing"}},
+								tfbuild.TraversalResource("system_file", "test"),
+							),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_link.test", "id", linkPath),
+						resource.TestCheckResourceAttr("system_link.test", "target", targetPath),
+						resource.TestCheckResourceAttr("system_link.test", "resolves", "true"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccLink_create_hard(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		linkPath := testRunLinkPath(target, testConfig.linkName)
+		targetPath := testRunLinkPath(target, testConfig.targetName)
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccFileBlock("test", targetPath,
+							tfbuild.AttributeString("content", "hello world!"),
+						),
+						testAccLinkBlock("test", linkPath, targetPath,
+							tfbuild.AttributeString("type", "hard"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_file", "test")),
+						),
+						tfbuild.Data("system_file", "test",
+							tfbuild.AttributeString("path", linkPath),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_link", "test")),
+						),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_link.test", "type", "hard"),
+						resource.TestCheckResourceAttr("system_link.test", "target", targetPath),
+						resource.TestCheckResourceAttr("system_link.test", "resolves", "true"),
+						resource.TestCheckResourceAttr("data.system_file.test", "content", "hello world!"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccLink_dangling(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccLinkBlock("test", testRunLinkPath(target, testConfig.linkName), testRunLinkPath(target, testConfig.targetName)),
+					)),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_link.test", "resolves", "false"),
+					),
+					ExpectNonEmptyPlan: true,
+				},
+			},
+		})
+	})
+}
+
 func testRunLinkPath(target acctest.Target, nameParts ...string) string {
 	return path.Join(target.BasePath, strings.Join(nameParts, ""))
 }
@@ -123,3 +270,47 @@ func testAccLinkBlock(name string, path string, target string, attrs ...tfbuild.
 
 	return tfbuild.Resource("system_link", name, resourceAttrs...)
 }
+
+func TestAccLink_on_change(t *testing.T) {
+	testConfig := newTestLinkConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		linkPath := testRunLinkPath(target, testConfig.linkName)
+		logPath := testRunLinkPath(target, testConfig.linkName+".log")
+
+		config := func(targetName string) string {
+			return tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				testAccLinkBlock("test", linkPath, testRunLinkPath(target, targetName),
+					tfbuild.InnerBlock("on_change",
+						tfbuild.AttributeString("command", fmt.Sprintf("readlink %s >> %s", linkPath, logPath)),
+					),
+				),
+				tfbuild.Data("system_file", "log",
+					tfbuild.AttributeString("path", logPath),
+					tfbuild.DependsOn(tfbuild.TraversalResource("system_link", "test")),
+				),
+			))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: config(testConfig.targetName),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", testRunLinkPath(target, testConfig.targetName)+"\n"),
+					),
+				},
+				{
+					Config: config(testConfig.targetName + "-new"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("data.system_file.log", "content", fmt.Sprintf("%s\n%s-new\n", testRunLinkPath(target, testConfig.targetName), testRunLinkPath(target, testConfig.targetName))),
+					),
+				},
+			},
+		})
+	})
+}
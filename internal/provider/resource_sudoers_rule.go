@@ -0,0 +1,332 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/sudoers"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"sort"
+)
+
+const resourceSudoersRuleName = "system_sudoers_rule"
+
+const (
+	resourceSudoersRuleAttrId          = "id"
+	resourceSudoersRuleAttrName        = "name"
+	resourceSudoersRuleAttrUsers       = "users"
+	resourceSudoersRuleAttrGroups      = "groups"
+	resourceSudoersRuleAttrHosts       = "hosts"
+	resourceSudoersRuleAttrRunAsUsers  = "runas_users"
+	resourceSudoersRuleAttrRunAsGroups = "runas_groups"
+	resourceSudoersRuleAttrCommands    = "commands"
+	resourceSudoersRuleAttrNoPasswd    = "nopasswd"
+	resourceSudoersRuleAttrSetEnv      = "setenv"
+	resourceSudoersRuleAttrEnvKeep     = "env_keep"
+	resourceSudoersRuleAttrPath        = "path"
+	resourceSudoersRuleAttrContent     = "content"
+)
+
+// resourceSudoersRuleHeader is the comment at the beginning of the drop-in file
+const resourceSudoersRuleHeader = "Managed by terraform"
+
+func resourceSudoersRule() *schema.Resource {
+	sr := &SyncResource{
+		CreateContext: resourceSudoersRuleCreate,
+		ReadContext:   resourceSudoersRuleRead,
+		UpdateContext: resourceSudoersRuleUpdate,
+		DeleteContext: resourceSudoersRuleDelete,
+	}
+
+	identifierSet := func(description string) *schema.Schema {
+		return &schema.Schema{
+			Description: description,
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Set:         schema.HashString,
+			Elem: &schema.Schema{
+				Type:             schema.TypeString,
+				ValidateDiagFunc: validate.StringMatch(sudoers.IdentifierRegexp, "invalid name"),
+			},
+		}
+	}
+
+	users := identifierSet("Set of names of the users, uids prefixed with `#`, or aliases to which the rule applies. At least one of `users` and `groups` is required.")
+	users.AtLeastOneOf = []string{resourceSudoersRuleAttrUsers, resourceSudoersRuleAttrGroups}
+
+	groups := identifierSet("Set of names of the groups to which the rule applies. The names must not contain the prefix `%`. At least one of `users` and `groups` is required.")
+	groups.AtLeastOneOf = []string{resourceSudoersRuleAttrUsers, resourceSudoersRuleAttrGroups}
+
+	return &schema.Resource{
+		Description: fmt.Sprintf("`%s` manages a rule of sudo in a drop-in file in `%s` on the remote system. The file is validated using `visudo` before the file is installed.", resourceSudoersRuleName, client.SudoersDir),
+
+		CreateContext: sr.CreateContextSync,
+		ReadContext:   sr.ReadContextSync,
+		UpdateContext: sr.UpdateContextSync,
+		DeleteContext: sr.DeleteContextSync,
+
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSudoersRuleImportState,
+		},
+
+		CustomizeDiff: resourceSudoersRuleCustomizeDiff,
+
+		SchemaVersion: 1,
+
+		Schema: map[string]*schema.Schema{
+			resourceSudoersRuleAttrId: {
+				Description: "ID of the sudoers rule",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceSudoersRuleAttrName: {
+				Description:      fmt.Sprintf("Name of the drop-in file in `%s`. Must only contain letters, digits, `_`, and `-` because sudo skips files which contain a `.`.", client.SudoersDir),
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validate.StringMatch(sudoers.NameRegexp, "invalid file name"),
+			},
+			resourceSudoersRuleAttrUsers:  users,
+			resourceSudoersRuleAttrGroups: groups,
+			resourceSudoersRuleAttrHosts:  identifierSet("Set of hosts on which the rule applies. Defaults to `ALL`."),
+			resourceSudoersRuleAttrRunAsUsers: identifierSet("Set of users as which the commands may be executed, e.g. `ALL` or `root`. " +
+				"If neither `runas_users` nor `runas_groups` is set, the commands may be executed as `root`."),
+			resourceSudoersRuleAttrRunAsGroups: identifierSet("Set of groups as which the commands may be executed, e.g. `ALL`."),
+			resourceSudoersRuleAttrCommands: {
+				Description: "List of commands which may be executed. A command is `ALL`, `sudoedit` with a path, or the absolute path of a command with optional arguments. The characters `\\`, `,`, `:`, and `=` in the arguments are escaped.",
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: resourceSudoersRuleValidateCommand,
+				},
+			},
+			resourceSudoersRuleAttrNoPasswd: {
+				Description: "If `true`, the commands may be executed without password (`NOPASSWD`). Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceSudoersRuleAttrSetEnv: {
+				Description: "If `true`, the user may override the environment of the commands (`SETENV`). Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceSudoersRuleAttrEnvKeep: {
+				Description: "Set of names of environment variables which are retained when the commands are executed (`env_keep`). A trailing `*` matches variables with the prefix.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validate.StringMatch(sudoers.EnvVarRegexp, "invalid environment variable"),
+				},
+			},
+			resourceSudoersRuleAttrPath: {
+				Description: "Path of the drop-in file",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceSudoersRuleAttrContent: {
+				Description: "Content of the drop-in file. The file is installed again if the file has been changed outside of terraform.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceSudoersRuleValidateCommand(val interface{}, path cty.Path) diag.Diagnostics {
+	err := sudoers.ValidateCommand(val.(string))
+	if err != nil {
+		return diag.Diagnostics{
+			{
+				Severity:      diag.Error,
+				Summary:       "invalid command",
+				Detail:        err.Error(),
+				AttributePath: path,
+			},
+		}
+	}
+
+	return nil
+}
+
+// resourceSudoersRuleSortedSet returns the elements of the set sorted which renders a stable file
+func resourceSudoersRuleSortedSet(v interface{}) []string {
+	var result []string
+	for _, e := range v.(*schema.Set).List() {
+		result = append(result, e.(string))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// resourceSudoersRuleRender returns the content of the drop-in file
+func resourceSudoersRuleRender(d resourceDataGetter) string {
+	r := sudoers.Rule{
+		Users:       resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrUsers)),
+		Groups:      resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrGroups)),
+		Hosts:       resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrHosts)),
+		RunAsUsers:  resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrRunAsUsers)),
+		RunAsGroups: resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrRunAsGroups)),
+		NoPasswd:    d.Get(resourceSudoersRuleAttrNoPasswd).(bool),
+		SetEnv:      d.Get(resourceSudoersRuleAttrSetEnv).(bool),
+		EnvKeep:     resourceSudoersRuleSortedSet(d.Get(resourceSudoersRuleAttrEnvKeep)),
+	}
+
+	for _, c := range d.Get(resourceSudoersRuleAttrCommands).([]interface{}) {
+		r.Commands = append(r.Commands, c.(string))
+	}
+
+	return r.Render(resourceSudoersRuleHeader)
+}
+
+func resourceSudoersRuleDiagnostics(err error) diag.Diagnostics {
+	switch {
+	case errors.Is(err, client.ErrSudoersRuleIncludeDirMissing):
+		return newDetailedDiagnostic(diag.Error, "sudoers does not include drop-in folder", fmt.Sprintf("/etc/sudoers must contain the directive `#includedir %s`", client.SudoersDir), nil)
+	case errors.Is(err, client.ErrSudoersRuleInvalid):
+		return newDetailedDiagnostic(diag.Error, "invalid sudoers rule", fmt.Sprintf("the rule has been rejected by visudo and has not been installed: %s", err.Error()), nil)
+	case errors.Is(err, client.ErrSudoersRuleLockout):
+		return newDetailedDiagnostic(diag.Error, "sudo privileges of the connection are revoked", "the change would revoke the privileges of the user of the connection to execute commands using sudo and has been reverted", nil)
+	}
+
+	return diag.FromErr(err)
+}
+
+func resourceSudoersRuleApply(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewSudoersRuleClient(p.System)
+
+	err := c.Apply(ctx, client.SudoersRule{
+		Name:    d.Get(resourceSudoersRuleAttrName).(string),
+		Content: resourceSudoersRuleRender(d),
+	})
+	if err != nil {
+		return resourceSudoersRuleDiagnostics(err)
+	}
+
+	return nil
+}
+
+func resourceSudoersRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceSudoersRuleApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	d.SetId(d.Get(resourceSudoersRuleAttrName).(string))
+
+	return resourceSudoersRuleRead(ctx, d, meta)
+}
+
+func resourceSudoersRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewSudoersRuleClient(p.System)
+
+	r, err := c.Get(ctx, d.Get(resourceSudoersRuleAttrName).(string))
+	if errors.Is(err, client.ErrSudoersRuleNotFound) {
+		// Drop-in file has been removed outside of terraform
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_ = d.Set(resourceSudoersRuleAttrPath, r.Path())
+	_ = d.Set(resourceSudoersRuleAttrContent, r.Content)
+
+	return nil
+}
+
+func resourceSudoersRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	diagErr := resourceSudoersRuleApply(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	return resourceSudoersRuleRead(ctx, d, meta)
+}
+
+func resourceSudoersRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewSudoersRuleClient(p.System)
+
+	err := c.Delete(ctx, d.Get(resourceSudoersRuleAttrName).(string))
+	if err != nil && !errors.Is(err, client.ErrSudoersRuleNotFound) {
+		return resourceSudoersRuleDiagnostics(err)
+	}
+
+	return nil
+}
+
+func resourceSudoersRuleCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	for _, attr := range []string{resourceSudoersRuleAttrUsers, resourceSudoersRuleAttrGroups, resourceSudoersRuleAttrHosts, resourceSudoersRuleAttrRunAsUsers, resourceSudoersRuleAttrRunAsGroups, resourceSudoersRuleAttrCommands, resourceSudoersRuleAttrNoPasswd, resourceSudoersRuleAttrSetEnv, resourceSudoersRuleAttrEnvKeep} {
+		if !d.NewValueKnown(attr) {
+			return d.SetNewComputed(resourceSudoersRuleAttrContent)
+		}
+	}
+
+	// A drop-in file which deviates from the rule is shown as drift
+	if content := resourceSudoersRuleRender(d); content != d.Get(resourceSudoersRuleAttrContent).(string) {
+		return d.SetNew(resourceSudoersRuleAttrContent, content)
+	}
+
+	return nil
+}
+
+func resourceSudoersRuleImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return nil, fmt.Errorf("unable to get provider from meta")
+	}
+
+	// Expect import id in the format `name`. The attributes are parsed from the drop-in file.
+	name := d.Id()
+	if !sudoers.NameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid file name %q", name)
+	}
+
+	r, err := client.NewSudoersRuleClient(p.System).Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := sudoers.Parse(r.Content)
+	if err != nil {
+		return nil, fmt.Errorf("drop-in file %s has not been created by %s: %w", r.Path(), resourceSudoersRuleName, err)
+	}
+
+	_ = d.Set(resourceSudoersRuleAttrName, name)
+	_ = d.Set(resourceSudoersRuleAttrUsers, stringSliceToSet(rule.Users))
+	_ = d.Set(resourceSudoersRuleAttrGroups, stringSliceToSet(rule.Groups))
+	_ = d.Set(resourceSudoersRuleAttrHosts, stringSliceToSet(rule.Hosts))
+	_ = d.Set(resourceSudoersRuleAttrRunAsUsers, stringSliceToSet(rule.RunAsUsers))
+	_ = d.Set(resourceSudoersRuleAttrRunAsGroups, stringSliceToSet(rule.RunAsGroups))
+	_ = d.Set(resourceSudoersRuleAttrCommands, rule.Commands)
+	_ = d.Set(resourceSudoersRuleAttrNoPasswd, rule.NoPasswd)
+	_ = d.Set(resourceSudoersRuleAttrSetEnv, rule.SetEnv)
+	_ = d.Set(resourceSudoersRuleAttrEnvKeep, stringSliceToSet(rule.EnvKeep))
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,102 @@
+package provider_test
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest"
+	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"regexp"
+	"testing"
+)
+
+func TestAccSudoersRule_create(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+		ruleName := fmt.Sprintf("tf-%s", userName)
+
+		userConfig := []tfbuild.FileElement{
+			acctest.ProviderConfigBlock(target.Configs.Default()),
+			testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+			testAccUserBlock("test", userName,
+				tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+			),
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(append(userConfig,
+						testAccSudoersRuleBlock("test", ruleName,
+							tfbuild.Attribute("users", tfbuild.StringList(userName)),
+							tfbuild.Attribute("runas_users", tfbuild.StringList("root")),
+							tfbuild.Attribute("commands", tfbuild.StringList("/usr/bin/id", "/bin/echo a=b")),
+							tfbuild.AttributeBool("nopasswd", true),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "test")),
+						),
+					)...))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_sudoers_rule.test", "id", ruleName),
+						resource.TestCheckResourceAttr("system_sudoers_rule.test", "path", fmt.Sprintf("/etc/sudoers.d/%s", ruleName)),
+						resource.TestCheckResourceAttr("system_sudoers_rule.test", "content", fmt.Sprintf("# Managed by terraform\n%s ALL=(root) NOPASSWD: /usr/bin/id, /bin/echo a\\=b\n", userName)),
+					),
+				},
+				{
+					ImportState:       true,
+					ResourceName:      "system_sudoers_rule.test",
+					ImportStateId:     ruleName,
+					ImportStateVerify: true,
+				},
+				{
+					// Update the commands
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(append(userConfig,
+						testAccSudoersRuleBlock("test", ruleName,
+							tfbuild.Attribute("users", tfbuild.StringList(userName)),
+							tfbuild.Attribute("commands", tfbuild.StringList("ALL")),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_user", "test")),
+						),
+					)...))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_sudoers_rule.test", "content", fmt.Sprintf("# Managed by terraform\n%s ALL=ALL\n", userName)),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccSudoersRule_invalid(t *testing.T) {
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccSudoersRuleBlock("test", "tf-invalid",
+							tfbuild.Attribute("users", tfbuild.StringList("root")),
+							tfbuild.Attribute("commands", tfbuild.StringList("systemctl restart nginx")),
+						),
+					))),
+					ExpectError: regexp.MustCompile(`invalid command`),
+				},
+			},
+		})
+	})
+}
+
+func testAccSudoersRuleBlock(resourceName string, name string, attrs ...tfbuild.BlockElement) tfbuild.FileElement {
+	resourceAttrs := []tfbuild.BlockElement{
+		tfbuild.AttributeString("name", name),
+	}
+	resourceAttrs = append(resourceAttrs, attrs...)
+
+	return tfbuild.Resource("system_sudoers_rule", resourceName, resourceAttrs...)
+}
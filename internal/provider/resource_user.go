@@ -2,13 +2,21 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/neuspaces/terraform-provider-system/internal/client"
 	"github.com/neuspaces/terraform-provider-system/internal/extlib/to"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/filemode"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/shacrypt"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/yescrypt"
 	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 const resourceUserName = "system_user"
@@ -22,8 +30,45 @@ const (
 	resourceUserAttrSystem = "system"
 	resourceUserAttrHome   = "home"
 	resourceUserAttrShell  = "shell"
+
+	resourceUserAttrGroups       = "groups"
+	resourceUserAttrGroupsPolicy = "groups_policy"
+
+	resourceUserAttrCreateHome          = "create_home"
+	resourceUserAttrSkel                = "skel"
+	resourceUserAttrMoveHome            = "move_home"
+	resourceUserAttrRemoveHomeOnDestroy = "remove_home_on_destroy"
+	resourceUserAttrHomeMode            = "home_mode"
+	resourceUserAttrHomeUid             = "home_uid"
+	resourceUserAttrHomeGid             = "home_gid"
+
+	resourceUserAttrPasswordHash     = "password_hash"
+	resourceUserAttrPassword         = "password"
+	resourceUserAttrPasswordScheme   = "password_scheme"
+	resourceUserAttrLocked           = "locked"
+	resourceUserAttrExpireDate       = "expire_date"
+	resourceUserAttrPasswordMaxDays  = "password_max_days"
+	resourceUserAttrPasswordMinDays  = "password_min_days"
+	resourceUserAttrPasswordWarnDays = "password_warn_days"
+)
+
+const (
+	resourceUserGroupsPolicyAdditive      = "additive"
+	resourceUserGroupsPolicyAuthoritative = "authoritative"
+)
+
+const (
+	resourceUserPasswordSchemeSha512Crypt = "sha512crypt"
+	resourceUserPasswordSchemeYescrypt    = "yescrypt"
 )
 
+var resourceUserPasswordSchemes = []string{
+	resourceUserPasswordSchemeSha512Crypt,
+	resourceUserPasswordSchemeYescrypt,
+}
+
+var regexpUserExpireDate = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}|-1)$`)
+
 func resourceUser() *schema.Resource {
 	return &schema.Resource{
 		Description: fmt.Sprintf("`%s` manages a user on the remote system.", resourceUserName),
@@ -32,6 +77,9 @@ func resourceUser() *schema.Resource {
 		ReadContext:   resourceUserRead,
 		UpdateContext: resourceUserUpdate,
 		DeleteContext: resourceUserDelete,
+
+		CustomizeDiff: resourceUserCustomizeDiff,
+
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -70,6 +118,23 @@ func resourceUser() *schema.Resource {
 				Computed:     true,
 				ExactlyOneOf: []string{resourceUserAttrGid, resourceUserAttrGroup},
 			},
+			resourceUserAttrGroups: {
+				Description: fmt.Sprintf("Set of names of the supplementary groups of the user. Groups must exist. The membership in other groups depends on `%s`.", resourceUserAttrGroupsPolicy),
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+				},
+			},
+			resourceUserAttrGroupsPolicy: {
+				Description:      fmt.Sprintf("Policy for the membership in supplementary groups which are not in `%[1]s`. `%[2]s` retains the membership in other groups, e.g. if the membership is managed by `system_group_membership`. `%[3]s` removes the user from all other supplementary groups. Defaults to `%[2]s`.", resourceUserAttrGroups, resourceUserGroupsPolicyAdditive, resourceUserGroupsPolicyAuthoritative),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          resourceUserGroupsPolicyAdditive,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{resourceUserGroupsPolicyAdditive, resourceUserGroupsPolicyAuthoritative}, false)),
+			},
 			resourceUserAttrSystem: {
 				Description: "Set to `true` to create a system user.",
 				Type:        schema.TypeBool,
@@ -78,7 +143,7 @@ func resourceUser() *schema.Resource {
 				ForceNew:    true,
 			},
 			resourceUserAttrHome: {
-				Description:      "Path to the home folder of the user. The folder is expected to exist and will not be created.",
+				Description:      fmt.Sprintf("Path to the home folder of the user. The folder is created if `%s` is `true` and moved on change if `%s` is `true`. Otherwise, the folder is expected to exist.", resourceUserAttrCreateHome, resourceUserAttrMoveHome),
 				Type:             schema.TypeString,
 				Optional:         true,
 				Computed:         true,
@@ -91,6 +156,103 @@ func resourceUser() *schema.Resource {
 				Computed:         true,
 				ValidateDiagFunc: validate.AbsolutePath(),
 			},
+			resourceUserAttrCreateHome: {
+				Description: fmt.Sprintf("Set to `true` to create the home folder when the user is created. The home folder is also created if it does not exist when `%[1]s` changes to `true` or `%[2]s` changes. The home folder is populated from the skeleton folder and owned by the user. Defaults to `false`.", resourceUserAttrCreateHome, resourceUserAttrHome),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceUserAttrSkel: {
+				Description:      fmt.Sprintf("Path to the skeleton folder which is copied to the home folder when the home folder is created. Requires `%s`. Defaults to the skeleton folder of the remote system, usually `/etc/skel`.", resourceUserAttrCreateHome),
+				Type:             schema.TypeString,
+				Optional:         true,
+				RequiredWith:     []string{resourceUserAttrCreateHome},
+				ValidateDiagFunc: validate.AbsolutePath(),
+			},
+			resourceUserAttrMoveHome: {
+				Description: fmt.Sprintf("Set to `true` to move the content of the home folder to the new path when `%s` changes. Defaults to `false`.", resourceUserAttrHome),
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceUserAttrRemoveHomeOnDestroy: {
+				Description: "Set to `true` to remove the home folder and the mail spool of the user when the user is destroyed. Defaults to `false`.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			resourceUserAttrHomeMode: {
+				Description: "Mode of the home folder. Empty if the home folder does not exist.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			resourceUserAttrHomeUid: {
+				Description: "Uid of the owner of the home folder. `-1` if the home folder does not exist.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			resourceUserAttrHomeGid: {
+				Description: "Gid of the group of the home folder. `-1` if the home folder does not exist.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			resourceUserAttrPasswordHash: {
+				Description:   fmt.Sprintf("Crypt hash of the password of the user as stored in `/etc/shadow`, e.g. a sha512-crypt hash `$6$...` or a yescrypt hash `$y$...`. The hash is set verbatim. Mutually exclusive with `%s`. Requires a connection as root or `sudo`.", resourceUserAttrPassword),
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{resourceUserAttrPassword},
+			},
+			resourceUserAttrPassword: {
+				Description:      fmt.Sprintf("Password of the user. The password is hashed by the provider using the scheme of `%[1]s`. The salt and the parameters of an existing hash of the scheme are retained so that the hash only changes with the password. The password is set again if it does not match the hash on the remote system. Mutually exclusive with `%[2]s`. Requires a connection as root or `sudo`.", resourceUserAttrPasswordScheme, resourceUserAttrPasswordHash),
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ConflictsWith:    []string{resourceUserAttrPasswordHash},
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotEmpty),
+			},
+			resourceUserAttrPasswordScheme: {
+				Description:      fmt.Sprintf("Scheme which is used to hash `%[1]s`. Either `%[2]s` or `%[3]s`. If not set, the scheme of the existing hash on the remote system is retained and `%[2]s` is used for a new hash. If set, an existing hash of another scheme is replaced.", resourceUserAttrPassword, resourceUserPasswordSchemeSha512Crypt, resourceUserPasswordSchemeYescrypt),
+				Type:             schema.TypeString,
+				Optional:         true,
+				RequiredWith:     []string{resourceUserAttrPassword},
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(resourceUserPasswordSchemes, false)),
+			},
+			resourceUserAttrLocked: {
+				Description: "Set to `true` to lock the password of the user. A locked password prevents password authentication; other authentication methods such as ssh keys are not affected. Users without a password are reported as locked on most distributions. If not set, the lock is not changed.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+			},
+			resourceUserAttrExpireDate: {
+				Description:      "Date on which the account expires in the format `YYYY-MM-DD`. Set to `-1` to disable the expiration.",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateDiagFunc: validate.StringMatch(regexpUserExpireDate, "invalid date, expected format YYYY-MM-DD or -1"),
+			},
+			resourceUserAttrPasswordMaxDays: {
+				Description:  "Maximum number of days a password is valid. Set to `-1` to disable the check.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+			resourceUserAttrPasswordMinDays: {
+				Description:  "Minimum number of days between password changes. Set to `-1` to disable the check.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+			resourceUserAttrPasswordWarnDays: {
+				Description:  "Number of days before the password expires during which the user is warned. Set to `-1` to disable the warning.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
 		},
 	}
 }
@@ -126,6 +288,49 @@ func resourceUserGetResourceData(d *schema.ResourceData) (*client.User, diag.Dia
 		r.Shell = d.Get(resourceUserAttrShell).(string)
 	}
 
+	// The home folder is only created if it is enabled or the path changes to retain a home folder removed on purpose
+	r.CreateHome = d.Get(resourceUserAttrCreateHome).(bool) && d.HasChanges(resourceUserAttrCreateHome, resourceUserAttrHome)
+	r.Skel = d.Get(resourceUserAttrSkel).(string)
+	r.MoveHome = d.Get(resourceUserAttrMoveHome).(bool)
+
+	if v := d.Get(resourceUserAttrPassword).(string); v != "" && d.HasChanges(resourceUserAttrPassword, resourceUserAttrPasswordScheme) {
+		hash, err := resourceUserHashPassword(v, d.Get(resourceUserAttrPasswordScheme).(string), d.Get(resourceUserAttrPasswordHash).(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+
+		r.PasswordHash = to.StringPtr(hash)
+	} else if d.HasChange(resourceUserAttrPasswordHash) {
+		if v, ok := d.GetOk(resourceUserAttrPasswordHash); ok {
+			r.PasswordHash = to.StringPtr(v.(string))
+		}
+	}
+
+	// The lock is only applied if configured because the state always contains the computed value. HasChange does not
+	// report a change from the zero value on create, so a configured value is always applied on create.
+	// https://github.com/hashicorp/terraform-plugin-sdk/issues/817
+	rawConfig := d.GetRawConfig()
+	configured := !rawConfig.IsNull() && rawConfig.IsKnown() && !rawConfig.GetAttr(resourceUserAttrLocked).IsNull()
+	if configured && (d.Id() == "" || d.HasChange(resourceUserAttrLocked)) {
+		r.Locked = to.BoolPtr(d.Get(resourceUserAttrLocked).(bool))
+	}
+
+	if d.HasChange(resourceUserAttrExpireDate) {
+		r.ExpireDate = to.StringPtr(d.Get(resourceUserAttrExpireDate).(string))
+	}
+
+	if d.HasChange(resourceUserAttrPasswordMaxDays) {
+		r.PasswordMaxDays = to.IntPtr(d.Get(resourceUserAttrPasswordMaxDays).(int))
+	}
+
+	if d.HasChange(resourceUserAttrPasswordMinDays) {
+		r.PasswordMinDays = to.IntPtr(d.Get(resourceUserAttrPasswordMinDays).(int))
+	}
+
+	if d.HasChange(resourceUserAttrPasswordWarnDays) {
+		r.PasswordWarnDays = to.IntPtr(d.Get(resourceUserAttrPasswordWarnDays).(int))
+	}
+
 	return r, nil
 }
 
@@ -138,6 +343,35 @@ func resourceUserSetResourceData(r *client.User, d *schema.ResourceData) diag.Di
 	_ = d.Set(resourceUserAttrHome, r.Home)
 	_ = d.Set(resourceUserAttrShell, r.Shell)
 
+	if r.PasswordHash != nil {
+		_ = d.Set(resourceUserAttrPasswordHash, to.String(r.PasswordHash))
+
+		// A password which does not match the hash is shown as drift
+		if v := d.Get(resourceUserAttrPassword).(string); v != "" && !resourceUserVerifyPassword(v, d.Get(resourceUserAttrPasswordScheme).(string), to.String(r.PasswordHash)) {
+			_ = d.Set(resourceUserAttrPassword, "")
+		}
+	}
+
+	if r.Locked != nil {
+		_ = d.Set(resourceUserAttrLocked, to.Bool(r.Locked))
+	}
+
+	if r.ExpireDate != nil {
+		_ = d.Set(resourceUserAttrExpireDate, to.String(r.ExpireDate))
+	}
+
+	if r.PasswordMaxDays != nil {
+		_ = d.Set(resourceUserAttrPasswordMaxDays, to.Int(r.PasswordMaxDays))
+	}
+
+	if r.PasswordMinDays != nil {
+		_ = d.Set(resourceUserAttrPasswordMinDays, to.Int(r.PasswordMinDays))
+	}
+
+	if r.PasswordWarnDays != nil {
+		_ = d.Set(resourceUserAttrPasswordWarnDays, to.Int(r.PasswordWarnDays))
+	}
+
 	return nil
 }
 
@@ -155,11 +389,18 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, meta interf
 	}
 
 	id, err := c.Create(ctx, *r)
+	if id >= 0 {
+		// The user is retained in the state if the account could not be updated after the user has been created
+		d.SetId(strconv.Itoa(id))
+	}
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	d.SetId(strconv.Itoa(id))
+	diagErr = resourceUserApplyGroups(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
 
 	return resourceUserRead(ctx, d, meta)
 }
@@ -187,6 +428,32 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, meta interfac
 		return diagErr
 	}
 
+	groups, err := client.NewGroupMembershipClient(p.System).GetUserGroups(ctx, r.Name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The membership in groups which are not managed is only retained in the state if the policy is authoritative
+	if d.Get(resourceUserAttrGroupsPolicy).(string) == resourceUserGroupsPolicyAuthoritative {
+		_ = d.Set(resourceUserAttrGroups, stringSliceToSet(groups))
+	} else {
+		_ = d.Set(resourceUserAttrGroups, stringSliceToSet(groups).Intersection(d.Get(resourceUserAttrGroups).(*schema.Set)))
+	}
+
+	home, err := client.NewFolderClient(p.System).Get(ctx, r.Home)
+	switch {
+	case errors.Is(err, client.ErrFolderNotFound):
+		_ = d.Set(resourceUserAttrHomeMode, "")
+		_ = d.Set(resourceUserAttrHomeUid, -1)
+		_ = d.Set(resourceUserAttrHomeGid, -1)
+	case err != nil:
+		return diag.FromErr(err)
+	default:
+		_ = d.Set(resourceUserAttrHomeMode, filemode.Mode(home.Mode).String())
+		_ = d.Set(resourceUserAttrHomeUid, home.Uid)
+		_ = d.Set(resourceUserAttrHomeGid, home.Gid)
+	}
+
 	return nil
 }
 
@@ -208,6 +475,11 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.FromErr(err)
 	}
 
+	diagErr = resourceUserApplyGroups(ctx, d, meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
 	return resourceUserRead(ctx, d, meta)
 }
 
@@ -224,10 +496,124 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, meta interf
 		return diag.FromErr(err)
 	}
 
-	err = c.Delete(ctx, id)
+	err = c.Delete(ctx, id, d.Get(resourceUserAttrRemoveHomeOnDestroy).(bool))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	return nil
 }
+
+// resourceUserApplyGroups adds the user to the supplementary groups and removes the user from the groups which have
+// been removed from the resource. The user is removed from all other groups if the policy is authoritative.
+func resourceUserApplyGroups(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if !d.HasChanges(resourceUserAttrGroups, resourceUserAttrGroupsPolicy) {
+		return nil
+	}
+
+	p, diagErr := providerFromMeta(meta)
+	if diagErr != nil {
+		return diagErr
+	}
+
+	c := client.NewGroupMembershipClient(p.System)
+
+	name := d.Get(resourceUserAttrName).(string)
+
+	currentGroups, err := c.GetUserGroups(ctx, name)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	current := stringSliceToSet(currentGroups)
+
+	o, n := d.GetChange(resourceUserAttrGroups)
+
+	removed := o.(*schema.Set).Difference(n.(*schema.Set)).Intersection(current)
+	if d.Get(resourceUserAttrGroupsPolicy).(string) == resourceUserGroupsPolicyAuthoritative {
+		removed = current.Difference(n.(*schema.Set))
+	}
+
+	for _, group := range removed.List() {
+		err := c.Remove(ctx, group.(string), []string{name})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	for _, group := range n.(*schema.Set).Difference(current).List() {
+		err := c.Add(ctx, group.(string), []string{name})
+		if errors.Is(err, client.ErrGroupMembershipGroupNotFound) {
+			return newDetailedDiagnostic(diag.Error, "group not found", fmt.Sprintf("the group %q does not exist", group.(string)), cty.GetAttrPath(resourceUserAttrGroups))
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
+
+func resourceUserCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	// The home folder is read again from the new path or after it has been created
+	if d.Id() != "" && (d.HasChange(resourceUserAttrHome) || d.HasChange(resourceUserAttrCreateHome) && d.Get(resourceUserAttrCreateHome).(bool)) {
+		for _, attr := range []string{resourceUserAttrHomeMode, resourceUserAttrHomeUid, resourceUserAttrHomeGid} {
+			if err := d.SetNewComputed(attr); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The hash is derived from the password on apply
+	if d.HasChanges(resourceUserAttrPassword, resourceUserAttrPasswordScheme) && d.Get(resourceUserAttrPassword).(string) != "" {
+		return d.SetNewComputed(resourceUserAttrPasswordHash)
+	}
+
+	return nil
+}
+
+// resourceUserHashPassword returns the hash of the password using the scheme. If the scheme is empty, the scheme of the
+// existing hash is used and sha512-crypt otherwise. The salt and the parameters of an existing hash of the scheme are
+// retained to derive the same hash from the same password.
+func resourceUserHashPassword(password string, scheme string, existingHash string) (string, error) {
+	if scheme == "" {
+		scheme = resourceUserPasswordSchemeSha512Crypt
+		if strings.HasPrefix(existingHash, yescrypt.Prefix) {
+			scheme = resourceUserPasswordSchemeYescrypt
+		}
+	}
+
+	if scheme == resourceUserPasswordSchemeYescrypt {
+		setting, err := yescrypt.Parse(existingHash)
+		if err != nil {
+			setting, err = yescrypt.NewSetting()
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return yescrypt.Hash(password, setting)
+	}
+
+	salt, rounds, err := shacrypt.Parse(existingHash)
+	if err != nil {
+		salt, err = shacrypt.NewSalt()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return shacrypt.Sha512(password, salt, rounds), nil
+}
+
+// resourceUserVerifyPassword reports whether the hash is a hash of the password. If the scheme is not empty, the hash
+// must be of the scheme.
+func resourceUserVerifyPassword(password string, scheme string, hash string) bool {
+	switch {
+	case strings.HasPrefix(hash, shacrypt.Sha512Prefix):
+		return (scheme == "" || scheme == resourceUserPasswordSchemeSha512Crypt) && shacrypt.Verify(password, hash)
+	case strings.HasPrefix(hash, yescrypt.Prefix):
+		return (scheme == "" || scheme == resourceUserPasswordSchemeYescrypt) && yescrypt.Verify(password, hash)
+	default:
+		return false
+	}
+}
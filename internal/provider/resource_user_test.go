@@ -6,6 +6,7 @@ import (
 	"github.com/neuspaces/terraform-provider-system/internal/acctest"
 	"github.com/neuspaces/terraform-provider-system/internal/acctest/tfbuild"
 	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"regexp"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -15,6 +16,9 @@ var (
 	testUserId uint32
 )
 
+// testUserPasswordHash is the sha512-crypt hash of `password`
+const testUserPasswordHash = "$6$abc$rvqzMBuMVukmply9mZJpW0wJMdDfgUKLDrSNxf9l66h/ytQiKNAdqHSj5YPJpxWJpVjRXibQXRddCl9xYHQnd0"
+
 type testUserConfig struct {
 	userName string
 }
@@ -232,6 +236,367 @@ func TestAccUser_system_create(t *testing.T) {
 	})
 }
 
+func TestAccUser_password(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password", "secret"),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestMatchResourceAttr("system_user.test", "password_hash", regexp.MustCompile(`^\$6\$`)),
+						resource.TestCheckResourceAttr("system_user.test", "locked", "false"),
+					),
+				},
+				{
+					// Lock the password
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password", "secret"),
+							tfbuild.AttributeBool("locked", true),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestMatchResourceAttr("system_user.test", "password_hash", regexp.MustCompile(`^\$6\$`)),
+						resource.TestCheckResourceAttr("system_user.test", "locked", "true"),
+					),
+				},
+				{
+					// Replace the password by a hash
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password_hash", testUserPasswordHash),
+							tfbuild.AttributeBool("locked", false),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "password_hash", testUserPasswordHash),
+						resource.TestCheckResourceAttr("system_user.test", "locked", "false"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_passwordScheme(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password", "secret"),
+							tfbuild.AttributeString("password_scheme", "yescrypt"),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestMatchResourceAttr("system_user.test", "password_hash", regexp.MustCompile(`^\$y\$j9T\$`)),
+					),
+				},
+				{
+					// The scheme of the existing hash is retained if the scheme is not set
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password", "secret"),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestMatchResourceAttr("system_user.test", "password_hash", regexp.MustCompile(`^\$y\$j9T\$`)),
+					),
+				},
+				{
+					// The existing hash is replaced if the scheme differs
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("password", "secret"),
+							tfbuild.AttributeString("password_scheme", "sha512crypt"),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestMatchResourceAttr("system_user.test", "password_hash", regexp.MustCompile(`^\$6\$`)),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_lockedUnset(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		testUserConfig := func(shell string) string {
+			return provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+				testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+					tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+					tfbuild.AttributeString("shell", shell),
+				),
+			)))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					// The user has no password which cannot be unlocked
+					Config: testUserConfig("/bin/sh"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "shell", "/bin/sh"),
+					),
+				},
+				{
+					// Update an unrelated attribute without changing the lock
+					Config: testUserConfig("/bin/false"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "shell", "/bin/false"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_aging(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("expire_date", "2099-12-31"),
+							tfbuild.AttributeInt("password_max_days", 90),
+							tfbuild.AttributeInt("password_min_days", 1),
+							tfbuild.AttributeInt("password_warn_days", 14),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "expire_date", "2099-12-31"),
+						resource.TestCheckResourceAttr("system_user.test", "password_max_days", "90"),
+						resource.TestCheckResourceAttr("system_user.test", "password_min_days", "1"),
+						resource.TestCheckResourceAttr("system_user.test", "password_warn_days", "14"),
+					),
+				},
+				{
+					// Disable the expiration and the aging
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("expire_date", "-1"),
+							tfbuild.AttributeInt("password_max_days", -1),
+							tfbuild.AttributeInt("password_min_days", -1),
+							tfbuild.AttributeInt("password_warn_days", -1),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "expire_date", "-1"),
+						resource.TestCheckResourceAttr("system_user.test", "password_max_days", "-1"),
+						resource.TestCheckResourceAttr("system_user.test", "password_min_days", "-1"),
+						resource.TestCheckResourceAttr("system_user.test", "password_warn_days", "-1"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_home(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", userName,
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("home", fmt.Sprintf("/home/%s", userName)),
+							tfbuild.AttributeBool("create_home", true),
+							tfbuild.AttributeBool("move_home", true),
+							tfbuild.AttributeBool("remove_home_on_destroy", true),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "home", fmt.Sprintf("/home/%s", userName)),
+						resource.TestCheckResourceAttrPair("system_user.test", "home_uid", "system_user.test", "uid"),
+						resource.TestCheckResourceAttrPair("system_user.test", "home_gid", "system_user.test", "gid"),
+						resource.TestCheckResourceAttrSet("system_user.test", "home_mode"),
+					),
+				},
+				{
+					// Move the home folder
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+						testAccUserBlock("test", userName,
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+							tfbuild.AttributeString("home", fmt.Sprintf("/home/%s-moved", userName)),
+							tfbuild.AttributeBool("create_home", true),
+							tfbuild.AttributeBool("move_home", true),
+							tfbuild.AttributeBool("remove_home_on_destroy", true),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "home", fmt.Sprintf("/home/%s-moved", userName)),
+						resource.TestCheckResourceAttrPair("system_user.test", "home_uid", "system_user.test", "uid"),
+						resource.TestCheckResourceAttrSet("system_user.test", "home_mode"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_createHomeOnUpdate(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		userName := testRunUserName(testConfig.userName, "a")
+
+		testUserConfig := func(createHome bool) string {
+			return provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+				acctest.ProviderConfigBlock(target.Configs.Default()),
+				testAccGroupBlock("test", testRunGroupName(testConfig.userName, "a")),
+				testAccUserBlock("test", userName,
+					tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "test", "name")),
+					tfbuild.AttributeString("home", fmt.Sprintf("/home/%s", userName)),
+					tfbuild.AttributeBool("create_home", createHome),
+					tfbuild.AttributeBool("remove_home_on_destroy", true),
+				),
+			)))
+		}
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: testUserConfig(false),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "home_mode", ""),
+						resource.TestCheckResourceAttr("system_user.test", "home_uid", "-1"),
+					),
+				},
+				{
+					// Create the home folder of the existing user
+					Config: testUserConfig(true),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttrPair("system_user.test", "home_uid", "system_user.test", "uid"),
+						resource.TestCheckResourceAttrPair("system_user.test", "home_gid", "system_user.test", "gid"),
+						resource.TestCheckResourceAttrSet("system_user.test", "home_mode"),
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestAccUser_groups(t *testing.T) {
+	testConfig := newTestUserConfig()
+
+	acctest.Current().Targets.Foreach(t, func(t *testing.T, target acctest.Target) {
+		t.Parallel()
+
+		groupA := testRunGroupName(testConfig.userName, "a")
+		groupB := testRunGroupName(testConfig.userName, "b")
+
+		resource.Test(t, resource.TestCase{
+			ProviderFactories: acctest.ProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("a", groupA),
+						testAccGroupBlock("b", groupB),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "a", "name")),
+							tfbuild.Attribute("groups", tfbuild.StringList(groupA, groupB)),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_group", "b")),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "groups.#", "2"),
+						resource.TestCheckTypeSetElemAttr("system_user.test", "groups.*", groupA),
+						resource.TestCheckTypeSetElemAttr("system_user.test", "groups.*", groupB),
+						resource.TestCheckResourceAttr("system_user.test", "groups_policy", "additive"),
+					),
+				},
+				{
+					// Remove the user from all groups except groupA
+					Config: provider.TestLogString(t, tfbuild.FileString(tfbuild.File(
+						acctest.ProviderConfigBlock(target.Configs.Default()),
+						testAccGroupBlock("a", groupA),
+						testAccGroupBlock("b", groupB),
+						testAccUserBlock("test", testRunUserName(testConfig.userName, "a"),
+							tfbuild.AttributeTraversal("group", tfbuild.TraversalResourceAttribute("system_group", "a", "name")),
+							tfbuild.Attribute("groups", tfbuild.StringList(groupA)),
+							tfbuild.AttributeString("groups_policy", "authoritative"),
+							tfbuild.DependsOn(tfbuild.TraversalResource("system_group", "b")),
+						),
+					))),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("system_user.test", "groups.#", "1"),
+						resource.TestCheckTypeSetElemAttr("system_user.test", "groups.*", groupA),
+					),
+				},
+			},
+		})
+	})
+}
+
 func testRunUserName(userName string, extensions ...string) string {
 	return "test" + acctest.Current().Id + userName + strings.Join(extensions, "")
 }
@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/fileattr"
+	"sort"
+	"strings"
+)
+
+const (
+	fileAttrsAttrAcl               = "acl"
+	fileAttrsAttrXattrs            = "xattrs"
+	fileAttrsAttrSelinuxContext    = "selinux_context"
+	fileAttrsAttrSelinuxRestorecon = "selinux_restorecon"
+	fileAttrsAttrAttributes        = "attributes"
+)
+
+// fileAttrsKind is the kind of path which determines the supported attributes
+type fileAttrsKind int
+
+const (
+	fileAttrsKindFile fileAttrsKind = iota
+	fileAttrsKindFolder
+	fileAttrsKindLink
+)
+
+// fileAttrsLockingAttributes prevent modifications of a path and are cleared before the path is modified
+var fileAttrsLockingAttributes = []fileattr.Attribute{
+	fileattr.AttributeImmutable,
+	fileattr.AttributeAppendOnly,
+}
+
+// withFileAttrsSchema adds the attributes of the kind to the schema s
+func withFileAttrsSchema(kind fileAttrsKind, s map[string]*schema.Schema) map[string]*schema.Schema {
+	s[fileAttrsAttrSelinuxContext] = &schema.Schema{
+		Description:   fmt.Sprintf("SELinux security context like `system_u:object_r:etc_t:s0`. Applied using `chcon`. Conflicts with `%s`.", fileAttrsAttrSelinuxRestorecon),
+		Type:          schema.TypeString,
+		Optional:      true,
+		Computed:      true,
+		ConflictsWith: []string{fileAttrsAttrSelinuxRestorecon},
+	}
+
+	s[fileAttrsAttrSelinuxRestorecon] = &schema.Schema{
+		Description:   fmt.Sprintf("Restore the SELinux security context to the default of the policy using `restorecon`. A deviating security context is detected as drift. Conflicts with `%s`. Defaults to `false`.", fileAttrsAttrSelinuxContext),
+		Type:          schema.TypeBool,
+		Optional:      true,
+		Default:       false,
+		ConflictsWith: []string{fileAttrsAttrSelinuxContext},
+	}
+
+	if kind == fileAttrsKindLink {
+		// Access control lists, extended attributes, and file attributes do not apply to symlinks
+		return s
+	}
+
+	aclDescription := "Named entries of the POSIX access control list in the form `user:<name>:<perms>` or `group:<name>:<perms>` like `user:alice:r-x`. Entries of the owner, owning group, other, and the mask are derived from the mode. Applied using `setfacl`."
+	if kind == fileAttrsKindFolder {
+		aclDescription += " Entries of the default access control list which is inherited by new files and folders are prefixed with `default:`."
+	}
+
+	s[fileAttrsAttrAcl] = &schema.Schema{
+		Description: aclDescription,
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Computed:    true,
+		Elem: &schema.Schema{
+			Type:             schema.TypeString,
+			ValidateDiagFunc: validateFileAttrsAclEntry(kind == fileAttrsKindFolder),
+		},
+	}
+
+	s[fileAttrsAttrXattrs] = &schema.Schema{
+		Description:      "Extended attributes in the `user.` and `security.` namespaces like `user.checksum`. Values are text. `security.selinux` is managed by `selinux_context`. Applied using `setfattr`.",
+		Type:             schema.TypeMap,
+		Optional:         true,
+		Computed:         true,
+		Elem:             &schema.Schema{Type: schema.TypeString},
+		ValidateDiagFunc: validateFileAttrsXattrs(),
+	}
+
+	var attributes []string
+	for _, a := range fileattr.Attributes() {
+		attributes = append(attributes, string(a))
+	}
+
+	s[fileAttrsAttrAttributes] = &schema.Schema{
+		Description: fmt.Sprintf("File attributes which are set using `chattr`. Supported attributes are `%s`. The attributes `%s` and `%s` are cleared temporarily when the resource is modified or destroyed.", strings.Join(attributes, "`, `"), fileattr.AttributeImmutable, fileattr.AttributeAppendOnly),
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Computed:    true,
+		Elem: &schema.Schema{
+			Type:         schema.TypeString,
+			ValidateFunc: validation.StringInSlice(attributes, false),
+		},
+	}
+
+	return s
+}
+
+// validateFileAttrsAclEntry expects a named access control list entry in the canonical form
+func validateFileAttrsAclEntry(allowDefault bool) schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		s, ok := val.(string)
+		if !ok {
+			return newDetailedDiagnostic(diag.Error, "unexpected type", fmt.Sprintf("expected string, got %T", val), path)
+		}
+
+		e, err := fileattr.ParseAclEntry(s)
+		if err != nil {
+			return newDetailedDiagnostic(diag.Error, "invalid acl entry", err.Error(), path)
+		}
+
+		if !e.IsNamed() {
+			return newDetailedDiagnostic(diag.Error, "invalid acl entry", fmt.Sprintf("entry %q does not apply to a named user or group; entries of the owner, owning group, other, and the mask are derived from the mode", s), path)
+		}
+
+		if e.Default && !allowDefault {
+			return newDetailedDiagnostic(diag.Error, "invalid acl entry", fmt.Sprintf("default entry %q is only supported on folders", s), path)
+		}
+
+		if e.String() != s {
+			return newDetailedDiagnostic(diag.Error, "invalid acl entry", fmt.Sprintf("expected entry in the canonical form %q", e.String()), path)
+		}
+
+		return nil
+	}
+}
+
+// validateFileAttrsXattrs expects names of extended attributes which can be managed
+func validateFileAttrsXattrs() schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return newDetailedDiagnostic(diag.Error, "unexpected type", fmt.Sprintf("expected map, got %T", val), path)
+		}
+
+		var diags diag.Diagnostics
+		for name := range m {
+			if !fileattr.IsManagedXattr(name) {
+				diags = append(diags, newDetailedDiagnostic(diag.Error, "invalid extended attribute", fmt.Sprintf("extended attribute %q is not in the `user.` or `security.` namespace or is maintained by the system", name), path.IndexString(name))...)
+			}
+		}
+		return diags
+	}
+}
+
+// fileAttrsRead sets the attributes of the kind from the remote system
+func fileAttrsRead(ctx context.Context, c client.FileAttrsClient, kind fileAttrsKind, path string, d *schema.ResourceData) diag.Diagnostics {
+	attrs, err := c.Get(ctx, path)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	_ = d.Set(fileAttrsAttrSelinuxContext, attrs.SelinuxContext)
+
+	if d.Get(fileAttrsAttrSelinuxRestorecon).(bool) {
+		// A deviating security context is reported as drift of attribute `selinux_restorecon`
+		restored, err := c.SelinuxContextRestored(ctx, path)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		_ = d.Set(fileAttrsAttrSelinuxRestorecon, restored)
+	}
+
+	if kind == fileAttrsKindLink {
+		return nil
+	}
+
+	var acl []string
+	for _, e := range attrs.Acl {
+		acl = append(acl, e.String())
+	}
+	_ = d.Set(fileAttrsAttrAcl, acl)
+
+	_ = d.Set(fileAttrsAttrXattrs, attrs.Xattrs)
+
+	var attributes []string
+	for _, a := range attrs.Attributes {
+		attributes = append(attributes, string(a))
+	}
+	_ = d.Set(fileAttrsAttrAttributes, attributes)
+
+	return nil
+}
+
+// fileAttrsUnlock clears the attributes immutable and append only which prevent modifications of the path. Returns
+// true if attributes have been cleared.
+func fileAttrsUnlock(ctx context.Context, c client.FileAttrsClient, kind fileAttrsKind, path string, d *schema.ResourceData) (bool, diag.Diagnostics) {
+	if kind == fileAttrsKindLink {
+		return false, nil
+	}
+
+	// The attributes as of the prior state are set on the remote system
+	o, _ := d.GetChange(fileAttrsAttrAttributes)
+
+	var locking []fileattr.Attribute
+	for _, a := range fileAttrsLockingAttributes {
+		if o.(*schema.Set).Contains(string(a)) {
+			locking = append(locking, a)
+		}
+	}
+
+	if len(locking) == 0 {
+		return false, nil
+	}
+
+	err := c.SetAttributes(ctx, path, nil, locking)
+	if err != nil {
+		return false, diag.FromErr(err)
+	}
+
+	return true, nil
+}
+
+// fileAttrsApply applies the attributes of the kind which have changed. All attributes are applied if reapply is true
+// because the path has been replaced or fileAttrsUnlock cleared attributes.
+func fileAttrsApply(ctx context.Context, c client.FileAttrsClient, kind fileAttrsKind, path string, d *schema.ResourceData, reapply bool) diag.Diagnostics {
+	if d.HasChange(fileAttrsAttrSelinuxContext) || reapply {
+		if selinuxContext := d.Get(fileAttrsAttrSelinuxContext).(string); selinuxContext != "" {
+			err := c.SetSelinuxContext(ctx, path, selinuxContext)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	if (d.HasChange(fileAttrsAttrSelinuxRestorecon) || reapply) && d.Get(fileAttrsAttrSelinuxRestorecon).(bool) {
+		err := c.RestoreSelinuxContext(ctx, path)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if kind == fileAttrsKindLink {
+		return nil
+	}
+
+	if d.HasChange(fileAttrsAttrAcl) || (reapply && d.Get(fileAttrsAttrAcl).(*schema.Set).Len() > 0) {
+		var entries []fileattr.AclEntry
+		for _, v := range d.Get(fileAttrsAttrAcl).(*schema.Set).List() {
+			e, err := fileattr.ParseAclEntry(v.(string))
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			entries = append(entries, e)
+		}
+
+		err := c.SetAcl(ctx, path, entries)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange(fileAttrsAttrXattrs) || reapply {
+		o, n := d.GetChange(fileAttrsAttrXattrs)
+		oldXattrs, newXattrs := o.(map[string]interface{}), n.(map[string]interface{})
+
+		set := map[string]string{}
+		for name, value := range newXattrs {
+			if oldValue, ok := oldXattrs[name]; !ok || oldValue != value || reapply {
+				set[name] = value.(string)
+			}
+		}
+
+		var remove []string
+		for name := range oldXattrs {
+			if _, ok := newXattrs[name]; !ok {
+				remove = append(remove, name)
+			}
+		}
+		sort.Strings(remove)
+
+		err := c.SetXattrs(ctx, path, set, remove)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange(fileAttrsAttrAttributes) || reapply {
+		o, n := d.GetChange(fileAttrsAttrAttributes)
+		oldAttributes, newAttributes := o.(*schema.Set), n.(*schema.Set)
+
+		var set, clear []fileattr.Attribute
+		for _, v := range newAttributes.List() {
+			set = append(set, fileattr.Attribute(v.(string)))
+		}
+		for _, v := range oldAttributes.Difference(newAttributes).List() {
+			clear = append(clear, fileattr.Attribute(v.(string)))
+		}
+
+		err := c.SetAttributes(ctx, path, set, clear)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/neuspaces/terraform-provider-system/internal/client"
+	"github.com/neuspaces/terraform-provider-system/internal/system"
+)
+
+const (
+	onChangeAttrOnChange = "on_change"
+	onChangeAttrCommand  = "command"
+	onChangeAttrRunAs    = "run_as"
+	onChangeAttrPending  = "on_change_pending"
+)
+
+// withOnChangeSchema adds the block `on_change` to the schema s. The description of the block mentions the changes
+// which trigger the command.
+func withOnChangeSchema(changes string, s map[string]*schema.Schema) map[string]*schema.Schema {
+	s[onChangeAttrOnChange] = &schema.Schema{
+		Description: fmt.Sprintf("Command which is executed on the remote system after the resource has been created or %s changed in the same apply. The apply fails if the command exits with a non-zero exit code.", changes),
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				onChangeAttrCommand: {
+					Description:      "Command to execute including arguments. The command is executed in a shell context. Example: `systemctl reload nginx`.",
+					Type:             schema.TypeString,
+					Required:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+				},
+				onChangeAttrRunAs: {
+					Description:      "Name of the user which executes the command using `su`. Requires a connection as root or `sudo`. Defaults to the user of the connection.",
+					Type:             schema.TypeString,
+					Optional:         true,
+					ValidateDiagFunc: validation.ToDiagFunc(validation.StringIsNotWhiteSpace),
+				},
+			},
+		},
+	}
+
+	s[onChangeAttrPending] = &schema.Schema{
+		Description: fmt.Sprintf("Whether the command of `%s` failed. A failed command is executed again on the next apply.", onChangeAttrOnChange),
+		Type:        schema.TypeBool,
+		Computed:    true,
+	}
+
+	return s
+}
+
+// onChangeRun executes the command of `on_change` after the resource has been created, after any of the attributes
+// differs from the prior state, or if the command failed before. Expects that the attributes have been read from the
+// remote system.
+func onChangeRun(ctx context.Context, s system.System, d *schema.ResourceData, attrs ...string) diag.Diagnostics {
+	pending, _ := d.GetChange(onChangeAttrPending)
+
+	v, ok := d.GetOk(onChangeAttrOnChange)
+	if !ok {
+		// A failed command is discarded if the block has been removed
+		if pending.(bool) {
+			_ = d.Set(onChangeAttrPending, false)
+		}
+		return nil
+	}
+
+	changed := d.IsNewResource() || pending.(bool)
+	for _, attr := range attrs {
+		o, _ := d.GetChange(attr)
+		if o != d.Get(attr) {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	m, err := expandListSingle(v)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	h := client.Hook{
+		Command: m[onChangeAttrCommand].(string),
+		RunAs:   m[onChangeAttrRunAs].(string),
+	}
+
+	err = client.NewHookClient(s).Run(ctx, h)
+	if err != nil {
+		// The resource has been changed and is retained in the state. The command is executed again on the next apply.
+		_ = d.Set(onChangeAttrPending, true)
+		return newDetailedDiagnostic(diag.Error, "on_change command failed", err.Error(), cty.GetAttrPath(onChangeAttrOnChange))
+	}
+
+	if pending.(bool) {
+		_ = d.Set(onChangeAttrPending, false)
+	}
+
+	return nil
+}
+
+// onChangeCustomizeDiff plans the execution of a command which failed before
+func onChangeCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.Get(onChangeAttrPending).(bool) {
+		return nil
+	}
+
+	return d.SetNew(onChangeAttrPending, false)
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/neuspaces/terraform-provider-system/internal/source"
+	"github.com/neuspaces/terraform-provider-system/internal/validate"
+	"strings"
+)
+
+// sourceStatePrefix is the prefix of the etag of a source in the state
+const sourceStatePrefix = "etag="
+
+// newSourceRegistry returns a registry of the sources which are supported by the attribute `source` of a resource.
+// Local paths are opened as `file://` urls.
+func newSourceRegistry() *source.Registry {
+	sources, err := source.NewRegistry(
+		source.WithClients(
+			source.NewMetaCache(source.NewFileClient()),
+			source.NewMetaCache(source.NewHttpClient()),
+		),
+		source.WithDefaultScheme(source.FileScheme),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return sources
+}
+
+// sourceValidateDiagFunc returns a ValidateDiagFunc which expects a url that can be opened by the registry
+func sourceValidateDiagFunc(sources *source.Registry) schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		valUrl, err := validate.ExpectUrl(val, path)
+		if err != nil {
+			return err
+		}
+
+		// Attempt to open
+		s, openErr := sources.OpenUrl(valUrl)
+		if openErr != nil {
+			return []diag.Diagnostic{
+				{
+					Severity:      diag.Error,
+					Summary:       fmt.Sprintf("failed to open url %q", valUrl.String()),
+					Detail:        openErr.Error(),
+					AttributePath: path,
+				},
+			}
+		}
+
+		_ = s.Close()
+
+		return nil
+	}
+}
+
+// sourceStateFunc returns a StateFunc which stores the etag of the referenced source in the state in the form
+// etag=[etag]. The value is stored unchanged if the source cannot be opened; the error is reported by the validation
+// and when the source is read.
+func sourceStateFunc(sources *source.Registry) schema.SchemaStateFunc {
+	return func(val interface{}) string {
+		valStr, _ := val.(string)
+
+		// Pass-through etag
+		if strings.HasPrefix(valStr, sourceStatePrefix) {
+			return valStr
+		}
+
+		// Get etag from source meta struct
+		s, err := sources.Open(valStr)
+		if err != nil {
+			return valStr
+		}
+		defer func() {
+			_ = s.Close()
+		}()
+
+		m, err := s.Meta()
+		if err != nil {
+			return valStr
+		}
+
+		return sourceStatePrefix + m.ETag()
+	}
+}
@@ -0,0 +1,46 @@
+package provider_test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"github.com/neuspaces/terraform-provider-system/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSourceStateFunc verifies the state of the attribute `source` of the resources which read a source
+func TestSourceStateFunc(t *testing.T) {
+	t.Parallel()
+
+	content := []byte("hello world\n")
+	path := filepath.Join(t.TempDir(), "source")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	sum := md5.Sum(content)
+	expectEtag := "etag=" + base64.StdEncoding.EncodeToString(sum[:])
+
+	p := provider.New("dev")()
+
+	for _, name := range []string{"system_file", "system_archive"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stateFunc := p.ResourcesMap[name].Schema["source"].StateFunc
+			require.NotNil(t, stateFunc)
+
+			assert.Equal(t, expectEtag, stateFunc(path))
+			assert.Equal(t, expectEtag, stateFunc(expectEtag))
+
+			// A source which cannot be opened is stored unchanged
+			missing := filepath.Join(filepath.Dir(path), "missing")
+			assert.NotPanics(t, func() {
+				assert.Equal(t, missing, stateFunc(missing))
+			})
+			assert.Equal(t, "", stateFunc(nil))
+		})
+	}
+}
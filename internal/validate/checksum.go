@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/checksum"
+)
+
+// Checksum returns a schema.SchemaValidateDiagFunc which tests if the provided value
+// is of type string and can be parsed as checksum in the format `algorithm:hex`
+func Checksum() schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		strVal, err := expectString(val, path)
+		if err != nil {
+			return err
+		}
+
+		_, parseErr := checksum.Parse(strVal)
+		if parseErr != nil {
+			return []diag.Diagnostic{
+				{
+					Severity:      diag.Error,
+					Summary:       "invalid checksum",
+					Detail:        parseErr.Error(),
+					AttributePath: path,
+				},
+			}
+		}
+
+		return nil
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/neuspaces/terraform-provider-system/internal/lib/authorizedkeys"
 	"github.com/neuspaces/terraform-provider-system/internal/sshclient"
 	"golang.org/x/crypto/ssh"
 )
@@ -93,3 +94,29 @@ func AuthorizedKey() schema.SchemaValidateDiagFunc {
 		return nil
 	}
 }
+
+// AuthorizedKeyWithOptions validates if the value can be parsed as line of an authorized_keys file
+// including the supported options of OpenSSH.
+func AuthorizedKeyWithOptions() schema.SchemaValidateDiagFunc {
+	return func(val interface{}, path cty.Path) diag.Diagnostics {
+		strVal, diagErr := expectString(val, path)
+		if diagErr != nil {
+			return diagErr
+		}
+
+		_, err := authorizedkeys.Parse(strVal)
+
+		if err != nil {
+			return []diag.Diagnostic{
+				{
+					Severity:      diag.Error,
+					Summary:       "invalid authorized key format",
+					Detail:        err.Error(),
+					AttributePath: path,
+				},
+			}
+		}
+
+		return nil
+	}
+}